@@ -0,0 +1,590 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/alaingilbert/ogame/pkg/activityTracker"
+	"github.com/alaingilbert/ogame/pkg/device"
+	"github.com/alaingilbert/ogame/pkg/gameforge/solvers"
+	"github.com/alaingilbert/ogame/pkg/openapi"
+	"github.com/alaingilbert/ogame/pkg/wrapper"
+	"github.com/labstack/echo/v4"
+)
+
+// DefaultAccount name of the account served on the routes without the /accounts/:name prefix
+const DefaultAccount = "default"
+
+var (
+	ErrAccountNotFound    = errors.New("account not found")
+	ErrAccountExists      = errors.New("account already exists")
+	ErrAccountDisabled    = errors.New("account disabled")
+	ErrAccountStarting    = errors.New("account is logging in, retry later")
+	ErrInvalidAccountName = errors.New("invalid account name, only letters, digits, '-' and '_' are allowed")
+)
+
+var accountNameRgx = regexp.MustCompile(`^[a-zA-Z0-9_-]+$`)
+
+// ProxyConfig proxy used by an account
+type ProxyConfig struct {
+	Address   string
+	Username  string `json:",omitempty"`
+	Password  string `json:",omitempty"`
+	Type      string `json:",omitempty"` // socks5 (default) or http
+	LoginOnly bool   `json:",omitempty"`
+}
+
+// DeviceConfig virtual device of an account, zero values are replaced by the ogamed defaults
+type DeviceConfig struct {
+	Name                string // Defaults to the name of the account
+	Os                  device.Os
+	Browser             device.Browser
+	Memory              int
+	HardwareConcurrency int
+	ScreenColorDepth    int
+	ScreenWidth         int
+	ScreenHeight        int
+	Timezone            string
+	Languages           string
+}
+
+// withDefaults fills the empty fields of the device
+func (d DeviceConfig) withDefaults(accountName string) DeviceConfig {
+	if d.Name == "" {
+		d.Name = accountName
+	}
+	if d.Os == "" {
+		d.Os = device.Windows
+	}
+	if d.Browser == "" {
+		d.Browser = device.Chrome
+	}
+	if d.Memory == 0 {
+		d.Memory = 8
+	}
+	if d.HardwareConcurrency == 0 {
+		d.HardwareConcurrency = 16
+	}
+	if d.ScreenColorDepth == 0 {
+		d.ScreenColorDepth = 24
+	}
+	if d.ScreenWidth == 0 {
+		d.ScreenWidth = 1900
+	}
+	if d.ScreenHeight == 0 {
+		d.ScreenHeight = 900
+	}
+	if d.Timezone == "" {
+		d.Timezone = "America/Los_Angeles"
+	}
+	if d.Languages == "" {
+		d.Languages = "en-US,en"
+	}
+	return d
+}
+
+// build creates the device, loaded from ~/.ogame/devices/<name> if it already exists
+func (d DeviceConfig) build() (*device.Device, error) {
+	return device.NewBuilder(d.Name).
+		SetOsName(d.Os).
+		SetBrowserName(d.Browser).
+		SetMemory(d.Memory).
+		SetHardwareConcurrency(d.HardwareConcurrency).
+		ScreenColorDepth(d.ScreenColorDepth).
+		SetScreenWidth(d.ScreenWidth).
+		SetScreenHeight(d.ScreenHeight).
+		SetTimezone(d.Timezone).
+		SetLanguages(d.Languages).
+		Build()
+}
+
+// AccountConfig one account served by ogamed
+type AccountConfig struct {
+	Name      string
+	Universe  string
+	Username  string
+	Password  string
+	Language  string
+	Lobby     string `json:",omitempty"`
+	AutoLogin bool
+	Disabled  bool         `json:",omitempty"`
+	Proxy     *ProxyConfig `json:",omitempty"`
+	Device    DeviceConfig
+	NjaApiKey string `json:",omitempty"` // Ninja captcha solver API key
+}
+
+// validate checks the required fields and fills the defaults
+func (c *AccountConfig) validate() error {
+	if !accountNameRgx.MatchString(c.Name) {
+		return ErrInvalidAccountName
+	}
+	if c.Universe == "" || c.Username == "" {
+		return errors.New("universe and username are required")
+	}
+	if c.Language == "" {
+		c.Language = "en"
+	}
+	c.Device = c.Device.withDefaults(c.Name)
+	return nil
+}
+
+// Config content of the ogamed config file
+type Config struct {
+	Accounts []AccountConfig
+}
+
+// LoadConfig reads an ogamed config file
+func LoadConfig(path string) (Config, error) {
+	var config Config
+	by, err := os.ReadFile(path)
+	if err != nil {
+		return config, err
+	}
+	err = json.Unmarshal(by, &config)
+	return config, err
+}
+
+// BotFactory creates the bot of an account, the bot must stop when the context is cancelled
+type BotFactory func(ctx context.Context, config AccountConfig) (*wrapper.OGame, error)
+
+// newBotFactory creates the bots like ogamed does for a single account
+func newBotFactory(apiNewHostname string) BotFactory {
+	return func(ctx context.Context, config AccountConfig) (*wrapper.OGame, error) {
+		deviceInst, err := config.Device.build()
+		if err != nil {
+			return nil, err
+		}
+		params := wrapper.Params{
+			Ctx:            ctx,
+			Device:         deviceInst,
+			Universe:       config.Universe,
+			Username:       config.Username,
+			Password:       config.Password,
+			Lang:           config.Language,
+			AutoLogin:      config.AutoLogin,
+			Lobby:          config.Lobby,
+			APINewHostname: apiNewHostname,
+		}
+		if config.Proxy != nil {
+			params.Proxy = config.Proxy.Address
+			params.ProxyUsername = config.Proxy.Username
+			params.ProxyPassword = config.Proxy.Password
+			params.ProxyType = config.Proxy.Type
+			params.ProxyLoginOnly = config.Proxy.LoginOnly
+			if params.ProxyType == "" {
+				params.ProxyType = "socks5"
+			}
+		}
+		if config.NjaApiKey != "" {
+			params.CaptchaSolver = solvers.NinjaSolver(config.NjaApiKey)
+		}
+		return wrapper.NewWithParams(params)
+	}
+}
+
+// AccountStatus state of an account, as returned by the API
+type AccountStatus struct {
+	Name      string
+	Universe  string
+	Username  string
+	Language  string
+	Enabled   bool
+	LoggedIn  bool
+	Error     string `json:",omitempty"` // Last error while creating the bot
+	Temporary bool   `json:",omitempty"` // Defined by the command line flags, not saved in the config file
+}
+
+type account struct {
+	config        AccountConfig
+	temporary     bool
+	bot           *wrapper.OGame
+	tracker       *activityTracker.Tracker
+	cancel        context.CancelFunc // Stops the bot
+	cancelTracker context.CancelFunc // Stops the activity tracker and the events poller
+	starting      bool               // The bot is being created, outside the lock
+	err           error
+}
+
+// Accounts accounts served by ogamed, changes made at runtime are saved in the config file
+type Accounts struct {
+	sync.Mutex
+	ctx              context.Context
+	newBot           BotFactory
+	activityInterval time.Duration
+	eventsInterval   time.Duration
+	path             string
+	accounts         map[string]*account
+}
+
+// NewAccounts creates an empty set of accounts.
+// The attacks and fleets of the bots are polled every eventsInterval, not at all if it is zero.
+func NewAccounts(ctx context.Context, newBot BotFactory, activityInterval, eventsInterval time.Duration) *Accounts {
+	return &Accounts{
+		ctx:              ctx,
+		newBot:           newBot,
+		activityInterval: activityInterval,
+		eventsInterval:   eventsInterval,
+		accounts:         make(map[string]*account),
+	}
+}
+
+// Load adds the accounts of a config file, the file is then updated with the changes made at runtime.
+// Accounts whose bot cannot be created are kept with their error, and can be enabled again later.
+func (a *Accounts) Load(path string) error {
+	config, err := LoadConfig(path)
+	if err != nil {
+		return err
+	}
+	a.Lock()
+	names := make(map[string]bool)
+	for i := range config.Accounts {
+		accountConfig := &config.Accounts[i]
+		if err := accountConfig.validate(); err != nil {
+			a.Unlock()
+			return errors.New(accountConfig.Name + ": " + err.Error())
+		}
+		if _, ok := a.accounts[accountConfig.Name]; ok || names[accountConfig.Name] {
+			a.Unlock()
+			return errors.New(accountConfig.Name + ": " + ErrAccountExists.Error())
+		}
+		names[accountConfig.Name] = true
+	}
+	a.path = path
+	for _, accountConfig := range config.Accounts {
+		a.accounts[accountConfig.Name] = &account{config: accountConfig}
+	}
+	a.Unlock()
+	for _, accountConfig := range config.Accounts {
+		if !accountConfig.Disabled {
+			if err := a.start(accountConfig.Name); err != nil {
+				log.Println("account " + accountConfig.Name + ": " + err.Error())
+			}
+		}
+	}
+	return nil
+}
+
+// Add adds an account and starts its bot unless it is disabled
+func (a *Accounts) Add(config AccountConfig) error {
+	return a.add(config, false)
+}
+
+// AddTemporary same as Add, but the account is not saved in the config file
+func (a *Accounts) AddTemporary(config AccountConfig) error {
+	return a.add(config, true)
+}
+
+func (a *Accounts) add(config AccountConfig, temporary bool) error {
+	if err := config.validate(); err != nil {
+		return err
+	}
+	a.Lock()
+	if _, ok := a.accounts[config.Name]; ok {
+		a.Unlock()
+		return ErrAccountExists
+	}
+	acc := &account{config: config, temporary: temporary}
+	a.accounts[config.Name] = acc
+	a.Unlock()
+	if !config.Disabled {
+		if err := a.start(config.Name); err != nil {
+			// The account is not added if its bot cannot be created
+			a.Lock()
+			if a.accounts[config.Name] == acc {
+				delete(a.accounts, config.Name)
+			}
+			a.Unlock()
+			return err
+		}
+	}
+	a.Lock()
+	defer a.Unlock()
+	return a.saveLocked()
+}
+
+// Remove stops the bot of an account and forgets it
+func (a *Accounts) Remove(name string) error {
+	a.Lock()
+	defer a.Unlock()
+	acc, ok := a.accounts[name]
+	if !ok {
+		return ErrAccountNotFound
+	}
+	a.stopLocked(acc)
+	if acc.cancel != nil {
+		acc.cancel()
+	}
+	delete(a.accounts, name)
+	return a.saveLocked()
+}
+
+// Enable starts the bot of a disabled account
+func (a *Accounts) Enable(name string) error {
+	if err := a.start(name); err != nil {
+		return err
+	}
+	a.Lock()
+	defer a.Unlock()
+	acc, ok := a.accounts[name]
+	if !ok {
+		return ErrAccountNotFound
+	}
+	acc.config.Disabled = false
+	return a.saveLocked()
+}
+
+// Disable stops the bot of an account, requests to the account are refused until it is enabled again
+func (a *Accounts) Disable(name string) error {
+	a.Lock()
+	defer a.Unlock()
+	acc, ok := a.accounts[name]
+	if !ok {
+		return ErrAccountNotFound
+	}
+	if acc.starting {
+		return ErrAccountStarting
+	}
+	a.stopLocked(acc)
+	acc.config.Disabled = true
+	return a.saveLocked()
+}
+
+// Get returns the bot and activity tracker of an enabled account
+func (a *Accounts) Get(name string) (*wrapper.OGame, *activityTracker.Tracker, error) {
+	a.Lock()
+	defer a.Unlock()
+	acc, ok := a.accounts[name]
+	if !ok {
+		return nil, nil, ErrAccountNotFound
+	}
+	if acc.starting {
+		return nil, nil, ErrAccountStarting
+	}
+	if acc.config.Disabled || acc.bot == nil {
+		return nil, nil, ErrAccountDisabled
+	}
+	return acc.bot, acc.tracker, nil
+}
+
+// Statuses returns the state of all the accounts, sorted by name
+func (a *Accounts) Statuses() []AccountStatus {
+	a.Lock()
+	defer a.Unlock()
+	out := make([]AccountStatus, 0, len(a.accounts))
+	for _, acc := range a.accounts {
+		status := AccountStatus{
+			Name:      acc.config.Name,
+			Universe:  acc.config.Universe,
+			Username:  acc.config.Username,
+			Language:  acc.config.Language,
+			Enabled:   !acc.config.Disabled && acc.bot != nil,
+			Temporary: acc.temporary,
+		}
+		if acc.bot != nil {
+			status.LoggedIn = acc.bot.IsLoggedIn()
+		}
+		if acc.err != nil {
+			status.Error = acc.err.Error()
+		}
+		out = append(out, status)
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].Name < out[j].Name })
+	return out
+}
+
+// start creates the bot of the account if needed, and starts its activity tracker and events poller.
+// The bot is created without holding the lock since it logs in, requests to the other accounts are not blocked meanwhile.
+func (a *Accounts) start(name string) error {
+	a.Lock()
+	acc, ok := a.accounts[name]
+	if !ok {
+		a.Unlock()
+		return ErrAccountNotFound
+	}
+	if acc.starting {
+		a.Unlock()
+		return ErrAccountStarting
+	}
+	if acc.bot != nil {
+		a.runLocked(acc)
+		a.Unlock()
+		return nil
+	}
+	acc.starting = true
+	config := acc.config
+	a.Unlock()
+
+	ctx, cancel := context.WithCancel(a.ctx)
+	bot, err := a.newBot(ctx, config)
+
+	a.Lock()
+	defer a.Unlock()
+	acc.starting = false
+	if a.accounts[name] != acc { // Removed while logging in
+		cancel()
+		return ErrAccountNotFound
+	}
+	if err != nil {
+		cancel()
+		acc.err = err
+		return err
+	}
+	acc.bot, acc.cancel, acc.err = bot, cancel, nil
+	acc.tracker = activityTracker.New(bot)
+	a.runLocked(acc)
+	return nil
+}
+
+// runLocked enables the bot of the account, and starts its activity tracker and events poller, the lock must be held
+func (a *Accounts) runLocked(acc *account) {
+	acc.bot.Enable()
+	if acc.cancelTracker == nil {
+		ctx, cancel := context.WithCancel(a.ctx)
+		acc.cancelTracker = cancel
+		go acc.tracker.Run(ctx, a.activityInterval)
+		go pollEvents(ctx, acc.bot, a.eventsInterval)
+	}
+}
+
+// stopLocked disables the bot of the account and stops its activity tracker and events poller, the lock must be held
+func (a *Accounts) stopLocked(acc *account) {
+	if acc.cancelTracker != nil {
+		acc.cancelTracker()
+		acc.cancelTracker = nil
+	}
+	if acc.bot != nil {
+		acc.bot.Disable()
+	}
+}
+
+// saveLocked writes the accounts in the config file, the lock must be held
+func (a *Accounts) saveLocked() error {
+	if a.path == "" {
+		return nil
+	}
+	config := Config{Accounts: make([]AccountConfig, 0)}
+	for _, acc := range a.accounts {
+		if !acc.temporary {
+			config.Accounts = append(config.Accounts, acc.config)
+		}
+	}
+	sort.Slice(config.Accounts, func(i, j int) bool { return config.Accounts[i].Name < config.Accounts[j].Name })
+	return writeJSONFile(a.path, config)
+}
+
+// writeJSONFile replaces the content of a file with the indented json of v, atomically
+func writeJSONFile(path string, v any) error {
+	by, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		return err
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(path), ".ogamed-*")
+	if err != nil {
+		return err
+	}
+	_, err = tmp.Write(by)
+	if closeErr := tmp.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		_ = os.Remove(tmp.Name())
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
+
+func accountErrorResp(c echo.Context, err error) error {
+	switch {
+	case errors.Is(err, ErrAccountNotFound):
+		return c.JSON(http.StatusNotFound, wrapper.ErrorCodeResp(404, "account_not_found", err.Error()))
+	case errors.Is(err, ErrAccountExists):
+		return c.JSON(http.StatusConflict, wrapper.ErrorCodeResp(409, "account_exists", err.Error()))
+	case errors.Is(err, ErrAccountDisabled):
+		return c.JSON(http.StatusServiceUnavailable, wrapper.ErrorCodeResp(503, "account_disabled", err.Error()))
+	case errors.Is(err, ErrAccountStarting):
+		return c.JSON(http.StatusServiceUnavailable, wrapper.ErrorCodeResp(503, "account_starting", err.Error()))
+	}
+	return wrapper.ErrorJSON(c, http.StatusBadRequest, err)
+}
+
+// AccountMiddleware sets the bot of the account named by the :name route param in the context.
+// When name is not empty, that account is used instead.
+func (a *Accounts) AccountMiddleware(name string) echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			accountName := name
+			if accountName == "" {
+				accountName = c.Param("name")
+			}
+			bot, tracker, err := a.Get(accountName)
+			if err != nil {
+				return accountErrorResp(c, err)
+			}
+			c.Set("bot", bot)
+			c.Set(activityTracker.ContextKey, tracker)
+			return next(c)
+		}
+	}
+}
+
+// GetAccountsHandler returns the state of all the accounts
+func (a *Accounts) GetAccountsHandler(c echo.Context) error {
+	return c.JSON(http.StatusOK, wrapper.SuccessResp(a.Statuses()))
+}
+
+// AddAccountHandler adds an account, the request body is a json AccountConfig
+func (a *Accounts) AddAccountHandler(c echo.Context) error {
+	var config AccountConfig
+	if err := json.NewDecoder(c.Request().Body).Decode(&config); err != nil {
+		return c.JSON(http.StatusBadRequest, wrapper.ErrorResp(400, err.Error()))
+	}
+	if err := a.Add(config); err != nil {
+		return accountErrorResp(c, err)
+	}
+	return c.JSON(http.StatusOK, wrapper.SuccessResp(nil))
+}
+
+// RemoveAccountHandler removes an account
+func (a *Accounts) RemoveAccountHandler(c echo.Context) error {
+	if err := a.Remove(c.Param("name")); err != nil {
+		return accountErrorResp(c, err)
+	}
+	return c.JSON(http.StatusOK, wrapper.SuccessResp(nil))
+}
+
+// EnableAccountHandler enables an account
+func (a *Accounts) EnableAccountHandler(c echo.Context) error {
+	if err := a.Enable(c.Param("name")); err != nil {
+		return accountErrorResp(c, err)
+	}
+	return c.JSON(http.StatusOK, wrapper.SuccessResp(nil))
+}
+
+// DisableAccountHandler disables an account
+func (a *Accounts) DisableAccountHandler(c echo.Context) error {
+	if err := a.Disable(c.Param("name")); err != nil {
+		return accountErrorResp(c, err)
+	}
+	return c.JSON(http.StatusOK, wrapper.SuccessResp(nil))
+}
+
+// Routes routes managing the accounts, with their documentation
+func (a *Accounts) Routes() []openapi.Route {
+	nameParam := []openapi.Param{{Name: "name", In: openapi.InPath, Type: ""}}
+	return []openapi.Route{
+		{Method: http.MethodGet, Path: "/accounts", Handler: a.GetAccountsHandler, Tag: "accounts", Summary: "State of all the accounts", Result: []AccountStatus{}, Root: true, Scope: wrapper.ScopeAdmin},
+		{Method: http.MethodPost, Path: "/accounts", Handler: a.AddAccountHandler, Tag: "accounts", Summary: "Add an account and save it in the config file", Body: AccountConfig{}, Root: true},
+		{Method: http.MethodDelete, Path: "/accounts/:name", Handler: a.RemoveAccountHandler, Tag: "accounts", Summary: "Remove an account", Params: nameParam, Root: true},
+		{Method: http.MethodPost, Path: "/accounts/:name/enable", Handler: a.EnableAccountHandler, Tag: "accounts", Summary: "Enable an account, starting its bot", Params: nameParam, Root: true},
+		{Method: http.MethodPost, Path: "/accounts/:name/disable", Handler: a.DisableAccountHandler, Tag: "accounts", Summary: "Disable an account, stopping its bot", Params: nameParam, Root: true},
+	}
+}
@@ -0,0 +1,137 @@
+package main
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/alaingilbert/ogame/pkg/device"
+	"github.com/alaingilbert/ogame/pkg/wrapper"
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+)
+
+func testBotFactory(ctx context.Context, config AccountConfig) (*wrapper.OGame, error) {
+	return wrapper.NewWithParams(wrapper.Params{
+		Ctx:      ctx,
+		Device:   new(device.Device),
+		Universe: config.Universe,
+		Username: config.Username,
+		Lang:     config.Language,
+		Quiet:    true,
+	})
+}
+
+func newTestServer(accounts *Accounts) *echo.Echo {
+	e := echo.New()
+	e.GET("/accounts", accounts.GetAccountsHandler)
+	e.POST("/accounts", accounts.AddAccountHandler)
+	e.DELETE("/accounts/:name", accounts.RemoveAccountHandler)
+	e.POST("/accounts/:name/enable", accounts.EnableAccountHandler)
+	e.POST("/accounts/:name/disable", accounts.DisableAccountHandler)
+	auth := NewAuth("", "", nil)
+	registerBotRoutes(e.Group("/accounts/:name", accounts.AccountMiddleware("")), auth)
+	registerBotRoutes(e.Group("", accounts.AccountMiddleware(DefaultAccount)), auth)
+	return e
+}
+
+func request(e *echo.Echo, method, path, body string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(method, path, strings.NewReader(body))
+	req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+	rec := httptest.NewRecorder()
+	e.ServeHTTP(rec, req)
+	return rec
+}
+
+func TestAccounts(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	path := filepath.Join(t.TempDir(), "ogamed.json")
+	assert.NoError(t, os.WriteFile(path, []byte(`{"Accounts": [
+		{"Name": "alice", "Universe": "Bellatrix", "Username": "alice@example.com"},
+		{"Name": "bob", "Universe": "Andromeda", "Username": "bob@example.com", "Disabled": true}
+	]}`), 0600))
+	accounts := NewAccounts(ctx, testBotFactory, 0, 0)
+	assert.NoError(t, accounts.Load(path))
+	assert.NoError(t, accounts.AddTemporary(AccountConfig{Name: DefaultAccount, Universe: "Orion", Username: "me@example.com"}))
+	e := newTestServer(accounts)
+
+	rec := request(e, http.MethodGet, "/accounts/alice/bot/universe-name", "")
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Contains(t, rec.Body.String(), "Bellatrix")
+	rec = request(e, http.MethodGet, "/bot/universe-name", "")
+	assert.Contains(t, rec.Body.String(), "Orion")
+	rec = request(e, http.MethodGet, "/accounts/bob/bot/universe-name", "")
+	assert.Equal(t, http.StatusServiceUnavailable, rec.Code)
+	rec = request(e, http.MethodGet, "/accounts/carol/bot/universe-name", "")
+	assert.Equal(t, http.StatusNotFound, rec.Code)
+
+	rec = request(e, http.MethodPost, "/accounts", `{"Name": "carol", "Universe": "Cygnus", "Username": "carol@example.com"}`)
+	assert.Equal(t, http.StatusOK, rec.Code)
+	rec = request(e, http.MethodPost, "/accounts", `{"Name": "carol", "Universe": "Cygnus", "Username": "carol@example.com"}`)
+	assert.Equal(t, http.StatusConflict, rec.Code)
+	rec = request(e, http.MethodPost, "/accounts", `{"Name": "../etc", "Universe": "Cygnus", "Username": "x"}`)
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+	rec = request(e, http.MethodGet, "/accounts/carol/bot/universe-name", "")
+	assert.Contains(t, rec.Body.String(), "Cygnus")
+
+	assert.Equal(t, http.StatusOK, request(e, http.MethodPost, "/accounts/bob/enable", "").Code)
+	assert.Equal(t, http.StatusOK, request(e, http.MethodGet, "/accounts/bob/bot/universe-name", "").Code)
+	assert.Equal(t, http.StatusOK, request(e, http.MethodPost, "/accounts/alice/disable", "").Code)
+	assert.Equal(t, http.StatusServiceUnavailable, request(e, http.MethodGet, "/accounts/alice/bot/universe-name", "").Code)
+	assert.Equal(t, http.StatusOK, request(e, http.MethodPost, "/accounts/alice/enable", "").Code)
+	assert.Equal(t, http.StatusOK, request(e, http.MethodGet, "/accounts/alice/bot/universe-name", "").Code)
+	assert.Equal(t, http.StatusOK, request(e, http.MethodDelete, "/accounts/carol", "").Code)
+	assert.Equal(t, http.StatusNotFound, request(e, http.MethodDelete, "/accounts/carol", "").Code)
+
+	statuses := accounts.Statuses()
+	assert.Len(t, statuses, 3)
+	assert.Equal(t, "alice", statuses[0].Name)
+	assert.Equal(t, DefaultAccount, statuses[2].Name)
+	assert.True(t, statuses[2].Temporary)
+
+	// Runtime changes are saved, without the account defined by the flags
+	config, err := LoadConfig(path)
+	assert.NoError(t, err)
+	assert.Len(t, config.Accounts, 2)
+	assert.Equal(t, "alice", config.Accounts[0].Name)
+	assert.Equal(t, "alice", config.Accounts[0].Device.Name)
+	assert.Equal(t, device.Windows, config.Accounts[0].Device.Os)
+	assert.False(t, config.Accounts[1].Disabled)
+}
+
+func TestAccounts_SlowLogin(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	loggingIn, release := make(chan struct{}), make(chan struct{})
+	slowFactory := func(ctx context.Context, config AccountConfig) (*wrapper.OGame, error) {
+		if config.Name == "slow" {
+			close(loggingIn)
+			<-release
+		}
+		return testBotFactory(ctx, config)
+	}
+	accounts := NewAccounts(ctx, slowFactory, 0, 0)
+	assert.NoError(t, accounts.Add(AccountConfig{Name: "fast", Universe: "Orion", Username: "fast@example.com"}))
+	added := make(chan error)
+	go func() {
+		added <- accounts.Add(AccountConfig{Name: "slow", Universe: "Orion", Username: "slow@example.com"})
+	}()
+	<-loggingIn
+
+	// The other accounts are served while the slow one logs in
+	_, _, err := accounts.Get("fast")
+	assert.NoError(t, err)
+	_, _, err = accounts.Get("slow")
+	assert.ErrorIs(t, err, ErrAccountStarting)
+	assert.ErrorIs(t, accounts.Disable("slow"), ErrAccountStarting)
+
+	close(release)
+	assert.NoError(t, <-added)
+	_, _, err = accounts.Get("slow")
+	assert.NoError(t, err)
+}
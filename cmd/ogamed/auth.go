@@ -0,0 +1,462 @@
+package main
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/alaingilbert/ogame/pkg/openapi"
+	"github.com/alaingilbert/ogame/pkg/wrapper"
+	"github.com/labstack/echo/v4"
+)
+
+// DefaultAuditLogSize number of audit entries kept in memory
+const DefaultAuditLogSize = 1000
+
+// callerContextKey key of the echo context holding the APIKey of the caller
+const callerContextKey = "caller"
+
+var (
+	ErrKeyNotFound  = errors.New("api key not found")
+	ErrInvalidScope = errors.New("invalid scope")
+	ErrKeyNameEmpty = errors.New("api key name is required")
+)
+
+// Scopes scopes an api key can be given
+var Scopes = []string{wrapper.ScopeRead, wrapper.ScopeFleet, wrapper.ScopeBuild, wrapper.ScopeMarketplace, wrapper.ScopeAdmin}
+
+// APIKey api key of a tool calling ogamed, only the hash of its token is kept
+type APIKey struct {
+	ID        string
+	Name      string // Shown as the initiator of the tasks in the task queue
+	Scopes    []string
+	CreatedAt time.Time
+}
+
+// allows returns either or not the key can call a route requiring scope, every key can call the read routes
+func (k APIKey) allows(scope string) bool {
+	if scope == wrapper.ScopeRead {
+		return true
+	}
+	for _, s := range k.Scopes {
+		if s == scope || s == wrapper.ScopeAdmin {
+			return true
+		}
+	}
+	return false
+}
+
+// CreatedKey api key just created, the token is not retrievable later
+type CreatedKey struct {
+	APIKey
+	Token string
+}
+
+// CreateKeyRequest body of the create key route
+type CreateKeyRequest struct {
+	Name   string
+	Scopes []string
+}
+
+type storedKey struct {
+	APIKey
+	Hash string // Hex encoded sha256 of the token
+}
+
+// keysFile content of the api keys file
+type keysFile struct {
+	Keys []storedKey
+}
+
+func hashToken(token string) string {
+	h := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(h[:])
+}
+
+func randomHex(n int) (string, error) {
+	b := make([]byte, n)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
+
+// Auth authenticates the requests with an api key (Bearer token or X-API-Key header) or the basic auth user.
+// Every request is allowed when neither api keys nor basic auth are configured.
+type Auth struct {
+	sync.Mutex
+	basicUsername string
+	basicPassword string
+	path          string
+	keys          map[string]storedKey // By id
+	audit         *AuditLog
+}
+
+// NewAuth creates the authentication of ogamed, the basic auth user has the admin scope.
+// Basic auth is disabled if the username or password is empty.
+func NewAuth(basicUsername, basicPassword string, audit *AuditLog) *Auth {
+	a := &Auth{keys: make(map[string]storedKey), audit: audit}
+	if basicUsername != "" && basicPassword != "" {
+		a.basicUsername, a.basicPassword = basicUsername, basicPassword
+	}
+	return a
+}
+
+// Load loads the api keys of a file, the file is then updated when keys are created or revoked.
+// A missing file is created with the first key.
+func (a *Auth) Load(path string) error {
+	a.Lock()
+	defer a.Unlock()
+	a.path = path
+	by, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	} else if err != nil {
+		return err
+	}
+	var file keysFile
+	if err := json.Unmarshal(by, &file); err != nil {
+		return err
+	}
+	for _, key := range file.Keys {
+		a.keys[key.ID] = key
+	}
+	return nil
+}
+
+// Keys returns the api keys, by creation date
+func (a *Auth) Keys() []APIKey {
+	a.Lock()
+	defer a.Unlock()
+	out := make([]APIKey, 0, len(a.keys))
+	for _, key := range a.keys {
+		out = append(out, key.APIKey)
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].CreatedAt.Before(out[j].CreatedAt) })
+	return out
+}
+
+// CreateKey creates an api key, and returns it with its token
+func (a *Auth) CreateKey(name string, scopes []string) (CreatedKey, error) {
+	if strings.TrimSpace(name) == "" {
+		return CreatedKey{}, ErrKeyNameEmpty
+	}
+	if len(scopes) == 0 {
+		scopes = []string{wrapper.ScopeRead}
+	}
+	for _, scope := range scopes {
+		if !isScope(scope) {
+			return CreatedKey{}, fmt.Errorf("%w %s", ErrInvalidScope, scope)
+		}
+	}
+	id, err := randomHex(6)
+	if err != nil {
+		return CreatedKey{}, err
+	}
+	secret, err := randomHex(24)
+	if err != nil {
+		return CreatedKey{}, err
+	}
+	token := "ogd_" + secret
+	key := storedKey{APIKey: APIKey{ID: id, Name: name, Scopes: scopes, CreatedAt: time.Now()}, Hash: hashToken(token)}
+	a.Lock()
+	defer a.Unlock()
+	a.keys[id] = key
+	if err := a.saveLocked(); err != nil {
+		delete(a.keys, id)
+		return CreatedKey{}, err
+	}
+	return CreatedKey{APIKey: key.APIKey, Token: token}, nil
+}
+
+// RevokeKey deletes an api key
+func (a *Auth) RevokeKey(id string) error {
+	a.Lock()
+	defer a.Unlock()
+	key, ok := a.keys[id]
+	if !ok {
+		return ErrKeyNotFound
+	}
+	delete(a.keys, id)
+	if err := a.saveLocked(); err != nil {
+		a.keys[id] = key
+		return err
+	}
+	return nil
+}
+
+func isScope(scope string) bool {
+	for _, s := range Scopes {
+		if s == scope {
+			return true
+		}
+	}
+	return false
+}
+
+// saveLocked writes the api keys in the keys file, the lock must be held
+func (a *Auth) saveLocked() error {
+	if a.path == "" {
+		return nil
+	}
+	file := keysFile{Keys: make([]storedKey, 0, len(a.keys))}
+	for _, key := range a.keys {
+		file.Keys = append(file.Keys, key)
+	}
+	sort.Slice(file.Keys, func(i, j int) bool { return file.Keys[i].CreatedAt.Before(file.Keys[j].CreatedAt) })
+	return writeJSONFile(a.path, file)
+}
+
+// authenticate returns the api key of the request, the basic auth user has the admin scope.
+// When no authentication is configured, the caller is an anonymous admin.
+func (a *Auth) authenticate(req *http.Request) (APIKey, bool) {
+	token := req.Header.Get("X-API-Key")
+	if auth := req.Header.Get(echo.HeaderAuthorization); strings.HasPrefix(auth, "Bearer ") {
+		token = strings.TrimPrefix(auth, "Bearer ")
+	}
+	a.Lock()
+	defer a.Unlock()
+	if token != "" {
+		hash := hashToken(token)
+		for _, key := range a.keys {
+			if subtle.ConstantTimeCompare([]byte(hash), []byte(key.Hash)) == 1 {
+				return key.APIKey, true
+			}
+		}
+		return APIKey{}, false
+	}
+	if a.basicUsername != "" {
+		// Be careful to use constant time comparison to prevent timing attacks
+		username, password, ok := req.BasicAuth()
+		if ok && subtle.ConstantTimeCompare([]byte(username), []byte(a.basicUsername)) == 1 &&
+			subtle.ConstantTimeCompare([]byte(password), []byte(a.basicPassword)) == 1 {
+			return APIKey{Name: username, Scopes: []string{wrapper.ScopeAdmin}}, true
+		}
+		return APIKey{}, false
+	}
+	if len(a.keys) > 0 {
+		return APIKey{}, false
+	}
+	return APIKey{Scopes: []string{wrapper.ScopeAdmin}}, true
+}
+
+// Middleware authenticates every request, and sets the name of the caller as the initiator of its tasks
+func (a *Auth) Middleware() echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			key, ok := a.authenticate(c.Request())
+			if !ok {
+				if a.basicUsername != "" {
+					c.Response().Header().Set(echo.HeaderWWWAuthenticate, `Basic realm="Restricted"`)
+				}
+				return c.JSON(http.StatusUnauthorized, wrapper.ErrorResp(401, "invalid or missing credentials"))
+			}
+			c.Set(callerContextKey, key)
+			if key.Name != "" {
+				c.Set(wrapper.InitiatorContextKey, key.Name)
+			}
+			return next(c)
+		}
+	}
+}
+
+// Require rejects the callers whose api key does not have the scope
+func (a *Auth) Require(scope string) echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			key, _ := c.Get(callerContextKey).(APIKey)
+			if !key.allows(scope) {
+				return c.JSON(http.StatusForbidden, wrapper.ErrorCodeResp(403, "insufficient_scope", "the "+scope+" scope is required"))
+			}
+			return next(c)
+		}
+	}
+}
+
+// Audit records the request in the audit log once it is processed
+func (a *Auth) Audit(next echo.HandlerFunc) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		err := next(c)
+		if a.audit == nil {
+			return err
+		}
+		status := c.Response().Status
+		if err != nil {
+			status = http.StatusInternalServerError
+			var httpErr *echo.HTTPError
+			if errors.As(err, &httpErr) {
+				status = httpErr.Code
+			}
+		}
+		key, _ := c.Get(callerContextKey).(APIKey)
+		a.audit.Record(AuditEntry{
+			Time:   time.Now(),
+			KeyID:  key.ID,
+			Caller: key.Name,
+			Method: c.Request().Method,
+			Path:   c.Request().URL.Path,
+			Status: status,
+		})
+		return err
+	}
+}
+
+func keyErrorResp(c echo.Context, err error) error {
+	switch {
+	case errors.Is(err, ErrKeyNotFound):
+		return c.JSON(http.StatusNotFound, wrapper.ErrorCodeResp(404, "key_not_found", err.Error()))
+	case errors.Is(err, ErrKeyNameEmpty), errors.Is(err, ErrInvalidScope):
+		return c.JSON(http.StatusBadRequest, wrapper.ErrorCodeResp(400, "invalid_key", err.Error()))
+	}
+	return wrapper.ErrorJSON(c, http.StatusInternalServerError, err)
+}
+
+// GetKeysHandler returns the api keys, without their token
+func (a *Auth) GetKeysHandler(c echo.Context) error {
+	return c.JSON(http.StatusOK, wrapper.SuccessResp(a.Keys()))
+}
+
+// CreateKeyHandler creates an api key, the request body is a json CreateKeyRequest
+func (a *Auth) CreateKeyHandler(c echo.Context) error {
+	var req CreateKeyRequest
+	if err := json.NewDecoder(c.Request().Body).Decode(&req); err != nil {
+		return c.JSON(http.StatusBadRequest, wrapper.ErrorResp(400, err.Error()))
+	}
+	key, err := a.CreateKey(req.Name, req.Scopes)
+	if err != nil {
+		return keyErrorResp(c, err)
+	}
+	return c.JSON(http.StatusOK, wrapper.SuccessResp(key))
+}
+
+// RevokeKeyHandler deletes an api key
+func (a *Auth) RevokeKeyHandler(c echo.Context) error {
+	if err := a.RevokeKey(c.Param("id")); err != nil {
+		return keyErrorResp(c, err)
+	}
+	return c.JSON(http.StatusOK, wrapper.SuccessResp(nil))
+}
+
+// GetAuditHandler returns the last entries of the audit log, the "limit" query param defaults to 100
+func (a *Auth) GetAuditHandler(c echo.Context) error {
+	limit := 100
+	if s := c.QueryParam("limit"); s != "" {
+		v, err := strconv.Atoi(s)
+		if err != nil || v < 0 {
+			return c.JSON(http.StatusBadRequest, wrapper.ErrorResp(400, "invalid limit"))
+		}
+		limit = v
+	}
+	entries := make([]AuditEntry, 0)
+	if a.audit != nil {
+		entries = a.audit.Entries(limit)
+	}
+	return c.JSON(http.StatusOK, wrapper.SuccessResp(entries))
+}
+
+// Routes routes managing the api keys, with their documentation
+func (a *Auth) Routes() []openapi.Route {
+	return []openapi.Route{
+		{Method: http.MethodGet, Path: "/keys", Handler: a.GetKeysHandler, Tag: "auth", Summary: "Api keys, without their token", Result: []APIKey{}, Root: true, Scope: wrapper.ScopeAdmin},
+		{Method: http.MethodPost, Path: "/keys", Handler: a.CreateKeyHandler, Tag: "auth", Summary: "Create an api key, its token is only returned once. Scopes: read (default), fleet, build, marketplace, admin",
+			Body: CreateKeyRequest{}, Result: CreatedKey{}, Root: true, Scope: wrapper.ScopeAdmin},
+		{Method: http.MethodDelete, Path: "/keys/:id", Handler: a.RevokeKeyHandler, Tag: "auth", Summary: "Revoke an api key",
+			Params: []openapi.Param{{Name: "id", In: openapi.InPath, Type: ""}}, Root: true, Scope: wrapper.ScopeAdmin},
+		{Method: http.MethodGet, Path: "/audit", Handler: a.GetAuditHandler, Tag: "auth", Summary: "Last mutating requests, and the api key that made them",
+			Params: []openapi.Param{{Name: "limit", In: openapi.InQuery, Type: 0, Description: "Number of entries, 100 by default"}}, Result: []AuditEntry{}, Root: true, Scope: wrapper.ScopeAdmin},
+	}
+}
+
+// routeScope scope required by a route, read for GET and HEAD and admin for the other methods if not set.
+// GET routes changing the state of the game must set their scope.
+func routeScope(route openapi.Route) string {
+	switch {
+	case route.Scope != "":
+		return route.Scope
+	case route.Method == http.MethodGet || route.Method == http.MethodHead:
+		return wrapper.ScopeRead
+	}
+	return wrapper.ScopeAdmin
+}
+
+// scoped returns the routes with their required scope set
+func scoped(routes []openapi.Route) []openapi.Route {
+	out := make([]openapi.Route, len(routes))
+	for i, route := range routes {
+		route.Scope = routeScope(route)
+		out[i] = route
+	}
+	return out
+}
+
+// AuditEntry mutating request made to ogamed
+type AuditEntry struct {
+	Time   time.Time
+	KeyID  string `json:",omitempty"` // Empty for the basic auth user
+	Caller string // Name of the api key, or the basic auth username. Empty if authentication is disabled
+	Method string
+	Path   string
+	Status int
+}
+
+// AuditLog keeps the last entries in memory, and appends them as json lines to a file if any
+type AuditLog struct {
+	sync.Mutex
+	size    int
+	entries []AuditEntry
+	file    *os.File
+}
+
+// NewAuditLog creates an audit log keeping size entries in memory, and appending them to path if not empty
+func NewAuditLog(path string, size int) (*AuditLog, error) {
+	l := &AuditLog{size: max(size, 1)}
+	if path != "" {
+		f, err := os.OpenFile(path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0600)
+		if err != nil {
+			return nil, err
+		}
+		l.file = f
+	}
+	return l, nil
+}
+
+// Record adds an entry to the log
+func (l *AuditLog) Record(entry AuditEntry) {
+	l.Lock()
+	defer l.Unlock()
+	l.entries = append(l.entries, entry)
+	if len(l.entries) > l.size {
+		l.entries = l.entries[len(l.entries)-l.size:]
+	}
+	if l.file != nil {
+		by, _ := json.Marshal(entry)
+		_, _ = l.file.Write(append(by, '\n'))
+	}
+}
+
+// Entries returns the last limit entries, oldest first
+func (l *AuditLog) Entries(limit int) []AuditEntry {
+	l.Lock()
+	defer l.Unlock()
+	start := max(len(l.entries)-limit, 0)
+	return append(make([]AuditEntry, 0, len(l.entries)-start), l.entries[start:]...)
+}
+
+// Close closes the file of the log
+func (l *AuditLog) Close() error {
+	if l.file == nil {
+		return nil
+	}
+	return l.file.Close()
+}
@@ -0,0 +1,141 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/alaingilbert/ogame/pkg/openapi"
+	"github.com/alaingilbert/ogame/pkg/wrapper"
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newAuthServer(auth *Auth) *echo.Echo {
+	e := echo.New()
+	e.Use(auth.Middleware())
+	addRoutes(e, auth, auth.Routes())
+	addRoutes(e, auth, []openapi.Route{
+		{Method: http.MethodGet, Path: "/ping", Handler: func(c echo.Context) error { return c.JSON(http.StatusOK, wrapper.SuccessResp("pong")) }},
+		{Method: http.MethodPost, Path: "/fleet", Scope: wrapper.ScopeFleet, Handler: func(c echo.Context) error {
+			return c.JSON(http.StatusOK, wrapper.SuccessResp(c.Get(wrapper.InitiatorContextKey)))
+		}},
+	})
+	return e
+}
+
+func authRequest(e *echo.Echo, method, path, token, body string) (*httptest.ResponseRecorder, wrapper.APIResp) {
+	req := httptest.NewRequest(method, path, nil)
+	if body != "" {
+		req = httptest.NewRequest(method, path, strings.NewReader(body))
+		req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+	}
+	if token != "" {
+		req.Header.Set(echo.HeaderAuthorization, "Bearer "+token)
+	}
+	rec := httptest.NewRecorder()
+	e.ServeHTTP(rec, req)
+	var resp wrapper.APIResp
+	_ = json.Unmarshal(rec.Body.Bytes(), &resp)
+	return rec, resp
+}
+
+func createKey(t *testing.T, e *echo.Echo, token, body string) CreatedKey {
+	rec, _ := authRequest(e, http.MethodPost, "/keys", token, body)
+	require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+	var resp struct{ Result CreatedKey }
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &resp))
+	return resp.Result
+}
+
+func TestAuth(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "keys.json")
+	audit, err := NewAuditLog("", 10)
+	require.NoError(t, err)
+	auth := NewAuth("", "", audit)
+	require.NoError(t, auth.Load(path))
+	e := newAuthServer(auth)
+
+	// Without keys every request is allowed
+	rec, _ := authRequest(e, http.MethodGet, "/ping", "", "")
+	assert.Equal(t, http.StatusOK, rec.Code)
+	admin := createKey(t, e, "", `{"Name": "admin", "Scopes": ["admin"]}`)
+	reader := createKey(t, e, admin.Token, `{"Name": "dashboard"}`)
+	assert.Equal(t, []string{wrapper.ScopeRead}, reader.Scopes)
+	fleet := createKey(t, e, admin.Token, `{"Name": "fleet-saver", "Scopes": ["fleet"]}`)
+	rec, resp := authRequest(e, http.MethodPost, "/keys", admin.Token, `{"Name": "x", "Scopes": ["unknown"]}`)
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+	assert.Equal(t, "invalid_key", resp.ErrorCode)
+
+	// Once a key exists, requests must be authenticated
+	rec, _ = authRequest(e, http.MethodGet, "/ping", "", "")
+	assert.Equal(t, http.StatusUnauthorized, rec.Code)
+	rec, _ = authRequest(e, http.MethodGet, "/ping", "ogd_invalid", "")
+	assert.Equal(t, http.StatusUnauthorized, rec.Code)
+	rec, _ = authRequest(e, http.MethodGet, "/ping", reader.Token, "")
+	assert.Equal(t, http.StatusOK, rec.Code)
+	rec, resp = authRequest(e, http.MethodPost, "/fleet", reader.Token, "")
+	assert.Equal(t, http.StatusForbidden, rec.Code)
+	assert.Equal(t, "insufficient_scope", resp.ErrorCode)
+	rec, _ = authRequest(e, http.MethodGet, "/keys", fleet.Token, "")
+	assert.Equal(t, http.StatusForbidden, rec.Code)
+	// GET routes changing the state of the game require their scope
+	registerBotRoutes(e, auth)
+	rec, resp = authRequest(e, http.MethodGet, "/bot/celestials/123/abandon", reader.Token, "")
+	assert.Equal(t, http.StatusForbidden, rec.Code)
+	assert.Equal(t, "insufficient_scope", resp.ErrorCode)
+	rec, _ = authRequest(e, http.MethodGet, "/bot/buy-offer-of-the-day", reader.Token, "")
+	assert.Equal(t, http.StatusForbidden, rec.Code)
+
+	// The name of the key is the initiator of the tasks, and the mutating requests are audited
+	rec, resp = authRequest(e, http.MethodPost, "/fleet", fleet.Token, "")
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, "fleet-saver", resp.Result)
+	entries := audit.Entries(10)
+	require.Len(t, entries, 9) // 4 key creations, and the 5 requests on the routes not requiring the read scope
+	last := entries[len(entries)-1]
+	assert.Equal(t, AuditEntry{Time: last.Time, KeyID: fleet.ID, Caller: "fleet-saver", Method: http.MethodPost, Path: "/fleet", Status: http.StatusOK}, last)
+	assert.Equal(t, "/bot/buy-offer-of-the-day", entries[len(entries)-2].Path)
+	assert.Equal(t, http.StatusForbidden, entries[len(entries)-2].Status)
+	assert.Len(t, audit.Entries(2), 2)
+
+	// Keys are saved in the keys file
+	rec, _ = authRequest(e, http.MethodDelete, "/keys/"+reader.ID, admin.Token, "")
+	assert.Equal(t, http.StatusOK, rec.Code)
+	rec, _ = authRequest(e, http.MethodDelete, "/keys/"+reader.ID, admin.Token, "")
+	assert.Equal(t, http.StatusNotFound, rec.Code)
+	reloaded := NewAuth("", "", nil)
+	require.NoError(t, reloaded.Load(path))
+	keys := reloaded.Keys()
+	require.Len(t, keys, 2)
+	assert.Equal(t, admin.APIKey.ID, keys[0].ID)
+	_, ok := reloaded.authenticate(httptest.NewRequest(http.MethodGet, "/", nil))
+	assert.False(t, ok)
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req.Header.Set("X-API-Key", fleet.Token)
+	key, ok := reloaded.authenticate(req)
+	assert.True(t, ok)
+	assert.Equal(t, "fleet-saver", key.Name)
+}
+
+func TestAuth_BasicAuth(t *testing.T) {
+	e := newAuthServer(NewAuth("admin", "secret", nil))
+	rec, _ := authRequest(e, http.MethodGet, "/ping", "", "")
+	assert.Equal(t, http.StatusUnauthorized, rec.Code)
+	assert.Equal(t, `Basic realm="Restricted"`, rec.Header().Get(echo.HeaderWWWAuthenticate))
+
+	req := httptest.NewRequest(http.MethodPost, "/fleet", nil)
+	req.SetBasicAuth("admin", "secret")
+	rec = httptest.NewRecorder()
+	e.ServeHTTP(rec, req)
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Contains(t, rec.Body.String(), `"Result":"admin"`)
+	req.SetBasicAuth("admin", "wrong")
+	rec = httptest.NewRecorder()
+	e.ServeHTTP(rec, req)
+	assert.Equal(t, http.StatusUnauthorized, rec.Code)
+}
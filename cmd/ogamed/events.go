@@ -0,0 +1,34 @@
+package main
+
+import (
+	"context"
+	"time"
+
+	"github.com/alaingilbert/ogame/pkg/wrapper"
+)
+
+// DefaultEventsInterval interval between two polls of the attacks and fleets of a bot
+const DefaultEventsInterval = time.Minute
+
+// pollEvents fetches the attacks and fleets of the bot every interval until the context is cancelled,
+// so the attackDetected and fleetReturned events are published without a client polling them.
+// Polling is disabled if interval is not positive.
+func pollEvents(ctx context.Context, bot *wrapper.OGame, interval time.Duration) {
+	if interval <= 0 {
+		return
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+		case <-ctx.Done():
+			return
+		}
+		if !bot.IsEnabled() || !bot.IsLoggedIn() {
+			continue
+		}
+		_, _ = bot.GetAttacks()
+		_, _, _ = bot.GetFleets()
+	}
+}
@@ -2,14 +2,14 @@ package main
 
 import (
 	"context"
-	"crypto/subtle"
-	"github.com/alaingilbert/ogame/pkg/device"
-	"github.com/alaingilbert/ogame/pkg/gameforge/solvers"
+	"github.com/alaingilbert/ogame/pkg/activityTracker"
+	"github.com/alaingilbert/ogame/pkg/openapi"
 	"github.com/alaingilbert/ogame/pkg/wrapper"
 	"github.com/labstack/echo/v4"
 	"github.com/labstack/echo/v4/middleware"
 	"github.com/urfave/cli/v3"
 	"log"
+	"net/http"
 	"os"
 	"strconv"
 )
@@ -157,6 +157,36 @@ func main() {
 			Value:   "device_name",
 			Sources: cli.EnvVars("OGAMED_DEVICENAME"),
 		},
+		&cli.StringFlag{
+			Name:    "config",
+			Usage:   "Path to a json file describing the accounts to serve on /accounts/:name/bot/...",
+			Value:   "",
+			Sources: cli.EnvVars("OGAMED_CONFIG"),
+		},
+		&cli.DurationFlag{
+			Name:    "activity-interval",
+			Usage:   "Interval between two polls of the activity of the tracked players",
+			Value:   activityTracker.DefaultInterval,
+			Sources: cli.EnvVars("OGAMED_ACTIVITY_INTERVAL"),
+		},
+		&cli.DurationFlag{
+			Name:    "events-interval",
+			Usage:   "Interval between two polls of the attacks and fleets, to stream their events (0 to disable)",
+			Value:   DefaultEventsInterval,
+			Sources: cli.EnvVars("OGAMED_EVENTS_INTERVAL"),
+		},
+		&cli.StringFlag{
+			Name:    "keys-file",
+			Usage:   "Path to the json file storing the api keys, managed on /keys",
+			Value:   "",
+			Sources: cli.EnvVars("OGAMED_KEYS_FILE"),
+		},
+		&cli.StringFlag{
+			Name:    "audit-log",
+			Usage:   "Path to a file where the mutating requests are appended as json lines",
+			Value:   "",
+			Sources: cli.EnvVars("OGAMED_AUDIT_LOG"),
+		},
 	}
 	app.Action = start
 	if err := app.Run(context.Background(), os.Args); err != nil {
@@ -187,45 +217,41 @@ func start(ctx context.Context, c *cli.Command) error {
 	corsEnabled := c.Bool("cors-enabled")
 	njaApiKey := c.String("nja-api-key")
 	deviceName := c.String("device-name")
-	// TODO: put device config in flags & env variables
-	deviceInst, err := device.NewBuilder(deviceName).
-		SetOsName(device.Windows).
-		SetBrowserName(device.Chrome).
-		SetMemory(8).
-		SetHardwareConcurrency(16).
-		ScreenColorDepth(24).
-		SetScreenWidth(1900).
-		SetScreenHeight(900).
-		SetTimezone("America/Los_Angeles").
-		SetLanguages("en-US,en").
-		Build()
-	if err != nil {
-		panic(err)
-	}
-
-	params := wrapper.Params{
-		Ctx:            ctx,
-		Device:         deviceInst,
-		Universe:       universe,
-		Username:       username,
-		Password:       password,
-		Lang:           language,
-		AutoLogin:      autoLogin,
-		Proxy:          proxyAddr,
-		ProxyUsername:  proxyUsername,
-		ProxyPassword:  proxyPassword,
-		ProxyType:      proxyType,
-		ProxyLoginOnly: proxyLoginOnly,
-		Lobby:          lobby,
-		APINewHostname: apiNewHostname,
-	}
-	if njaApiKey != "" {
-		params.CaptchaSolver = solvers.NinjaSolver(njaApiKey)
+	configPath := c.String("config")
+	activityInterval := c.Duration("activity-interval")
+	eventsInterval := c.Duration("events-interval")
+	keysPath := c.String("keys-file")
+	auditLogPath := c.String("audit-log")
+	accounts := NewAccounts(ctx, newBotFactory(apiNewHostname), activityInterval, eventsInterval)
+	if configPath != "" {
+		if err := accounts.Load(configPath); err != nil {
+			return err
+		}
 	}
-
-	bot, err := wrapper.NewWithParams(params)
-	if err != nil {
-		return err
+	if universe != "" {
+		config := AccountConfig{
+			Name:      DefaultAccount,
+			Universe:  universe,
+			Username:  username,
+			Password:  password,
+			Language:  language,
+			Lobby:     lobby,
+			AutoLogin: autoLogin,
+			Device:    DeviceConfig{Name: deviceName},
+			NjaApiKey: njaApiKey,
+		}
+		if proxyAddr != "" {
+			config.Proxy = &ProxyConfig{
+				Address:   proxyAddr,
+				Username:  proxyUsername,
+				Password:  proxyPassword,
+				Type:      proxyType,
+				LoginOnly: proxyLoginOnly,
+			}
+		}
+		if err := accounts.AddTemporary(config); err != nil {
+			return err
+		}
 	}
 
 	e := echo.New()
@@ -234,139 +260,45 @@ func start(ctx context.Context, c *cli.Command) error {
 	}
 	e.Use(func(next echo.HandlerFunc) echo.HandlerFunc {
 		return func(ctx echo.Context) error {
-			ctx.Set("bot", bot)
 			ctx.Set("version", version)
 			ctx.Set("commit", commit)
 			ctx.Set("date", date)
+			ctx.Set(wrapper.CORSContextKey, corsEnabled)
 			return next(ctx)
 		}
 	})
+	auditLog, err := NewAuditLog(auditLogPath, DefaultAuditLogSize)
+	if err != nil {
+		return err
+	}
+	defer auditLog.Close()
+	auth := NewAuth(basicAuthUsername, basicAuthPassword, auditLog)
 	if len(basicAuthUsername) > 0 && len(basicAuthPassword) > 0 {
 		log.Println("Enable Basic Auth")
-		e.Use(middleware.BasicAuth(func(username, password string, c echo.Context) (bool, error) {
-			// Be careful to use constant time comparison to prevent timing attacks
-			if subtle.ConstantTimeCompare([]byte(username), []byte(basicAuthUsername)) == 1 &&
-				subtle.ConstantTimeCompare([]byte(password), []byte(basicAuthPassword)) == 1 {
-				return true, nil
-			}
-			return false, nil
-		}))
 	}
+	if keysPath != "" {
+		if err := auth.Load(keysPath); err != nil {
+			return err
+		}
+	}
+	e.Use(auth.Middleware())
 	e.HideBanner = true
 	e.HidePort = true
 	e.Debug = false
 	e.GET("/", wrapper.HomeHandler)
-	e.GET("/tasks", wrapper.TasksHandler)
-
-	// CAPTCHA Handler
-	e.GET("/bot/captcha", wrapper.GetCaptchaHandler)
-	e.POST("/bot/captcha/solve", wrapper.GetCaptchaSolverHandler)
-	e.GET("/bot/captcha/challenge", wrapper.GetCaptchaChallengeHandler)
 
-	e.GET("/bot/ip", wrapper.GetPublicIPHandler)
-	e.GET("/bot/server", wrapper.GetServerHandler)
-	e.GET("/bot/server-data", wrapper.GetServerDataHandler)
-	e.POST("/bot/set-user-agent", wrapper.SetUserAgentHandler)
-	e.GET("/bot/server-url", wrapper.ServerURLHandler)
-	e.GET("/bot/language", wrapper.GetLanguageHandler)
-	e.GET("/bot/empire/type/:typeID", wrapper.GetEmpireHandler)
-	e.POST("/bot/page-content", wrapper.PageContentHandler)
-	e.GET("/bot/login", wrapper.LoginHandler)
-	e.GET("/bot/logout", wrapper.LogoutHandler)
-	e.GET("/bot/username", wrapper.GetUsernameHandler)
-	e.GET("/bot/universe-name", wrapper.GetUniverseNameHandler)
-	e.GET("/bot/server/speed", wrapper.GetUniverseSpeedHandler)
-	e.GET("/bot/server/speed-fleet", wrapper.GetUniverseSpeedFleetHandler)
-	e.GET("/bot/server/version", wrapper.ServerVersionHandler)
-	e.GET("/bot/server/time", wrapper.ServerTimeHandler)
-	e.GET("/bot/is-under-attack", wrapper.IsUnderAttackHandler)
-	e.GET("/bot/is-vacation-mode", wrapper.IsVacationModeHandler)
-	e.GET("/bot/user-infos", wrapper.GetUserInfosHandler)
-	e.GET("/bot/character-class", wrapper.GetCharacterClassHandler)
-	e.GET("/bot/has-commander", wrapper.HasCommanderHandler)
-	e.GET("/bot/has-admiral", wrapper.HasAdmiralHandler)
-	e.GET("/bot/has-engineer", wrapper.HasEngineerHandler)
-	e.GET("/bot/has-geologist", wrapper.HasGeologistHandler)
-	e.GET("/bot/has-technocrat", wrapper.HasTechnocratHandler)
-	e.POST("/bot/send-message", wrapper.SendMessageHandler)
-	e.GET("/bot/fleets", wrapper.GetFleetsHandler)
-	e.GET("/bot/fleets/slots", wrapper.GetSlotsHandler)
-	e.POST("/bot/fleets/:fleetID/cancel", wrapper.CancelFleetHandler)
-	e.GET("/bot/espionage-report/:msgid", wrapper.GetEspionageReportHandler)
-	e.GET("/bot/espionage-report/:galaxy/:system/:position", wrapper.GetEspionageReportForHandler)
-	e.GET("/bot/espionage-report", wrapper.GetEspionageReportMessagesHandler)
-	e.POST("/bot/delete-report/:messageID", wrapper.DeleteMessageHandler)
-	e.POST("/bot/delete-all-espionage-reports", wrapper.DeleteEspionageMessagesHandler)
-	e.POST("/bot/delete-all-reports/:tabIndex", wrapper.DeleteMessagesFromTabHandler)
-	e.GET("/bot/attacks", wrapper.GetAttacksHandler)
-	e.GET("/bot/get-auction", wrapper.GetAuctionHandler)
-	e.POST("/bot/do-auction", wrapper.DoAuctionHandler)
-	e.GET("/bot/galaxy-infos/:galaxy/:system", wrapper.GalaxyInfosHandler)
-	e.GET("/bot/get-research", wrapper.GetResearchHandler)
-	e.GET("/bot/buy-offer-of-the-day", wrapper.BuyOfferOfTheDayHandler)
-	e.GET("/bot/price/:ogameID/:nbr", wrapper.GetPriceHandler)
-	e.GET("/bot/requirements/:ogameID", wrapper.GetRequirementsHandler)
-	e.GET("/bot/moons", wrapper.GetMoonsHandler)
-	e.GET("/bot/moons/:moonID", wrapper.GetMoonHandler)
-	e.GET("/bot/moons/:galaxy/:system/:position", wrapper.GetMoonByCoordHandler)
-	e.GET("/bot/celestials/:celestialID/items", wrapper.GetCelestialItemsHandler)
-	e.GET("/bot/celestials/:celestialID/items/:itemRef/activate", wrapper.ActivateCelestialItemHandler)
-	e.GET("/bot/celestials/:celestialID/techs", wrapper.TechsHandler)
-	e.GET("/bot/celestials/:celestialID/abandon", wrapper.CelestialAbandonHandler)
-	e.GET("/bot/planets", wrapper.GetPlanetsHandler)
-	e.GET("/bot/planets/:planetID", wrapper.GetPlanetHandler)
-	e.GET("/bot/planets/:planetID/is-under-attack", wrapper.IsUnderAttackByIDHandler)
-	e.GET("/bot/planets/:galaxy/:system/:position", wrapper.GetPlanetByCoordHandler)
-	e.GET("/bot/planets/:planetID/resources-details", wrapper.GetResourcesDetailsHandler)
-	e.GET("/bot/planets/:planetID/resource-settings", wrapper.GetResourceSettingsHandler)
-	e.POST("/bot/planets/:planetID/resource-settings", wrapper.SetResourceSettingsHandler)
-	e.GET("/bot/planets/:planetID/resources-buildings", wrapper.GetResourcesBuildingsHandler)
-	e.GET("/bot/planets/:planetID/lifeform-buildings", wrapper.GetLfBuildingsHandler)
-	e.GET("/bot/planets/:planetID/lifeform-techs", wrapper.GetLfResearchHandler)
-	e.GET("/bot/planets/:planetID/defence", wrapper.GetDefenseHandler)
-	e.GET("/bot/planets/:planetID/ships", wrapper.GetShipsHandler)
-	e.GET("/bot/planets/:planetID/facilities", wrapper.GetFacilitiesHandler)
-	e.POST("/bot/planets/:planetID/build/:ogameID/:nbr", wrapper.BuildHandler)
-	e.POST("/bot/planets/:planetID/build/cancelable/:ogameID", wrapper.BuildCancelableHandler)
-	e.POST("/bot/planets/:planetID/build/production/:ogameID/:nbr", wrapper.BuildProductionHandler)
-	e.POST("/bot/planets/:planetID/build/building/:ogameID", wrapper.BuildBuildingHandler)
-	e.POST("/bot/planets/:planetID/build/technology/:ogameID", wrapper.BuildTechnologyHandler)
-	e.POST("/bot/planets/:planetID/build/defence/:ogameID/:nbr", wrapper.BuildDefenseHandler)
-	e.POST("/bot/planets/:planetID/build/ships/:ogameID/:nbr", wrapper.BuildShipsHandler)
-	e.POST("/bot/planets/:planetID/teardown/:ogameID", wrapper.TeardownHandler)
-	e.GET("/bot/planets/:planetID/production", wrapper.GetProductionHandler)
-	e.GET("/bot/planets/:planetID/constructions", wrapper.ConstructionsBeingBuiltHandler)
-	e.POST("/bot/planets/:planetID/cancel-building", wrapper.CancelBuildingHandler)
-	e.POST("/bot/planets/:planetID/cancel-research", wrapper.CancelResearchHandler)
-	e.GET("/bot/planets/:planetID/resources", wrapper.GetResourcesHandler)
-	e.POST("/bot/planets/:planetID/send-fleet", wrapper.SendFleetHandler)
-	e.POST("/bot/planets/:planetID/send-discovery", wrapper.SendDiscoveryHandler)
-	e.POST("/bot/planets/:planetID/send-ipm", wrapper.SendIPMHandler)
-	e.GET("/bot/moons/:moonID/phalanx/:galaxy/:system/:position", wrapper.PhalanxHandler)
-	e.POST("/bot/moons/:moonID/jump-gate", wrapper.JumpGateHandler)
-	e.GET("/game/allianceInfo.php", wrapper.GetAlliancePageContentHandler) // Example: //game/allianceInfo.php?allianceId=500127
+	// Accounts and api keys management
+	addRoutes(e, auth, accounts.Routes())
+	addRoutes(e, auth, auth.Routes())
+	registerBotRoutes(e.Group("/accounts/:name", accounts.AccountMiddleware("")), auth)
 
-	// Get/Post Page Content
-	e.GET("/game/index.php", wrapper.GetFromGameHandler)
-	e.POST("/game/index.php", wrapper.PostToGameHandler)
+	// Routes without prefix are served by the default account
+	defaultAccount := e.Group("", accounts.AccountMiddleware(DefaultAccount))
+	registerBotRoutes(defaultAccount, auth)
+	registerGameRoutes(defaultAccount, auth)
 
-	// For AntiGame plugin
-	// Static content
-	e.GET("/cdn/*", wrapper.GetStaticHandler)
-	e.GET("/assets/css/*", wrapper.GetStaticHandler)
-	e.GET("/headerCache/*", wrapper.GetStaticHandler)
-	e.GET("/favicon.ico", wrapper.GetStaticHandler)
-	e.GET("/game/sw.js", wrapper.GetStaticHandler)
-
-	// JSON API
-	/*
-		/api/serverData.xml
-		/api/localization.xml
-		/api/players.xml
-		/api/universe.xml
-	*/
-	e.GET("/api/*", wrapper.GetStaticHandler)
-	e.HEAD("/api/*", wrapper.GetStaticHEADHandler) // AntiGame uses this to check if the cached XML files need to be refreshed
+	spec := newSpec(accounts, auth)
+	e.GET("/openapi.json", spec.Handler)
 
 	if enableTLS {
 		log.Println("Enable TLS Support")
@@ -375,3 +307,62 @@ func start(ctx context.Context, c *cli.Command) error {
 	log.Println("Disable TLS Support")
 	return e.Start(host + ":" + strconv.Itoa(port))
 }
+
+// router registers routes, implemented by *echo.Echo and *echo.Group
+type router interface {
+	Add(method, path string, h echo.HandlerFunc, m ...echo.MiddlewareFunc) *echo.Route
+}
+
+// addRoutes registers the routes with the scope they require.
+// The calls of the routes not requiring the read scope are audited, including the ones rejected for lack of scope.
+func addRoutes(r router, auth *Auth, routes []openapi.Route) {
+	for _, route := range scoped(routes) {
+		middlewares := []echo.MiddlewareFunc{auth.Require(route.Scope)}
+		if route.Scope != wrapper.ScopeRead {
+			middlewares = []echo.MiddlewareFunc{auth.Audit, auth.Require(route.Scope)}
+		}
+		r.Add(route.Method, route.Path, route.Handler, middlewares...)
+	}
+}
+
+// registerBotRoutes registers the routes of the bot API
+func registerBotRoutes(r router, auth *Auth) {
+	addRoutes(r, auth, wrapper.Routes)
+	addRoutes(r, auth, activityTracker.Routes)
+}
+
+// registerGameRoutes registers the routes proxying the game pages, only served for the default account.
+// They are browsed like the game, only the ones posting to the game are audited.
+func registerGameRoutes(r router, auth *Auth) {
+	for _, route := range scoped(wrapper.GameRoutes) {
+		middlewares := []echo.MiddlewareFunc{auth.Require(route.Scope)}
+		if route.Method == http.MethodPost {
+			middlewares = []echo.MiddlewareFunc{auth.Audit, auth.Require(route.Scope)}
+		}
+		r.Add(route.Method, route.Path, route.Handler, middlewares...)
+	}
+}
+
+// newSpec OpenAPI specification of all the routes served by ogamed
+func newSpec(accounts *Accounts, auth *Auth) *openapi.Spec {
+	spec := openapi.New(openapi.Info{
+		Title:       "ogamed",
+		Description: "REST API of the ogame bot. Every bot route is served for the default account, and for any account under /accounts/{name}.",
+		Version:     version,
+	})
+	spec.Servers = []openapi.Server{
+		{URL: "/", Description: "Default account"},
+		{URL: "/accounts/{name}", Description: "Account from the config file", Variables: map[string]openapi.ServerVariable{"name": {Default: DefaultAccount}}},
+	}
+	spec.Components.SecuritySchemes = map[string]openapi.SecurityScheme{
+		"bearerAuth": {Type: "http", Scheme: "bearer", Description: "Api key created on POST /keys, can also be given in the X-API-Key header"},
+		"basicAuth":  {Type: "http", Scheme: "basic", Description: "User of the --basic-auth-username/--basic-auth-password flags, has the admin scope"},
+	}
+	spec.Security = []openapi.SecurityRequirement{{"bearerAuth": {}}, {"basicAuth": {}}}
+	return spec.
+		AddRoutes(scoped(accounts.Routes())...).
+		AddRoutes(scoped(auth.Routes())...).
+		AddRoutes(scoped(wrapper.Routes)...).
+		AddRoutes(scoped(activityTracker.Routes)...).
+		AddRoutes(scoped(wrapper.GameRoutes)...)
+}
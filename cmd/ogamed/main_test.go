@@ -0,0 +1,50 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/alaingilbert/ogame/pkg/openapi"
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewSpec(t *testing.T) {
+	accounts := NewAccounts(context.Background(), testBotFactory, 0, 0)
+	auth := NewAuth("", "", nil)
+	e := newTestServer(accounts)
+	addRoutes(e, auth, auth.Routes())
+	registerGameRoutes(e, auth)
+	spec := newSpec(accounts, auth)
+	e.GET("/openapi.json", spec.Handler)
+
+	rec := request(e, http.MethodGet, "/openapi.json", "")
+	require.Equal(t, http.StatusOK, rec.Code)
+	var served openapi.Spec
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &served))
+
+	// Every route served has its documentation
+	toSpecPath := func(path string) string {
+		path = regexp.MustCompile(`:([a-zA-Z0-9_]+)`).ReplaceAllString(path, "{$1}")
+		return strings.Replace(path, "*", "{path}", 1)
+	}
+	for _, route := range e.Routes() {
+		if route.Method == echo.RouteNotFound || route.Path == "/openapi.json" {
+			continue
+		}
+		path := toSpecPath(route.Path)
+		if _, ok := served.Paths[path]; !ok { // Bot routes of an account are documented without their prefix
+			path = strings.TrimPrefix(path, "/accounts/{name}")
+		}
+		item, ok := served.Paths[path]
+		if !assert.True(t, ok, "undocumented path %s", route.Path) {
+			continue
+		}
+		assert.Contains(t, item, strings.ToLower(route.Method), "undocumented route %s %s", route.Method, route.Path)
+	}
+}
@@ -0,0 +1,446 @@
+package activityTracker
+
+import (
+	"context"
+	"errors"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/alaingilbert/clockwork"
+	"github.com/alaingilbert/ogame/pkg/galaxyScanner"
+	"github.com/alaingilbert/ogame/pkg/ogame"
+	"github.com/alaingilbert/ogame/pkg/wrapper"
+)
+
+// Default settings of the tracker
+const (
+	DefaultInterval       = 10 * time.Minute
+	DefaultMaxSamples     = 10000 // Per player
+	DefaultHighscorePages = 10
+	DefaultDelay          = time.Second // Between two pages requests
+)
+
+// ErrNotTracked player is not tracked
+var ErrNotTracked = errors.New("player not tracked")
+
+// Activity timers of the galaxy page
+const (
+	activeNow    = 15 // Active in the last 15 minutes
+	maxActiveMin = 59 // Activity older than an hour is not shown
+)
+
+// Highscore categories and types
+const (
+	playerCategory = 1
+	totalType      = 0
+)
+
+// Source where an activity sample comes from
+type Source string
+
+const (
+	PlanetSource    Source = "planet"
+	MoonSource      Source = "moon"
+	HighscoreSource Source = "highscore"
+)
+
+// Bot methods of the wrapper used by the tracker
+type Bot interface {
+	GalaxyInfos(galaxy, system int64, opts ...wrapper.Option) (ogame.SystemInfos, error)
+	Highscore(category, typ, page int64) (ogame.Highscore, error)
+}
+
+// Sample one observation of the activity of a player
+type Sample struct {
+	Time         time.Time
+	PlayerID     int64
+	Source       Source
+	Coordinate   ogame.Coordinate // Zero for highscore samples
+	Activity     int64            // Galaxy activity timer: 0 none in the last hour, 15 active now, [16, 59] minutes ago
+	Score        int64            // Highscore points, for highscore samples
+	Active       bool             // Activity seen (timer shown or points changed)
+	LastActiveAt time.Time        // Estimated time of the activity, zero if not active
+}
+
+// Heatmap share of the observations in which the player was active, by weekday and hour of the day
+type Heatmap struct {
+	PlayerID     int64
+	Location     string
+	Observations [7][24]int     // Number of polls in each slot, index 0 is Sunday. A poll with an activity is in the slot of the activity
+	Active       [7][24]int     // Number of polls with an activity in each slot
+	Ratio        [7][24]float64 // Active / Observations
+}
+
+// Slot hour of a day of the week
+type Slot struct {
+	Weekday time.Weekday
+	Hour    int
+	Ratio   float64
+}
+
+// OnlineHours returns the slots in which the player was active at least in the given share of the observations,
+// most active first
+func (h Heatmap) OnlineHours(threshold float64) []Slot {
+	out := make([]Slot, 0)
+	for day := 0; day < 7; day++ {
+		for hour := 0; hour < 24; hour++ {
+			if h.Observations[day][hour] > 0 && h.Ratio[day][hour] >= threshold {
+				out = append(out, Slot{Weekday: time.Weekday(day), Hour: hour, Ratio: h.Ratio[day][hour]})
+			}
+		}
+	}
+	sort.SliceStable(out, func(i, j int) bool { return out[i].Ratio > out[j].Ratio })
+	return out
+}
+
+// observation activity of a player aggregated over all the samples of a poll
+type observation struct {
+	Time     time.Time
+	Active   bool
+	ActiveAt time.Time
+}
+
+// player tracked player
+type player struct {
+	planets      []ogame.Coordinate // Empty to use the planets of the galaxy store
+	samples      []Sample
+	observations []observation
+	heatmap      Heatmap
+	lastScore    *int64
+}
+
+// Tracker polls the galaxy and highscore pages, and keeps a time series of the activity of tracked players
+type Tracker struct {
+	sync.Mutex
+	bot            Bot
+	clock          clockwork.Clock
+	store          *galaxyScanner.Store
+	location       *time.Location
+	maxSamples     int
+	highscorePages int64
+	delay          time.Duration
+	players        map[int64]*player
+	onPoll         func(error)
+}
+
+// New creates a tracker
+func New(bot Bot) *Tracker {
+	return NewWithClock(bot, clockwork.NewRealClock())
+}
+
+// NewWithClock same as New, with a custom clock
+func NewWithClock(bot Bot, clock clockwork.Clock) *Tracker {
+	return &Tracker{
+		bot:            bot,
+		clock:          clock,
+		location:       time.UTC,
+		maxSamples:     DefaultMaxSamples,
+		highscorePages: DefaultHighscorePages,
+		delay:          DefaultDelay,
+		players:        make(map[int64]*player),
+	}
+}
+
+// SetStore sets the galaxy store used to find the planets of players tracked without coordinates
+func (t *Tracker) SetStore(store *galaxyScanner.Store) {
+	t.Lock()
+	defer t.Unlock()
+	t.store = store
+}
+
+// SetLocation sets the timezone of the heatmaps, existing heatmaps are rebuilt
+func (t *Tracker) SetLocation(location *time.Location) {
+	t.Lock()
+	defer t.Unlock()
+	t.location = location
+	for id, p := range t.players {
+		p.heatmap = Heatmap{PlayerID: id, Location: location.String()}
+		for _, o := range p.observations {
+			t.addToHeatmapLocked(p, o)
+		}
+	}
+}
+
+// SetHighscorePages sets the number of highscore pages polled to find the points of the players, 0 to disable
+func (t *Tracker) SetHighscorePages(pages int64) {
+	t.Lock()
+	defer t.Unlock()
+	t.highscorePages = pages
+}
+
+// SetDelay sets the delay between two pages requests
+func (t *Tracker) SetDelay(delay time.Duration) {
+	t.Lock()
+	defer t.Unlock()
+	t.delay = delay
+}
+
+// OnPoll sets a callback called with the error of each poll executed by Run (nil if the poll succeeded)
+func (t *Tracker) OnPoll(clb func(error)) {
+	t.Lock()
+	defer t.Unlock()
+	t.onPoll = clb
+}
+
+// Track starts tracking a player on the given planets and moons.
+// Without coordinates, the planets of the player in the galaxy store are used.
+func (t *Tracker) Track(playerID int64, planets ...ogame.Coordinate) {
+	t.Lock()
+	defer t.Unlock()
+	p, ok := t.players[playerID]
+	if !ok {
+		p = &player{heatmap: Heatmap{PlayerID: playerID, Location: t.location.String()}}
+		t.players[playerID] = p
+	}
+	p.planets = planets
+}
+
+// Untrack stops tracking a player and forgets its samples
+func (t *Tracker) Untrack(playerID int64) {
+	t.Lock()
+	defer t.Unlock()
+	delete(t.players, playerID)
+}
+
+// Players returns the ids of the tracked players
+func (t *Tracker) Players() []int64 {
+	t.Lock()
+	defer t.Unlock()
+	out := make([]int64, 0, len(t.players))
+	for id := range t.players {
+		out = append(out, id)
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i] < out[j] })
+	return out
+}
+
+// Samples returns the samples of a player since the given time
+func (t *Tracker) Samples(playerID int64, since time.Time) ([]Sample, error) {
+	t.Lock()
+	defer t.Unlock()
+	p, ok := t.players[playerID]
+	if !ok {
+		return nil, ErrNotTracked
+	}
+	out := make([]Sample, 0)
+	for _, s := range p.samples {
+		if !s.Time.Before(since) {
+			out = append(out, s)
+		}
+	}
+	return out, nil
+}
+
+// Heatmap returns the estimated online hours of a player
+func (t *Tracker) Heatmap(playerID int64) (Heatmap, error) {
+	t.Lock()
+	defer t.Unlock()
+	p, ok := t.players[playerID]
+	if !ok {
+		return Heatmap{}, ErrNotTracked
+	}
+	h := p.heatmap
+	for day := 0; day < 7; day++ {
+		for hour := 0; hour < 24; hour++ {
+			if h.Observations[day][hour] > 0 {
+				h.Ratio[day][hour] = float64(h.Active[day][hour]) / float64(h.Observations[day][hour])
+			}
+		}
+	}
+	return h, nil
+}
+
+// addToHeatmapLocked counts an observation in the heatmap of the player, the lock must be held.
+// An observation with an activity is counted in the slot of the estimated activity time, otherwise in the slot of the poll.
+func (t *Tracker) addToHeatmapLocked(p *player, o observation) {
+	at := o.Time
+	if o.Active {
+		at = o.ActiveAt
+	}
+	at = at.In(t.location)
+	p.heatmap.Observations[at.Weekday()][at.Hour()]++
+	if o.Active {
+		p.heatmap.Active[at.Weekday()][at.Hour()]++
+	}
+}
+
+// addSamplesLocked adds the samples of a poll to the time series of the player, the lock must be held
+func (t *Tracker) addSamplesLocked(p *player, samples []Sample, now time.Time) {
+	o := observation{Time: now}
+	for _, s := range samples {
+		if s.Active && (!o.Active || s.LastActiveAt.After(o.ActiveAt)) {
+			o.Active = true
+			o.ActiveAt = s.LastActiveAt
+		}
+	}
+	p.samples = append(p.samples, samples...)
+	p.observations = append(p.observations, o)
+	if t.maxSamples > 0 && len(p.samples) > t.maxSamples {
+		p.samples = append([]Sample(nil), p.samples[len(p.samples)-t.maxSamples:]...)
+	}
+	if t.maxSamples > 0 && len(p.observations) > t.maxSamples {
+		p.observations = append([]observation(nil), p.observations[len(p.observations)-t.maxSamples:]...)
+	}
+	t.addToHeatmapLocked(p, o)
+}
+
+// activitySample converts a galaxy activity timer
+func activitySample(playerID int64, source Source, coord ogame.Coordinate, activity int64, now time.Time) Sample {
+	s := Sample{Time: now, PlayerID: playerID, Source: source, Coordinate: coord, Activity: activity}
+	if activity >= activeNow && activity <= maxActiveMin {
+		s.Active = true
+		s.LastActiveAt = now
+		if activity > activeNow {
+			s.LastActiveAt = now.Add(-time.Duration(activity) * time.Minute)
+		}
+	}
+	return s
+}
+
+type systemKey struct {
+	galaxy int64
+	system int64
+}
+
+// wait blocks for the delay between two requests
+func (t *Tracker) wait(ctx context.Context) error {
+	t.Lock()
+	delay := t.delay
+	t.Unlock()
+	if delay <= 0 {
+		return nil
+	}
+	select {
+	case <-t.clock.After(delay):
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// Poll takes one sample of the activity of every tracked player.
+// The galaxy pages of the systems of the players are loaded, then the highscore pages until all the players are found.
+func (t *Tracker) Poll(ctx context.Context) error {
+	t.Lock()
+	store := t.store
+	highscorePages := t.highscorePages
+	targets := make(map[systemKey]map[ogame.Coordinate]int64) // system -> planet coordinate -> player
+	for id, p := range t.players {
+		planets := p.planets
+		if len(planets) == 0 && store != nil {
+			for _, planet := range store.Planets(galaxyScanner.OfPlayer(id)) {
+				planets = append(planets, planet.Coordinate)
+			}
+		}
+		for _, coord := range planets {
+			key := systemKey{coord.Galaxy, coord.System}
+			if targets[key] == nil {
+				targets[key] = make(map[ogame.Coordinate]int64)
+			}
+			targets[key][coord.Planet()] = id
+		}
+	}
+	nbPlayers := len(t.players)
+	t.Unlock()
+
+	var firstErr error
+	samples := make(map[int64][]Sample)
+	for key, planets := range targets {
+		if err := t.wait(ctx); err != nil {
+			return err
+		}
+		infos, err := t.bot.GalaxyInfos(key.galaxy, key.system)
+		if err != nil {
+			if firstErr == nil {
+				firstErr = err
+			}
+			continue
+		}
+		now := t.clock.Now()
+		infos.Each(func(planet *ogame.PlanetInfos) {
+			if planet == nil {
+				return
+			}
+			coord := planet.Coordinate
+			coord.Type = ogame.PlanetType
+			playerID, ok := planets[coord]
+			if !ok || planet.Player.ID != playerID {
+				return
+			}
+			samples[playerID] = append(samples[playerID], activitySample(playerID, PlanetSource, coord, planet.Activity, now))
+			if planet.Moon != nil {
+				samples[playerID] = append(samples[playerID], activitySample(playerID, MoonSource, coord.Moon(), planet.Moon.Activity, now))
+			}
+		})
+	}
+
+	found := 0
+	for page := int64(1); page <= highscorePages && found < nbPlayers; page++ {
+		if err := t.wait(ctx); err != nil {
+			return err
+		}
+		highscore, err := t.bot.Highscore(playerCategory, totalType, page)
+		if err != nil {
+			if firstErr == nil {
+				firstErr = err
+			}
+			break
+		}
+		now := t.clock.Now()
+		t.Lock()
+		for _, hp := range highscore.Players {
+			p, ok := t.players[hp.ID]
+			if !ok {
+				continue
+			}
+			found++
+			s := Sample{Time: now, PlayerID: hp.ID, Source: HighscoreSource, Score: hp.Score}
+			if p.lastScore != nil && *p.lastScore != hp.Score {
+				s.Active = true
+				s.LastActiveAt = now
+			}
+			score := hp.Score
+			p.lastScore = &score
+			samples[hp.ID] = append(samples[hp.ID], s)
+		}
+		t.Unlock()
+		if page >= highscore.NbPage {
+			break
+		}
+	}
+
+	now := t.clock.Now()
+	t.Lock()
+	for id, p := range t.players {
+		if len(samples[id]) > 0 {
+			t.addSamplesLocked(p, samples[id], now)
+		}
+	}
+	t.Unlock()
+	return firstErr
+}
+
+// Run polls every interval (DefaultInterval if not set) until the context is cancelled
+func (t *Tracker) Run(ctx context.Context, interval time.Duration) {
+	if interval <= 0 {
+		interval = DefaultInterval
+	}
+	for {
+		err := t.Poll(ctx)
+		if ctx.Err() != nil {
+			return
+		}
+		t.Lock()
+		clb := t.onPoll
+		t.Unlock()
+		if clb != nil {
+			clb(err)
+		}
+		select {
+		case <-t.clock.After(interval):
+		case <-ctx.Done():
+			return
+		}
+	}
+}
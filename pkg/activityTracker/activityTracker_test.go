@@ -0,0 +1,149 @@
+package activityTracker
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/alaingilbert/clockwork"
+	"github.com/alaingilbert/ogame/pkg/galaxyScanner"
+	"github.com/alaingilbert/ogame/pkg/ogame"
+	"github.com/alaingilbert/ogame/pkg/wrapper"
+	"github.com/stretchr/testify/assert"
+)
+
+var _ Bot = (*wrapper.OGame)(nil)
+
+type fakeBot struct {
+	planets []*ogame.PlanetInfos
+	scores  map[int64]int64
+	systems []systemKey
+	pages   []int64
+	err     error
+}
+
+func (b *fakeBot) GalaxyInfos(galaxy, system int64, _ ...wrapper.Option) (ogame.SystemInfos, error) {
+	b.systems = append(b.systems, systemKey{galaxy, system})
+	var infos ogame.SystemInfos
+	infos.SetGalaxy(galaxy)
+	infos.SetSystem(system)
+	for _, p := range b.planets {
+		if p.Coordinate.Galaxy == galaxy && p.Coordinate.System == system {
+			infos.SetPlanet(int(p.Coordinate.Position-1), p)
+		}
+	}
+	return infos, nil
+}
+
+func (b *fakeBot) Highscore(_, _, page int64) (ogame.Highscore, error) {
+	b.pages = append(b.pages, page)
+	if b.err != nil {
+		return ogame.Highscore{}, b.err
+	}
+	out := ogame.Highscore{NbPage: 5, CurrPage: page}
+	if page == 2 {
+		for id, score := range b.scores {
+			out.Players = append(out.Players, ogame.HighscorePlayer{ID: id, Score: score})
+		}
+	}
+	return out, nil
+}
+
+func planetInfos(playerID int64, coord ogame.Coordinate, activity int64) *ogame.PlanetInfos {
+	p := &ogame.PlanetInfos{Coordinate: coord, Activity: activity}
+	p.Player.ID = playerID
+	return p
+}
+
+func TestTracker_Run(t *testing.T) {
+	errHighscore := errors.New("highscore failed")
+	tracker := NewWithClock(&fakeBot{err: errHighscore}, clockwork.NewFakeClock())
+	tracker.SetDelay(0)
+	tracker.SetStore(galaxyScanner.NewStore())
+	tracker.Track(7)
+	ctx, cancel := context.WithCancel(context.Background())
+	errs := make([]error, 0)
+	tracker.OnPoll(func(err error) {
+		errs = append(errs, err)
+		cancel()
+	})
+	tracker.Run(ctx, time.Hour)
+	assert.Len(t, errs, 1)
+	assert.ErrorIs(t, errs[0], errHighscore)
+}
+
+func TestTracker_Poll(t *testing.T) {
+	monday := time.Date(2024, 1, 1, 10, 0, 0, 0, time.UTC)
+	clock := clockwork.NewFakeClockAt(monday)
+	home := ogame.Coordinate{Galaxy: 1, System: 2, Position: 3, Type: ogame.PlanetType}
+	colony := ogame.Coordinate{Galaxy: 2, System: 4, Position: 8, Type: ogame.PlanetType}
+	planet := planetInfos(7, home, 0)
+	planet.Moon = &ogame.MoonInfos{Activity: 35}
+	bot := &fakeBot{
+		planets: []*ogame.PlanetInfos{planet, planetInfos(8, ogame.Coordinate{Galaxy: 1, System: 2, Position: 4}, 15)},
+		scores:  map[int64]int64{7: 1000},
+	}
+	tracker := NewWithClock(bot, clock)
+	tracker.SetDelay(0)
+
+	store := galaxyScanner.NewStore()
+	store.Update(1, 2, []galaxyScanner.Planet{{Coordinate: home, PlayerID: 7}}, monday)
+	store.Update(2, 4, []galaxyScanner.Planet{{Coordinate: colony, PlayerID: 7}}, monday)
+	tracker.SetStore(store)
+	tracker.Track(7)
+	assert.Equal(t, []int64{7}, tracker.Players())
+
+	assert.NoError(t, tracker.Poll(context.Background()))
+	assert.ElementsMatch(t, []systemKey{{1, 2}, {2, 4}}, bot.systems)
+	assert.Equal(t, []int64{1, 2}, bot.pages) // Stops once all the players are found
+	samples, err := tracker.Samples(7, time.Time{})
+	assert.NoError(t, err)
+	assert.Len(t, samples, 3) // Player 8 is not tracked, the colony is empty in the galaxy
+	assert.Equal(t, PlanetSource, samples[0].Source)
+	assert.False(t, samples[0].Active)
+	assert.Equal(t, MoonSource, samples[1].Source)
+	assert.True(t, samples[1].Active)
+	assert.Equal(t, monday.Add(-35*time.Minute), samples[1].LastActiveAt)
+	assert.Equal(t, HighscoreSource, samples[2].Source)
+	assert.False(t, samples[2].Active) // First score seen
+
+	// Only the points changed
+	clock.Advance(time.Hour)
+	planet.Moon = nil
+	bot.scores[7] = 1100
+	tracker.Track(7, home)
+	assert.NoError(t, tracker.Poll(context.Background()))
+	samples, err = tracker.Samples(7, clock.Now())
+	assert.NoError(t, err)
+	assert.Len(t, samples, 2)
+	assert.False(t, samples[0].Active)
+	assert.True(t, samples[1].Active)
+
+	// Nothing changed
+	clock.Advance(time.Hour)
+	assert.NoError(t, tracker.Poll(context.Background()))
+
+	heatmap, err := tracker.Heatmap(7)
+	assert.NoError(t, err)
+	// The first poll is counted in the slot of the moon activity, 35 minutes before it
+	assert.Equal(t, 0, heatmap.Observations[time.Monday][10])
+	assert.Equal(t, 1, heatmap.Observations[time.Monday][9])
+	assert.Equal(t, 1, heatmap.Active[time.Monday][9])
+	assert.Equal(t, 1, heatmap.Observations[time.Monday][11])
+	assert.Equal(t, 1, heatmap.Observations[time.Monday][12])
+	assert.Equal(t, float64(1), heatmap.Ratio[time.Monday][11])
+	assert.Equal(t, float64(0), heatmap.Ratio[time.Monday][12])
+	assert.Equal(t, []Slot{{Weekday: time.Monday, Hour: 9, Ratio: 1}, {Weekday: time.Monday, Hour: 11, Ratio: 1}}, heatmap.OnlineHours(0.5))
+
+	tracker.SetLocation(time.FixedZone("UTC+2", 2*3600))
+	heatmap, _ = tracker.Heatmap(7)
+	assert.Equal(t, 1, heatmap.Observations[time.Monday][13])
+	assert.Equal(t, "UTC+2", heatmap.Location)
+
+	tracker.Untrack(7)
+	_, err = tracker.Heatmap(7)
+	assert.ErrorIs(t, err, ErrNotTracked)
+	_, err = tracker.Samples(7, time.Time{})
+	assert.ErrorIs(t, err, ErrNotTracked)
+}
@@ -0,0 +1,145 @@
+package activityTracker
+
+import (
+	"errors"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+
+	"github.com/alaingilbert/ogame/pkg/ogame"
+	"github.com/alaingilbert/ogame/pkg/openapi"
+	"github.com/alaingilbert/ogame/pkg/utils"
+	"github.com/alaingilbert/ogame/pkg/wrapper"
+	"github.com/labstack/echo/v4"
+)
+
+// ContextKey key of the tracker in the echo context
+const ContextKey = "activityTracker"
+
+// DefaultThreshold share of the observations with an activity for an hour to be reported as online
+const DefaultThreshold = 0.5
+
+func trackerAndPlayer(c echo.Context) (*Tracker, int64, error) {
+	tracker := c.Get(ContextKey).(*Tracker)
+	playerID, err := utils.ParseI64(c.Param("playerID"))
+	return tracker, playerID, err
+}
+
+func notTrackedResp(c echo.Context, err error) error {
+	if errors.Is(err, ErrNotTracked) {
+		return c.JSON(http.StatusNotFound, wrapper.ErrorCodeResp(404, "player_not_tracked", err.Error()))
+	}
+	return wrapper.ErrorJSON(c, http.StatusInternalServerError, err)
+}
+
+// GetTrackedPlayersHandler returns the ids of the tracked players
+func GetTrackedPlayersHandler(c echo.Context) error {
+	tracker := c.Get(ContextKey).(*Tracker)
+	return c.JSON(http.StatusOK, wrapper.SuccessResp(tracker.Players()))
+}
+
+// TrackRequest body of the track route, the planets of the player to watch (eg: 1:2:3)
+type TrackRequest struct {
+	Coordinates []string
+}
+
+// ParseForm implements wrapper.FormRequest
+func (r *TrackRequest) ParseForm(form url.Values) error {
+	r.Coordinates = form["coordinates"]
+	return nil
+}
+
+// TrackPlayerHandler starts tracking a player, on the coordinates of the body if any
+func TrackPlayerHandler(c echo.Context) error {
+	tracker, playerID, err := trackerAndPlayer(c)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, wrapper.ErrorResp(400, err.Error()))
+	}
+	var req TrackRequest
+	if err := wrapper.BindRequest(c, &req); err != nil {
+		return c.JSON(http.StatusBadRequest, wrapper.ErrorResp(400, err.Error()))
+	}
+	planets := make([]ogame.Coordinate, 0)
+	for _, str := range req.Coordinates {
+		coord, err := ogame.ParseCoord(str)
+		if err != nil {
+			return c.JSON(http.StatusBadRequest, wrapper.ErrorResp(400, err.Error()))
+		}
+		planets = append(planets, coord)
+	}
+	tracker.Track(playerID, planets...)
+	return c.JSON(http.StatusOK, wrapper.SuccessResp(nil))
+}
+
+// UntrackPlayerHandler stops tracking a player
+func UntrackPlayerHandler(c echo.Context) error {
+	tracker, playerID, err := trackerAndPlayer(c)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, wrapper.ErrorResp(400, err.Error()))
+	}
+	tracker.Untrack(playerID)
+	return c.JSON(http.StatusOK, wrapper.SuccessResp(nil))
+}
+
+// GetHeatmapHandler returns the activity heatmap of a player and its estimated online hours.
+// The "threshold" query param is the share of observations with an activity needed for an hour to be online.
+func GetHeatmapHandler(c echo.Context) error {
+	tracker, playerID, err := trackerAndPlayer(c)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, wrapper.ErrorResp(400, err.Error()))
+	}
+	threshold := DefaultThreshold
+	if str := c.QueryParam("threshold"); str != "" {
+		if threshold, err = strconv.ParseFloat(str, 64); err != nil {
+			return c.JSON(http.StatusBadRequest, wrapper.ErrorResp(400, err.Error()))
+		}
+	}
+	heatmap, err := tracker.Heatmap(playerID)
+	if err != nil {
+		return notTrackedResp(c, err)
+	}
+	return c.JSON(http.StatusOK, wrapper.SuccessResp(HeatmapResult{
+		Heatmap:     heatmap,
+		OnlineHours: heatmap.OnlineHours(threshold),
+	}))
+}
+
+// GetSamplesHandler returns the activity samples of a player, since the "since" query param (unix timestamp)
+func GetSamplesHandler(c echo.Context) error {
+	tracker, playerID, err := trackerAndPlayer(c)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, wrapper.ErrorResp(400, err.Error()))
+	}
+	var since time.Time
+	if str := c.QueryParam("since"); str != "" {
+		ts, err := utils.ParseI64(str)
+		if err != nil {
+			return c.JSON(http.StatusBadRequest, wrapper.ErrorResp(400, err.Error()))
+		}
+		since = time.Unix(ts, 0)
+	}
+	samples, err := tracker.Samples(playerID, since)
+	if err != nil {
+		return notTrackedResp(c, err)
+	}
+	return c.JSON(http.StatusOK, wrapper.SuccessResp(samples))
+}
+
+// HeatmapResult result of the heatmap route
+type HeatmapResult struct {
+	Heatmap     Heatmap
+	OnlineHours []Slot
+}
+
+// Routes routes of the activity tracker, with their documentation
+var Routes = []openapi.Route{
+	{Method: http.MethodGet, Path: "/bot/activity", Handler: GetTrackedPlayersHandler, Tag: "activity", Summary: "Ids of the tracked players", Result: []int64{}},
+	{Method: http.MethodPost, Path: "/bot/activity/:playerID", Handler: TrackPlayerHandler, Tag: "activity", Summary: "Start tracking a player",
+		Params: []openapi.Param{{Name: "coordinates", In: openapi.InForm, Type: "", Repeated: true, Description: "Planets of the player to watch, eg: 1:2:3"}}, Body: TrackRequest{}},
+	{Method: http.MethodDelete, Path: "/bot/activity/:playerID", Handler: UntrackPlayerHandler, Tag: "activity", Summary: "Stop tracking a player"},
+	{Method: http.MethodGet, Path: "/bot/activity/:playerID/heatmap", Handler: GetHeatmapHandler, Tag: "activity", Summary: "Activity heatmap and estimated online hours of a player",
+		Params: []openapi.Param{{Name: "threshold", In: openapi.InQuery, Type: float64(0), Description: "Share of the observations with an activity for an hour to be online, default 0.5"}}, Result: HeatmapResult{}},
+	{Method: http.MethodGet, Path: "/bot/activity/:playerID/samples", Handler: GetSamplesHandler, Tag: "activity", Summary: "Activity samples of a player",
+		Params: []openapi.Param{{Name: "since", In: openapi.InQuery, Description: "Unix timestamp"}}, Result: []Sample{}},
+}
@@ -0,0 +1,380 @@
+package buildQueue
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/alaingilbert/clockwork"
+	"github.com/alaingilbert/ogame/pkg/ogame"
+	"github.com/alaingilbert/ogame/pkg/wrapper"
+)
+
+// Default delays of the executor
+const (
+	DefaultRetryDelay   = 5 * time.Minute  // Wait before trying again when nothing could be built
+	DefaultRefreshDelay = 10 * time.Second // Wait before checking the countdown of a construction that was just started
+	DefaultIdleDelay    = 15 * time.Minute // Wait when there is nothing to do, profiles can be changed in the meantime
+)
+
+// Bot functions of the wrapper used by the executor
+type Bot interface {
+	CharacterClass() ogame.CharacterClass
+	GetCachedCelestial(wrapper.IntoCelestial) (wrapper.Celestial, error)
+	GetCachedLfBonuses() (ogame.LfBonuses, error)
+	GetResearch() (ogame.Researches, error)
+}
+
+// ErrInvalidTarget returned when a profile has a target that is not a building or a technology
+var ErrInvalidTarget = errors.New("only buildings and technologies can be targets")
+
+// Queue kinds of the constructions queues of a celestial
+type Queue int64
+
+// Queues of a celestial, research queue is shared by all the celestials
+const (
+	BuildingQueue Queue = iota
+	ResearchQueue
+	LfBuildingQueue
+	LfResearchQueue
+)
+
+func (q Queue) String() string {
+	switch q {
+	case BuildingQueue:
+		return "building"
+	case ResearchQueue:
+		return "research"
+	case LfBuildingQueue:
+		return "lfBuilding"
+	case LfResearchQueue:
+		return "lfResearch"
+	default:
+		return "unknown"
+	}
+}
+
+// QueueOf returns the queue in which the object is built, id must be a building or a technology
+func QueueOf(id ogame.ID) Queue {
+	if id.IsLfBuilding() {
+		return LfBuildingQueue
+	} else if id.IsLfTech() {
+		return LfResearchQueue
+	} else if id.IsTech() {
+		return ResearchQueue
+	}
+	return BuildingQueue
+}
+
+// Target level wanted for a building or technology
+type Target struct {
+	ID    ogame.ID
+	Level int64
+}
+
+// Profile targets of a celestial.
+// When several targets of a queue can be started, the first one in the profile is built first.
+type Profile []Target
+
+// BuildResult outcome of an attempt to build a target
+type BuildResult struct {
+	CelestialID ogame.CelestialID
+	ID          ogame.ID
+	Level       int64 // Level being built
+	Err         error
+}
+
+// Executor keeps the building, research, lifeform building and lifeform research queues of the celestials
+// busy until the targets of their profile are reached.
+type Executor struct {
+	bot          Bot
+	clock        clockwork.Clock
+	mu           sync.Mutex
+	profiles     map[ogame.CelestialID]Profile
+	wakeCh       chan struct{}
+	retryDelay   time.Duration
+	refreshDelay time.Duration
+	idleDelay    time.Duration
+	onBuild      func(BuildResult)
+}
+
+// New creates a new executor
+func New(bot Bot) *Executor {
+	return NewWithClock(bot, clockwork.NewRealClock())
+}
+
+// NewWithClock creates a new executor using the given clock
+func NewWithClock(bot Bot, clock clockwork.Clock) *Executor {
+	return &Executor{
+		bot:          bot,
+		clock:        clock,
+		profiles:     make(map[ogame.CelestialID]Profile),
+		wakeCh:       make(chan struct{}, 1),
+		retryDelay:   DefaultRetryDelay,
+		refreshDelay: DefaultRefreshDelay,
+		idleDelay:    DefaultIdleDelay,
+	}
+}
+
+// SetRetryDelay sets the time to wait before trying again when a queue is free but nothing could be built
+func (e *Executor) SetRetryDelay(delay time.Duration) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.retryDelay = delay
+}
+
+// OnBuild sets a callback called after each attempt to build a target
+func (e *Executor) OnBuild(clb func(BuildResult)) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.onBuild = clb
+}
+
+// SetProfile sets the targets of a celestial.
+// Returns ErrInvalidTarget if a target is not a building or a technology, ships and defenses are not leveled.
+func (e *Executor) SetProfile(celestialID ogame.CelestialID, profile Profile) error {
+	for _, target := range profile {
+		if !target.ID.IsBuilding() && !target.ID.IsTech() && !target.ID.IsLfTech() {
+			return fmt.Errorf("%w: %s", ErrInvalidTarget, target.ID)
+		}
+	}
+	e.mu.Lock()
+	e.profiles[celestialID] = append(Profile{}, profile...)
+	e.mu.Unlock()
+	e.wake()
+	return nil
+}
+
+// RemoveProfile stops building on a celestial
+func (e *Executor) RemoveProfile(celestialID ogame.CelestialID) {
+	e.mu.Lock()
+	delete(e.profiles, celestialID)
+	e.mu.Unlock()
+	e.wake()
+}
+
+// Profiles returns the profiles of all the celestials
+func (e *Executor) Profiles() map[ogame.CelestialID]Profile {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	out := make(map[ogame.CelestialID]Profile, len(e.profiles))
+	for id, profile := range e.profiles {
+		out[id] = append(Profile{}, profile...)
+	}
+	return out
+}
+
+func (e *Executor) wake() {
+	select {
+	case e.wakeCh <- struct{}{}:
+	default:
+	}
+}
+
+// Run keeps the queues busy until the context is cancelled
+func (e *Executor) Run(ctx context.Context) error {
+	for {
+		wait := e.idleDelay
+		if next := e.Tick(); !next.IsZero() {
+			wait = max(next.Sub(e.clock.Now()), 0)
+		}
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-e.wakeCh:
+		case <-e.clock.After(wait):
+		}
+	}
+}
+
+// Tick starts the constructions that can be started on every celestial.
+// Returns the time at which the queues must be checked again, zero if all the targets are reached.
+func (e *Executor) Tick() (next time.Time) {
+	profiles := e.Profiles()
+	ids := make([]ogame.CelestialID, 0, len(profiles))
+	for id := range profiles {
+		ids = append(ids, id)
+	}
+	sort.Slice(ids, func(i, j int) bool { return ids[i] < ids[j] })
+	if len(ids) == 0 {
+		return
+	}
+	researches, err := e.bot.GetResearch()
+	if err != nil {
+		e.report(BuildResult{Err: err})
+		return e.clock.Now().Add(e.getRetryDelay())
+	}
+	lfBonuses, _ := e.bot.GetCachedLfBonuses()
+	for _, id := range ids {
+		at := e.tickCelestial(id, profiles[id], researches, lfBonuses)
+		if !at.IsZero() && (next.IsZero() || at.Before(next)) {
+			next = at
+		}
+	}
+	return next
+}
+
+// celestialState levels and queues of a celestial
+type celestialState struct {
+	celestial     wrapper.Celestial
+	constructions ogame.Constructions
+	researches    ogame.Researches
+	resBuildings  ogame.ResourcesBuildings
+	facilities    ogame.Facilities
+	lfBuildings   ogame.LfBuildings
+	lfResearches  ogame.LfResearches
+	resources     ogame.Resources
+	lfBonuses     ogame.LfBonuses
+}
+
+func (s celestialState) level(id ogame.ID) int64 {
+	switch QueueOf(id) {
+	case ResearchQueue:
+		return s.researches.ByID(id)
+	case LfBuildingQueue:
+		return s.lfBuildings.ByID(id)
+	case LfResearchQueue:
+		if lvl := s.lfResearches.ByID(id); lvl != nil {
+			return *lvl
+		}
+		return 0
+	}
+	if id.IsFacility() {
+		return s.facilities.ByID(id)
+	}
+	return s.resBuildings.ByID(id)
+}
+
+func (s celestialState) busy(queue Queue) ogame.Construction {
+	switch queue {
+	case ResearchQueue:
+		return s.constructions.Research
+	case LfBuildingQueue:
+		return s.constructions.LfBuilding
+	case LfResearchQueue:
+		return s.constructions.LfResearch
+	}
+	return s.constructions.Building
+}
+
+func (e *Executor) loadState(celestialID ogame.CelestialID, researches ogame.Researches, lfBonuses ogame.LfBonuses) (s celestialState, err error) {
+	s.researches = researches
+	s.lfBonuses = lfBonuses
+	if s.celestial, err = e.bot.GetCachedCelestial(celestialID); err != nil {
+		return
+	}
+	if s.constructions, err = s.celestial.ConstructionsBeingBuilt(); err != nil {
+		return
+	}
+	if s.resBuildings, err = s.celestial.GetResourcesBuildings(); err != nil {
+		return
+	}
+	if s.facilities, err = s.celestial.GetFacilities(); err != nil {
+		return
+	}
+	if s.lfBuildings, err = s.celestial.GetLfBuildings(); err != nil {
+		return
+	}
+	if s.lfResearches, err = s.celestial.GetLfResearch(); err != nil {
+		return
+	}
+	if s.resources, err = s.celestial.GetResources(); err != nil {
+		return
+	}
+	return s, nil
+}
+
+func (e *Executor) getRetryDelay() time.Duration {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	return e.retryDelay
+}
+
+func (e *Executor) report(res BuildResult) {
+	e.mu.Lock()
+	onBuild := e.onBuild
+	e.mu.Unlock()
+	if onBuild != nil {
+		onBuild(res)
+	}
+}
+
+func (e *Executor) tickCelestial(celestialID ogame.CelestialID, profile Profile, researches ogame.Researches, lfBonuses ogame.LfBonuses) (next time.Time) {
+	now := e.clock.Now()
+	retryDelay := e.getRetryDelay()
+	s, err := e.loadState(celestialID, researches, lfBonuses)
+	if err != nil {
+		e.report(BuildResult{CelestialID: celestialID, Err: err})
+		return now.Add(retryDelay)
+	}
+	setNext := func(at time.Time) {
+		if next.IsZero() || at.Before(next) {
+			next = at
+		}
+	}
+	for _, queue := range []Queue{BuildingQueue, ResearchQueue, LfBuildingQueue, LfResearchQueue} {
+		if construction := s.busy(queue); construction.ID != 0 {
+			if e.hasPendingTarget(s, profile, queue) {
+				setNext(now.Add(construction.Countdown))
+			}
+			continue
+		}
+		target, ok, pending := e.nextTarget(s, profile, queue)
+		if !ok {
+			if pending {
+				setNext(now.Add(retryDelay)) // Requirements or resources are missing, they may be built or produced meanwhile
+			}
+			continue
+		}
+		var buildErr error
+		if queue == ResearchQueue || queue == LfResearchQueue {
+			buildErr = s.celestial.BuildTechnology(target.ID)
+		} else {
+			buildErr = s.celestial.BuildBuilding(target.ID)
+		}
+		e.report(BuildResult{CelestialID: celestialID, ID: target.ID, Level: s.level(target.ID) + 1, Err: buildErr})
+		if buildErr != nil {
+			setNext(now.Add(retryDelay))
+			continue
+		}
+		s.resources = s.resources.Sub(ogame.Objs.ByID(target.ID).GetPrice(s.level(target.ID)+1, s.lfBonuses))
+		setNext(now.Add(e.refreshDelay))
+	}
+	return next
+}
+
+// hasPendingTarget returns either or not a target of the queue is not reached yet
+func (e *Executor) hasPendingTarget(s celestialState, profile Profile, queue Queue) bool {
+	for _, target := range profile {
+		if QueueOf(target.ID) == queue && s.level(target.ID) < target.Level {
+			return true
+		}
+	}
+	return false
+}
+
+// nextTarget returns the first target of the queue that can be built and is affordable.
+// pending is true if some targets are not reached yet.
+func (e *Executor) nextTarget(s celestialState, profile Profile, queue Queue) (target Target, ok, pending bool) {
+	class := e.bot.CharacterClass()
+	for _, t := range profile {
+		if QueueOf(t.ID) != queue || s.level(t.ID) >= t.Level {
+			continue
+		}
+		pending = true
+		obj := ogame.Objs.ByID(t.ID)
+		if obj == nil {
+			continue
+		}
+		if !obj.IsAvailable(s.celestial.GetType(), s.resBuildings, s.lfBuildings, s.lfResearches, s.facilities, s.researches, s.resources.Energy, class) {
+			continue
+		}
+		if s.resources.CanAfford(obj.GetPrice(s.level(t.ID)+1, s.lfBonuses)) {
+			return t, true, true
+		}
+	}
+	return Target{}, false, pending
+}
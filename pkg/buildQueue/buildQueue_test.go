@@ -0,0 +1,156 @@
+package buildQueue
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/alaingilbert/clockwork"
+	"github.com/alaingilbert/ogame/pkg/ogame"
+	"github.com/alaingilbert/ogame/pkg/wrapper"
+	"github.com/stretchr/testify/assert"
+)
+
+var _ Bot = (*wrapper.OGame)(nil)
+
+type fakeCelestial struct {
+	wrapper.Celestial
+	constructions ogame.Constructions
+	resBuildings  ogame.ResourcesBuildings
+	facilities    ogame.Facilities
+	lfBuildings   ogame.LfBuildings
+	resources     ogame.Resources
+	built         []ogame.ID
+	buildErr      error
+}
+
+func (c *fakeCelestial) GetType() ogame.CelestialType { return ogame.PlanetType }
+func (c *fakeCelestial) ConstructionsBeingBuilt() (ogame.Constructions, error) {
+	return c.constructions, nil
+}
+func (c *fakeCelestial) GetResourcesBuildings(...wrapper.Option) (ogame.ResourcesBuildings, error) {
+	return c.resBuildings, nil
+}
+func (c *fakeCelestial) GetFacilities(...wrapper.Option) (ogame.Facilities, error) {
+	return c.facilities, nil
+}
+func (c *fakeCelestial) GetLfBuildings(...wrapper.Option) (ogame.LfBuildings, error) {
+	return c.lfBuildings, nil
+}
+func (c *fakeCelestial) GetLfResearch(...wrapper.Option) (ogame.LfResearches, error) {
+	return ogame.LfResearches{}, nil
+}
+func (c *fakeCelestial) GetResources() (ogame.Resources, error) { return c.resources, nil }
+func (c *fakeCelestial) BuildBuilding(id ogame.ID) error {
+	c.built = append(c.built, id)
+	return c.buildErr
+}
+func (c *fakeCelestial) BuildTechnology(id ogame.ID) error {
+	c.built = append(c.built, id)
+	return c.buildErr
+}
+
+type fakeBot struct {
+	celestials map[ogame.CelestialID]*fakeCelestial
+	researches ogame.Researches
+}
+
+func (b *fakeBot) CharacterClass() ogame.CharacterClass         { return ogame.Collector }
+func (b *fakeBot) GetCachedLfBonuses() (ogame.LfBonuses, error) { return *ogame.NewLfBonuses(), nil }
+func (b *fakeBot) GetResearch() (ogame.Researches, error)       { return b.researches, nil }
+func (b *fakeBot) GetCachedCelestial(v wrapper.IntoCelestial) (wrapper.Celestial, error) {
+	if c, ok := b.celestials[v.(ogame.CelestialID)]; ok {
+		return c, nil
+	}
+	return nil, errors.New("celestial not found")
+}
+
+func TestQueueOf(t *testing.T) {
+	assert.Equal(t, BuildingQueue, QueueOf(ogame.MetalMineID))
+	assert.Equal(t, BuildingQueue, QueueOf(ogame.RoboticsFactoryID))
+	assert.Equal(t, ResearchQueue, QueueOf(ogame.EnergyTechnologyID))
+	assert.Equal(t, LfBuildingQueue, QueueOf(ogame.ResidentialSectorID))
+	assert.Equal(t, LfResearchQueue, QueueOf(ogame.IntergalacticEnvoysID))
+}
+
+func TestExecutor_Tick(t *testing.T) {
+	clock := clockwork.NewFakeClock()
+	planet := &fakeCelestial{
+		resBuildings:  ogame.ResourcesBuildings{MetalMine: 10},
+		facilities:    ogame.Facilities{ResearchLab: 1},
+		lfBuildings:   ogame.LfBuildings{LifeformType: ogame.Humans},
+		resources:     ogame.Resources{Metal: 1e6, Crystal: 1e6, Deuterium: 1e6},
+		constructions: ogame.Constructions{Building: ogame.Construction{ID: ogame.CrystalMineID, Level: 5, Countdown: time.Hour}},
+	}
+	bot := &fakeBot{celestials: map[ogame.CelestialID]*fakeCelestial{1: planet}}
+	e := NewWithClock(bot, clock)
+	results := make([]BuildResult, 0)
+	e.OnBuild(func(res BuildResult) { results = append(results, res) })
+	assert.True(t, e.Tick().IsZero())
+
+	assert.ErrorIs(t, e.SetProfile(1, Profile{{ID: ogame.LightFighterID, Level: 10}}), ErrInvalidTarget)
+	assert.ErrorIs(t, e.SetProfile(1, Profile{{ID: ogame.RocketLauncherID, Level: 10}}), ErrInvalidTarget)
+	assert.Empty(t, e.Profiles())
+
+	assert.NoError(t, e.SetProfile(1, Profile{
+		{ID: ogame.MetalMineID, Level: 12},
+		{ID: ogame.GravitonTechnologyID, Level: 1}, // Requirements not met
+		{ID: ogame.EnergyTechnologyID, Level: 1},
+		{ID: ogame.ResidentialSectorID, Level: 3},
+	}))
+	next := e.Tick()
+	assert.Equal(t, clock.Now().Add(DefaultRefreshDelay), next)
+	assert.Equal(t, []ogame.ID{ogame.EnergyTechnologyID, ogame.ResidentialSectorID}, planet.built)
+	assert.Equal(t, BuildResult{CelestialID: 1, ID: ogame.EnergyTechnologyID, Level: 1}, results[0])
+
+	// Building queue is busy for an hour, others are running
+	planet.built = nil
+	planet.constructions.Research = ogame.Construction{ID: ogame.EnergyTechnologyID, Level: 1, Countdown: 2 * time.Hour}
+	planet.constructions.LfBuilding = ogame.Construction{ID: ogame.ResidentialSectorID, Level: 1, Countdown: 3 * time.Hour}
+	assert.Equal(t, clock.Now().Add(time.Hour), e.Tick())
+	assert.Empty(t, planet.built)
+
+	// Building queue is free, the metal mine is built
+	planet.constructions.Building = ogame.Construction{}
+	e.Tick()
+	assert.Equal(t, []ogame.ID{ogame.MetalMineID}, planet.built)
+
+	// Not enough resources
+	planet.built = nil
+	planet.buildErr = errors.New("not enough resources")
+	e.SetRetryDelay(time.Minute)
+	assert.Equal(t, clock.Now().Add(time.Minute), e.Tick())
+	assert.Equal(t, []ogame.ID{ogame.MetalMineID}, planet.built)
+
+	e.RemoveProfile(1)
+	assert.Empty(t, e.Profiles())
+}
+
+func TestExecutor_TargetsReached(t *testing.T) {
+	planet := &fakeCelestial{resBuildings: ogame.ResourcesBuildings{MetalMine: 12}}
+	bot := &fakeBot{celestials: map[ogame.CelestialID]*fakeCelestial{1: planet}}
+	e := NewWithClock(bot, clockwork.NewFakeClock())
+	assert.NoError(t, e.SetProfile(1, Profile{{ID: ogame.MetalMineID, Level: 12}}))
+	assert.True(t, e.Tick().IsZero())
+	assert.Empty(t, planet.built)
+}
+
+func TestExecutor_NotAffordable(t *testing.T) {
+	clock := clockwork.NewFakeClock()
+	planet := &fakeCelestial{
+		resBuildings: ogame.ResourcesBuildings{MetalMine: 10},
+		resources:    ogame.Resources{Metal: 2000, Crystal: 2000},
+	}
+	bot := &fakeBot{celestials: map[ogame.CelestialID]*fakeCelestial{1: planet}}
+	e := NewWithClock(bot, clock)
+	assert.NoError(t, e.SetProfile(1, Profile{{ID: ogame.MetalMineID, Level: 12}, {ID: ogame.SolarPlantID, Level: 1}}))
+
+	// Metal mine 11 is too expensive, the solar plant is built instead
+	assert.Equal(t, clock.Now().Add(DefaultRefreshDelay), e.Tick())
+	assert.Equal(t, []ogame.ID{ogame.SolarPlantID}, planet.built)
+
+	planet.built = nil
+	planet.resBuildings.SolarPlant = 1
+	assert.Equal(t, clock.Now().Add(DefaultRetryDelay), e.Tick())
+	assert.Empty(t, planet.built)
+}
@@ -308,6 +308,20 @@ type EmpireExtractorBytes interface {
 	ExtractEmpireJSON(pageHTML []byte) (any, error)
 }
 
+// CombatReportExtractorBytes popup that shows the full combat report
+type CombatReportExtractorBytes interface {
+	ExtractCombatReport(pageHTML []byte) (ogame.CombatReport, error)
+}
+
+type CombatReportExtractorDoc interface {
+	ExtractCombatReportFromDoc(*goquery.Document) (ogame.CombatReport, error)
+}
+
+type CombatReportExtractorBytesDoc interface {
+	CombatReportExtractorBytes
+	CombatReportExtractorDoc
+}
+
 // EspionageReportExtractorBytes popup that shows the full espionage report
 type EspionageReportExtractorBytes interface {
 	ExtractEspionageReport(pageHTML []byte) (ogame.EspionageReport, error)
@@ -526,6 +540,7 @@ type Extractor interface {
 	GetLifeformEnabled() bool
 	SetLifeformEnabled(lifeformEnabled bool)
 
+	CombatReportExtractorBytesDoc
 	DefensesExtractorBytesDoc
 	EspionageReportExtractorBytesDoc
 	EventListExtractorBytesDoc
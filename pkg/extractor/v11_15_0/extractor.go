@@ -59,6 +59,20 @@ func (e *Extractor) ExtractCombatReportMessagesFromDoc(doc *goquery.Document) ([
 	return extractCombatReportMessagesFromDoc(doc)
 }
 
+// ExtractCombatReport ...
+func (e *Extractor) ExtractCombatReport(pageHTML []byte) (ogame.CombatReport, error) {
+	doc, err := goquery.NewDocumentFromReader(bytes.NewReader(pageHTML))
+	if err != nil {
+		return ogame.CombatReport{}, err
+	}
+	return e.ExtractCombatReportFromDoc(doc)
+}
+
+// ExtractCombatReportFromDoc ...
+func (e *Extractor) ExtractCombatReportFromDoc(doc *goquery.Document) (ogame.CombatReport, error) {
+	return extractCombatReportFromDoc(doc)
+}
+
 // ExtractExpeditionMessages ...
 func (e *Extractor) ExtractExpeditionMessages(pageHTML []byte) ([]ogame.ExpeditionMessage, int64, error) {
 	doc, err := goquery.NewDocumentFromReader(bytes.NewReader(pageHTML))
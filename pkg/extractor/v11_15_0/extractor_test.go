@@ -36,6 +36,11 @@ func TestExtractLfBonuses(t *testing.T) {
 	assert.Equal(t, -0.0019, bonuses.LfShipBonuses[ogame.LightFighterID].FuelConsumption)
 	assert.Equal(t, 0.006, bonuses.CostTimeBonuses[ogame.AllianceDepotID].Cost)
 	assert.Equal(t, 0.012, bonuses.CostTimeBonuses[ogame.AllianceDepotID].Duration)
+	assert.Equal(t, 0.0072, bonuses.LfResourceBonuses.MetalProduction)
+	assert.Equal(t, 0.0072, bonuses.LfResourceBonuses.CrystalProduction)
+	assert.Equal(t, 0.016, bonuses.LfResourceBonuses.DeuteriumProduction)
+	assert.Equal(t, 0.3614, bonuses.LfResourceBonuses.MetalStorageCapacity)
+	assert.Equal(t, 0.3614, bonuses.LfResourceBonuses.DeuteriumStorageCapacity)
 }
 
 func TestExtractAllianceClass(t *testing.T) {
@@ -81,3 +86,59 @@ func TestExtractCombatReports(t *testing.T) {
 	}
 	assert.Equal(t, ogame.FleetID(14238943), msgs[0].FleetID)
 }
+
+func TestExtractCombatReport(t *testing.T) {
+	pageHTMLBytes, _ := os.ReadFile("../../../samples/v12.0.29/en/combat_reports.json")
+	var res struct {
+		Messages []string `json:"messages"`
+	}
+	_ = json.Unmarshal(pageHTMLBytes, &res)
+	report, err := NewExtractor().ExtractCombatReport([]byte(res.Messages[0]))
+	assert.NoError(t, err)
+	assert.Equal(t, int64(25395174), report.ID)
+	assert.Equal(t, int64(1739771928), report.Date.Unix())
+	assert.Equal(t, ogame.Coordinate{Galaxy: 4, System: 106, Position: 6, Type: ogame.PlanetType}, report.Destination)
+	assert.Equal(t, ogame.AttackerWinner, report.Winner)
+	assert.Equal(t, ogame.Resources{Metal: 2666, Crystal: 2667, Deuterium: 2667}, report.Loot)
+	assert.Equal(t, int64(75), report.LootPercentage)
+	assert.Len(t, report.Attackers, 1)
+	assert.Equal(t, ogame.FleetID(14238943), report.Attackers[0].FleetID)
+	assert.Equal(t, "Mogul Euler", report.Attackers[0].PlayerName)
+	assert.Equal(t, "WIND", report.Attackers[0].AllianceTag)
+	assert.Equal(t, ogame.Discoverer, report.Attackers[0].CharacterClass)
+	assert.Equal(t, ogame.Coordinate{Galaxy: 4, System: 106, Position: 8, Type: ogame.PlanetType}, report.Attackers[0].Origin)
+	assert.Equal(t, int64(160), report.Attackers[0].WeaponPercentage)
+	assert.Equal(t, int64(1), report.Attackers[0].Ships.SmallCargo)
+	assert.Len(t, report.Defenders, 1)
+	assert.Equal(t, "KeyMaster113", report.Defenders[0].PlayerName)
+	assert.Equal(t, int64(140), report.Defenders[0].ArmourPercentage)
+	assert.Empty(t, report.Rounds)
+}
+
+func TestExtractCombatReport_Rounds(t *testing.T) {
+	pageHTML := `<div class="detail_msg" data-msg-id="1"><div class="rawMessageData"
+data-raw-timestamp='1739771928' data-raw-coords='1:2:3'
+data-raw-defenderSpaceObject='{"type":"moon"}'
+data-raw-fleets='[{"side":"defender","fleetId":0,"combatTechnologies":[{"technologyId":401,"amount":10}]},{"side":"attacker","fleetId":5,"combatTechnologies":[{"technologyId":207,"amount":3}]}]'
+data-raw-combatRounds='[{"fleets":[{"side":"attacker","fleetId":5,"technologies":[{"technologyId":207,"destroyed":1,"remaining":2}]},{"side":"defender","fleetId":0,"technologies":[{"technologyId":401,"destroyed":10,"remaining":0}]}],"statistics":[{"side":"attacker","hits":9,"absorbedDamage":100,"fullStrength":1000},{"side":"defender","hits":10,"absorbedDamage":50,"fullStrength":800}]}]'
+data-raw-result='{"winner":"attacker","debris":{"resources":[{"resource":"metal","amount":300}]},"totalValueOfUnitsLost":[{"side":"defender","value":20000},{"side":"attacker","value":60000}],"repairedTechnologies":[{"technologyId":401,"amount":7}],"moonCreation":{"chance":3},"honor":[{"side":"defender","points":4},{"side":"attacker","points":-2}]}'>
+</div></div>`
+	report, err := NewExtractor().ExtractCombatReport([]byte(pageHTML))
+	assert.NoError(t, err)
+	assert.Equal(t, ogame.MoonType, report.Destination.Type)
+	assert.Equal(t, ogame.Resources{Metal: 300}, report.Debris)
+	assert.Equal(t, int64(60000), report.AttackerLostValue)
+	assert.Equal(t, int64(20000), report.DefenderLostValue)
+	assert.Equal(t, int64(-2), report.AttackerHonorPoints)
+	assert.Equal(t, int64(4), report.DefenderHonorPoints)
+	assert.Equal(t, int64(3), report.MoonChance)
+	assert.Equal(t, int64(7), report.RepairedDefenses.RocketLauncher)
+	assert.Equal(t, int64(10), report.Defenders[0].Defenses.RocketLauncher)
+	assert.Len(t, report.Rounds, 1)
+	round := report.Rounds[0]
+	assert.Equal(t, int64(2), round.Attackers[0].Ships.Battleship)
+	assert.Equal(t, int64(1), round.Attackers[0].LostShips.Battleship)
+	assert.Equal(t, int64(10), round.Defenders[0].LostDefenses.RocketLauncher)
+	assert.Equal(t, int64(9), round.AttackerHits)
+	assert.Equal(t, int64(800), round.DefenderFullStrength)
+}
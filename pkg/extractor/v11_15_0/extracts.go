@@ -101,6 +101,203 @@ func extractCombatReportMessagesFromDoc(doc *goquery.Document) ([]ogame.CombatRe
 	return msgs, 1, nil
 }
 
+type rawCombatTechnology struct {
+	TechnologyID int64
+	Amount       int64
+	Destroyed    int64
+	Remaining    int64
+}
+
+type rawCombatResources []struct {
+	Resource string
+	Amount   int64
+}
+
+func (r rawCombatResources) toResources() (out ogame.Resources) {
+	for _, resource := range r {
+		if ogame.IsStrDeuterium(resource.Resource) {
+			out.Deuterium = resource.Amount
+		} else if ogame.IsStrCrystal(resource.Resource) {
+			out.Crystal = resource.Amount
+		} else if ogame.IsStrMetal(resource.Resource) {
+			out.Metal = resource.Amount
+		}
+	}
+	return
+}
+
+// extractCombatReportFromDoc extracts the combat report from the raw data of the message (data-raw-fleets, data-raw-combatrounds, data-raw-result)
+func extractCombatReportFromDoc(doc *goquery.Document) (ogame.CombatReport, error) {
+	report := ogame.CombatReport{}
+	report.ID = utils.DoParseI64(doc.Find("div.detail_msg").AttrOr("data-msg-id", "0"))
+	if report.ID == 0 {
+		report.ID = utils.DoParseI64(doc.Find(".msg").AttrOr("data-msg-id", "0"))
+	}
+	rawMessageData := doc.Find("div.rawMessageData").First()
+	fleetsStr, exists := rawMessageData.Attr("data-raw-fleets")
+	if !exists {
+		return report, errors.New("failed to extract combat report")
+	}
+
+	var fleets []struct {
+		Side    string
+		FleetID int64
+		Player  struct {
+			ID       int64
+			Name     string
+			Alliance *struct{ Tag string }
+			ClassID  int64
+		}
+		SpaceObject struct {
+			Type        string
+			Coordinates struct{ Galaxy, System, Position int64 }
+		}
+		CombatResearchPercentage []struct{ ID, Percentage int64 }
+		CombatTechnologies       []rawCombatTechnology
+	}
+	if err := json.Unmarshal([]byte(fleetsStr), &fleets); err != nil {
+		return report, errors.New("failed to parse combat report fleets: " + err.Error())
+	}
+	var result struct {
+		Winner string
+		Loot   struct {
+			Percentage int64
+			Resources  rawCombatResources
+		}
+		Debris struct {
+			Resources rawCombatResources
+		}
+		TotalValueOfUnitsLost []struct {
+			Side  string
+			Value int64
+		}
+		RepairedTechnologies []rawCombatTechnology
+		MoonCreation         struct {
+			Chance  int64
+			Created bool
+		}
+		Honor []struct {
+			Side   string
+			Points int64
+		}
+	}
+	if err := json.Unmarshal([]byte(rawMessageData.AttrOr("data-raw-result", "")), &result); err != nil {
+		return report, errors.New("failed to parse combat report result: " + err.Error())
+	}
+	// Each round gives the units destroyed during the round and the units remaining for every fleet
+	var rounds []struct {
+		Fleets []struct {
+			Side         string
+			FleetID      int64
+			Technologies []rawCombatTechnology
+		}
+		Statistics []struct {
+			Side           string
+			Hits           int64
+			AbsorbedDamage int64
+			FullStrength   int64
+		}
+	}
+	_ = json.Unmarshal([]byte(rawMessageData.AttrOr("data-raw-combatrounds", "[]")), &rounds)
+
+	report.Date = time.Unix(utils.DoParseI64(rawMessageData.AttrOr("data-raw-timestamp", "0")), 0)
+	report.Destination = ogame.DoParseCoord(rawMessageData.AttrOr("data-raw-coords", ""))
+	var defenderSpaceObject struct{ Type string }
+	_ = json.Unmarshal([]byte(rawMessageData.AttrOr("data-raw-defenderspaceobject", "")), &defenderSpaceObject)
+	report.Destination.Type = ogame.PlanetType
+	if defenderSpaceObject.Type == "moon" {
+		report.Destination.Type = ogame.MoonType
+	}
+
+	report.Winner = result.Winner
+	report.Loot = result.Loot.Resources.toResources()
+	report.LootPercentage = result.Loot.Percentage
+	report.Debris = result.Debris.Resources.toResources()
+	for _, lost := range result.TotalValueOfUnitsLost {
+		if lost.Side == ogame.AttackerWinner {
+			report.AttackerLostValue = lost.Value
+		} else {
+			report.DefenderLostValue = lost.Value
+		}
+	}
+	for _, honor := range result.Honor {
+		if honor.Side == ogame.AttackerWinner {
+			report.AttackerHonorPoints = honor.Points
+		} else {
+			report.DefenderHonorPoints = honor.Points
+		}
+	}
+	for _, tech := range result.RepairedTechnologies {
+		report.RepairedDefenses.Set(ogame.ID(tech.TechnologyID), tech.Amount)
+	}
+	report.MoonChance = result.MoonCreation.Chance
+	report.MoonCreated = result.MoonCreation.Created
+
+	for _, fleet := range fleets {
+		p := ogame.CombatReportParticipant{
+			FleetID:        ogame.FleetID(fleet.FleetID),
+			PlayerID:       fleet.Player.ID,
+			PlayerName:     fleet.Player.Name,
+			CharacterClass: ogame.CharacterClass(fleet.Player.ClassID),
+			Origin: ogame.Coordinate{
+				Galaxy:   fleet.SpaceObject.Coordinates.Galaxy,
+				System:   fleet.SpaceObject.Coordinates.System,
+				Position: fleet.SpaceObject.Coordinates.Position,
+				Type:     ogame.PlanetType,
+			},
+		}
+		if fleet.Player.Alliance != nil {
+			p.AllianceTag = fleet.Player.Alliance.Tag
+		}
+		if fleet.SpaceObject.Type == "moon" {
+			p.Origin.Type = ogame.MoonType
+		}
+		for _, research := range fleet.CombatResearchPercentage {
+			switch ogame.ID(research.ID) {
+			case ogame.WeaponsTechnologyID:
+				p.WeaponPercentage = research.Percentage
+			case ogame.ShieldingTechnologyID:
+				p.ShieldPercentage = research.Percentage
+			case ogame.ArmourTechnologyID:
+				p.ArmourPercentage = research.Percentage
+			}
+		}
+		for _, tech := range fleet.CombatTechnologies {
+			p.SetUnit(ogame.ID(tech.TechnologyID), tech.Amount)
+		}
+		if fleet.Side == ogame.AttackerWinner {
+			report.Attackers = append(report.Attackers, p)
+		} else {
+			report.Defenders = append(report.Defenders, p)
+		}
+	}
+
+	for _, r := range rounds {
+		round := ogame.CombatReportRound{}
+		for _, fleet := range r.Fleets {
+			roundFleet := ogame.CombatReportRoundFleet{FleetID: ogame.FleetID(fleet.FleetID)}
+			for _, tech := range fleet.Technologies {
+				roundFleet.SetUnit(ogame.ID(tech.TechnologyID), tech.Remaining)
+				roundFleet.SetLostUnit(ogame.ID(tech.TechnologyID), tech.Destroyed)
+			}
+			if fleet.Side == ogame.AttackerWinner {
+				round.Attackers = append(round.Attackers, roundFleet)
+			} else {
+				round.Defenders = append(round.Defenders, roundFleet)
+			}
+		}
+		for _, stats := range r.Statistics {
+			if stats.Side == ogame.AttackerWinner {
+				round.AttackerHits, round.AttackerAbsorbedDamage, round.AttackerFullStrength = stats.Hits, stats.AbsorbedDamage, stats.FullStrength
+			} else {
+				round.DefenderHits, round.DefenderAbsorbedDamage, round.DefenderFullStrength = stats.Hits, stats.AbsorbedDamage, stats.FullStrength
+			}
+		}
+		report.Rounds = append(report.Rounds, round)
+	}
+	return report, nil
+}
+
 func extractEspionageReportMessageIDsFromDoc(doc *goquery.Document) ([]ogame.EspionageReportSummary, int64, error) {
 	msgs := make([]ogame.EspionageReportSummary, 0)
 	for _, s := range doc.Find(".msg").EachIter() {
@@ -448,6 +645,7 @@ func extractExpeditionMessagesFromDoc(doc *goquery.Document, location *time.Loca
 				msg.Ships.Battlecruiser = msgDataStruct.Battlecruiser.Amount
 				msg.Ships.Reaper = msgDataStruct.Reaper.Amount
 				msg.Ships.Pathfinder = msgDataStruct.Pathfinder.Amount
+				msg.Outcome = ogame.ClassifyExpeditionMessage(msg)
 
 				msgs = append(msgs, msg)
 			}
@@ -484,6 +682,8 @@ func assignBonusValue(s *goquery.Selection, b *ogame.LfBonuses, category string,
 	case "Resources":
 		if subcategory == "Expedition" {
 			extractResourcesExpeditionBonus(s, b)
+		} else {
+			extractResourcesProductionBonus(s, b, subcategory)
 		}
 	case "Characterclasses":
 		if subcategory == "3" {
@@ -510,6 +710,26 @@ func extractResourcesExpeditionBonus(s *goquery.Selection, l *ogame.LfBonuses) {
 	l.LfResourceBonuses.ResourcesExpedition = extractBonusFromStringPercentage(txt)
 }
 
+// Extracts production (subcategory 0: metal, 1: crystal, 2: deuterium) and storage capacity (Capacity0-2) bonuses
+func extractResourcesProductionBonus(s *goquery.Selection, l *ogame.LfBonuses, subcategory string) {
+	bonus := extractBonusFromStringPercentage(s.Find("div.subCategoryBonus").First().Text())
+	r := &l.LfResourceBonuses
+	switch subcategory {
+	case "0":
+		r.MetalProduction = bonus
+	case "1":
+		r.CrystalProduction = bonus
+	case "2":
+		r.DeuteriumProduction = bonus
+	case "Capacity0":
+		r.MetalStorageCapacity = bonus
+	case "Capacity1":
+		r.CrystalStorageCapacity = bonus
+	case "Capacity2":
+		r.DeuteriumStorageCapacity = bonus
+	}
+}
+
 // Extracts cost reduction
 func extractCostReductionBonus(s *goquery.Selection, l *ogame.LfBonuses, subcategory string) {
 	i := utils.DoParseI64(subcategory)
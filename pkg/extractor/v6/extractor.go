@@ -980,6 +980,16 @@ func (e *Extractor) ExtractPhalanxNewToken(pageHTML []byte) (string, error) {
 	panic("not implemented")
 }
 
+// ExtractCombatReport ...
+func (e *Extractor) ExtractCombatReport(pageHTML []byte) (ogame.CombatReport, error) {
+	panic("not implemented")
+}
+
+// ExtractCombatReportFromDoc ...
+func (e *Extractor) ExtractCombatReportFromDoc(doc *goquery.Document) (ogame.CombatReport, error) {
+	panic("not implemented")
+}
+
 // ExtractJumpGate return the available ships to send, form token, possible moon IDs and wait time (if any)
 // given a jump gate popup html.
 func (e *Extractor) ExtractJumpGate(pageHTML []byte) (ogame.ShipsInfos, string, []ogame.MoonID, int64, error) {
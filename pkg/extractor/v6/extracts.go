@@ -129,7 +129,7 @@ func extractCelestialByPredicateFnFromDoc(doc *goquery.Document, clb func(ogame.
 	if celestial := utils.Find(celestials, func(c ogame.Celestial) bool { return clb(c) }); celestial != nil {
 		return *celestial, nil
 	}
-	return nil, errors.New("invalid celestial")
+	return nil, ogame.ErrCelestialNotFound
 }
 
 func extractCelestialsFromDoc(doc *goquery.Document) []ogame.Celestial {
@@ -180,7 +180,7 @@ func extractPlanetMoonFromDoc[T CelestialTypes](doc *goquery.Document, v any) (T
 	if typed, ok := celestial.(T); ok {
 		return typed, nil
 	}
-	return zero, errors.New("not found")
+	return zero, ogame.ErrCelestialNotFound
 }
 
 func extractPlanetFromDoc(doc *goquery.Document, v any) (ogame.Planet, error) {
@@ -221,6 +221,12 @@ func TestExtractExpeditionMessages(t *testing.T) {
 	assert.Equal(t, ogame.Coordinate{Galaxy: 1, System: 8, Position: 16, Type: ogame.PlanetType}, msgs[0].Coordinate)
 	assert.Equal(t, `We came across the remains of a previous expedition! Our technicians will try to get some of the ships to work again.<br/><br/>The following ships are now part of the fleet:<br/>Espionage Probe: 1880<br/>Light Fighter: 161<br/>Small Cargo: 156`,
 		msgs[0].Content)
+	outcomes := make([]ogame.ExpeditionOutcome, 0)
+	for _, msg := range msgs {
+		outcomes = append(outcomes, msg.Outcome)
+	}
+	assert.Equal(t, []ogame.ExpeditionOutcome{ogame.ShipsOutcome, ogame.ResourcesOutcome, ogame.NothingOutcome, ogame.PiratesOutcome,
+		ogame.ShipsOutcome, ogame.PiratesOutcome, ogame.DarkMatterOutcome, ogame.NothingOutcome, ogame.ShipsOutcome, ogame.PiratesOutcome}, outcomes)
 }
 
 func TestExtractGalaxyExpeditionDebrisDM(t *testing.T) {
@@ -717,6 +717,7 @@ func extractExpeditionMessagesFromDoc(doc *goquery.Document, location *time.Loca
 				msg.Coordinate.Type = ogame.PlanetType
 				msg.Content, _ = s.Find("span.msg_content").Html()
 				msg.Content = strings.TrimSpace(msg.Content)
+				msg.Outcome = ogame.ClassifyExpeditionMessage(msg)
 				msgs = append(msgs, msg)
 			}
 		}
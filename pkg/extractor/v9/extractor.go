@@ -208,3 +208,17 @@ func (e *Extractor) ExtractAvailableDiscoveries(pageHTML []byte) (int64, error)
 func (e *Extractor) extractAvailableDiscoveriesFromDoc(doc *goquery.Document) int64 {
 	return extractAvailableDiscoveriesFromDoc(doc)
 }
+
+// ExtractCombatReport ...
+func (e *Extractor) ExtractCombatReport(pageHTML []byte) (ogame.CombatReport, error) {
+	doc, err := goquery.NewDocumentFromReader(bytes.NewReader(pageHTML))
+	if err != nil {
+		return ogame.CombatReport{}, err
+	}
+	return e.ExtractCombatReportFromDoc(doc)
+}
+
+// ExtractCombatReportFromDoc ...
+func (e *Extractor) ExtractCombatReportFromDoc(doc *goquery.Document) (ogame.CombatReport, error) {
+	return extractCombatReportFromDoc(doc)
+}
@@ -280,3 +280,60 @@ func TestExtractLfSlotsFromDoc(t *testing.T) {
 	assert.True(t, slots[7].Locked)
 	assert.True(t, slots[8].Locked)
 }
+
+func TestExtractCombatReport(t *testing.T) {
+	pageHTMLBytes, _ := os.ReadFile("../../../samples/unversioned/combat_reports_msg_2.html")
+	report, err := NewExtractor().ExtractCombatReport(pageHTMLBytes)
+	assert.NoError(t, err)
+	assert.Equal(t, ogame.DefenderWinner, report.Winner)
+	assert.Equal(t, ogame.Coordinate{Galaxy: 4, System: 116, Position: 12, Type: ogame.PlanetType}, report.Destination)
+	assert.Equal(t, ogame.Resources{Metal: 3854900, Crystal: 2988300}, report.Debris)
+	assert.Equal(t, int64(9560000), report.AttackerLostValue)
+	assert.Equal(t, int64(1240000), report.DefenderLostValue)
+	assert.Equal(t, int64(85), report.AttackerHonorPoints)
+	assert.Equal(t, int64(1377), report.DefenderHonorPoints)
+	assert.Equal(t, int64(20), report.MoonChance)
+	assert.False(t, report.MoonCreated)
+	assert.Equal(t, int64(61), report.RepairedDefenses.RocketLauncher)
+
+	assert.Len(t, report.Attackers, 1)
+	attacker := report.Attackers[0]
+	assert.Equal(t, ogame.FleetID(5509724), attacker.FleetID)
+	assert.Equal(t, "hammad", attacker.PlayerName)
+	assert.Equal(t, int64(107088), attacker.PlayerID)
+	assert.Equal(t, ogame.Coordinate{Galaxy: 4, System: 233, Position: 12, Type: ogame.PlanetType}, attacker.Origin)
+	assert.Equal(t, int64(110), attacker.WeaponPercentage)
+	assert.Equal(t, int64(197), attacker.Ships.LargeCargo)
+	assert.Equal(t, ogame.NoClass, attacker.CharacterClass) // Report older than the character classes
+
+	assert.Len(t, report.Defenders, 1)
+	defender := report.Defenders[0]
+	assert.Equal(t, "Commodore Nomad", defender.PlayerName)
+	assert.Equal(t, int64(120), defender.WeaponPercentage)
+	assert.Equal(t, int64(927), defender.Ships.LargeCargo)
+	assert.Equal(t, int64(961), defender.Defenses.RocketLauncher)
+
+	assert.Len(t, report.Rounds, 3)
+	assert.Equal(t, int64(1073), report.Rounds[0].AttackerHits)
+	assert.Equal(t, int64(2931733), report.Rounds[0].DefenderFullStrength)
+	assert.Equal(t, int64(44), report.Rounds[0].Attackers[0].Ships.LargeCargo)
+	assert.Equal(t, int64(153), report.Rounds[0].Attackers[0].LostShips.LargeCargo)
+	assert.Equal(t, int64(58), report.Rounds[0].Defenders[0].LostDefenses.RocketLauncher)
+	assert.Equal(t, int64(0), report.Rounds[2].Attackers[0].Ships.LargeCargo)
+}
+
+func TestExtractCombatReport_CharacterClass(t *testing.T) {
+	pageHTMLBytes, _ := os.ReadFile("../../../samples/v7.1/en/combat_report_attacked.html")
+	report, err := NewExtractor().ExtractCombatReport(pageHTMLBytes)
+	assert.NoError(t, err)
+	assert.Len(t, report.Attackers, 1)
+	assert.Equal(t, ogame.Collector, report.Attackers[0].CharacterClass)
+	assert.Len(t, report.Defenders, 1)
+	assert.Equal(t, ogame.NoClass, report.Defenders[0].CharacterClass)
+}
+
+func TestExtractCombatReport_NoCombatData(t *testing.T) {
+	pageHTMLBytes, _ := os.ReadFile("../../../samples/unversioned/combat_reports_msg_lost.html")
+	_, err := NewExtractor().ExtractCombatReport(pageHTMLBytes)
+	assert.Error(t, err)
+}
@@ -1,9 +1,12 @@
 package v9
 
 import (
+	"bytes"
+	"encoding/json"
 	"errors"
 	"fmt"
 	"regexp"
+	"sort"
 	"strconv"
 	"strings"
 	"time"
@@ -936,3 +939,209 @@ func extractAvailableDiscoveriesFromDoc(doc *goquery.Document) int64 {
 	total := utils.DoParseI64(totalString)
 	return total - used
 }
+
+// combatInt number that the combat report json encodes either as a number or as a string
+type combatInt int64
+
+func (n *combatInt) UnmarshalJSON(data []byte) error {
+	str := strings.Trim(string(data), `"`)
+	switch str {
+	case "", "null", "false":
+		*n = 0
+	case "true":
+		*n = 1
+	default:
+		f, err := strconv.ParseFloat(str, 64)
+		if err != nil {
+			return err
+		}
+		*n = combatInt(f)
+	}
+	return nil
+}
+
+// unmarshalCombatMap decodes a json object, or an array indexed by position (php encodes empty/sequential maps as arrays).
+// Returns the keys sorted numerically.
+func unmarshalCombatMap[V any](data json.RawMessage) (map[string]V, []string) {
+	out := make(map[string]V)
+	if trimmed := bytes.TrimSpace(data); len(trimmed) > 0 && trimmed[0] == '[' {
+		var arr []V
+		_ = json.Unmarshal(trimmed, &arr)
+		for i, v := range arr {
+			out[strconv.Itoa(i)] = v
+		}
+	} else {
+		_ = json.Unmarshal(data, &out)
+	}
+	keys := make([]string, 0, len(out))
+	for k := range out {
+		keys = append(keys, k)
+	}
+	sort.Slice(keys, func(i, j int) bool { return utils.DoParseI64(keys[i]) < utils.DoParseI64(keys[j]) })
+	return out, keys
+}
+
+type combatDataMember struct {
+	OwnerName        string
+	OwnerID          combatInt
+	OwnerCoordinates string
+	OwnerPlanetType  combatInt
+	FleetID          combatInt
+	OwnerAllianceTag string
+	ArmorPercentage  combatInt
+	WeaponPercentage combatInt
+	ShieldPercentage combatInt
+	ShipDetails      json.RawMessage
+
+	OwnerCharacterClassID combatInt // Missing in the reports older than the character classes
+}
+
+func (m combatDataMember) toParticipant() ogame.CombatReportParticipant {
+	p := ogame.CombatReportParticipant{
+		FleetID:          ogame.FleetID(m.FleetID),
+		PlayerID:         int64(m.OwnerID),
+		PlayerName:       m.OwnerName,
+		AllianceTag:      m.OwnerAllianceTag,
+		CharacterClass:   ogame.CharacterClass(m.OwnerCharacterClassID),
+		Origin:           ogame.DoParseCoord(m.OwnerCoordinates),
+		WeaponPercentage: int64(m.WeaponPercentage),
+		ShieldPercentage: int64(m.ShieldPercentage),
+		ArmourPercentage: int64(m.ArmorPercentage),
+	}
+	p.Origin.Type = ogame.CelestialType(m.OwnerPlanetType)
+	details, _ := unmarshalCombatMap[struct{ Count combatInt }](m.ShipDetails)
+	for id, detail := range details {
+		p.SetUnit(ogame.ID(utils.DoParseI64(id)), int64(detail.Count))
+	}
+	return p
+}
+
+type combatDataRound struct {
+	Statistic *struct {
+		HitsAttacker           combatInt
+		HitsDefender           combatInt
+		AbsorbedDamageAttacker combatInt
+		AbsorbedDamageDefender combatInt
+		FullStrengthAttacker   combatInt
+		FullStrengthDefender   combatInt
+	}
+	AttackerShips             json.RawMessage
+	DefenderShips             json.RawMessage
+	AttackerLossesInThisRound json.RawMessage
+	DefenderLossesInThisRound json.RawMessage
+}
+
+// combatRoundFleets builds the fleets of one side at the end of a round, in the same order as the participants
+func combatRoundFleets(participantsKeys []string, participants []ogame.CombatReportParticipant, shipsRaw, lossesRaw json.RawMessage) []ogame.CombatReportRoundFleet {
+	ships, _ := unmarshalCombatMap[json.RawMessage](shipsRaw)
+	losses, _ := unmarshalCombatMap[json.RawMessage](lossesRaw)
+	out := make([]ogame.CombatReportRoundFleet, 0, len(participantsKeys))
+	for i, key := range participantsKeys {
+		fleet := ogame.CombatReportRoundFleet{FleetID: participants[i].FleetID}
+		units, _ := unmarshalCombatMap[combatInt](ships[key])
+		for id, nbr := range units {
+			fleet.SetUnit(ogame.ID(utils.DoParseI64(id)), int64(nbr))
+		}
+		lost, _ := unmarshalCombatMap[combatInt](losses[key])
+		for id, nbr := range lost {
+			fleet.SetLostUnit(ogame.ID(utils.DoParseI64(id)), int64(nbr))
+		}
+		out = append(out, fleet)
+	}
+	return out
+}
+
+// extractCombatReportFromDoc extracts the combat report from the "messagedetails" page,
+// the report is a json object given to the combat report viewer (combatData).
+func extractCombatReportFromDoc(doc *goquery.Document) (ogame.CombatReport, error) {
+	report := ogame.CombatReport{}
+	report.ID = utils.DoParseI64(doc.Find("div.detail_msg").AttrOr("data-msg-id", "0"))
+	var combatDataStr string
+	combatDataRgx := regexp.MustCompile(`combatData = jQuery\.parseJSON\('(.+)'\);`)
+	for _, s := range doc.Find("script").EachIter() {
+		if m := combatDataRgx.FindStringSubmatch(s.Text()); len(m) == 2 {
+			combatDataStr = strings.ReplaceAll(m[1], `\'`, `'`)
+			break
+		}
+	}
+	if combatDataStr == "" {
+		return report, errors.New("failed to find combat data")
+	}
+	var data struct {
+		EventTimestamp int64 `json:"event_timestamp"`
+		Coordinates    struct {
+			Galaxy     combatInt
+			System     combatInt
+			Position   combatInt
+			PlanetType combatInt
+		}
+		Attacker        json.RawMessage
+		Defender        json.RawMessage
+		CombatRounds    []combatDataRound
+		Statistic       struct{ LostUnitsAttacker, LostUnitsDefender combatInt }
+		Result          string
+		Debris          struct{ Metal, Crystal, Deuterium combatInt }
+		Loot            struct{ Metal, Crystal, Deuterium combatInt }
+		RepairedDefense json.RawMessage
+		Moon            struct {
+			Genesis bool
+			Chance  combatInt
+		}
+		Honor struct {
+			AttackerHonorPoints combatInt
+			DefenderHonorPoints combatInt
+		}
+		LootPercentage combatInt
+	}
+	if err := json.Unmarshal([]byte(combatDataStr), &data); err != nil {
+		return report, errors.New("failed to parse combat data: " + err.Error())
+	}
+
+	report.Date = time.Unix(data.EventTimestamp, 0)
+	report.Destination = ogame.Coordinate{
+		Galaxy:   int64(data.Coordinates.Galaxy),
+		System:   int64(data.Coordinates.System),
+		Position: int64(data.Coordinates.Position),
+		Type:     ogame.CelestialType(data.Coordinates.PlanetType),
+	}
+	report.Winner = data.Result
+	report.Loot = ogame.Resources{Metal: int64(data.Loot.Metal), Crystal: int64(data.Loot.Crystal), Deuterium: int64(data.Loot.Deuterium)}
+	report.LootPercentage = int64(data.LootPercentage)
+	report.Debris = ogame.Resources{Metal: int64(data.Debris.Metal), Crystal: int64(data.Debris.Crystal), Deuterium: int64(data.Debris.Deuterium)}
+	report.AttackerLostValue = int64(data.Statistic.LostUnitsAttacker)
+	report.DefenderLostValue = int64(data.Statistic.LostUnitsDefender)
+	report.AttackerHonorPoints = int64(data.Honor.AttackerHonorPoints)
+	report.DefenderHonorPoints = int64(data.Honor.DefenderHonorPoints)
+	report.MoonChance = int64(data.Moon.Chance)
+	report.MoonCreated = data.Moon.Genesis
+	repaired, _ := unmarshalCombatMap[combatInt](data.RepairedDefense)
+	for id, nbr := range repaired {
+		report.RepairedDefenses.Set(ogame.ID(utils.DoParseI64(id)), int64(nbr))
+	}
+
+	attackers, attackersKeys := unmarshalCombatMap[combatDataMember](data.Attacker)
+	for _, key := range attackersKeys {
+		report.Attackers = append(report.Attackers, attackers[key].toParticipant())
+	}
+	defenders, defendersKeys := unmarshalCombatMap[combatDataMember](data.Defender)
+	for _, key := range defendersKeys {
+		report.Defenders = append(report.Defenders, defenders[key].toParticipant())
+	}
+
+	for _, r := range data.CombatRounds {
+		if r.Statistic == nil {
+			continue // First entry is the fleets before the combat
+		}
+		report.Rounds = append(report.Rounds, ogame.CombatReportRound{
+			Attackers:              combatRoundFleets(attackersKeys, report.Attackers, r.AttackerShips, r.AttackerLossesInThisRound),
+			Defenders:              combatRoundFleets(defendersKeys, report.Defenders, r.DefenderShips, r.DefenderLossesInThisRound),
+			AttackerHits:           int64(r.Statistic.HitsAttacker),
+			DefenderHits:           int64(r.Statistic.HitsDefender),
+			AttackerAbsorbedDamage: int64(r.Statistic.AbsorbedDamageAttacker),
+			DefenderAbsorbedDamage: int64(r.Statistic.AbsorbedDamageDefender),
+			AttackerFullStrength:   int64(r.Statistic.FullStrengthAttacker),
+			DefenderFullStrength:   int64(r.Statistic.FullStrengthDefender),
+		})
+	}
+	return report, nil
+}
@@ -0,0 +1,495 @@
+package farm
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/alaingilbert/clockwork"
+	"github.com/alaingilbert/ogame/pkg/galaxyScanner"
+	"github.com/alaingilbert/ogame/pkg/logistics"
+	"github.com/alaingilbert/ogame/pkg/ogame"
+	"github.com/alaingilbert/ogame/pkg/wrapper"
+)
+
+// Default settings of the farmer
+const (
+	DefaultProbes          = 4
+	DefaultMaxProbes       = 10 // Targets probed per round
+	DefaultReportMaxAge    = time.Hour
+	DefaultMinRaidInterval = 6 * time.Hour
+	DefaultMaxRaidsPerDay  = 3
+	DefaultRoundInterval   = 5 * time.Minute
+	DefaultReportPages     = 1
+)
+
+// historyWindow raids and read messages older than this are forgotten, the last raid of a target is always kept
+const historyWindow = 24 * time.Hour
+
+// Highscore categories and types used to rank the targets
+const (
+	playerCategory  = 1
+	economyHighType = 1
+)
+
+// Bot methods of the wrapper used by the farmer
+type Bot interface {
+	GetEspionageReport(msgID int64) (ogame.EspionageReport, error)
+	GetEspionageReportMessages(maxPage int64) ([]ogame.EspionageReportSummary, error)
+	GetShips(celestialID ogame.CelestialID, opts ...wrapper.Option) (ogame.ShipsInfos, error)
+	GetSlots() (ogame.Slots, error)
+	Highscore(category, typ, page int64) (ogame.Highscore, error)
+	MiniFleetSpy(coordinate ogame.Coordinate, nbShips int64, opts ...wrapper.Option) (ogame.Fleet, error)
+	SendFleet(celestialID ogame.CelestialID, ships ogame.ShipsInfos, speed ogame.Speed, where ogame.Coordinate,
+		mission ogame.MissionID, resources ogame.Resources, holdingTime, unionID int64) (ogame.Fleet, error)
+}
+
+// Config settings of the farmer
+type Config struct {
+	Origin          ogame.CelestialID
+	OriginCoord     ogame.Coordinate
+	CargoShip       ogame.ID    // Ship used to raid (LargeCargoID if not set)
+	Speed           ogame.Speed // Speed of the raids (HundredPercent if not set)
+	Probes          int64       // Probes sent to spy a target
+	MaxProbes       int         // Maximum number of targets probed per round
+	MinLoot         int64       // Minimum total loot of a raid
+	MinScore        int64       // Minimum economy score of the targets, when scores are loaded
+	ReportMaxAge    time.Duration
+	MinRaidInterval time.Duration // Minimum delay between two raids on the same target
+	MaxRaidsPerDay  int           // Maximum number of raids on the same target in 24h
+	ReservedSlots   int64         // Fleet slots kept free
+}
+
+// DefaultConfig returns the default settings for raids sent from the origin
+func DefaultConfig(origin ogame.CelestialID, originCoord ogame.Coordinate) Config {
+	return Config{
+		Origin:          origin,
+		OriginCoord:     originCoord,
+		CargoShip:       ogame.LargeCargoID,
+		Speed:           ogame.HundredPercent,
+		Probes:          DefaultProbes,
+		MaxProbes:       DefaultMaxProbes,
+		ReportMaxAge:    DefaultReportMaxAge,
+		MinRaidInterval: DefaultMinRaidInterval,
+		MaxRaidsPerDay:  DefaultMaxRaidsPerDay,
+	}
+}
+
+func (c Config) cargoShip() ogame.ID {
+	if c.CargoShip == 0 {
+		return ogame.LargeCargoID
+	}
+	return c.CargoShip
+}
+
+func (c Config) speed() ogame.Speed {
+	if c.Speed == 0 {
+		return ogame.HundredPercent
+	}
+	return c.Speed
+}
+
+// Raid raid sent on a target
+type Raid struct {
+	At       time.Time
+	ReturnAt time.Time
+	FleetID  ogame.FleetID
+	Ships    ogame.ShipsInfos
+	Loot     ogame.Resources // Expected loot
+}
+
+// History raids and probes sent on a target
+type History struct {
+	Coordinate  ogame.Coordinate
+	LastProbeAt time.Time
+	Raids       []Raid
+}
+
+// raidsSince returns the number of raids sent since the given time
+func (h History) raidsSince(since time.Time) (out int) {
+	for _, r := range h.Raids {
+		if !r.At.Before(since) {
+			out++
+		}
+	}
+	return
+}
+
+// trimRaids removes the raids sent before the given time, except the last one
+func (h *History) trimRaids(before time.Time) {
+	kept := make([]Raid, 0, len(h.Raids))
+	for i, r := range h.Raids {
+		if !r.At.Before(before) || i == len(h.Raids)-1 {
+			kept = append(kept, r)
+		}
+	}
+	h.Raids = kept
+}
+
+// lastRaid returns the last raid sent on the target
+func (h History) lastRaid() (Raid, bool) {
+	if len(h.Raids) == 0 {
+		return Raid{}, false
+	}
+	return h.Raids[len(h.Raids)-1], true
+}
+
+// Target inactive planet evaluated for a raid
+type Target struct {
+	Planet      galaxyScanner.Planet
+	Score       int64                  // Economy score of the player, 0 if unknown
+	Report      *ogame.EspionageReport // Latest espionage report, nil if none
+	Loot        ogame.Resources        // Expected loot at arrival
+	Ships       ogame.ShipsInfos       // Cargo ships needed for the loot
+	FlightTime  int64                  // One way, in seconds
+	Fuel        int64
+	LootPerHour float64 // Loot minus fuel, per hour of round trip
+}
+
+// production returns the hourly metal and crystal production estimated from the mines of the report
+func production(report ogame.EspionageReport, universeSpeed int64) ogame.Resources {
+	buildings := report.ResourcesBuildings()
+	if buildings == nil {
+		return ogame.Resources{}
+	}
+	speed := max(universeSpeed, 1)
+	return ogame.Resources{
+		Metal:   ogame.MetalMine.Production(speed, 1, 1, 0, buildings.MetalMine),
+		Crystal: ogame.CrystalMine.Production(speed, 1, 1, 0, buildings.CrystalMine),
+	}
+}
+
+// Evaluate computes the expected loot of a target at arrival, the ships needed to carry it and the loot per hour
+// of flight. Targets without report can not be evaluated and are returned as is.
+func Evaluate(planet galaxyScanner.Planet, report *ogame.EspionageReport, config Config, params logistics.Params, now time.Time) Target {
+	target := Target{Planet: planet, Report: report}
+	if report == nil {
+		return target
+	}
+	cargoShip := ogame.Objs.GetShip(config.cargoShip())
+	if cargoShip == nil {
+		return target
+	}
+	var single ogame.ShipsInfos
+	single.Set(cargoShip.GetID(), 1)
+	secs, _ := params.FlightTime(config.OriginCoord, planet.Coordinate, single, config.speed(), ogame.Attack)
+	arrival := now.Add(time.Duration(secs) * time.Second)
+
+	ratio := report.PlunderRatio(params.CharacterClass)
+	hours := max(arrival.Sub(report.Date).Hours(), 0)
+	prod := production(*report, params.ServerData.Speed)
+	loot := report.Loot(params.CharacterClass)
+	loot.Metal += int64(float64(prod.Metal) * hours * ratio)
+	loot.Crystal += int64(float64(prod.Crystal) * hours * ratio)
+	target.Loot = loot
+
+	multiplier := float64(params.ServerData.CargoHyperspaceTechMultiplier) / 100
+	nb := loot.FitsIn(cargoShip, params.Researches, params.LfBonuses, params.CharacterClass, multiplier, params.ProbeRaids)
+	target.Ships.Set(cargoShip.GetID(), max(nb, 1))
+	target.FlightTime, target.Fuel = params.FlightTime(config.OriginCoord, planet.Coordinate, target.Ships, config.speed(), ogame.Attack)
+	if roundTrip := float64(2*target.FlightTime) / 3600; roundTrip > 0 {
+		target.LootPerHour = float64(loot.Total()-target.Fuel) / roundTrip
+	}
+	return target
+}
+
+// Rank sorts the targets by loot per hour of flight, best first
+func Rank(targets []Target) {
+	sort.SliceStable(targets, func(i, j int) bool { return targets[i].LootPerHour > targets[j].LootPerHour })
+}
+
+// Farmer finds inactive targets in the galaxy store, probes them, and raids the most profitable ones
+type Farmer struct {
+	sync.Mutex
+	bot      Bot
+	store    *galaxyScanner.Store
+	params   func() (logistics.Params, error)
+	clock    clockwork.Clock
+	config   Config
+	scores   map[int64]int64
+	reports  map[ogame.Coordinate]ogame.EspionageReport
+	readMsgs map[int64]time.Time // Time at which the message was read
+	history  map[ogame.Coordinate]*History
+	onRound  func([]Raid, error)
+}
+
+// New creates a farmer that uses the bot account settings
+func New(bot wrapper.Wrapper, store *galaxyScanner.Store, config Config) *Farmer {
+	return NewWithClock(bot, store, func() (logistics.Params, error) { return logistics.ParamsFromBot(bot) }, config, clockwork.NewRealClock())
+}
+
+// NewWithClock same as New, with custom params and clock
+func NewWithClock(bot Bot, store *galaxyScanner.Store, params func() (logistics.Params, error), config Config, clock clockwork.Clock) *Farmer {
+	return &Farmer{
+		bot:      bot,
+		store:    store,
+		params:   params,
+		clock:    clock,
+		config:   config,
+		scores:   make(map[int64]int64),
+		reports:  make(map[ogame.Coordinate]ogame.EspionageReport),
+		readMsgs: make(map[int64]time.Time),
+		history:  make(map[ogame.Coordinate]*History),
+	}
+}
+
+// SetConfig replaces the settings of the farmer
+func (f *Farmer) SetConfig(config Config) {
+	f.Lock()
+	defer f.Unlock()
+	f.config = config
+}
+
+// History returns the raids and probes sent on a target
+func (f *Farmer) History(coord ogame.Coordinate) History {
+	f.Lock()
+	defer f.Unlock()
+	if h, ok := f.history[coord]; ok {
+		out := *h
+		out.Raids = append([]Raid(nil), h.Raids...)
+		return out
+	}
+	return History{Coordinate: coord}
+}
+
+func (f *Farmer) historyLocked(coord ogame.Coordinate) *History {
+	h, ok := f.history[coord]
+	if !ok {
+		h = &History{Coordinate: coord}
+		f.history[coord] = h
+	}
+	return h
+}
+
+// LoadScores loads the economy highscore of the players, from page 1 to maxPage
+func (f *Farmer) LoadScores(maxPage int64) error {
+	scores := make(map[int64]int64)
+	for page := int64(1); page <= maxPage; page++ {
+		highscore, err := f.bot.Highscore(playerCategory, economyHighType, page)
+		if err != nil {
+			return err
+		}
+		for _, player := range highscore.Players {
+			scores[player.ID] = player.Score
+		}
+		if page >= highscore.NbPage {
+			break
+		}
+	}
+	f.Lock()
+	f.scores = scores
+	f.Unlock()
+	return nil
+}
+
+// ReadReports fetches the new espionage reports and keeps the latest one of each target.
+// A report that cannot be fetched is skipped, and fetched again by the next call.
+func (f *Farmer) ReadReports() error {
+	summaries, err := f.bot.GetEspionageReportMessages(DefaultReportPages)
+	if err != nil {
+		return err
+	}
+	now := f.clock.Now()
+	f.Lock()
+	for id, readAt := range f.readMsgs {
+		if now.Sub(readAt) > historyWindow {
+			delete(f.readMsgs, id)
+		}
+	}
+	f.Unlock()
+	for _, summary := range summaries {
+		f.Lock()
+		_, read := f.readMsgs[summary.ID]
+		f.Unlock()
+		if summary.Type != ogame.Report || read {
+			continue
+		}
+		report, err := f.bot.GetEspionageReport(summary.ID)
+		if err != nil {
+			continue
+		}
+		coord := report.Coordinate
+		f.Lock()
+		f.readMsgs[summary.ID] = now
+		if existing, ok := f.reports[coord]; !ok || report.Date.After(existing.Date) {
+			f.reports[coord] = report
+		}
+		f.Unlock()
+	}
+	return nil
+}
+
+// isCandidate returns either or not the planet may be farmed
+func isCandidate(p galaxyScanner.Planet) bool {
+	return p.Inactive && !p.Vacation && !p.Banned && !p.Administrator && p.PlayerID != 0
+}
+
+// canRaidLocked returns either or not the target was not raided too recently, the lock must be held
+func (f *Farmer) canRaidLocked(coord ogame.Coordinate, now time.Time) bool {
+	h, ok := f.history[coord]
+	if !ok {
+		return true
+	}
+	if last, ok := h.lastRaid(); ok && (now.Sub(last.At) < f.config.MinRaidInterval || now.Before(last.ReturnAt)) {
+		return false
+	}
+	return f.config.MaxRaidsPerDay <= 0 || h.raidsSince(now.Add(-historyWindow)) < f.config.MaxRaidsPerDay
+}
+
+// Targets returns the inactive targets of the store that can be raided, best first.
+// Targets without a fresh espionage report are at the end, ordered by economy score.
+func (f *Farmer) Targets() ([]Target, error) {
+	params, err := f.params()
+	if err != nil {
+		return nil, err
+	}
+	now := f.clock.Now()
+	f.Lock()
+	defer f.Unlock()
+	evaluated := make([]Target, 0)
+	unknown := make([]Target, 0)
+	for _, planet := range f.store.Planets(isCandidate) {
+		if !f.canRaidLocked(planet.Coordinate, now) {
+			continue
+		}
+		score, hasScore := f.scores[planet.PlayerID]
+		if len(f.scores) > 0 && (!hasScore || score < f.config.MinScore) {
+			continue
+		}
+		var report *ogame.EspionageReport
+		if r, ok := f.reports[planet.Coordinate]; ok && now.Sub(r.Date) <= f.config.ReportMaxAge {
+			report = &r
+		}
+		target := Evaluate(planet, report, f.config, params, now)
+		target.Score = score
+		if report == nil {
+			unknown = append(unknown, target)
+		} else {
+			evaluated = append(evaluated, target)
+		}
+	}
+	Rank(evaluated)
+	sort.SliceStable(unknown, func(i, j int) bool { return unknown[i].Score > unknown[j].Score })
+	return append(evaluated, unknown...), nil
+}
+
+// freeSlots returns the number of slots that can be used by the farmer
+func (f *Farmer) freeSlots() (int64, error) {
+	slots, err := f.bot.GetSlots()
+	if err != nil {
+		return 0, err
+	}
+	f.Lock()
+	reserved := f.config.ReservedSlots
+	f.Unlock()
+	return max(slots.Total-slots.InUse-reserved, 0), nil
+}
+
+// Round reads the new reports, raids the defenceless targets with a fresh report, then probes the targets
+// without a fresh report. Returns the raids sent, and the first error sending a raid.
+func (f *Farmer) Round() ([]Raid, error) {
+	if err := f.ReadReports(); err != nil {
+		return nil, err
+	}
+	targets, err := f.Targets()
+	if err != nil {
+		return nil, err
+	}
+	slots, err := f.freeSlots()
+	if err != nil {
+		return nil, err
+	}
+	f.Lock()
+	config := f.config
+	f.Unlock()
+	available, err := f.bot.GetShips(config.Origin)
+	if err != nil {
+		return nil, err
+	}
+
+	var sendErr error
+	raids := make([]Raid, 0)
+	for _, target := range targets {
+		if slots <= 0 || target.Report == nil {
+			break
+		}
+		if !target.Report.IsDefenceless() || target.Loot.Total() < config.MinLoot || !available.Has(target.Ships) {
+			continue
+		}
+		fleet, err := f.bot.SendFleet(config.Origin, target.Ships, config.speed(), target.Planet.Coordinate, ogame.Attack, ogame.Resources{}, 0, 0)
+		if err != nil {
+			if sendErr == nil {
+				sendErr = err
+			}
+			continue
+		}
+		now := f.clock.Now()
+		raid := Raid{At: now, ReturnAt: now.Add(time.Duration(2*target.FlightTime) * time.Second), FleetID: fleet.ID, Ships: target.Ships, Loot: target.Loot}
+		if !fleet.BackTime.IsZero() {
+			raid.ReturnAt = fleet.BackTime
+		}
+		f.Lock()
+		h := f.historyLocked(target.Planet.Coordinate)
+		h.Raids = append(h.Raids, raid)
+		h.trimRaids(now.Add(-historyWindow))
+		delete(f.reports, target.Planet.Coordinate) // Resources of the report are gone
+		f.Unlock()
+		raids = append(raids, raid)
+		available.Sub(target.Ships)
+		slots--
+	}
+
+	probed := 0
+	for _, target := range targets {
+		if slots <= 0 || probed >= config.MaxProbes {
+			break
+		}
+		if target.Report != nil {
+			continue
+		}
+		f.Lock()
+		lastProbe := f.historyLocked(target.Planet.Coordinate).LastProbeAt
+		f.Unlock()
+		if !lastProbe.IsZero() && f.clock.Since(lastProbe) < config.ReportMaxAge {
+			continue // Report on its way
+		}
+		if _, err := f.bot.MiniFleetSpy(target.Planet.Coordinate, max(config.Probes, 1), wrapper.ChangePlanet(config.Origin)); err != nil {
+			continue
+		}
+		f.Lock()
+		f.historyLocked(target.Planet.Coordinate).LastProbeAt = f.clock.Now()
+		f.Unlock()
+		probed++
+		slots--
+	}
+	return raids, sendErr
+}
+
+// OnRound sets a callback called with the result of each round executed by Run
+func (f *Farmer) OnRound(clb func([]Raid, error)) {
+	f.Lock()
+	defer f.Unlock()
+	f.onRound = clb
+}
+
+// Run executes a round every interval (DefaultRoundInterval if not set) until the context is cancelled
+func (f *Farmer) Run(ctx context.Context, interval time.Duration) {
+	if interval <= 0 {
+		interval = DefaultRoundInterval
+	}
+	for {
+		raids, err := f.Round()
+		f.Lock()
+		onRound := f.onRound
+		f.Unlock()
+		if onRound != nil {
+			onRound(raids, err)
+		}
+		select {
+		case <-f.clock.After(interval):
+		case <-ctx.Done():
+			return
+		}
+	}
+}
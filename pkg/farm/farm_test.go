@@ -0,0 +1,247 @@
+package farm
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/alaingilbert/clockwork"
+	"github.com/alaingilbert/ogame/pkg/galaxyScanner"
+	"github.com/alaingilbert/ogame/pkg/logistics"
+	"github.com/alaingilbert/ogame/pkg/ogame"
+	"github.com/alaingilbert/ogame/pkg/utils"
+	"github.com/alaingilbert/ogame/pkg/wrapper"
+	"github.com/stretchr/testify/assert"
+)
+
+var _ Bot = (*wrapper.OGame)(nil)
+
+var testParams = logistics.Params{
+	ServerData: wrapper.ServerData{Galaxies: 9, Systems: 499, Speed: 1, SpeedFleetWar: 1, GlobalDeuteriumSaveFactor: 1,
+		CargoHyperspaceTechMultiplier: 5},
+	Researches: ogame.Researches{CombustionDrive: 6, ImpulseDrive: 4},
+}
+
+var origin = ogame.Coordinate{Galaxy: 1, System: 100, Position: 8, Type: ogame.PlanetType}
+
+func coord(system, position int64) ogame.Coordinate {
+	return ogame.Coordinate{Galaxy: 1, System: system, Position: position, Type: ogame.PlanetType}
+}
+
+func defenceless(c ogame.Coordinate, resources ogame.Resources, date time.Time) ogame.EspionageReport {
+	return ogame.EspionageReport{
+		Resources:              resources,
+		Coordinate:             c,
+		Type:                   ogame.Report,
+		Date:                   date,
+		IsInactive:             true,
+		HasFleetInformation:    true,
+		HasDefensesInformation: true,
+		LightFighter:           utils.I64Ptr(0),
+		RocketLauncher:         utils.I64Ptr(0),
+	}
+}
+
+type sent struct {
+	where ogame.Coordinate
+	ships ogame.ShipsInfos
+}
+
+type fakeBot struct {
+	reports    map[int64]ogame.EspionageReport
+	reportErrs map[int64]error
+	sendErrs   map[ogame.Coordinate]error
+	ships      ogame.ShipsInfos
+	slots      ogame.Slots
+	slotsErr   error
+	sent       []sent
+	spied      []ogame.Coordinate
+}
+
+func (b *fakeBot) GetEspionageReport(msgID int64) (ogame.EspionageReport, error) {
+	if err := b.reportErrs[msgID]; err != nil {
+		return ogame.EspionageReport{}, err
+	}
+	return b.reports[msgID], nil
+}
+func (b *fakeBot) GetEspionageReportMessages(int64) ([]ogame.EspionageReportSummary, error) {
+	out := make([]ogame.EspionageReportSummary, 0)
+	for id, r := range b.reports {
+		out = append(out, ogame.EspionageReportSummary{ID: id, Type: ogame.Report, Target: r.Coordinate})
+	}
+	return out, nil
+}
+func (b *fakeBot) GetShips(ogame.CelestialID, ...wrapper.Option) (ogame.ShipsInfos, error) {
+	return b.ships, nil
+}
+func (b *fakeBot) GetSlots() (ogame.Slots, error) { return b.slots, b.slotsErr }
+func (b *fakeBot) Highscore(_, _, _ int64) (ogame.Highscore, error) {
+	return ogame.Highscore{NbPage: 1, Players: []ogame.HighscorePlayer{{ID: 1, Score: 5000}, {ID: 2, Score: 100}, {ID: 3, Score: 9000}}}, nil
+}
+func (b *fakeBot) MiniFleetSpy(coordinate ogame.Coordinate, _ int64, _ ...wrapper.Option) (ogame.Fleet, error) {
+	b.spied = append(b.spied, coordinate)
+	b.slots.InUse++
+	return ogame.Fleet{}, nil
+}
+func (b *fakeBot) SendFleet(_ ogame.CelestialID, ships ogame.ShipsInfos, _ ogame.Speed, where ogame.Coordinate,
+	_ ogame.MissionID, _ ogame.Resources, _, _ int64) (ogame.Fleet, error) {
+	if err := b.sendErrs[where]; err != nil {
+		return ogame.Fleet{}, err
+	}
+	b.sent = append(b.sent, sent{where, ships})
+	b.slots.InUse++
+	return ogame.Fleet{ID: ogame.FleetID(len(b.sent))}, nil
+}
+
+func TestEvaluate(t *testing.T) {
+	now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	config := DefaultConfig(1, origin)
+	near := galaxyScanner.Planet{Coordinate: coord(101, 1)}
+	far := galaxyScanner.Planet{Coordinate: coord(300, 1)}
+	report := defenceless(near.Coordinate, ogame.Resources{Metal: 200000, Crystal: 100000}, now)
+
+	target := Evaluate(near, &report, config, testParams, now)
+	assert.Equal(t, ogame.Resources{Metal: 100000, Crystal: 50000}, target.Loot) // No mines in the report
+	assert.Equal(t, ogame.ShipsInfos{LargeCargo: 6}, target.Ships)
+	assert.Greater(t, target.LootPerHour, float64(0))
+
+	report.MetalMine = utils.I64Ptr(20)
+	report.CrystalMine = utils.I64Ptr(15)
+	report.HasBuildingsInformation = true
+	report.Date = now.Add(-time.Hour)
+	target = Evaluate(near, &report, config, testParams, now)
+	assert.Greater(t, target.Loot.Metal, int64(100000)) // Production since the report
+	assert.Greater(t, target.Loot.Crystal, int64(50000))
+	report.HasBuildingsInformation, report.Date = false, now
+
+	report.Coordinate = far.Coordinate
+	farTarget := Evaluate(far, &report, config, testParams, now)
+	assert.Greater(t, farTarget.FlightTime, target.FlightTime)
+
+	targets := []Target{farTarget, target}
+	Rank(targets)
+	assert.Equal(t, near.Coordinate, targets[0].Planet.Coordinate)
+
+	assert.Nil(t, Evaluate(near, nil, config, testParams, now).Report)
+}
+
+func TestFarmer_Round(t *testing.T) {
+	clock := clockwork.NewFakeClockAt(time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC))
+	store := galaxyScanner.NewStore()
+	store.Update(1, 101, []galaxyScanner.Planet{
+		{Coordinate: coord(101, 1), PlayerID: 1, Inactive: true},
+		{Coordinate: coord(101, 2), PlayerID: 2, Inactive: true}, // Score too low
+		{Coordinate: coord(101, 3), PlayerID: 3, Inactive: true, Vacation: true},
+		{Coordinate: coord(101, 4), PlayerID: 4},                 // Active
+		{Coordinate: coord(101, 5), PlayerID: 3, Inactive: true}, // No report, will be probed
+	}, clock.Now())
+	bot := &fakeBot{
+		reports: map[int64]ogame.EspionageReport{
+			10: defenceless(coord(101, 1), ogame.Resources{Metal: 100000, Crystal: 50000}, clock.Now()),
+		},
+		ships: ogame.ShipsInfos{LargeCargo: 100},
+		slots: ogame.Slots{Total: 10},
+	}
+	config := DefaultConfig(1, origin)
+	config.MinScore = 1000
+	f := NewWithClock(bot, store, func() (logistics.Params, error) { return testParams, nil }, config, clock)
+	assert.NoError(t, f.LoadScores(5))
+
+	raids, err := f.Round()
+	assert.NoError(t, err)
+	assert.Len(t, raids, 1)
+	assert.Len(t, bot.sent, 1)
+	assert.Equal(t, coord(101, 1), bot.sent[0].where)
+	assert.Equal(t, []ogame.Coordinate{coord(101, 5)}, bot.spied)
+	assert.Len(t, f.History(coord(101, 1)).Raids, 1)
+
+	// Target just raided and report on its way, nothing is sent
+	clock.Advance(10 * time.Minute)
+	bot.reports = map[int64]ogame.EspionageReport{}
+	raids, err = f.Round()
+	assert.NoError(t, err)
+	assert.Empty(t, raids)
+	assert.Len(t, bot.spied, 1)
+
+	// New report after the minimum interval
+	clock.Advance(DefaultMinRaidInterval)
+	bot.reports[11] = defenceless(coord(101, 1), ogame.Resources{Metal: 100000}, clock.Now())
+	raids, err = f.Round()
+	assert.NoError(t, err)
+	assert.Len(t, raids, 1)
+
+	// Not enough free slots
+	clock.Advance(DefaultMinRaidInterval)
+	bot.reports[12] = defenceless(coord(101, 1), ogame.Resources{Metal: 100000}, clock.Now())
+	bot.slots.InUse = bot.slots.Total
+	raids, err = f.Round()
+	assert.NoError(t, err)
+	assert.Empty(t, raids)
+}
+
+func TestFarmer_RoundErrors(t *testing.T) {
+	clock := clockwork.NewFakeClockAt(time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC))
+	store := galaxyScanner.NewStore()
+	store.Update(1, 101, []galaxyScanner.Planet{
+		{Coordinate: coord(101, 1), PlayerID: 1, Inactive: true},
+		{Coordinate: coord(101, 2), PlayerID: 2, Inactive: true},
+		{Coordinate: coord(101, 3), PlayerID: 3, Inactive: true},
+	}, clock.Now())
+	errSend := errors.New("send failed")
+	bot := &fakeBot{
+		reports: map[int64]ogame.EspionageReport{
+			10: defenceless(coord(101, 1), ogame.Resources{Metal: 300000}, clock.Now()),
+			11: defenceless(coord(101, 2), ogame.Resources{Metal: 200000}, clock.Now()),
+			12: defenceless(coord(101, 3), ogame.Resources{Metal: 100000}, clock.Now()),
+		},
+		reportErrs: map[int64]error{12: errors.New("report failed")},
+		sendErrs:   map[ogame.Coordinate]error{coord(101, 1): errSend},
+		ships:      ogame.ShipsInfos{LargeCargo: 100},
+		slots:      ogame.Slots{Total: 10},
+	}
+	config := DefaultConfig(1, origin)
+	config.MaxProbes = 0
+	f := NewWithClock(bot, store, func() (logistics.Params, error) { return testParams, nil }, config, clock)
+
+	// The failing report is skipped, and the other targets are raided after the failing one
+	raids, err := f.Round()
+	assert.ErrorIs(t, err, errSend)
+	assert.Len(t, raids, 1)
+	assert.Equal(t, []sent{{coord(101, 2), raids[0].Ships}}, bot.sent)
+	assert.Empty(t, f.History(coord(101, 1)).Raids)
+
+	// The report is read once it can be fetched
+	delete(bot.reportErrs, 12)
+	delete(bot.sendErrs, coord(101, 1))
+	raids, err = f.Round()
+	assert.NoError(t, err)
+	assert.Len(t, raids, 2)
+
+	// Raids and read messages older than 24h are forgotten, except the last raid of a target
+	for i := 0; i < 5; i++ {
+		clock.Advance(DefaultMinRaidInterval + time.Hour)
+		bot.reports = map[int64]ogame.EspionageReport{int64(20 + i): defenceless(coord(101, 2), ogame.Resources{Metal: 200000}, clock.Now())}
+		_, err = f.Round()
+		assert.NoError(t, err)
+	}
+	assert.Len(t, f.History(coord(101, 2)).Raids, 3)
+	assert.Len(t, f.History(coord(101, 1)).Raids, 1)
+	f.Lock()
+	assert.Len(t, f.readMsgs, 4)
+	f.Unlock()
+}
+
+func TestFarmer_Run(t *testing.T) {
+	clock := clockwork.NewFakeClockAt(time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC))
+	errSlots := errors.New("slots failed")
+	bot := &fakeBot{slotsErr: errSlots}
+	f := NewWithClock(bot, galaxyScanner.NewStore(), func() (logistics.Params, error) { return testParams, nil }, DefaultConfig(1, origin), clock)
+	errs := make([]error, 0)
+	f.OnRound(func(raids []Raid, err error) { errs = append(errs, err) })
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	f.Run(ctx, time.Minute)
+	assert.Len(t, errs, 1)
+	assert.ErrorIs(t, errs[0], errSlots)
+}
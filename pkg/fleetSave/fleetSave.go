@@ -0,0 +1,397 @@
+package fleetSave
+
+import (
+	"context"
+	"errors"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/alaingilbert/clockwork"
+	"github.com/alaingilbert/ogame/pkg/logistics"
+	"github.com/alaingilbert/ogame/pkg/ogame"
+	"github.com/alaingilbert/ogame/pkg/taskRunner"
+	"github.com/alaingilbert/ogame/pkg/wrapper"
+)
+
+// ErrNoSafeDestination returned when no destination allows the fleet to be away at impact
+var ErrNoSafeDestination = errors.New("no safe destination")
+
+// ErrNothingToSave returned when the attacked celestial has no ship that can fly
+var ErrNothingToSave = errors.New("nothing to save")
+
+// Default settings of the fleet save
+const (
+	DefaultReactBefore  = 5 * time.Minute  // Fleets are saved when the impact is closer than this
+	DefaultRecallMargin = 2 * time.Minute  // Fleets come back this long after the impact
+	DefaultPollInterval = 30 * time.Second // Time between two checks of the incoming attacks
+	maxLogEntries       = 1000
+)
+
+// Bot functions of the wrapper used by the fleet save
+type Bot interface {
+	GetAttacks(...wrapper.Option) ([]ogame.AttackEvent, error)
+	GetCachedCelestials() []wrapper.Celestial
+	SendFleet(celestialID ogame.CelestialID, ships ogame.ShipsInfos, speed ogame.Speed, where ogame.Coordinate,
+		mission ogame.MissionID, resources ogame.Resources, holdingTime, unionID int64) (ogame.Fleet, error)
+	ScheduleCancelFleetAt(at time.Time, fleetID ogame.FleetID) (*taskRunner.TaskHandle, error)
+}
+
+// Action fleet save of the ships of a celestial
+type Action struct {
+	AttackID    int64
+	Origin      ogame.CelestialID
+	Coordinate  ogame.Coordinate // Coordinate of the origin
+	Destination ogame.Coordinate
+	Mission     ogame.MissionID
+	Speed       ogame.Speed
+	Ships       ogame.ShipsInfos
+	Resources   ogame.Resources
+	FlightTime  int64 // One way, in seconds
+	Fuel        int64
+	ImpactAt    time.Time
+	RecallAt    time.Time     // Zero when the fleet stays at the destination
+	FleetID     ogame.FleetID // Set once the fleet is sent
+}
+
+// LogKind kind of the entries of the event log
+type LogKind string
+
+// Kinds of the entries of the event log
+const (
+	DetectedLog LogKind = "detected" // Hostile fleet seen for the first time
+	SavedLog    LogKind = "saved"    // Fleet sent, and recall scheduled if any
+	DryRunLog   LogKind = "dryRun"   // Fleet would have been sent
+	ErrorLog    LogKind = "error"
+)
+
+// LogEntry entry of the event log
+type LogEntry struct {
+	Time     time.Time
+	Kind     LogKind
+	AttackID int64
+	Message  string
+	Action   *Action `json:",omitempty"`
+}
+
+// Celestial own celestial and what is on it
+type Celestial struct {
+	ID         ogame.CelestialID
+	Coordinate ogame.Coordinate
+	Ships      ogame.ShipsInfos
+	Resources  ogame.Resources
+}
+
+// Plan returns the fleet save of the ships of the attacked celestial, celestials are the own celestials.
+// Destinations are tried in order: the moon of the attacked planet, the debris field when the fleet has recyclers,
+// then the other celestials closest first. Destinations under attack are skipped.
+// The slowest speed allowing the fleet to be away at impact is used. When the flight is long enough,
+// the recall is planned for the fleet to come back recallMargin after the impact,
+// otherwise the fleet stays at the destination (debris field excluded).
+func Plan(attack ogame.AttackEvent, celestials []Celestial, attacked map[ogame.Coordinate]bool, params logistics.Params,
+	recallMargin time.Duration, now time.Time) (Action, error) {
+	var origin Celestial
+	var found bool
+	for _, c := range celestials {
+		if c.Coordinate.Equal(attack.Destination) {
+			origin, found = c, true
+			break
+		}
+	}
+	if !found {
+		return Action{}, errors.New("attacked celestial not found " + attack.Destination.String())
+	}
+	var ships ogame.ShipsInfos
+	for shipID, nbr := range origin.Ships.IterFlyable() {
+		ships.Set(shipID, nbr)
+	}
+	if !ships.HasShips() {
+		return Action{}, ErrNothingToSave
+	}
+
+	type destination struct {
+		coord   ogame.Coordinate
+		mission ogame.MissionID
+	}
+	destinations := make([]destination, 0)
+	others := make([]Celestial, 0)
+	for _, c := range celestials {
+		if c.ID == origin.ID || attacked[c.Coordinate] {
+			continue
+		}
+		if origin.Coordinate.Type == ogame.PlanetType && c.Coordinate.Equal(origin.Coordinate.Moon()) {
+			destinations = append(destinations, destination{c.Coordinate, ogame.Park})
+			continue
+		}
+		others = append(others, c)
+	}
+	if ships.Recycler > 0 {
+		destinations = append(destinations, destination{origin.Coordinate.Debris(), ogame.RecycleDebrisField})
+	}
+	sd := params.ServerData
+	distance := func(c Celestial) int64 {
+		return wrapper.Distance(origin.Coordinate, c.Coordinate, sd.Galaxies, sd.Systems, params.SystemsSkip, sd.DonutGalaxy, sd.DonutSystem)
+	}
+	sort.SliceStable(others, func(i, j int) bool { return distance(others[i]) < distance(others[j]) })
+	for _, c := range others {
+		destinations = append(destinations, destination{c.Coordinate, ogame.Park})
+	}
+
+	impactIn := attack.ArrivalTime.Sub(now)
+	recallAfter := (impactIn + recallMargin) / 2 // Fleet comes back after twice the time it flew
+	for _, dest := range destinations {
+		for _, speed := range logistics.Speeds {
+			secs, fuel := params.FlightTime(origin.Coordinate, dest.coord, ships, speed, dest.mission)
+			flight := time.Duration(secs) * time.Second
+			if fuel > origin.Resources.Deuterium {
+				continue
+			}
+			action := Action{
+				AttackID:    attack.ID,
+				Origin:      origin.ID,
+				Coordinate:  origin.Coordinate,
+				Destination: dest.coord,
+				Mission:     dest.mission,
+				Speed:       speed,
+				Ships:       ships,
+				FlightTime:  secs,
+				Fuel:        fuel,
+				ImpactAt:    attack.ArrivalTime,
+			}
+			if flight > recallAfter {
+				action.RecallAt = now.Add(recallAfter)
+			} else if dest.mission == ogame.RecycleDebrisField {
+				continue // Would be back before the impact
+			}
+			action.Resources = loadable(origin.Resources, fuel, params.Cargo(ships))
+			return action, nil
+		}
+	}
+	return Action{}, ErrNoSafeDestination
+}
+
+// loadable returns the resources that fit in the cargo, deuterium first then crystal then metal
+func loadable(resources ogame.Resources, fuel, cargo int64) (out ogame.Resources) {
+	out.Deuterium = max(min(resources.Deuterium-fuel, cargo), 0)
+	out.Crystal = min(resources.Crystal, cargo-out.Deuterium)
+	out.Metal = min(resources.Metal, cargo-out.Deuterium-out.Crystal)
+	return
+}
+
+// isHostile returns either or not the attack can destroy the fleet
+func isHostile(attack ogame.AttackEvent) bool {
+	return attack.MissionType == ogame.Attack || attack.MissionType == ogame.GroupedAttack || attack.MissionType == ogame.Destroy
+}
+
+// FleetSave saves the fleets of the celestials targeted by hostile fleets
+type FleetSave struct {
+	bot          Bot
+	params       func() (logistics.Params, error)
+	clock        clockwork.Clock
+	mu           sync.Mutex
+	dryRun       bool
+	reactBefore  time.Duration
+	recallMargin time.Duration
+	pollInterval time.Duration
+	seen         map[int64]struct{}
+	handled      map[int64]struct{}
+	log          []LogEntry
+}
+
+// New creates a fleet save for the bot
+func New(bot wrapper.Wrapper) *FleetSave {
+	return NewWithClock(bot, func() (logistics.Params, error) { return logistics.ParamsFromBot(bot) }, clockwork.NewRealClock())
+}
+
+// NewWithClock creates a fleet save, params returns the account settings used to compute flight times and cargo
+func NewWithClock(bot Bot, params func() (logistics.Params, error), clock clockwork.Clock) *FleetSave {
+	return &FleetSave{
+		bot:          bot,
+		params:       params,
+		clock:        clock,
+		reactBefore:  DefaultReactBefore,
+		recallMargin: DefaultRecallMargin,
+		pollInterval: DefaultPollInterval,
+		seen:         make(map[int64]struct{}),
+		handled:      make(map[int64]struct{}),
+	}
+}
+
+// SetDryRun when enabled, fleet saves are only logged
+func (f *FleetSave) SetDryRun(dryRun bool) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.dryRun = dryRun
+}
+
+// SetReactBefore sets how long before the impact the fleets are saved
+func (f *FleetSave) SetReactBefore(d time.Duration) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.reactBefore = d
+}
+
+// SetRecallMargin sets how long after the impact the fleets come back
+func (f *FleetSave) SetRecallMargin(d time.Duration) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.recallMargin = d
+}
+
+// Log returns the event log, oldest first
+func (f *FleetSave) Log() []LogEntry {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return append([]LogEntry{}, f.log...)
+}
+
+func (f *FleetSave) addLog(entry LogEntry) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	entry.Time = f.clock.Now()
+	f.log = append(f.log, entry)
+	if len(f.log) > maxLogEntries {
+		f.log = f.log[len(f.log)-maxLogEntries:]
+	}
+}
+
+// Run checks the incoming attacks until the context is cancelled
+func (f *FleetSave) Run(ctx context.Context) error {
+	for {
+		next, _ := f.Check()
+		wait := f.pollInterval
+		if !next.IsZero() {
+			wait = min(max(next.Sub(f.clock.Now()), 0), wait)
+		}
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-f.clock.After(wait):
+		}
+	}
+}
+
+// Check saves the fleets of the celestials that are about to be hit.
+// Returns the time at which the next attack must be handled, zero if none.
+func (f *FleetSave) Check() (next time.Time, err error) {
+	attacks, err := f.bot.GetAttacks()
+	if err != nil {
+		f.addLog(LogEntry{Kind: ErrorLog, Message: "failed to get attacks: " + err.Error()})
+		return time.Time{}, err
+	}
+	now := f.clock.Now()
+	f.mu.Lock()
+	dryRun, reactBefore, recallMargin := f.dryRun, f.reactBefore, f.recallMargin
+	f.mu.Unlock()
+
+	attacked := make(map[ogame.Coordinate]bool)
+	for _, attack := range attacks {
+		if isHostile(attack) {
+			attacked[attack.Destination] = true
+		}
+	}
+	due := make([]ogame.AttackEvent, 0)
+	for _, attack := range attacks {
+		if !isHostile(attack) || f.isHandled(attack.ID) {
+			continue
+		}
+		if f.markSeen(attack.ID) {
+			f.addLog(LogEntry{Kind: DetectedLog, AttackID: attack.ID,
+				Message: attack.AttackerName + " " + attack.Origin.String() + " -> " + attack.Destination.String() + " at " + attack.ArrivalTime.String()})
+		}
+		if !attack.ArrivalTime.After(now) {
+			continue // Too late, the fleet already hit
+		}
+		reactAt := attack.ArrivalTime.Add(-reactBefore)
+		if reactAt.After(now) {
+			if next.IsZero() || reactAt.Before(next) {
+				next = reactAt
+			}
+			continue
+		}
+		due = append(due, attack)
+	}
+	if len(due) == 0 {
+		return next, nil
+	}
+	params, err := f.params()
+	if err != nil {
+		f.addLog(LogEntry{Kind: ErrorLog, Message: "failed to get params: " + err.Error()})
+		return next, err
+	}
+	celestials, err := f.celestials()
+	if err != nil {
+		f.addLog(LogEntry{Kind: ErrorLog, Message: "failed to get celestials: " + err.Error()})
+		return next, err
+	}
+	// An attack is handled once its fleet is sent, failures are retried on the next polls until the impact
+	for _, attack := range due {
+		action, planErr := Plan(attack, celestials, attacked, params, recallMargin, now)
+		if planErr != nil {
+			f.addLog(LogEntry{Kind: ErrorLog, AttackID: attack.ID, Message: planErr.Error()})
+			continue
+		}
+		if dryRun {
+			f.setHandled(attack.ID)
+			f.addLog(LogEntry{Kind: DryRunLog, AttackID: attack.ID, Message: "would send fleet to " + action.Destination.String(), Action: &action})
+			continue
+		}
+		fleet, sendErr := f.bot.SendFleet(action.Origin, action.Ships, action.Speed, action.Destination, action.Mission, action.Resources, 0, 0)
+		if sendErr != nil {
+			f.addLog(LogEntry{Kind: ErrorLog, AttackID: attack.ID, Message: "failed to send fleet: " + sendErr.Error(), Action: &action})
+			continue
+		}
+		f.setHandled(attack.ID)
+		action.FleetID = fleet.ID
+		msg := "fleet sent to " + action.Destination.String()
+		if !action.RecallAt.IsZero() {
+			if _, recallErr := f.bot.ScheduleCancelFleetAt(action.RecallAt, fleet.ID); recallErr != nil {
+				f.addLog(LogEntry{Kind: ErrorLog, AttackID: attack.ID, Message: "failed to schedule recall: " + recallErr.Error(), Action: &action})
+				continue
+			}
+			msg += ", recall at " + action.RecallAt.String()
+		}
+		f.addLog(LogEntry{Kind: SavedLog, AttackID: attack.ID, Message: msg, Action: &action})
+	}
+	return next, nil
+}
+
+func (f *FleetSave) celestials() ([]Celestial, error) {
+	out := make([]Celestial, 0)
+	for _, c := range f.bot.GetCachedCelestials() {
+		ships, err := c.GetShips()
+		if err != nil {
+			return nil, err
+		}
+		resources, err := c.GetResources()
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, Celestial{ID: c.GetID(), Coordinate: c.GetCoordinate(), Ships: ships, Resources: resources})
+	}
+	return out, nil
+}
+
+func (f *FleetSave) isHandled(attackID int64) bool {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	_, ok := f.handled[attackID]
+	return ok
+}
+
+func (f *FleetSave) setHandled(attackID int64) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.handled[attackID] = struct{}{}
+}
+
+// markSeen returns true the first time an attack is seen
+func (f *FleetSave) markSeen(attackID int64) bool {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if _, ok := f.seen[attackID]; ok {
+		return false
+	}
+	f.seen[attackID] = struct{}{}
+	return true
+}
@@ -0,0 +1,182 @@
+package fleetSave
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/alaingilbert/clockwork"
+	"github.com/alaingilbert/ogame/pkg/logistics"
+	"github.com/alaingilbert/ogame/pkg/ogame"
+	"github.com/alaingilbert/ogame/pkg/taskRunner"
+	"github.com/alaingilbert/ogame/pkg/wrapper"
+	"github.com/stretchr/testify/assert"
+)
+
+var _ Bot = (*wrapper.OGame)(nil)
+
+var testParams = logistics.Params{
+	ServerData: wrapper.ServerData{Galaxies: 9, Systems: 499, SpeedFleetPeaceful: 1, SpeedFleetWar: 1, GlobalDeuteriumSaveFactor: 1,
+		CargoHyperspaceTechMultiplier: 5},
+	Researches: ogame.Researches{CombustionDrive: 6, ImpulseDrive: 4},
+}
+
+var (
+	planetCoord = ogame.Coordinate{Galaxy: 1, System: 1, Position: 1, Type: ogame.PlanetType}
+	otherCoord  = ogame.Coordinate{Galaxy: 1, System: 5, Position: 1, Type: ogame.PlanetType}
+)
+
+func TestPlan(t *testing.T) {
+	now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	attack := ogame.AttackEvent{ID: 1, MissionType: ogame.Attack, Destination: planetCoord, ArrivalTime: now.Add(5 * time.Minute)}
+	planet := Celestial{ID: 1, Coordinate: planetCoord, Ships: ogame.ShipsInfos{LargeCargo: 10, SolarSatellite: 20},
+		Resources: ogame.Resources{Metal: 1000000, Crystal: 20000, Deuterium: 10000}}
+	moon := Celestial{ID: 2, Coordinate: planetCoord.Moon()}
+	other := Celestial{ID: 3, Coordinate: otherCoord}
+
+	action, err := Plan(attack, []Celestial{other, planet, moon}, nil, testParams, time.Minute, now)
+	assert.NoError(t, err)
+	assert.Equal(t, planetCoord.Moon(), action.Destination)
+	assert.Equal(t, ogame.Park, action.Mission)
+	assert.Equal(t, ogame.TenPercent, action.Speed)
+	assert.Equal(t, ogame.ShipsInfos{LargeCargo: 10}, action.Ships)
+	assert.Equal(t, ogame.Resources{Metal: 250000 - 20000 - (10000 - action.Fuel), Crystal: 20000, Deuterium: 10000 - action.Fuel}, action.Resources)
+	if action.FlightTime > 180 {
+		assert.Equal(t, now.Add(3*time.Minute), action.RecallAt)
+	} else {
+		assert.True(t, action.RecallAt.IsZero())
+	}
+
+	// Moon is attacked too, the fleet goes to the other planet and is recalled to be back a minute after the impact
+	attacked := map[ogame.Coordinate]bool{planetCoord: true, planetCoord.Moon(): true}
+	action, err = Plan(attack, []Celestial{other, planet, moon}, attacked, testParams, time.Minute, now)
+	assert.NoError(t, err)
+	assert.Equal(t, otherCoord, action.Destination)
+	assert.Equal(t, now.Add(3*time.Minute), action.RecallAt)
+
+	_, err = Plan(attack, []Celestial{planet}, nil, testParams, time.Minute, now)
+	assert.ErrorIs(t, err, ErrNoSafeDestination)
+
+	planet.Ships = ogame.ShipsInfos{SolarSatellite: 10}
+	_, err = Plan(attack, []Celestial{planet, moon}, nil, testParams, time.Minute, now)
+	assert.ErrorIs(t, err, ErrNothingToSave)
+}
+
+func TestPlan_DebrisField(t *testing.T) {
+	now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	attack := ogame.AttackEvent{ID: 1, MissionType: ogame.Attack, Destination: planetCoord, ArrivalTime: now.Add(2 * time.Minute)}
+	planet := Celestial{ID: 1, Coordinate: planetCoord, Ships: ogame.ShipsInfos{Recycler: 5}, Resources: ogame.Resources{Deuterium: 1000}}
+	action, err := Plan(attack, []Celestial{planet}, nil, testParams, time.Minute, now)
+	assert.NoError(t, err)
+	assert.Equal(t, planetCoord.Debris(), action.Destination)
+	assert.Equal(t, ogame.RecycleDebrisField, action.Mission)
+	assert.Equal(t, now.Add(90*time.Second), action.RecallAt)
+}
+
+type fakeCelestial struct {
+	wrapper.Celestial
+	id        ogame.CelestialID
+	coord     ogame.Coordinate
+	ships     ogame.ShipsInfos
+	resources ogame.Resources
+}
+
+func (c fakeCelestial) GetID() ogame.CelestialID                             { return c.id }
+func (c fakeCelestial) GetCoordinate() ogame.Coordinate                      { return c.coord }
+func (c fakeCelestial) GetShips(...wrapper.Option) (ogame.ShipsInfos, error) { return c.ships, nil }
+func (c fakeCelestial) GetResources() (ogame.Resources, error)               { return c.resources, nil }
+
+type sentFleet struct {
+	celestialID ogame.CelestialID
+	where       ogame.Coordinate
+}
+
+type fakeBot struct {
+	attacks    []ogame.AttackEvent
+	celestials []wrapper.Celestial
+	sent       []sentFleet
+	recalls    map[ogame.FleetID]time.Time
+	sendErr    error
+}
+
+func (b *fakeBot) GetAttacks(...wrapper.Option) ([]ogame.AttackEvent, error) { return b.attacks, nil }
+func (b *fakeBot) GetCachedCelestials() []wrapper.Celestial                  { return b.celestials }
+func (b *fakeBot) SendFleet(celestialID ogame.CelestialID, _ ogame.ShipsInfos, _ ogame.Speed, where ogame.Coordinate,
+	_ ogame.MissionID, _ ogame.Resources, _, _ int64) (ogame.Fleet, error) {
+	if b.sendErr != nil {
+		return ogame.Fleet{}, b.sendErr
+	}
+	b.sent = append(b.sent, sentFleet{celestialID, where})
+	return ogame.Fleet{ID: ogame.FleetID(len(b.sent))}, nil
+}
+func (b *fakeBot) ScheduleCancelFleetAt(at time.Time, fleetID ogame.FleetID) (*taskRunner.TaskHandle, error) {
+	b.recalls[fleetID] = at
+	return nil, nil
+}
+
+func TestFleetSave_Check(t *testing.T) {
+	clock := clockwork.NewFakeClockAt(time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC))
+	impact := clock.Now().Add(time.Hour)
+	bot := &fakeBot{
+		attacks: []ogame.AttackEvent{
+			{ID: 1, MissionType: ogame.Attack, Destination: planetCoord, ArrivalTime: impact},
+			{ID: 2, MissionType: ogame.Spy, Destination: otherCoord, ArrivalTime: clock.Now()},
+		},
+		celestials: []wrapper.Celestial{
+			fakeCelestial{id: 1, coord: planetCoord, ships: ogame.ShipsInfos{LargeCargo: 10}, resources: ogame.Resources{Deuterium: 10000}},
+			fakeCelestial{id: 3, coord: otherCoord},
+		},
+		recalls: make(map[ogame.FleetID]time.Time),
+	}
+	f := NewWithClock(bot, func() (logistics.Params, error) { return testParams, nil }, clock)
+	f.SetDryRun(true)
+
+	next, err := f.Check()
+	assert.NoError(t, err)
+	assert.Equal(t, impact.Add(-DefaultReactBefore), next)
+	assert.Len(t, f.Log(), 1)
+	assert.Equal(t, DetectedLog, f.Log()[0].Kind)
+
+	clock.Advance(56 * time.Minute)
+	next, err = f.Check()
+	assert.NoError(t, err)
+	assert.True(t, next.IsZero())
+	logs := f.Log()
+	assert.Len(t, logs, 2)
+	assert.Equal(t, DryRunLog, logs[1].Kind)
+	assert.Equal(t, otherCoord, logs[1].Action.Destination)
+	assert.Empty(t, bot.sent)
+
+	// Already handled
+	_, _ = f.Check()
+	assert.Len(t, f.Log(), 2)
+
+	// Failing to send the fleet is retried on the next poll
+	bot.attacks[0].ID = 3
+	bot.sendErr = errors.New("network error")
+	f.SetDryRun(false)
+	_, err = f.Check()
+	assert.NoError(t, err)
+	logs = f.Log()
+	assert.Equal(t, ErrorLog, logs[len(logs)-1].Kind)
+	assert.Empty(t, bot.sent)
+	bot.sendErr = nil
+	_, err = f.Check()
+	assert.NoError(t, err)
+	assert.Equal(t, []sentFleet{{1, otherCoord}}, bot.sent)
+	logs = f.Log()
+	assert.Equal(t, SavedLog, logs[len(logs)-1].Kind)
+	assert.Equal(t, clock.Now().Add((4*time.Minute+DefaultRecallMargin)/2), bot.recalls[1])
+	_, _ = f.Check()
+	assert.Len(t, bot.sent, 1)
+
+	// Not retried after the impact
+	bot.attacks[0].ID = 4
+	bot.sendErr = errors.New("network error")
+	clock.Advance(5 * time.Minute)
+	logsCount := len(f.Log())
+	_, err = f.Check()
+	assert.NoError(t, err)
+	assert.Len(t, f.Log(), logsCount+1)
+	assert.Equal(t, DetectedLog, f.Log()[logsCount].Kind)
+}
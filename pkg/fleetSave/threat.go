@@ -0,0 +1,68 @@
+package fleetSave
+
+import (
+	"github.com/alaingilbert/ogame/pkg/ogame"
+	"github.com/alaingilbert/ogame/pkg/simulator"
+	"github.com/alaingilbert/ogame/pkg/wrapper"
+)
+
+// AssessOptions settings of AssessAttack
+type AssessOptions struct {
+	UsePhalanx bool  // Scan the origin of the attack with a moon in range when the fleet is not visible (costs deuterium)
+	MaxLosses  int64 // See simulator.DefenseParams.MaxLosses
+}
+
+// AssessAttack gathers our ships, defenses and resources on the attacked celestial, and what is known
+// about the attacker (phalanx, espionage report on the origin), then simulates the attack.
+func AssessAttack(bot wrapper.Wrapper, attack ogame.AttackEvent, opts AssessOptions, params simulator.SimulatorParams) (simulator.ThreatAssessment, error) {
+	celestial, err := bot.GetCachedCelestial(attack.Destination)
+	if err != nil {
+		return simulator.ThreatAssessment{}, err
+	}
+	ships, err := celestial.GetShips()
+	if err != nil {
+		return simulator.ThreatAssessment{}, err
+	}
+	defenses, err := celestial.GetDefense()
+	if err != nil {
+		return simulator.ThreatAssessment{}, err
+	}
+	resources, err := celestial.GetResources()
+	if err != nil {
+		return simulator.ThreatAssessment{}, err
+	}
+	lfBonuses, _ := bot.GetCachedLfBonuses()
+	allianceClass, _ := bot.GetCachedAllianceClass()
+	defense := simulator.DefenseParams{
+		Ships:          ships,
+		Defenses:       defenses,
+		Resources:      resources,
+		Researches:     bot.GetCachedResearch(),
+		LfBonuses:      lfBonuses,
+		CharacterClass: bot.CharacterClass(),
+		AllianceClass:  allianceClass,
+		MaxLosses:      opts.MaxLosses,
+	}
+
+	var intel simulator.ThreatIntel
+	if report, err := bot.GetEspionageReportFor(attack.Origin); err == nil {
+		intel.Report = &report
+	}
+	if attack.Ships == nil && opts.UsePhalanx {
+		for _, moon := range bot.GetCachedMoons() {
+			if fleets, err := moon.Phalanx(attack.Origin); err == nil {
+				intel.Phalanx = fleets
+				break
+			}
+		}
+	}
+
+	serverData := bot.GetServerData()
+	if params.CargoHyperspaceTechMultiplier == 0 {
+		params.CargoHyperspaceTechMultiplier = float64(serverData.CargoHyperspaceTechMultiplier) / 100
+	}
+	if params.FleetToDebris == 0 {
+		params.FleetToDebris = serverData.DebrisFactor
+	}
+	return simulator.AssessThreat(attack, intel, defense, params), nil
+}
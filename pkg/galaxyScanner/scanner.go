@@ -0,0 +1,221 @@
+package galaxyScanner
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/alaingilbert/clockwork"
+	"github.com/alaingilbert/ogame/pkg/ogame"
+	"github.com/alaingilbert/ogame/pkg/wrapper"
+)
+
+// DefaultDelay minimum delay between two galaxy pages requests
+const DefaultDelay = 2 * time.Second
+
+// DefaultSaveEvery number of systems scanned between two saves of the store
+const DefaultSaveEvery = 100
+
+// Bot methods of the wrapper used by the scanner
+type Bot interface {
+	GalaxyInfos(galaxy, system int64, opts ...wrapper.Option) (ogame.SystemInfos, error)
+	GetNbSystems() int64
+	GetServerData() wrapper.ServerData
+}
+
+// Scanner sweeps the galaxy pages of the universe and keeps the store up to date
+type Scanner struct {
+	sync.Mutex
+	bot         Bot
+	store       *Store
+	clock       clockwork.Clock
+	delay       time.Duration
+	saveEvery   int
+	origin      ogame.Coordinate
+	onChanges   func([]Change)
+	onScan      func(error)
+	lastRequest time.Time
+}
+
+// New creates a scanner that saves what it sees in the store
+func New(bot Bot, store *Store) *Scanner {
+	return NewWithClock(bot, store, clockwork.NewRealClock())
+}
+
+// NewWithClock same as New, with a custom clock
+func NewWithClock(bot Bot, store *Store, clock clockwork.Clock) *Scanner {
+	return &Scanner{
+		bot:       bot,
+		store:     store,
+		clock:     clock,
+		delay:     DefaultDelay,
+		saveEvery: DefaultSaveEvery,
+	}
+}
+
+// SetDelay sets the minimum delay between two galaxy pages requests
+func (s *Scanner) SetDelay(delay time.Duration) {
+	s.Lock()
+	defer s.Unlock()
+	s.delay = delay
+}
+
+// SetOrigin makes the sweeps start with the systems closest to the coordinate
+func (s *Scanner) SetOrigin(origin ogame.Coordinate) {
+	s.Lock()
+	defer s.Unlock()
+	s.origin = origin
+}
+
+// OnChanges sets a callback called with the changes detected by each system scan
+func (s *Scanner) OnChanges(clb func([]Change)) {
+	s.Lock()
+	defer s.Unlock()
+	s.onChanges = clb
+}
+
+// OnScan sets a callback called with the error of each sweep executed by Run (nil if the sweep succeeded)
+func (s *Scanner) OnScan(clb func(error)) {
+	s.Lock()
+	defer s.Unlock()
+	s.onScan = clb
+}
+
+// Store returns the store of the scanner
+func (s *Scanner) Store() *Store {
+	return s.store
+}
+
+// wait blocks until the delay since the last request has elapsed
+func (s *Scanner) wait(ctx context.Context) error {
+	s.Lock()
+	next := s.lastRequest.Add(s.delay)
+	s.Unlock()
+	if d := next.Sub(s.clock.Now()); d > 0 {
+		select {
+		case <-s.clock.After(d):
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+	s.Lock()
+	s.lastRequest = s.clock.Now()
+	s.Unlock()
+	return nil
+}
+
+// ScanSystem scans one system, respecting the delay between requests, and returns the changes detected
+func (s *Scanner) ScanSystem(ctx context.Context, galaxy, system int64) ([]Change, error) {
+	if err := s.wait(ctx); err != nil {
+		return nil, err
+	}
+	infos, err := s.bot.GalaxyInfos(galaxy, system)
+	if err != nil {
+		return nil, err
+	}
+	now := s.clock.Now()
+	planets := make([]Planet, 0)
+	infos.Each(func(p *ogame.PlanetInfos) {
+		if p != nil && !p.Destroyed {
+			planets = append(planets, planetFrom(p, now))
+		}
+	})
+	changes := s.store.Update(galaxy, system, planets, now)
+	s.Lock()
+	clb := s.onChanges
+	s.Unlock()
+	if clb != nil && len(changes) > 0 {
+		clb(changes)
+	}
+	return changes, nil
+}
+
+// Scan sweeps the whole universe once, the store is saved regularly and at the end of the sweep.
+// Systems that fail to be scanned are skipped, the first error is returned.
+func (s *Scanner) Scan(ctx context.Context) error {
+	serverData := s.bot.GetServerData()
+	s.Lock()
+	origin := s.origin
+	saveEvery := s.saveEvery
+	s.Unlock()
+	var firstErr error
+	systems := sweepOrder(origin, serverData.Galaxies, s.bot.GetNbSystems(), serverData.DonutGalaxy, serverData.DonutSystem)
+	for i, sys := range systems {
+		if _, err := s.ScanSystem(ctx, sys.Galaxy, sys.System); err != nil {
+			if ctx.Err() != nil {
+				_ = s.store.Save()
+				return ctx.Err()
+			}
+			if firstErr == nil {
+				firstErr = err
+			}
+		}
+		if saveEvery > 0 && (i+1)%saveEvery == 0 {
+			if err := s.store.Save(); err != nil && firstErr == nil {
+				firstErr = err
+			}
+		}
+	}
+	if err := s.store.Save(); err != nil && firstErr == nil {
+		firstErr = err
+	}
+	return firstErr
+}
+
+// Run sweeps the universe every interval until the context is cancelled
+func (s *Scanner) Run(ctx context.Context, interval time.Duration) {
+	for {
+		start := s.clock.Now()
+		err := s.Scan(ctx)
+		if ctx.Err() != nil {
+			return
+		}
+		s.Lock()
+		clb := s.onScan
+		s.Unlock()
+		if clb != nil {
+			clb(err)
+		}
+		select {
+		case <-s.clock.After(start.Add(interval).Sub(s.clock.Now())):
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+// ringDistance distance between a and b in [1, size], wrapping around when donut is true
+func ringDistance(a, b, size int64, donut bool) int64 {
+	d := max(a-b, b-a)
+	if donut {
+		d = min(d, size-d)
+	}
+	return d
+}
+
+// sweepOrder returns all the systems of the universe, the closest to the origin first.
+// Systems are in natural order when the origin is not set.
+func sweepOrder(origin ogame.Coordinate, galaxies, systems int64, donutGalaxy, donutSystem bool) []systemKey {
+	out := make([]systemKey, 0, galaxies*systems)
+	for g := int64(1); g <= galaxies; g++ {
+		for sys := int64(1); sys <= systems; sys++ {
+			out = append(out, systemKey{Galaxy: g, System: sys})
+		}
+	}
+	if origin.Galaxy == 0 {
+		return out
+	}
+	sort.SliceStable(out, func(i, j int) bool {
+		gi := ringDistance(out[i].Galaxy, origin.Galaxy, galaxies, donutGalaxy)
+		gj := ringDistance(out[j].Galaxy, origin.Galaxy, galaxies, donutGalaxy)
+		if gi != gj {
+			return gi < gj
+		} else if out[i].Galaxy != out[j].Galaxy {
+			return out[i].Galaxy < out[j].Galaxy
+		}
+		return ringDistance(out[i].System, origin.System, systems, donutSystem) <
+			ringDistance(out[j].System, origin.System, systems, donutSystem)
+	})
+	return out
+}
@@ -0,0 +1,96 @@
+package galaxyScanner
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/alaingilbert/clockwork"
+	"github.com/alaingilbert/ogame/pkg/ogame"
+	"github.com/alaingilbert/ogame/pkg/wrapper"
+	"github.com/stretchr/testify/assert"
+)
+
+var _ Bot = (*wrapper.OGame)(nil)
+
+type fakeBot struct {
+	planets map[systemKey][]*ogame.PlanetInfos
+	scanned []systemKey
+}
+
+func (b *fakeBot) GetNbSystems() int64 { return 3 }
+func (b *fakeBot) GetServerData() wrapper.ServerData {
+	return wrapper.ServerData{Galaxies: 2, Systems: 3, DonutSystem: true}
+}
+func (b *fakeBot) GalaxyInfos(galaxy, system int64, _ ...wrapper.Option) (ogame.SystemInfos, error) {
+	key := systemKey{galaxy, system}
+	b.scanned = append(b.scanned, key)
+	if galaxy == 2 && system == 2 {
+		return ogame.SystemInfos{}, errors.New("failed")
+	}
+	var infos ogame.SystemInfos
+	infos.SetGalaxy(galaxy)
+	infos.SetSystem(system)
+	for _, p := range b.planets[key] {
+		infos.SetPlanet(int(p.Coordinate.Position-1), p)
+	}
+	return infos, nil
+}
+
+func TestScanner_Scan(t *testing.T) {
+	planet := &ogame.PlanetInfos{ID: 1, Name: "home", Coordinate: ogame.Coordinate{Galaxy: 1, System: 3, Position: 5}, Activity: 15}
+	planet.Player.ID = 7
+	planet.Alliance = &ogame.AllianceInfos{ID: 2, Tag: "ALLY"}
+	bot := &fakeBot{planets: map[systemKey][]*ogame.PlanetInfos{{1, 3}: {planet}}}
+	clock := clockwork.NewFakeClock()
+	s := NewWithClock(bot, NewStore(), clock)
+	s.SetDelay(0)
+	s.SetOrigin(ogame.Coordinate{Galaxy: 1, System: 1})
+
+	err := s.Scan(context.Background())
+	assert.EqualError(t, err, "failed")
+	assert.Equal(t, []systemKey{{1, 1}, {1, 2}, {1, 3}, {2, 1}, {2, 2}, {2, 3}}, bot.scanned)
+	planets := s.Store().Planets()
+	assert.Len(t, planets, 1)
+	assert.Equal(t, ogame.Coordinate{Galaxy: 1, System: 3, Position: 5, Type: ogame.PlanetType}, planets[0].Coordinate)
+	assert.Equal(t, "ALLY", planets[0].AllianceTag)
+	assert.Equal(t, int64(7), planets[0].PlayerID)
+	assert.True(t, s.Store().ScannedAt(2, 2).IsZero())
+
+	var detected []Change
+	s.OnChanges(func(changes []Change) { detected = append(detected, changes...) })
+	planet.Activity = 0
+	planet.Moon = &ogame.MoonInfos{ID: 3}
+	_, err = s.ScanSystem(context.Background(), 1, 3)
+	assert.NoError(t, err)
+	assert.Len(t, detected, 2)
+	assert.Equal(t, ActivityChange, detected[0].Kind)
+	assert.Equal(t, MoonChange, detected[1].Kind)
+}
+
+func TestScanner_Run(t *testing.T) {
+	s := NewWithClock(&fakeBot{}, NewStore(), clockwork.NewFakeClock())
+	s.SetDelay(0)
+	ctx, cancel := context.WithCancel(context.Background())
+	errs := make([]error, 0)
+	s.OnScan(func(err error) {
+		errs = append(errs, err)
+		cancel()
+	})
+	s.Run(ctx, time.Hour)
+	assert.Len(t, errs, 1)
+	assert.EqualError(t, errs[0], "failed")
+}
+
+func TestSweepOrder(t *testing.T) {
+	origin := ogame.Coordinate{Galaxy: 1, System: 1}
+	order := sweepOrder(origin, 3, 5, true, true)
+	assert.Len(t, order, 15)
+	assert.Equal(t, []systemKey{{1, 1}, {1, 2}, {1, 5}, {1, 3}, {1, 4}, {2, 1}}, order[:6])
+	assert.Equal(t, systemKey{3, 1}, order[10]) // Galaxy 3 is next to galaxy 1 in a donut universe
+
+	order = sweepOrder(origin, 3, 5, false, false)
+	assert.Equal(t, []systemKey{{1, 1}, {1, 2}, {1, 3}, {1, 4}, {1, 5}, {2, 1}}, order[:6])
+	assert.Equal(t, systemKey{3, 5}, order[14])
+}
@@ -0,0 +1,558 @@
+package galaxyScanner
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/alaingilbert/ogame/pkg/ogame"
+)
+
+// DefaultMaxHistory maximum number of changes kept in the store, oldest are dropped first
+const DefaultMaxHistory = 500000
+
+// Moon moon of a planet as seen in the galaxy page
+type Moon struct {
+	ID       int64
+	Name     string
+	Diameter int64
+	Activity int64
+}
+
+// Planet planet of the universe as seen in the galaxy page
+type Planet struct {
+	Coordinate      ogame.Coordinate
+	ID              int64
+	Name            string
+	PlayerID        int64
+	PlayerName      string
+	PlayerRank      int64
+	AllianceID      int64
+	AllianceTag     string
+	Activity        int64 // no activity: 0, active: 15, inactive: [16, 59]
+	Inactive        bool
+	Vacation        bool
+	Banned          bool
+	Newbie          bool
+	StrongPlayer    bool
+	HonorableTarget bool
+	Administrator   bool
+	Moon            *Moon
+	Debris          ogame.Resources
+	UpdatedAt       time.Time
+}
+
+// planetFrom converts the galaxy page information of a planet
+func planetFrom(p *ogame.PlanetInfos, at time.Time) Planet {
+	out := Planet{
+		Coordinate:      p.Coordinate,
+		ID:              p.ID,
+		Name:            p.Name,
+		PlayerID:        p.Player.ID,
+		PlayerName:      p.Player.Name,
+		PlayerRank:      p.Player.Rank,
+		Activity:        p.Activity,
+		Inactive:        p.Inactive,
+		Vacation:        p.Vacation,
+		Banned:          p.Banned,
+		Newbie:          p.Newbie,
+		StrongPlayer:    p.StrongPlayer,
+		HonorableTarget: p.HonorableTarget,
+		Administrator:   p.Administrator,
+		Debris:          ogame.Resources{Metal: p.Debris.Metal, Crystal: p.Debris.Crystal, Deuterium: p.Debris.Deuterium},
+		UpdatedAt:       at,
+	}
+	out.Coordinate.Type = ogame.PlanetType
+	if p.Alliance != nil {
+		out.AllianceID = p.Alliance.ID
+		out.AllianceTag = p.Alliance.Tag
+	}
+	if p.Moon != nil {
+		out.Moon = &Moon{ID: p.Moon.ID, Name: p.Moon.Name, Diameter: p.Moon.Diameter, Activity: p.Moon.Activity}
+	}
+	return out
+}
+
+// ChangeKind kind of change detected between two scans of a planet
+type ChangeKind string
+
+const (
+	NewPlanetChange     ChangeKind = "new_planet"
+	RemovedPlanetChange ChangeKind = "removed_planet"
+	NameChange          ChangeKind = "name"
+	OwnerChange         ChangeKind = "owner"
+	AllianceChange      ChangeKind = "alliance"
+	ActivityChange      ChangeKind = "activity" // Activity timer shown or not, Old and New are "active" or "inactive"
+	InactiveChange      ChangeKind = "inactive"
+	VacationChange      ChangeKind = "vacation"
+	BannedChange        ChangeKind = "banned"
+	MoonChange          ChangeKind = "moon"
+	MoonActivityChange  ChangeKind = "moon_activity"
+	DebrisChange        ChangeKind = "debris"
+)
+
+// Change difference between two scans of a position
+type Change struct {
+	Time       time.Time
+	Coordinate ogame.Coordinate
+	PlayerID   int64 // Owner of the planet, previous owner for removed planets
+	Kind       ChangeKind
+	Old        string
+	New        string
+}
+
+func boolStr(b bool) string { return strconv.FormatBool(b) }
+
+func i64Str(v int64) string { return strconv.FormatInt(v, 10) }
+
+func moonStr(m *Moon) string {
+	if m == nil {
+		return ""
+	}
+	return i64Str(m.ID)
+}
+
+// activityStr returns "active" if the activity timer is shown (activity in the last hour).
+// The minutes of the timer change at each scan, only the transitions are recorded.
+func activityStr(activity int64) string {
+	if activity > 0 {
+		return "active"
+	}
+	return "inactive"
+}
+
+func moonActivity(m *Moon) int64 {
+	if m == nil {
+		return 0
+	}
+	return m.Activity
+}
+
+func debrisStr(r ogame.Resources) string {
+	return i64Str(r.Metal) + "/" + i64Str(r.Crystal) + "/" + i64Str(r.Deuterium)
+}
+
+// diff returns the changes between two scans of a position, nil planets are empty positions
+func diff(old, new *Planet, coord ogame.Coordinate, at time.Time) []Change {
+	out := make([]Change, 0)
+	add := func(kind ChangeKind, playerID int64, o, n string) {
+		if o != n {
+			out = append(out, Change{Time: at, Coordinate: coord, PlayerID: playerID, Kind: kind, Old: o, New: n})
+		}
+	}
+	switch {
+	case old == nil && new == nil:
+	case old == nil:
+		add(NewPlanetChange, new.PlayerID, "", new.Name)
+	case new == nil:
+		add(RemovedPlanetChange, old.PlayerID, old.Name, "")
+	default:
+		if old.ID != new.ID || old.PlayerID != new.PlayerID {
+			add(OwnerChange, new.PlayerID, old.PlayerName+" ("+i64Str(old.PlayerID)+")", new.PlayerName+" ("+i64Str(new.PlayerID)+")")
+			return out
+		}
+		add(NameChange, new.PlayerID, old.Name, new.Name)
+		add(AllianceChange, new.PlayerID, old.AllianceTag, new.AllianceTag)
+		add(ActivityChange, new.PlayerID, activityStr(old.Activity), activityStr(new.Activity))
+		add(InactiveChange, new.PlayerID, boolStr(old.Inactive), boolStr(new.Inactive))
+		add(VacationChange, new.PlayerID, boolStr(old.Vacation), boolStr(new.Vacation))
+		add(BannedChange, new.PlayerID, boolStr(old.Banned), boolStr(new.Banned))
+		add(MoonChange, new.PlayerID, moonStr(old.Moon), moonStr(new.Moon))
+		add(MoonActivityChange, new.PlayerID, activityStr(moonActivity(old.Moon)), activityStr(moonActivity(new.Moon)))
+		add(DebrisChange, new.PlayerID, debrisStr(old.Debris), debrisStr(new.Debris))
+	}
+	return out
+}
+
+// Filter selects planets of the store
+type Filter func(Planet) bool
+
+// InGalaxy planets of the galaxy
+func InGalaxy(galaxy int64) Filter {
+	return func(p Planet) bool { return p.Coordinate.Galaxy == galaxy }
+}
+
+// InSystems planets of the galaxy between the systems fromSystem and toSystem included
+func InSystems(galaxy, fromSystem, toSystem int64) Filter {
+	return func(p Planet) bool {
+		return p.Coordinate.Galaxy == galaxy && p.Coordinate.System >= fromSystem && p.Coordinate.System <= toSystem
+	}
+}
+
+// OfPlayer planets of the player
+func OfPlayer(playerID int64) Filter {
+	return func(p Planet) bool { return p.PlayerID == playerID }
+}
+
+// OfAlliance planets of the players of the alliance
+func OfAlliance(tag string) Filter {
+	return func(p Planet) bool { return p.AllianceTag == tag }
+}
+
+// IsInactive planets of inactive players
+func IsInactive() Filter {
+	return func(p Planet) bool { return p.Inactive }
+}
+
+// NotVacation planets of players that are not in vacation mode
+func NotVacation() Filter {
+	return func(p Planet) bool { return !p.Vacation }
+}
+
+// HasMoon planets with a moon
+func HasMoon() Filter {
+	return func(p Planet) bool { return p.Moon != nil }
+}
+
+// HasDebris planets with a debris field
+func HasDebris() Filter {
+	return func(p Planet) bool { return p.Debris.Total() > 0 }
+}
+
+type systemKey struct {
+	Galaxy int64
+	System int64
+}
+
+type systemScan struct {
+	Galaxy    int64
+	System    int64
+	ScannedAt time.Time
+}
+
+// storeFile content of the file of the store
+type storeFile struct {
+	Planets []Planet
+	Systems []systemScan
+	History []Change `json:",omitempty"` // Older stores, the history is now in the history file
+}
+
+// historyPath returns the path of the history file of the store saved at path, one json change per line
+func historyPath(path string) string { return path + ".history" }
+
+// Store universe database, planets indexed by coordinate with the history of their changes.
+// The store lives in memory and is saved in a json file, the changes are appended to a second file.
+type Store struct {
+	sync.RWMutex
+	path       string
+	planets    map[ogame.Coordinate]Planet
+	systems    map[systemKey]time.Time
+	history    []Change
+	maxHistory int
+	appended   int64 // Number of changes ever added to the history
+	savedSeq   int64 // Number of the last change written in the history file
+	rewrite    bool  // Changes were removed from the history, the history file must be rewritten
+
+	saveMu       sync.Mutex // Serializes the writes of the files
+	historyLines int        // Changes in the history file, guarded by saveMu
+}
+
+// NewStore creates an in-memory store, that is not saved
+func NewStore() *Store {
+	return &Store{
+		planets:    make(map[ogame.Coordinate]Planet),
+		systems:    make(map[systemKey]time.Time),
+		history:    make([]Change, 0),
+		maxHistory: DefaultMaxHistory,
+	}
+}
+
+// Open opens the store saved at path, an empty store is created if the file does not exist
+func Open(path string) (*Store, error) {
+	s := NewStore()
+	s.path = path
+	by, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return s, nil
+	} else if err != nil {
+		return nil, err
+	}
+	var content storeFile
+	if err := json.Unmarshal(by, &content); err != nil {
+		return nil, err
+	}
+	for _, p := range content.Planets {
+		s.planets[p.Coordinate] = p
+	}
+	for _, sys := range content.Systems {
+		s.systems[systemKey{sys.Galaxy, sys.System}] = sys.ScannedAt
+	}
+	s.history = append(s.history, content.History...)
+	s.rewrite = len(content.History) > 0
+	if err := s.loadHistory(); err != nil {
+		return nil, err
+	}
+	s.trimLocked()
+	s.appended = int64(len(s.history))
+	s.savedSeq = s.appended
+	return s, nil
+}
+
+// loadHistory reads the history file. A line that cannot be decoded (eg: interrupted write) is dropped,
+// and the file is rewritten on the next save.
+func (s *Store) loadHistory() error {
+	f, err := os.Open(historyPath(s.path))
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	} else if err != nil {
+		return err
+	}
+	defer f.Close()
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		s.historyLines++
+		var c Change
+		if err := json.Unmarshal(scanner.Bytes(), &c); err != nil {
+			s.rewrite = true
+			continue
+		}
+		s.history = append(s.history, c)
+	}
+	return scanner.Err()
+}
+
+// SetMaxHistory sets the maximum number of changes kept in the store
+func (s *Store) SetMaxHistory(maxHistory int) {
+	s.Lock()
+	defer s.Unlock()
+	s.maxHistory = maxHistory
+	s.trimLocked()
+}
+
+// trimLocked drops the oldest changes over the maximum, they stay in the history file until it is compacted
+func (s *Store) trimLocked() {
+	if s.maxHistory > 0 && len(s.history) > s.maxHistory {
+		s.history = append([]Change(nil), s.history[len(s.history)-s.maxHistory:]...)
+	}
+}
+
+// Save writes the planets and systems in the file of the store, and appends the new changes to the history file.
+// The history file is rewritten when it has twice as many changes as the store, or changes were pruned.
+// Does nothing for an in-memory store.
+func (s *Store) Save() error {
+	s.saveMu.Lock()
+	defer s.saveMu.Unlock()
+	s.Lock()
+	if s.path == "" {
+		s.Unlock()
+		return nil
+	}
+	content := storeFile{
+		Planets: s.planetsLocked(nil),
+		Systems: make([]systemScan, 0, len(s.systems)),
+	}
+	for k, at := range s.systems {
+		content.Systems = append(content.Systems, systemScan{Galaxy: k.Galaxy, System: k.System, ScannedAt: at})
+	}
+	sort.Slice(content.Systems, func(i, j int) bool {
+		if content.Systems[i].Galaxy == content.Systems[j].Galaxy {
+			return content.Systems[i].System < content.Systems[j].System
+		}
+		return content.Systems[i].Galaxy < content.Systems[j].Galaxy
+	})
+	by, err := json.Marshal(content)
+	path := s.path
+	rewrite := s.rewrite || s.historyLines > 2*len(s.history)
+	changes := s.history
+	if !rewrite {
+		first := s.appended - int64(len(s.history)) // Number of the changes before the first one in memory
+		changes = s.history[max(s.savedSeq-first, 0):]
+	}
+	changes = append([]Change(nil), changes...)
+	lastSeq := s.appended
+	s.rewrite = false
+	s.Unlock()
+	if err != nil {
+		return err
+	}
+	if err := writeFile(path, by); err != nil {
+		return err
+	}
+
+	var lines bytes.Buffer
+	enc := json.NewEncoder(&lines)
+	for _, c := range changes {
+		if err := enc.Encode(c); err != nil {
+			return err
+		}
+	}
+	if rewrite {
+		err = writeFile(historyPath(path), lines.Bytes())
+	} else {
+		err = appendFile(historyPath(path), lines.Bytes())
+	}
+	s.Lock()
+	defer s.Unlock()
+	if err != nil {
+		s.rewrite = true // The file may have a partial write
+		return err
+	}
+	if rewrite {
+		s.historyLines = 0
+	}
+	s.historyLines += len(changes)
+	s.savedSeq = lastSeq // Changes added while writing are saved next time
+	return nil
+}
+
+// writeFile replaces the file at path, using a temporary file so it is never partially written
+func writeFile(path string, by []byte) error {
+	tmp, err := os.CreateTemp(filepath.Dir(path), ".galaxy-*")
+	if err != nil {
+		return err
+	}
+	_, err = tmp.Write(by)
+	if closeErr := tmp.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		_ = os.Remove(tmp.Name())
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
+
+func appendFile(path string, by []byte) error {
+	f, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		return err
+	}
+	_, err = f.Write(by)
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+	return err
+}
+
+// Update replaces the planets of a system by the ones of a new scan, and returns the changes detected.
+// No changes are reported for the first scan of a system.
+func (s *Store) Update(galaxy, system int64, planets []Planet, at time.Time) []Change {
+	s.Lock()
+	defer s.Unlock()
+	key := systemKey{galaxy, system}
+	_, scanned := s.systems[key]
+	s.systems[key] = at
+	byPosition := make(map[int64]Planet, len(planets))
+	for _, p := range planets {
+		byPosition[p.Coordinate.Position] = p
+	}
+	changes := make([]Change, 0)
+	for position := int64(1); position <= 15; position++ {
+		coord := ogame.Coordinate{Galaxy: galaxy, System: system, Position: position, Type: ogame.PlanetType}
+		var oldPlanet, newPlanet *Planet
+		if p, ok := s.planets[coord]; ok {
+			oldPlanet = &p
+		}
+		if p, ok := byPosition[position]; ok {
+			p.Coordinate = coord
+			newPlanet = &p
+			s.planets[coord] = p
+		} else {
+			delete(s.planets, coord)
+		}
+		if scanned {
+			changes = append(changes, diff(oldPlanet, newPlanet, coord, at)...)
+		}
+	}
+	s.history = append(s.history, changes...)
+	s.appended += int64(len(changes))
+	s.trimLocked()
+	return changes
+}
+
+// Planet returns the planet at the coordinate
+func (s *Store) Planet(coord ogame.Coordinate) (Planet, bool) {
+	s.RLock()
+	defer s.RUnlock()
+	coord.Type = ogame.PlanetType
+	p, ok := s.planets[coord]
+	return p, ok
+}
+
+// Planets returns the planets matching all the filters, ordered by coordinate
+func (s *Store) Planets(filters ...Filter) []Planet {
+	s.RLock()
+	defer s.RUnlock()
+	return s.planetsLocked(filters)
+}
+
+func (s *Store) planetsLocked(filters []Filter) []Planet {
+	out := make([]Planet, 0)
+outer:
+	for _, p := range s.planets {
+		for _, filter := range filters {
+			if !filter(p) {
+				continue outer
+			}
+		}
+		out = append(out, p)
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].Coordinate.Cmp(out[j].Coordinate) < 0 })
+	return out
+}
+
+// Changes returns the changes detected since the given time, of the given kinds (all kinds if none)
+func (s *Store) Changes(since time.Time, kinds ...ChangeKind) []Change {
+	s.RLock()
+	defer s.RUnlock()
+	out := make([]Change, 0)
+	for _, c := range s.history {
+		if c.Time.Before(since) {
+			continue
+		}
+		if len(kinds) > 0 && !containsKind(kinds, c.Kind) {
+			continue
+		}
+		out = append(out, c)
+	}
+	return out
+}
+
+func containsKind(kinds []ChangeKind, kind ChangeKind) bool {
+	for _, k := range kinds {
+		if k == kind {
+			return true
+		}
+	}
+	return false
+}
+
+// ChangedPlanets returns the planets that had a change of one of the given kinds since the given time
+func (s *Store) ChangedPlanets(since time.Time, kinds ...ChangeKind) []Planet {
+	seen := make(map[ogame.Coordinate]bool)
+	for _, c := range s.Changes(since, kinds...) {
+		seen[c.Coordinate] = true
+	}
+	return s.Planets(func(p Planet) bool { return seen[p.Coordinate] })
+}
+
+// ScannedAt returns when the system was last scanned, zero if never
+func (s *Store) ScannedAt(galaxy, system int64) time.Time {
+	s.RLock()
+	defer s.RUnlock()
+	return s.systems[systemKey{galaxy, system}]
+}
+
+// Prune removes the changes older than the given time
+func (s *Store) Prune(before time.Time) {
+	s.Lock()
+	defer s.Unlock()
+	kept := make([]Change, 0, len(s.history))
+	for _, c := range s.history {
+		if !c.Time.Before(before) {
+			kept = append(kept, c)
+		}
+	}
+	if len(kept) < len(s.history) {
+		s.rewrite = true
+	}
+	s.history = kept
+}
@@ -0,0 +1,110 @@
+package galaxyScanner
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/alaingilbert/ogame/pkg/ogame"
+	"github.com/stretchr/testify/assert"
+)
+
+func planetAt(galaxy, system, position, playerID int64) Planet {
+	return Planet{
+		Coordinate: ogame.Coordinate{Galaxy: galaxy, System: system, Position: position, Type: ogame.PlanetType},
+		ID:         galaxy*10000 + system*100 + position,
+		Name:       "planet",
+		PlayerID:   playerID,
+	}
+}
+
+func TestStore_Update(t *testing.T) {
+	day1 := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	day2 := day1.Add(24 * time.Hour)
+	s := NewStore()
+
+	p1 := planetAt(3, 1, 4, 1)
+	p1.Inactive = true
+	p1.Moon = &Moon{ID: 1}
+	p2 := planetAt(3, 1, 8, 2)
+	p2.Inactive = true
+	changes := s.Update(3, 1, []Planet{p1, p2}, day1)
+	assert.Empty(t, changes) // First scan of the system
+	assert.Equal(t, day1, s.ScannedAt(3, 1))
+
+	p3 := planetAt(2, 1, 4, 1)
+	p3.Moon = &Moon{ID: 2}
+	s.Update(2, 1, []Planet{p3}, day1)
+
+	assert.Equal(t, []Planet{p1}, s.Planets(InGalaxy(3), IsInactive(), HasMoon()))
+	assert.Len(t, s.Planets(OfPlayer(1)), 2)
+
+	p1.Activity = 15
+	p2.PlayerID = 3
+	p4 := planetAt(3, 1, 10, 4)
+	changes = s.Update(3, 1, []Planet{p1, p2, p4}, day2)
+	assert.Equal(t, []Change{
+		{Time: day2, Coordinate: p1.Coordinate, PlayerID: 1, Kind: ActivityChange, Old: "inactive", New: "active"},
+		{Time: day2, Coordinate: p2.Coordinate, PlayerID: 3, Kind: OwnerChange, Old: " (2)", New: " (3)"},
+		{Time: day2, Coordinate: p4.Coordinate, PlayerID: 4, Kind: NewPlanetChange, New: "planet"},
+	}, changes)
+	assert.Equal(t, []Planet{p1}, s.ChangedPlanets(day1.Add(time.Hour), ActivityChange))
+	assert.Empty(t, s.Changes(day2.Add(time.Second)))
+
+	// Only the transitions of the activity are recorded, not the minutes of the timer
+	p1.Activity = 37
+	changes = s.Update(3, 1, []Planet{p1, p2}, day2.Add(time.Hour))
+	assert.Len(t, changes, 1)
+	assert.Equal(t, RemovedPlanetChange, changes[0].Kind)
+	_, ok := s.Planet(p4.Coordinate)
+	assert.False(t, ok)
+
+	s.Prune(day2.Add(time.Minute))
+	assert.Len(t, s.Changes(time.Time{}), 1)
+}
+
+func TestStore_Save(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "galaxy.json")
+	s, err := Open(path)
+	assert.NoError(t, err)
+	at := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	p := planetAt(1, 2, 3, 1)
+	p.AllianceTag = "TAG"
+	p.Moon = &Moon{ID: 5, Diameter: 8000}
+	s.Update(1, 2, []Planet{p}, at)
+	p.Vacation = true
+	s.Update(1, 2, []Planet{p}, at.Add(time.Hour))
+	assert.NoError(t, s.Save())
+
+	loaded, err := Open(path)
+	assert.NoError(t, err)
+	assert.Equal(t, []Planet{p}, loaded.Planets(OfAlliance("TAG")))
+	assert.Equal(t, at.Add(time.Hour), loaded.ScannedAt(1, 2))
+	assert.Len(t, loaded.Changes(at, VacationChange), 1)
+
+	// New changes are appended to the history file
+	p.Vacation = false
+	loaded.Update(1, 2, []Planet{p}, at.Add(2*time.Hour))
+	assert.NoError(t, loaded.Save())
+	assert.NoError(t, loaded.Save())
+	assert.Equal(t, 2, countLines(t, historyPath(path)))
+	loaded, err = Open(path)
+	assert.NoError(t, err)
+	assert.Len(t, loaded.Changes(at, VacationChange), 2)
+
+	// and the file is rewritten once changes are pruned
+	loaded.Prune(at.Add(90 * time.Minute))
+	assert.NoError(t, loaded.Save())
+	assert.Equal(t, 1, countLines(t, historyPath(path)))
+	loaded, err = Open(path)
+	assert.NoError(t, err)
+	assert.Len(t, loaded.Changes(time.Time{}), 1)
+}
+
+func countLines(t *testing.T, path string) int {
+	by, err := os.ReadFile(path)
+	assert.NoError(t, err)
+	return strings.Count(string(by), "\n")
+}
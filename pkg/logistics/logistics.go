@@ -0,0 +1,322 @@
+package logistics
+
+import (
+	"sort"
+	"time"
+
+	"github.com/alaingilbert/ogame/pkg/ogame"
+	"github.com/alaingilbert/ogame/pkg/simulator"
+	"github.com/alaingilbert/ogame/pkg/wrapper"
+)
+
+// DefaultCargoShips ships used to transport resources, in order of preference
+var DefaultCargoShips = []ogame.ID{ogame.LargeCargoID, ogame.SmallCargoID, ogame.PathfinderID}
+
+// Speeds fleet speeds tested when planning missions, slowest first
+var Speeds = []ogame.Speed{ogame.TenPercent, ogame.TwentyPercent, ogame.ThirtyPercent, ogame.FourtyPercent, ogame.FiftyPercent,
+	ogame.SixtyPercent, ogame.SeventyPercent, ogame.EightyPercent, ogame.NinetyPercent, ogame.HundredPercent}
+
+// Source own celestial that can send resources
+type Source struct {
+	CelestialID ogame.CelestialID
+	Coordinate  ogame.Coordinate
+	Resources   ogame.Resources  // Resources on the celestial now
+	Production  ogame.Resources  // Resources produced per hour
+	Reserve     ogame.Resources  // Resources that must stay on the celestial
+	Ships       ogame.ShipsInfos // Ships on the celestial
+}
+
+// Request resources needed on a celestial by a deadline
+type Request struct {
+	CelestialID ogame.CelestialID
+	Destination ogame.Coordinate
+	Needed      ogame.Resources // Total resources needed, including the ones already on the destination
+	Deadline    time.Time
+}
+
+// Params account settings used to compute cargo, flight times and fuel
+type Params struct {
+	ServerData     wrapper.ServerData
+	Researches     ogame.Researches
+	LfBonuses      ogame.LfBonuses
+	CharacterClass ogame.CharacterClass
+	AllianceClass  ogame.AllianceClass
+	ProbeRaids     bool
+	SystemsSkip    int64
+	FreeSlots      int64      // Maximum number of missions
+	CargoShips     []ogame.ID // Ships used to transport, in order of preference (DefaultCargoShips if empty)
+}
+
+// Mission transport proposed to deliver resources
+type Mission struct {
+	Origin      ogame.CelestialID
+	Coordinate  ogame.Coordinate // Coordinate of the origin
+	Destination ogame.Coordinate
+	Ships       ogame.ShipsInfos
+	Resources   ogame.Resources
+	Speed       ogame.Speed
+	FlightTime  int64 // Seconds
+	Fuel        int64
+	ArrivalAt   time.Time // When sent now
+}
+
+// Plan missions that deliver the resources of a request
+type Plan struct {
+	Request  Request
+	Local    ogame.Resources // Resources of the request already on the destination, or produced there before the deadline
+	Missions []Mission
+	Missing  ogame.Resources // Resources that cannot be delivered by the deadline
+	Fuel     int64
+	Ships    int64
+}
+
+// Feasible returns either or not all the resources can be delivered by the deadline
+func (p Plan) Feasible() bool {
+	return p.Missing.Metal <= 0 && p.Missing.Crystal <= 0 && p.Missing.Deuterium <= 0
+}
+
+func (p Params) cargoShips() []ogame.ID {
+	if len(p.CargoShips) > 0 {
+		return p.CargoShips
+	}
+	return DefaultCargoShips
+}
+
+func (p Params) cargoOf(shipID ogame.ID) int64 {
+	multiplier := float64(p.ServerData.CargoHyperspaceTechMultiplier) / 100
+	return ogame.Objs.GetShip(shipID).GetCargoCapacity(p.Researches, p.LfBonuses, p.CharacterClass, multiplier, p.ProbeRaids)
+}
+
+// Cargo returns the cargo capacity of the ships
+func (p Params) Cargo(ships ogame.ShipsInfos) int64 {
+	multiplier := float64(p.ServerData.CargoHyperspaceTechMultiplier) / 100
+	return ships.Cargo(p.Researches, p.LfBonuses, p.CharacterClass, multiplier, p.ProbeRaids)
+}
+
+// FlightTime returns the one way flight time in seconds and the fuel needed by the ships
+func (p Params) FlightTime(origin, destination ogame.Coordinate, ships ogame.ShipsInfos, speed ogame.Speed, mission ogame.MissionID) (secs, fuel int64) {
+	sd := p.ServerData
+	return wrapper.CalcFlightTime(origin, destination, sd.Galaxies, sd.Systems, sd.DonutGalaxy, sd.DonutSystem,
+		sd.GlobalDeuteriumSaveFactor, speed.Float64()/10, wrapper.GetFleetSpeedForMission(sd, mission),
+		ships, p.Researches, p.LfBonuses, p.CharacterClass, p.AllianceClass, p.SystemsSkip, 0)
+}
+
+// RaidParams returns our side of an attack with the ships sent from origin on the target of the espionage report,
+// including the flight time and fuel of the fleet
+func (p Params) RaidParams(origin ogame.Coordinate, report ogame.EspionageReport, ships ogame.ShipsInfos, speed ogame.Speed) simulator.RaidParams {
+	secs, fuel := p.FlightTime(origin, report.Coordinate, ships, speed, ogame.Attack)
+	return simulator.RaidParams{
+		Ships:          ships,
+		Researches:     p.Researches,
+		LfBonuses:      p.LfBonuses,
+		CharacterClass: p.CharacterClass,
+		AllianceClass:  p.AllianceClass,
+		FlightTime:     secs,
+		Fuel:           fuel,
+	}
+}
+
+func positive(r ogame.Resources) ogame.Resources {
+	return ogame.Resources{Metal: max(r.Metal, 0), Crystal: max(r.Crystal, 0), Deuterium: max(r.Deuterium, 0)}
+}
+
+func sub(a, b ogame.Resources) ogame.Resources {
+	return ogame.Resources{Metal: a.Metal - b.Metal, Crystal: a.Crystal - b.Crystal, Deuterium: a.Deuterium - b.Deuterium}
+}
+
+func minResources(a, b ogame.Resources) ogame.Resources {
+	return ogame.Resources{Metal: min(a.Metal, b.Metal), Crystal: min(a.Crystal, b.Crystal), Deuterium: min(a.Deuterium, b.Deuterium)}
+}
+
+// producedUntil returns the resources of the source at the given time
+func (s Source) producedUntil(now, at time.Time) ogame.Resources {
+	hours := max(at.Sub(now).Hours(), 0)
+	return ogame.Resources{
+		Metal:     s.Resources.Metal + int64(float64(s.Production.Metal)*hours),
+		Crystal:   s.Resources.Crystal + int64(float64(s.Production.Crystal)*hours),
+		Deuterium: s.Resources.Deuterium + int64(float64(s.Production.Deuterium)*hours),
+	}
+}
+
+// fleetFor returns the fewest cargo ships of the source able to carry the resources, the resources are reduced
+// to what the ships can carry, deuterium first then crystal then metal.
+func fleetFor(resources ogame.Resources, available ogame.ShipsInfos, params Params) (ogame.ShipsInfos, ogame.Resources) {
+	var ships ogame.ShipsInfos
+	remaining := resources.Total()
+	var capacity int64
+	for _, shipID := range params.cargoShips() {
+		cargo := params.cargoOf(shipID)
+		if remaining <= 0 || cargo <= 0 {
+			continue
+		}
+		nbr := min((remaining+cargo-1)/cargo, available.ByID(shipID))
+		ships.Set(shipID, nbr)
+		capacity += nbr * cargo
+		remaining -= nbr * cargo
+	}
+	if remaining > 0 {
+		carried := ogame.Resources{}
+		carried.Deuterium = min(resources.Deuterium, capacity)
+		carried.Crystal = min(resources.Crystal, capacity-carried.Deuterium)
+		carried.Metal = min(resources.Metal, capacity-carried.Deuterium-carried.Crystal)
+		resources = carried
+	}
+	return ships, resources
+}
+
+// missionFrom returns the cheapest mission from the source that arrives before the deadline, false if none
+func missionFrom(src Source, req Request, missing ogame.Resources, params Params, now time.Time) (Mission, bool) {
+	available := positive(sub(src.Resources, src.Reserve))
+	resources := minResources(available, missing)
+	if resources.Total() <= 0 {
+		return Mission{}, false
+	}
+	ships, carried := fleetFor(resources, src.Ships, params)
+	if !ships.HasShips() || carried.Total() <= 0 {
+		return Mission{}, false
+	}
+	for _, speed := range Speeds {
+		secs, fuel := params.FlightTime(src.Coordinate, req.Destination, ships, speed, ogame.Transport)
+		arrival := now.Add(time.Duration(secs) * time.Second)
+		if arrival.After(req.Deadline) {
+			continue
+		}
+		// Fuel is paid with the deuterium of the origin
+		if carried.Deuterium+fuel > available.Deuterium {
+			carried.Deuterium = max(available.Deuterium-fuel, 0)
+			if carried.Total() <= 0 {
+				return Mission{}, false
+			}
+		}
+		return Mission{
+			Origin:      src.CelestialID,
+			Coordinate:  src.Coordinate,
+			Destination: req.Destination,
+			Ships:       ships,
+			Resources:   carried,
+			Speed:       speed,
+			FlightTime:  secs,
+			Fuel:        fuel,
+			ArrivalAt:   arrival,
+		}, true
+	}
+	return Mission{}, false
+}
+
+// PlanTransport proposes the missions to send now so the destination has the needed resources by the deadline.
+// The production of the destination until the deadline is used first, then missions are chosen greedily,
+// the ones using the least fuel and ships per resource delivered first, one per source and at most params.FreeSlots.
+// Each mission uses the slowest speed that arrives before the deadline.
+func PlanTransport(req Request, sources []Source, params Params, now time.Time) Plan {
+	plan := Plan{Request: req}
+	others := make([]Source, 0, len(sources))
+	for _, src := range sources {
+		if src.CelestialID == req.CelestialID && req.CelestialID != 0 {
+			plan.Local = minResources(src.producedUntil(now, req.Deadline), req.Needed)
+			continue
+		}
+		others = append(others, src)
+	}
+	missing := positive(sub(req.Needed, plan.Local))
+	for int64(len(plan.Missions)) < params.FreeSlots && missing.Total() > 0 {
+		candidates := make([]Mission, 0)
+		for _, src := range others {
+			if mission, ok := missionFrom(src, req, missing, params, now); ok {
+				candidates = append(candidates, mission)
+			}
+		}
+		if len(candidates) == 0 {
+			break
+		}
+		cost := func(m Mission) float64 {
+			return float64(m.Fuel+m.Ships.CountShips()) / float64(m.Resources.Total())
+		}
+		sort.SliceStable(candidates, func(i, j int) bool {
+			ci, cj := cost(candidates[i]), cost(candidates[j])
+			if ci == cj {
+				return candidates[i].Resources.Total() > candidates[j].Resources.Total()
+			}
+			return ci < cj
+		})
+		best := candidates[0]
+		plan.Missions = append(plan.Missions, best)
+		plan.Fuel += best.Fuel
+		plan.Ships += best.Ships.CountShips()
+		missing = positive(sub(missing, best.Resources))
+		for i := range others {
+			if others[i].CelestialID == best.Origin {
+				others = append(others[:i], others[i+1:]...)
+				break
+			}
+		}
+	}
+	plan.Missing = missing
+	return plan
+}
+
+// Sources returns the celestials of the account with their resources, production and ships
+func Sources(bot wrapper.Wrapper) ([]Source, error) {
+	allResources, err := bot.GetAllResources()
+	if err != nil {
+		return nil, err
+	}
+	out := make([]Source, 0)
+	for _, celestial := range bot.GetCachedCelestials() {
+		ships, err := celestial.GetShips()
+		if err != nil {
+			return nil, err
+		}
+		src := Source{
+			CelestialID: celestial.GetID(),
+			Coordinate:  celestial.GetCoordinate(),
+			Resources:   allResources[celestial.GetID()],
+			Ships:       ships,
+		}
+		if celestial.GetType() == ogame.PlanetType {
+			if src.Production, err = bot.GetResourcesProductions(ogame.PlanetID(celestial.GetID())); err != nil {
+				return nil, err
+			}
+		}
+		out = append(out, src)
+	}
+	return out, nil
+}
+
+// ParamsFromBot returns the params of the account, with the slots currently free
+func ParamsFromBot(bot wrapper.Wrapper) (Params, error) {
+	slots, err := bot.GetSlots()
+	if err != nil {
+		return Params{}, err
+	}
+	lfBonuses, _ := bot.GetCachedLfBonuses()
+	allianceClass, _ := bot.GetCachedAllianceClass()
+	return Params{
+		ServerData:     bot.GetServerData(),
+		Researches:     bot.GetCachedResearch(),
+		LfBonuses:      lfBonuses,
+		CharacterClass: bot.CharacterClass(),
+		AllianceClass:  allianceClass,
+		ProbeRaids:     bot.GetServer().OGameSettings().ProbeRaidsEnabled(),
+		FreeSlots:      max(slots.Total-slots.InUse, 0),
+	}, nil
+}
+
+// Execute sends the missions of the plan with the fleet builder, stops at the first error
+func Execute(bot wrapper.Wrapper, plan Plan) ([]ogame.Fleet, error) {
+	fleets := make([]ogame.Fleet, 0, len(plan.Missions))
+	for _, mission := range plan.Missions {
+		fleet, err := wrapper.NewFleetBuilder(bot).
+			SetOrigin(mission.Origin).
+			SetDestination(mission.Destination).
+			SetMission(ogame.Transport).
+			SetSpeed(mission.Speed).
+			SetShips(mission.Ships).
+			SetResources(mission.Resources).
+			SendNow()
+		if err != nil {
+			return fleets, err
+		}
+		fleets = append(fleets, fleet)
+	}
+	return fleets, nil
+}
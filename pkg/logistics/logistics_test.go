@@ -0,0 +1,99 @@
+package logistics
+
+import (
+	"testing"
+	"time"
+
+	"github.com/alaingilbert/ogame/pkg/ogame"
+	"github.com/alaingilbert/ogame/pkg/wrapper"
+	"github.com/stretchr/testify/assert"
+)
+
+var testParams = Params{
+	ServerData: wrapper.ServerData{Galaxies: 9, Systems: 499, SpeedFleetPeaceful: 1, GlobalDeuteriumSaveFactor: 1,
+		CargoHyperspaceTechMultiplier: 5},
+	Researches: ogame.Researches{CombustionDrive: 6, ImpulseDrive: 4},
+	FreeSlots:  5,
+}
+
+func coord(galaxy, system, position int64) ogame.Coordinate {
+	return ogame.Coordinate{Galaxy: galaxy, System: system, Position: position, Type: ogame.PlanetType}
+}
+
+func TestPlanTransport(t *testing.T) {
+	now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	req := Request{CelestialID: 1, Destination: coord(1, 1, 1), Needed: ogame.Resources{Metal: 100000, Crystal: 50000}, Deadline: now.Add(24 * time.Hour)}
+	sources := []Source{
+		{CelestialID: 1, Coordinate: coord(1, 1, 1), Resources: ogame.Resources{Metal: 20000}, Production: ogame.Resources{Metal: 1000}},
+		{CelestialID: 2, Coordinate: coord(1, 2, 1), Resources: ogame.Resources{Metal: 100000, Crystal: 100000, Deuterium: 10000},
+			Ships: ogame.ShipsInfos{LargeCargo: 100, SmallCargo: 10}},
+		{CelestialID: 3, Coordinate: coord(3, 200, 1), Resources: ogame.Resources{Metal: 100000, Crystal: 100000, Deuterium: 10000},
+			Ships: ogame.ShipsInfos{LargeCargo: 100}},
+	}
+	plan := PlanTransport(req, sources, testParams, now)
+	assert.Equal(t, ogame.Resources{Metal: 44000}, plan.Local)
+	assert.True(t, plan.Feasible())
+	assert.Len(t, plan.Missions, 1)
+	mission := plan.Missions[0]
+	assert.Equal(t, ogame.CelestialID(2), mission.Origin) // Closest source uses less fuel
+	assert.Equal(t, ogame.Resources{Metal: 56000, Crystal: 50000}, mission.Resources)
+	assert.Equal(t, ogame.ShipsInfos{LargeCargo: 5}, mission.Ships)
+	assert.Equal(t, ogame.TenPercent, mission.Speed)
+	assert.False(t, mission.ArrivalAt.After(req.Deadline))
+	assert.Equal(t, mission.Fuel, plan.Fuel)
+}
+
+func TestPlanTransport_Deadline(t *testing.T) {
+	now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	req := Request{Destination: coord(1, 1, 1), Needed: ogame.Resources{Crystal: 10000}, Deadline: now.Add(2 * time.Hour)}
+	sources := []Source{{CelestialID: 2, Coordinate: coord(1, 2, 1), Resources: ogame.Resources{Crystal: 100000, Deuterium: 1000},
+		Ships: ogame.ShipsInfos{LargeCargo: 100}}}
+	plan := PlanTransport(req, sources, testParams, now)
+	assert.Len(t, plan.Missions, 1)
+	secs, _ := testParams.FlightTime(coord(1, 2, 1), coord(1, 1, 1), ogame.ShipsInfos{LargeCargo: 1}, plan.Missions[0].Speed, ogame.Transport)
+	assert.True(t, secs <= 7200)
+	assert.True(t, plan.Missions[0].Speed > ogame.TenPercent)
+
+	req.Deadline = now.Add(time.Minute)
+	plan = PlanTransport(req, sources, testParams, now)
+	assert.Empty(t, plan.Missions)
+	assert.False(t, plan.Feasible())
+	assert.Equal(t, ogame.Resources{Crystal: 10000}, plan.Missing)
+}
+
+func TestPlanTransport_SlotsAndCargo(t *testing.T) {
+	now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	req := Request{Destination: coord(1, 1, 1), Needed: ogame.Resources{Metal: 200000}, Deadline: now.Add(48 * time.Hour)}
+	sources := []Source{
+		{CelestialID: 2, Coordinate: coord(1, 2, 1), Resources: ogame.Resources{Metal: 200000, Deuterium: 5000}, Ships: ogame.ShipsInfos{LargeCargo: 2}},
+		{CelestialID: 3, Coordinate: coord(1, 3, 1), Resources: ogame.Resources{Metal: 200000, Deuterium: 5000}, Ships: ogame.ShipsInfos{LargeCargo: 2}},
+		{CelestialID: 4, Coordinate: coord(1, 4, 1), Resources: ogame.Resources{Metal: 200000}, Reserve: ogame.Resources{Metal: 200000},
+			Ships: ogame.ShipsInfos{LargeCargo: 100}},
+	}
+	params := testParams
+	params.FreeSlots = 1
+	plan := PlanTransport(req, sources, params, now)
+	assert.Len(t, plan.Missions, 1)
+	cargo := params.cargoOf(ogame.LargeCargoID)
+	assert.Equal(t, ogame.Resources{Metal: 2 * cargo}, plan.Missions[0].Resources)
+	assert.Equal(t, ogame.Resources{Metal: 200000 - 2*cargo}, plan.Missing)
+
+	params.FreeSlots = 5
+	plan = PlanTransport(req, sources, params, now)
+	assert.Len(t, plan.Missions, 2) // Source 4 has to keep its resources
+}
+
+func TestParams_RaidParams(t *testing.T) {
+	params := testParams
+	params.ServerData.SpeedFleetWar = 1
+	ships := ogame.ShipsInfos{SmallCargo: 10}
+	report := ogame.EspionageReport{Coordinate: coord(1, 3, 8)}
+	raid := params.RaidParams(coord(1, 1, 1), report, ships, ogame.HundredPercent)
+	secs, fuel := params.FlightTime(coord(1, 1, 1), coord(1, 3, 8), ships, ogame.HundredPercent, ogame.Attack)
+	assert.Equal(t, ships, raid.Ships)
+	assert.Equal(t, params.Researches, raid.Researches)
+	assert.Equal(t, secs, raid.FlightTime)
+	assert.Equal(t, fuel, raid.Fuel)
+	assert.True(t, raid.FlightTime > 0)
+	assert.True(t, raid.Fuel > 0)
+}
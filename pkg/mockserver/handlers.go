@@ -0,0 +1,520 @@
+package mockserver
+
+import (
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/alaingilbert/ogame/pkg/ogame"
+	"github.com/alaingilbert/ogame/pkg/utils"
+)
+
+const sessionCookieName = "PHPSESSID"
+
+type ogameError struct {
+	Message string `json:"message"`
+	Error   int64  `json:"error"`
+}
+
+func writeJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(v)
+}
+
+func writeHTML(w http.ResponseWriter, by []byte) {
+	w.Header().Set("Content-Type", "text/html; charset=UTF-8")
+	_, _ = w.Write(by)
+}
+
+func (s *Server) gameHost(st *State) string {
+	return fmt.Sprintf("s%d-%s.ogame.gameforge.com", st.ServerNumber, st.Lang)
+}
+
+func (s *Server) getSession() string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.session
+}
+
+func (s *Server) isBearerValid(r *http.Request) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.bearerToken != "" && r.Header.Get("Authorization") == "Bearer "+s.bearerToken
+}
+
+// gameforge.com, blackbox script and account authentication
+func (s *Server) serveGameforge(w http.ResponseWriter, r *http.Request) {
+	switch {
+	case r.URL.Path == "/tra/game1.js":
+		w.Header().Set("Date", s.State.Clock.Now().UTC().Format(http.TimeFormat))
+		w.Header().Set("Content-Type", "application/javascript")
+		_, _ = w.Write([]byte("/* blackbox */"))
+
+	case r.URL.Path == "/api/v1/auth/thin/sessions" && r.Method == http.MethodPost:
+		var payload struct {
+			Identity string `json:"identity"`
+			Password string `json:"password"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&payload); err != nil {
+			writeJSON(w, http.StatusBadRequest, map[string]string{"error": err.Error()})
+			return
+		}
+		var username, password string
+		s.State.Do(func(st *State) { username, password = st.Username, st.Password })
+		if payload.Identity != username || payload.Password != password {
+			writeJSON(w, http.StatusUnauthorized, map[string]string{"error": "invalid credentials"})
+			return
+		}
+		s.mu.Lock()
+		s.bearerToken = randomHex(16)
+		token := s.bearerToken
+		s.mu.Unlock()
+		writeJSON(w, http.StatusCreated, map[string]any{"token": token, "isPlatformLogin": false, "isGameAccountMigrated": false})
+
+	default:
+		http.NotFound(w, r)
+	}
+}
+
+// lobby.ogame.gameforge.com, servers list, accounts and login link
+func (s *Server) serveLobby(w http.ResponseWriter, r *http.Request) {
+	var snapshot State
+	s.State.Do(func(st *State) {
+		snapshot.ServerName, snapshot.ServerNumber, snapshot.Lang = st.ServerName, st.ServerNumber, st.Lang
+		snapshot.PlayerID, snapshot.PlayerName, snapshot.Galaxies = st.PlayerID, st.PlayerName, st.Galaxies
+		snapshot.Speed, snapshot.FleetSpeed = st.Speed, st.FleetSpeed
+	})
+	switch r.URL.Path {
+	case "/config/configuration.js":
+		w.Header().Set("Content-Type", "application/javascript")
+		_, _ = w.Write([]byte(`window.gfConfig = {"gameEnvironmentId":"0a31d605-ffaf-43e7-aa02-d06df7116fc8","platformGameId":"1dfd8e7e-6e1a-4eb1-8c64-03650b06f6ea"};`))
+
+	case "/api/servers":
+		writeJSON(w, http.StatusOK, []map[string]any{{
+			"language":      snapshot.Lang,
+			"number":        snapshot.ServerNumber,
+			"accountGroup":  snapshot.Lang + "_1",
+			"name":          snapshot.ServerName,
+			"playerCount":   1,
+			"playersOnline": 1,
+			"opened":        "2020-01-01T00:00:00+00:00",
+			"startDate":     "2020-01-01T00:00:00+00:00",
+			"endDate":       nil,
+			"serverClosed":  0,
+			"prefered":      0,
+			"signupClosed":  0,
+			"multiLanguage": 0,
+			"availableOn":   []string{},
+			"settings": map[string]any{
+				"aks":                      1,
+				"fleetSpeedWar":            snapshot.FleetSpeed,
+				"fleetSpeedHolding":        snapshot.FleetSpeed,
+				"fleetSpeedPeaceful":       snapshot.FleetSpeed,
+				"wreckField":               1,
+				"serverLabel":              "empire",
+				"economySpeed":             snapshot.Speed,
+				"planetFields":             0,
+				"universeSize":             snapshot.Galaxies,
+				"serverCategory":           "balanced",
+				"espionageProbeRaids":      0,
+				"premiumValidationGift":    0,
+				"debrisFieldFactorShips":   30,
+				"researchDurationDivisor":  1,
+				"debrisFieldFactorDefence": 0,
+			},
+		}})
+
+	case "/api/users/me/accounts":
+		if !s.isBearerValid(r) {
+			writeJSON(w, http.StatusUnauthorized, map[string]string{"error": "unauthorized"})
+			return
+		}
+		writeJSON(w, http.StatusOK, []map[string]any{{
+			"server":       map[string]any{"language": snapshot.Lang, "number": snapshot.ServerNumber},
+			"id":           snapshot.PlayerID,
+			"name":         snapshot.PlayerName,
+			"lastPlayed":   "2020-01-01T00:00:00+00:00",
+			"blocked":      false,
+			"bannedReason": "",
+			"details":      []any{},
+			"sitting":      map[string]any{"shared": false, "endTime": nil, "cooldownTime": nil},
+		}})
+
+	case "/api/users/me/loginLink":
+		if !s.isBearerValid(r) {
+			writeJSON(w, http.StatusUnauthorized, map[string]string{"error": "unauthorized"})
+			return
+		}
+		s.mu.Lock()
+		s.loginToken = randomHex(16)
+		loginToken := s.loginToken
+		s.mu.Unlock()
+		link := fmt.Sprintf("https://%s/game/lobbylogin.php?id=%d&token=%s", s.gameHost(&snapshot), snapshot.PlayerID, loginToken)
+		writeJSON(w, http.StatusOK, map[string]string{"url": link})
+
+	case "/api/users/me/logout":
+		s.mu.Lock()
+		s.bearerToken = ""
+		s.mu.Unlock()
+		w.WriteHeader(http.StatusOK)
+
+	default:
+		http.NotFound(w, r)
+	}
+}
+
+// sXXX-yy.ogame.gameforge.com, the game itself
+func (s *Server) serveGame(w http.ResponseWriter, r *http.Request) {
+	switch r.URL.Path {
+	case "/api/serverData.xml":
+		s.serveServerData(w)
+	case "/game/lobbylogin.php":
+		s.serveLobbyLogin(w, r)
+	case "/game/index.php":
+		s.serveIndex(w, r)
+	default:
+		http.NotFound(w, r)
+	}
+}
+
+func (s *Server) serveServerData(w http.ResponseWriter) {
+	type serverData struct {
+		XMLName                       xml.Name `xml:"serverData"`
+		Name                          string   `xml:"name"`
+		Number                        int64    `xml:"number"`
+		Language                      string   `xml:"language"`
+		Timezone                      string   `xml:"timezone"`
+		TimezoneOffset                string   `xml:"timezoneOffset"`
+		Domain                        string   `xml:"domain"`
+		Version                       string   `xml:"version"`
+		Speed                         int64    `xml:"speed"`
+		SpeedFleetPeaceful            int64    `xml:"speedFleetPeaceful"`
+		SpeedFleetWar                 int64    `xml:"speedFleetWar"`
+		SpeedFleetHolding             int64    `xml:"speedFleetHolding"`
+		Galaxies                      int64    `xml:"galaxies"`
+		Systems                       int64    `xml:"systems"`
+		ACS                           int64    `xml:"acs"`
+		RapidFire                     int64    `xml:"rapidFire"`
+		DebrisFactor                  float64  `xml:"debrisFactor"`
+		RepairFactor                  float64  `xml:"repairFactor"`
+		DonutGalaxy                   int64    `xml:"donutGalaxy"`
+		DonutSystem                   int64    `xml:"donutSystem"`
+		GlobalDeuteriumSaveFactor     float64  `xml:"globalDeuteriumSaveFactor"`
+		ProbeCargo                    int64    `xml:"probeCargo"`
+		ResearchDurationDivisor       int64    `xml:"researchDurationDivisor"`
+		DarkMatterNewAcount           int64    `xml:"darkMatterNewAcount"`
+		CargoHyperspaceTechMultiplier int64    `xml:"cargoHyperspaceTechMultiplier"`
+	}
+	var data serverData
+	s.State.Do(func(st *State) {
+		data = serverData{
+			Name:                          st.ServerName,
+			Number:                        st.ServerNumber,
+			Language:                      st.Lang,
+			Timezone:                      "UTC",
+			TimezoneOffset:                "+00:00",
+			Domain:                        s.gameHost(st),
+			Version:                       st.Version,
+			Speed:                         st.Speed,
+			SpeedFleetPeaceful:            st.FleetSpeed,
+			SpeedFleetWar:                 st.FleetSpeed,
+			SpeedFleetHolding:             st.FleetSpeed,
+			Galaxies:                      st.Galaxies,
+			Systems:                       st.Systems,
+			ACS:                           1,
+			RapidFire:                     1,
+			DebrisFactor:                  0.3,
+			RepairFactor:                  0.7,
+			DonutGalaxy:                   1,
+			DonutSystem:                   1,
+			GlobalDeuteriumSaveFactor:     1,
+			ProbeCargo:                    0,
+			ResearchDurationDivisor:       1,
+			DarkMatterNewAcount:           8000,
+			CargoHyperspaceTechMultiplier: 5,
+		}
+	})
+	w.Header().Set("Content-Type", "application/xml")
+	_, _ = w.Write([]byte(xml.Header))
+	_ = xml.NewEncoder(w).Encode(data)
+}
+
+func (s *Server) serveLobbyLogin(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	valid := s.loginToken != "" && r.URL.Query().Get("token") == s.loginToken
+	if valid {
+		s.loginToken = ""
+		s.session = randomHex(20)
+	}
+	session := s.session
+	s.mu.Unlock()
+	if !valid {
+		writeHTML(w, notLoggedPage())
+		return
+	}
+	http.SetCookie(w, &http.Cookie{Name: sessionCookieName, Value: session, Path: "/"})
+	var by []byte
+	s.State.Do(func(st *State) {
+		planet := s.currentPlanet(st)
+		by = s.renderFullPage(st, "overview", planet, s.overviewContent(st, planet))
+	})
+	writeHTML(w, by)
+}
+
+func notLoggedPage() []byte {
+	return []byte("<!DOCTYPE html>\n<html><head><title>OGame</title></head><body><div id=\"loginForm\"></div></body></html>\n")
+}
+
+// currentPlanet returns the planet selected with the "cp" parameter, defaults to the first planet
+func (s *Server) currentPlanet(st *State) *Planet {
+	if p := st.GetPlanet(st.current); p != nil {
+		return p
+	}
+	st.current = st.Planets[0].ID
+	return st.Planets[0]
+}
+
+func (s *Server) serveIndex(w http.ResponseWriter, r *http.Request) {
+	cookie, err := r.Cookie(sessionCookieName)
+	session := s.getSession()
+	if err != nil || session == "" || cookie.Value != session {
+		writeHTML(w, notLoggedPage())
+		return
+	}
+	_ = r.ParseForm()
+	vals := r.URL.Query()
+	page := vals.Get("page")
+	component := vals.Get("component")
+	name := page
+	if page == "ingame" || page == "componentOnly" || page == "ajax" {
+		name = component
+	}
+
+	var by []byte
+	var status = http.StatusOK
+	var handled = true
+	s.State.Do(func(st *State) {
+		if cp := vals.Get("cp"); cp != "" {
+			if id, err := strconv.ParseInt(cp, 10, 64); err == nil && st.GetPlanet(ogame.CelestialID(id)) != nil {
+				st.current = ogame.CelestialID(id)
+			}
+		}
+		planet := s.currentPlanet(st)
+		switch {
+		case name == "fetchResources":
+			by = resourcesJSON(st, planet)
+		case name == "fetchTechs":
+			by = techsJSON(st, planet)
+		case name == "logout":
+			s.mu.Lock()
+			s.session = ""
+			s.mu.Unlock()
+			by = notLoggedPage()
+		case name == "buildlistactions":
+			by = s.buildListActions(st, vals.Get("action"), r.PostForm)
+		case name == "fleetdispatch" && vals.Get("action") == "checkTarget":
+			by = s.checkTarget(st, planet, r.PostForm)
+		case name == "fleetdispatch" && vals.Get("action") == "sendFleet":
+			by = s.dispatchFleet(st, planet, r.PostForm)
+		case name == "movement" && vals.Get("return") != "":
+			if vals.Get("token") == st.token {
+				id, _ := strconv.ParseInt(vals.Get("return"), 10, 64)
+				_ = st.cancelFleet(ogame.FleetID(id))
+			}
+			by = s.renderFullPage(st, name, planet, s.movementContent(st))
+		default:
+			content, ok := s.pageContent(st, name, planet)
+			if !ok {
+				handled = false
+				return
+			}
+			by = s.renderFullPage(st, name, planet, content)
+		}
+	})
+	if !handled {
+		sample, ok := s.getSample(name)
+		if !ok {
+			status = http.StatusNotFound
+			sample = []byte("not found")
+		}
+		by = sample
+	}
+	if status != http.StatusOK {
+		w.WriteHeader(status)
+	}
+	_, _ = w.Write(by)
+}
+
+// pageContent returns the content of the full pages emulated by the mock server
+func (s *Server) pageContent(st *State, name string, planet *Planet) (string, bool) {
+	switch name {
+	case "overview":
+		return s.overviewContent(st, planet), true
+	case "supplies":
+		return levelsHTML(suppliesObjs, func(id ogame.ID) int64 { return planet.Levels[id] }), true
+	case "facilities":
+		return levelsHTML(facilitiesObjs, func(id ogame.ID) int64 { return planet.Levels[id] }), true
+	case "research":
+		return levelsHTML(researchObjs, st.researchLevel), true
+	case "shipyard":
+		return amountsHTML(shipsObjs, planet.Ships.ByID), true
+	case "defenses":
+		return amountsHTML(defensesObjs, planet.Defenses.ByID), true
+	case "fleetdispatch":
+		return s.fleetdispatchContent(st, planet), true
+	case "movement":
+		return s.movementContent(st), true
+	case "preferences", "lfbonuses", "lfbuildings", "lfresearch", "resourceSettings":
+		return "", true
+	}
+	return "", false
+}
+
+func techsJSON(st *State, planet *Planet) []byte {
+	out := make(map[string]int64)
+	for id, lvl := range planet.Levels {
+		out[utils.FI64(id)] = lvl
+	}
+	for id, lvl := range st.Researches {
+		out[utils.FI64(id)] = lvl
+	}
+	for id, nb := range planet.Ships.Iter() {
+		out[utils.FI64(id)] = nb
+	}
+	for _, o := range defensesObjs {
+		out[utils.FI64(o.ID)] = planet.Defenses.ByID(o.ID)
+	}
+	by, _ := json.Marshal(out)
+	return by
+}
+
+// Verify the token sent with an action and rotate it, like ogame does.
+func (st *State) useToken(token string) bool {
+	valid := token == st.token
+	st.token = randomHex(16)
+	return valid
+}
+
+func (s *Server) buildListActions(st *State, action string, form url.Values) []byte {
+	type response struct {
+		Status       string       `json:"status"`
+		Errors       []ogameError `json:"errors"`
+		Components   []any        `json:"components"`
+		NewAjaxToken string       `json:"newAjaxToken"`
+	}
+	failure := func(msg string) []byte {
+		by, _ := json.Marshal(response{Status: "failure", Errors: []ogameError{{Message: msg, Error: 0}}, Components: []any{}, NewAjaxToken: st.token})
+		return by
+	}
+	if !st.useToken(form.Get("token")) {
+		return failure("Invalid token")
+	}
+	techID, _ := strconv.ParseInt(form.Get("technologyId"), 10, 64)
+	var err error
+	switch action {
+	case "scheduleEntry":
+		planetID, _ := strconv.ParseInt(form.Get("planetId"), 10, 64)
+		amount, _ := strconv.ParseInt(form.Get("amount"), 10, 64)
+		err = st.build(ogame.CelestialID(planetID), ogame.ID(techID), amount)
+	case "cancelEntry":
+		listID, _ := strconv.ParseInt(form.Get("listId"), 10, 64)
+		err = st.cancel(ogame.ID(techID), listID)
+	default:
+		err = fmt.Errorf("unknown action %q", action)
+	}
+	if err != nil {
+		return failure(err.Error())
+	}
+	by, _ := json.Marshal(response{Status: "success", Errors: []ogameError{}, Components: []any{}, NewAjaxToken: st.token})
+	return by
+}
+
+// Parse the ships, destination and resources posted to the fleetdispatch component
+func parseFleetPayload(form url.Values) (ships ogame.ShipsInfos, where ogame.Coordinate, resources ogame.Resources) {
+	parse := func(key string) int64 {
+		v, _ := strconv.ParseInt(form.Get(key), 10, 64)
+		return v
+	}
+	for key := range form {
+		if strings.HasPrefix(key, "am") {
+			if id, err := strconv.ParseInt(strings.TrimPrefix(key, "am"), 10, 64); err == nil && ogame.ID(id).IsShip() {
+				ships.Set(ogame.ID(id), parse(key))
+			}
+		}
+	}
+	where = ogame.Coordinate{Galaxy: parse("galaxy"), System: parse("system"), Position: parse("position"), Type: ogame.CelestialType(parse("type"))}
+	resources = ogame.Resources{Metal: parse("metal"), Crystal: parse("crystal"), Deuterium: parse("deuterium")}
+	return
+}
+
+func (s *Server) checkTarget(st *State, planet *Planet, form url.Values) []byte {
+	type response struct {
+		Status       string       `json:"status"`
+		TargetOk     bool         `json:"targetOk"`
+		Errors       []ogameError `json:"errors"`
+		Components   []any        `json:"components"`
+		NewAjaxToken string       `json:"newAjaxToken"`
+	}
+	res := response{Status: "success", TargetOk: true, Errors: []ogameError{}, Components: []any{}}
+	validToken := st.useToken(form.Get("token"))
+	res.NewAjaxToken = st.token
+	ships, where, _ := parseFleetPayload(form)
+	if !validToken {
+		res.Status, res.TargetOk = "failure", false
+		res.Errors = append(res.Errors, ogameError{Message: "Fleet launch failure: The fleet could not be launched. Please try again later.", Error: 4047})
+	} else if !ships.HasShips() || !planet.Ships.Has(ships) {
+		res.Status, res.TargetOk = "failure", false
+		res.Errors = append(res.Errors, ogameError{Message: "Error, no ships available", Error: 4059})
+	} else if where.Galaxy < 1 || where.Galaxy > st.Galaxies || where.System < 1 || where.System > st.Systems || where.Position < 1 || where.Position > 16 {
+		res.Status, res.TargetOk = "failure", false
+		res.Errors = append(res.Errors, ogameError{Message: "You have to select a valid target.", Error: 4049})
+	}
+	by, _ := json.Marshal(res)
+	return by
+}
+
+func (s *Server) dispatchFleet(st *State, planet *Planet, form url.Values) []byte {
+	type response struct {
+		Success           bool         `json:"success"`
+		Message           string       `json:"message,omitempty"`
+		FleetSendingToken string       `json:"fleetSendingToken,omitempty"`
+		Errors            []ogameError `json:"errors,omitempty"`
+		Components        []any        `json:"components"`
+		RedirectURL       string       `json:"redirectUrl,omitempty"`
+		NewAjaxToken      string       `json:"newAjaxToken"`
+	}
+	validToken := st.useToken(form.Get("token"))
+	res := response{Components: []any{}, NewAjaxToken: st.token}
+	fail := func(msg string, code int64) []byte {
+		res.FleetSendingToken = st.token
+		res.Errors = []ogameError{{Message: msg, Error: code}}
+		by, _ := json.Marshal(res)
+		return by
+	}
+	if !validToken {
+		return fail("Fleet launch failure: The fleet could not be launched. Please try again later.", 4047)
+	}
+	ships, where, resources := parseFleetPayload(form)
+	mission, _ := strconv.ParseInt(form.Get("mission"), 10, 64)
+	speed, _ := strconv.ParseInt(form.Get("speed"), 10, 64)
+	if _, err := st.sendFleet(planet.ID, ships, where, ogame.MissionID(mission), resources, ogame.Speed(speed)); err != nil {
+		switch err {
+		case ErrNoShipsSelected, ErrNotEnoughShips:
+			return fail("Error, no ships available", 4059)
+		case ErrNotEnoughResources:
+			return fail("Insufficient resources.", 4060)
+		default:
+			return fail("Fleet launch failure: The fleet could not be launched. Please try again later.", 4047)
+		}
+	}
+	res.Success = true
+	res.Message = "Your fleet has been successfully sent."
+	res.RedirectURL = fmt.Sprintf("https://%s/game/index.php?page=ingame&component=fleetdispatch", s.gameHost(st))
+	by, _ := json.Marshal(res)
+	return by
+}
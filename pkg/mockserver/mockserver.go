@@ -0,0 +1,214 @@
+// Package mockserver provides an offline gameforge lobby and ogame universe served over httptest.
+//
+// Every request made through Server.Transport is routed to the mock, whatever the host it targets,
+// so the wrapper can run its full login flow and game actions without hitting a live universe.
+//
+//	srv := mockserver.New(nil)
+//	defer srv.Close()
+//	dev, _ := srv.NewDevice("test")
+//	bot, _ := wrapper.NewWithParams(wrapper.Params{Device: dev, Universe: "Bellatrix", Lang: "en",
+//		Username: "user@example.com", Password: "password", AutoLogin: true})
+package mockserver
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"net"
+	"net/http"
+	"net/http/cookiejar"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"github.com/alaingilbert/ogame/pkg/device"
+	"github.com/alaingilbert/ogame/pkg/httpclient"
+)
+
+// Server mock gameforge/ogame server
+type Server struct {
+	*httptest.Server
+	State *State
+
+	// SamplesDir is the directory recorded pages are served from (default "samples" folder of the repository)
+	SamplesDir string
+
+	mu          sync.Mutex
+	samples     map[string]string
+	bearerToken string
+	loginToken  string
+	session     string
+	chatHost    string
+	chatPort    string
+}
+
+// New creates and starts a mock server serving the given state.
+// If state is nil, NewState is used.
+func New(state *State) *Server {
+	if state == nil {
+		state = NewState()
+	}
+	s := &Server{
+		State:      state,
+		SamplesDir: defaultSamplesDir(),
+		samples:    defaultSamples(),
+	}
+	s.Server = httptest.NewServer(http.HandlerFunc(s.serveHTTP))
+	// The chat is dialed with https on the mock listener, connection will be refused by the plain http server.
+	s.chatHost, s.chatPort, _ = net.SplitHostPort(s.Listener.Addr().String())
+	return s
+}
+
+// Find the "samples" folder by walking up from the working directory
+func defaultSamplesDir() string {
+	dir, err := os.Getwd()
+	if err != nil {
+		return "samples"
+	}
+	for {
+		candidate := filepath.Join(dir, "samples")
+		if fi, err := os.Stat(candidate); err == nil && fi.IsDir() {
+			return candidate
+		}
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return "samples"
+		}
+		dir = parent
+	}
+}
+
+// Recorded pages served for components the mock does not emulate
+func defaultSamples() map[string]string {
+	return map[string]string{
+		"eventList":      "unversioned/eventList.html",
+		"traderOverview": "unversioned/traderOverview.html",
+		"galaxyContent":  "unversioned/galaxy_ajax.html",
+	}
+}
+
+// SetSample serves the recorded page at path (relative to SamplesDir) when the component/page "name" is requested.
+func (s *Server) SetSample(name, path string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.samples[name] = path
+}
+
+func (s *Server) getSample(name string) ([]byte, bool) {
+	s.mu.Lock()
+	path, ok := s.samples[name]
+	s.mu.Unlock()
+	if !ok {
+		return nil, false
+	}
+	by, err := os.ReadFile(filepath.Join(s.SamplesDir, path))
+	if err != nil {
+		return nil, false
+	}
+	return by, true
+}
+
+// Transport returns a RoundTripper that sends every request to the mock server.
+// The original host is kept in the Host header so the mock knows which service was targeted.
+func (s *Server) Transport() http.RoundTripper {
+	return &transport{addr: s.Listener.Addr().String(), base: s.Client().Transport}
+}
+
+// NewDevice creates a device whose http client talks to the mock server.
+// The fingerprint and cookies are kept in memory.
+func (s *Server) NewDevice(name string) (*device.Device, error) {
+	dev, err := device.NewBuilder(name).
+		SetPersistor(&memPersistor{}).
+		SetOsName(device.Windows).
+		SetBrowserName(device.Chrome).
+		SetMemory(8).
+		SetHardwareConcurrency(16).
+		ScreenColorDepth(24).
+		SetScreenWidth(1900).
+		SetScreenHeight(900).
+		SetTimezone("America/New_York").
+		SetLanguages("en-US,en").
+		Build()
+	if err != nil {
+		return nil, err
+	}
+	jar, err := cookiejar.New(nil)
+	if err != nil {
+		return nil, err
+	}
+	client := httpclient.NewClient(dev.GetClient().UserAgent())
+	client.Jar = jar
+	client.Transport = s.Transport()
+	dev.SetClient(client)
+	return dev, nil
+}
+
+type transport struct {
+	addr string
+	base http.RoundTripper
+}
+
+func (t *transport) RoundTrip(req *http.Request) (*http.Response, error) {
+	clone := req.Clone(req.Context())
+	clone.URL.Scheme = "http"
+	clone.URL.Host = t.addr
+	clone.Host = req.URL.Host
+	resp, err := t.base.RoundTrip(clone)
+	if err != nil {
+		return nil, err
+	}
+	resp.Request = req
+	return resp, nil
+}
+
+type memPersistor struct {
+	sync.Mutex
+	fingerprint *device.JsFingerprint
+}
+
+func (p *memPersistor) Load() (*device.JsFingerprint, error) {
+	p.Lock()
+	defer p.Unlock()
+	if p.fingerprint == nil {
+		return nil, os.ErrNotExist
+	}
+	fp := *p.fingerprint
+	return &fp, nil
+}
+
+func (p *memPersistor) Save(fingerprint *device.JsFingerprint) error {
+	p.Lock()
+	defer p.Unlock()
+	fp := *fingerprint
+	p.fingerprint = &fp
+	return nil
+}
+
+func randomHex(n int) string {
+	buf := make([]byte, n)
+	_, _ = rand.Read(buf)
+	return hex.EncodeToString(buf)
+}
+
+func requestHost(r *http.Request) string {
+	host := r.Host
+	if h, _, err := net.SplitHostPort(host); err == nil {
+		host = h
+	}
+	return strings.ToLower(host)
+}
+
+func (s *Server) serveHTTP(w http.ResponseWriter, r *http.Request) {
+	host := requestHost(r)
+	switch {
+	case host == "gameforge.com":
+		s.serveGameforge(w, r)
+	case strings.HasPrefix(host, "lobby") && strings.HasSuffix(host, ".gameforge.com"):
+		s.serveLobby(w, r)
+	case strings.HasSuffix(host, ".ogame.gameforge.com"):
+		s.serveGame(w, r)
+	default:
+		http.NotFound(w, r)
+	}
+}
@@ -0,0 +1,115 @@
+package mockserver_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/alaingilbert/clockwork"
+	"github.com/alaingilbert/ogame/pkg/mockserver"
+	"github.com/alaingilbert/ogame/pkg/ogame"
+	"github.com/alaingilbert/ogame/pkg/wrapper"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newBot(t *testing.T, state *mockserver.State) (*mockserver.Server, *wrapper.OGame) {
+	srv := mockserver.New(state)
+	t.Cleanup(srv.Close)
+	dev, err := srv.NewDevice("mockserver_test")
+	require.NoError(t, err)
+	bot, err := wrapper.NewWithParams(wrapper.Params{
+		Device:    dev,
+		Universe:  srv.State.ServerName,
+		Lang:      srv.State.Lang,
+		Username:  srv.State.Username,
+		Password:  srv.State.Password,
+		AutoLogin: true,
+		Quiet:     true,
+	})
+	require.NoError(t, err)
+	return srv, bot
+}
+
+func TestLogin(t *testing.T) {
+	srv, bot := newBot(t, nil)
+	assert.True(t, bot.IsLoggedIn())
+	assert.Equal(t, srv.State.PlayerName, bot.GetCachedPlayer().PlayerName)
+	planets := bot.GetCachedPlanets()
+	require.Len(t, planets, 1)
+	assert.Equal(t, ogame.PlanetID(33620000), planets[0].ID)
+	assert.Equal(t, ogame.Coordinate{Galaxy: 1, System: 1, Position: 8, Type: ogame.PlanetType}, planets[0].Coordinate)
+}
+
+func TestBadCredentials(t *testing.T) {
+	srv := mockserver.New(nil)
+	defer srv.Close()
+	dev, err := srv.NewDevice("mockserver_test")
+	require.NoError(t, err)
+	_, err = wrapper.NewWithParams(wrapper.Params{Device: dev, Universe: "Bellatrix", Lang: "en",
+		Username: "user@example.com", Password: "wrong", AutoLogin: true, Quiet: true})
+	assert.Error(t, err)
+}
+
+func TestGetResources(t *testing.T) {
+	_, bot := newBot(t, nil)
+	res, err := bot.GetResources(33620000)
+	require.NoError(t, err)
+	assert.Equal(t, int64(100000), res.Metal)
+	assert.Equal(t, int64(50000), res.Crystal)
+	assert.Equal(t, int64(25000), res.Deuterium)
+}
+
+func TestBuild(t *testing.T) {
+	srv, bot := newBot(t, nil)
+	require.NoError(t, bot.BuildShips(33620000, ogame.SmallCargoID, 2))
+	ships, err := bot.GetShips(33620000)
+	require.NoError(t, err)
+	assert.Equal(t, int64(12), ships.SmallCargo)
+	res, _ := bot.GetResources(33620000)
+	assert.Equal(t, int64(100000-4000), res.Metal)
+
+	require.NoError(t, bot.BuildBuilding(33620000, ogame.MetalMineID))
+	constructions, err := bot.ConstructionsBeingBuilt(33620000)
+	require.NoError(t, err)
+	assert.Equal(t, ogame.MetalMineID, constructions.Building.ID)
+	assert.Equal(t, int64(6), constructions.Building.Level)
+	assert.Error(t, bot.BuildBuilding(33620000, ogame.CrystalMineID)) // Queue is busy
+
+	require.NoError(t, bot.CancelBuilding(33620000))
+	srv.State.Do(func(st *mockserver.State) {
+		assert.Equal(t, int64(100000-4000), st.Planets[0].Resources.Metal)
+	})
+}
+
+func TestSendAndCancelFleet(t *testing.T) {
+	clock := clockwork.NewFakeClockAt(time.Now())
+	state := mockserver.NewState()
+	state.Clock = clock
+	srv, bot := newBot(t, state)
+	where := ogame.Coordinate{Galaxy: 1, System: 2, Position: 8, Type: ogame.PlanetType}
+	fleet, err := bot.SendFleet(33620000, ogame.ShipsInfos{SmallCargo: 5}, ogame.HundredPercent, where, ogame.Transport,
+		ogame.Resources{Metal: 1000}, 0, 0)
+	require.NoError(t, err)
+	assert.NotZero(t, fleet.ID)
+	assert.Equal(t, where, fleet.Destination)
+
+	fleets, slots, err := bot.GetFleets()
+	require.NoError(t, err)
+	require.Len(t, fleets, 1)
+	assert.Equal(t, int64(1), slots.InUse)
+	assert.Equal(t, int64(5), fleets[0].Ships.SmallCargo)
+
+	clock.Advance(10 * time.Second)
+	require.NoError(t, bot.CancelFleet(fleet.ID))
+	fleets, _, _ = bot.GetFleets()
+	require.Len(t, fleets, 1)
+	assert.True(t, fleets[0].ReturnFlight)
+
+	clock.Advance(time.Minute)
+	fleets, _, _ = bot.GetFleets()
+	assert.Len(t, fleets, 0)
+	srv.State.Do(func(st *mockserver.State) {
+		assert.Equal(t, int64(10), st.Planets[0].Ships.SmallCargo)
+		assert.Equal(t, int64(100000), st.Planets[0].Resources.Metal)
+	})
+}
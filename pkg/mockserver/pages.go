@@ -0,0 +1,316 @@
+package mockserver
+
+import (
+	"encoding/json"
+	"fmt"
+	"html"
+	"strings"
+
+	"github.com/alaingilbert/ogame/pkg/ogame"
+	"github.com/alaingilbert/ogame/pkg/utils"
+)
+
+type cssObj struct {
+	ID   ogame.ID
+	Name string
+}
+
+// Css class names used by ogame to identify technologies in the html
+var (
+	suppliesObjs = []cssObj{
+		{ogame.MetalMineID, "metalMine"},
+		{ogame.CrystalMineID, "crystalMine"},
+		{ogame.DeuteriumSynthesizerID, "deuteriumSynthesizer"},
+		{ogame.SolarPlantID, "solarPlant"},
+		{ogame.FusionReactorID, "fusionPlant"},
+		{ogame.MetalStorageID, "metalStorage"},
+		{ogame.CrystalStorageID, "crystalStorage"},
+		{ogame.DeuteriumTankID, "deuteriumStorage"},
+	}
+	facilitiesObjs = []cssObj{
+		{ogame.RoboticsFactoryID, "roboticsFactory"},
+		{ogame.ShipyardID, "shipyard"},
+		{ogame.ResearchLabID, "researchLaboratory"},
+		{ogame.AllianceDepotID, "allianceDepot"},
+		{ogame.MissileSiloID, "missileSilo"},
+		{ogame.NaniteFactoryID, "naniteFactory"},
+		{ogame.TerraformerID, "terraformer"},
+		{ogame.SpaceDockID, "repairDock"},
+		{ogame.LunarBaseID, "moonbase"},
+		{ogame.SensorPhalanxID, "sensorPhalanx"},
+		{ogame.JumpGateID, "jumpGate"},
+	}
+	researchObjs = []cssObj{
+		{ogame.EnergyTechnologyID, "energyTechnology"},
+		{ogame.LaserTechnologyID, "laserTechnology"},
+		{ogame.IonTechnologyID, "ionTechnology"},
+		{ogame.HyperspaceTechnologyID, "hyperspaceTechnology"},
+		{ogame.PlasmaTechnologyID, "plasmaTechnology"},
+		{ogame.CombustionDriveID, "combustionDriveTechnology"},
+		{ogame.ImpulseDriveID, "impulseDriveTechnology"},
+		{ogame.HyperspaceDriveID, "hyperspaceDriveTechnology"},
+		{ogame.EspionageTechnologyID, "espionageTechnology"},
+		{ogame.ComputerTechnologyID, "computerTechnology"},
+		{ogame.AstrophysicsID, "astrophysicsTechnology"},
+		{ogame.IntergalacticResearchNetworkID, "researchNetworkTechnology"},
+		{ogame.GravitonTechnologyID, "gravitonTechnology"},
+		{ogame.WeaponsTechnologyID, "weaponsTechnology"},
+		{ogame.ShieldingTechnologyID, "shieldingTechnology"},
+		{ogame.ArmourTechnologyID, "armorTechnology"},
+	}
+	shipsObjs = []cssObj{
+		{ogame.LightFighterID, "fighterLight"},
+		{ogame.HeavyFighterID, "fighterHeavy"},
+		{ogame.CruiserID, "cruiser"},
+		{ogame.BattleshipID, "battleship"},
+		{ogame.BattlecruiserID, "interceptor"},
+		{ogame.BomberID, "bomber"},
+		{ogame.DestroyerID, "destroyer"},
+		{ogame.DeathstarID, "deathstar"},
+		{ogame.ReaperID, "reaper"},
+		{ogame.PathfinderID, "explorer"},
+		{ogame.SmallCargoID, "transporterSmall"},
+		{ogame.LargeCargoID, "transporterLarge"},
+		{ogame.ColonyShipID, "colonyShip"},
+		{ogame.RecyclerID, "recycler"},
+		{ogame.EspionageProbeID, "espionageProbe"},
+		{ogame.SolarSatelliteID, "solarSatellite"},
+		{ogame.CrawlerID, "resbuggy"},
+	}
+	defensesObjs = []cssObj{
+		{ogame.RocketLauncherID, "rocketLauncher"},
+		{ogame.LightLaserID, "laserCannonLight"},
+		{ogame.HeavyLaserID, "laserCannonHeavy"},
+		{ogame.GaussCannonID, "gaussCannon"},
+		{ogame.IonCannonID, "ionCannon"},
+		{ogame.PlasmaTurretID, "plasmaCannon"},
+		{ogame.SmallShieldDomeID, "shieldDomeSmall"},
+		{ogame.LargeShieldDomeID, "shieldDomeLarge"},
+		{ogame.AntiBallisticMissilesID, "missileInterceptor"},
+		{ogame.InterplanetaryMissilesID, "missileInterplanetary"},
+	}
+)
+
+func coordStr(c ogame.Coordinate) string {
+	return fmt.Sprintf("%d:%d:%d", c.Galaxy, c.System, c.Position)
+}
+
+func resourceTooltip(rows ...int64) string {
+	var sb strings.Builder
+	sb.WriteString("<table>")
+	for _, v := range rows {
+		sb.WriteString("<tr><th></th><td>" + utils.FI64(v) + "</td></tr>")
+	}
+	sb.WriteString("</table>")
+	return sb.String()
+}
+
+// renderFullPage renders an ingame page containing everything the wrapper extracts from any full page.
+func (s *Server) renderFullPage(st *State, page string, planet *Planet, content string) []byte {
+	now := st.Clock.Now()
+	var sb strings.Builder
+	w := func(format string, a ...any) { _, _ = fmt.Fprintf(&sb, format, a...) }
+	w("<!DOCTYPE html>\n<html>\n<head>\n")
+	w(`<meta name="ogame-session" content="%s"/>`+"\n", s.session)
+	w(`<meta name="ogame-version" content="%s"/>`+"\n", st.Version)
+	w(`<meta name="ogame-timestamp" content="%d"/>`+"\n", now.Unix())
+	w(`<meta name="ogame-universe" content="%s"/>`+"\n", s.gameHost(st))
+	w(`<meta name="ogame-universe-name" content="%s"/>`+"\n", html.EscapeString(st.ServerName))
+	w(`<meta name="ogame-language" content="%s"/>`+"\n", st.Lang)
+	w(`<meta name="ogame-player-id" content="%d"/>`+"\n", st.PlayerID)
+	w(`<meta name="ogame-player-name" content="%s"/>`+"\n", html.EscapeString(st.PlayerName))
+	w(`<meta name="ogame-planet-id" content="%d"/>`+"\n", planet.ID)
+	w(`<meta name="ogame-planet-name" content="%s"/>`+"\n", html.EscapeString(planet.Name))
+	w(`<meta name="ogame-planet-coordinates" content="%s"/>`+"\n", coordStr(planet.Coordinate))
+	w(`<meta name="ogame-planet-type" content="planet"/>` + "\n")
+	w("</head>\n")
+	w(`<body id="ingamepage">` + "\n")
+	w(`<div id="resources">`)
+	res := planet.Resources
+	w(`<div id="metal_box" title="%s"></div>`, html.EscapeString(resourceTooltip(res.Metal, 0, 0)))
+	w(`<div id="crystal_box" title="%s"></div>`, html.EscapeString(resourceTooltip(res.Crystal, 0, 0)))
+	w(`<div id="deuterium_box" title="%s"></div>`, html.EscapeString(resourceTooltip(res.Deuterium, 0, 0)))
+	w(`<div id="energy_box" title="%s"></div>`, html.EscapeString(resourceTooltip(res.Energy, 0, 0)))
+	w(`<div id="darkmatter_box" title="%s"></div>`, html.EscapeString(resourceTooltip(st.Darkmatter, 0, 0)))
+	w("</div>\n")
+	w(`<div class="OGameClock">%s</div>`+"\n", now.UTC().Format("02.01.2006 15:04:05"))
+	w(`<div id="countColonies"><p class="textCenter"><span>%d/%d</span></p></div>`+"\n", len(st.Planets)-1, max(int64(len(st.Planets)-1), 1))
+	w(`<div id="planetList">` + "\n")
+	for _, p := range st.Planets {
+		title := fmt.Sprintf("<b>%s [%s]</b><br/>%skm (%d/%d)<br>%d°C to %d°C",
+			html.EscapeString(p.Name), coordStr(p.Coordinate), utils.FI64(p.Diameter), p.Fields.Built, p.Fields.Total, p.Temperature.Min, p.Temperature.Max)
+		w(`<div class="smallplanet" id="planet-%d"><a class="planetlink" href="https://%s/game/index.php?page=ingame&amp;component=%s&amp;cp=%d" title="%s"><img class="planetPic" src="planet.png"/><span class="planet-name">%s</span><span class="planet-koords">[%s]</span></a></div>`+"\n",
+			p.ID, s.gameHost(st), page, p.ID, html.EscapeString(title), html.EscapeString(p.Name), coordStr(p.Coordinate))
+	}
+	w("</div>\n")
+	w(`<div id="middle">` + "\n")
+	sb.WriteString(content)
+	w("</div>\n")
+	w("<script>\n")
+	w(`var currentPage = "%s";`+"\n", page)
+	w(`var token = "%s";`+"\n", st.token)
+	w(`window.token = '%s';`+"\n", st.token)
+	w(`var nodeUrl = "https:\/\/%s:%s\/socket.io\/socket.io.js";`+"\n", s.chatHost, s.chatPort)
+	w(`var ajaxChatToken = '%s';`+"\n", st.token)
+	w(`textContent[7] = "0 (Place 1 of 1)";` + "\n")
+	w(`textContent[9] = "0";` + "\n")
+	w("</script>\n")
+	w("</body>\n</html>\n")
+	return []byte(sb.String())
+}
+
+func levelsHTML(objs []cssObj, get func(ogame.ID) int64) string {
+	var sb strings.Builder
+	sb.WriteString(`<ul id="technologies">`)
+	for _, o := range objs {
+		_, _ = fmt.Fprintf(&sb, `<li class="technology %s" data-technology="%d"><span class="%s"><span class="level" data-value="%d">%d</span></span></li>`,
+			o.Name, o.ID, o.Name, get(o.ID), get(o.ID))
+	}
+	sb.WriteString("</ul>")
+	return sb.String()
+}
+
+func amountsHTML(objs []cssObj, get func(ogame.ID) int64) string {
+	var sb strings.Builder
+	sb.WriteString(`<ul id="technologies">`)
+	for _, o := range objs {
+		_, _ = fmt.Fprintf(&sb, `<li class="technology %s" data-technology="%d"><span class="%s"><span class="amount" data-value="%d">%d</span></span></li>`,
+			o.Name, o.ID, o.Name, get(o.ID), get(o.ID))
+	}
+	sb.WriteString("</ul>")
+	return sb.String()
+}
+
+func constructionHTML(c *construction, countdownClass, cancelFn string) string {
+	if c == nil {
+		return `<table class="construction active"><tbody><tr><td colspan="2" class="idle"></td></tr></tbody></table>`
+	}
+	name := ogame.Objs.ByID(c.ID).GetName()
+	return fmt.Sprintf(`<table class="construction active"><tbody>`+
+		`<tr><th colspan="2">%s</th></tr>`+
+		`<tr class="data"><td class="desc"><span class="countdown"><time class="%s" data-end="%d"></time></span><span class="level">Level %d</span></td>`+
+		`<td><a class="abortNow" onclick="%s(%d,%d,&quot;Cancel %s?&quot;); return false;"></a></td></tr>`+
+		`</tbody></table>`,
+		html.EscapeString(name), countdownClass, c.End.Unix(), c.Level, cancelFn, c.ID, c.ListID, html.EscapeString(name))
+}
+
+func (s *Server) overviewContent(st *State, planet *Planet) string {
+	var research *construction
+	if st.research != nil {
+		research = st.research
+	}
+	return `<div id="overviewcomponent">` +
+		constructionHTML(planet.building, "buildingCountdown", "cancelbuilding") +
+		constructionHTML(nil, "lfbuildingCountdown", "cancellfbuilding") +
+		constructionHTML(research, "researchCountdown", "cancelresearch") +
+		constructionHTML(nil, "lfResearchCountdown", "cancellfresearch") +
+		`</div>`
+}
+
+func (s *Server) movementContent(st *State) string {
+	var sb strings.Builder
+	w := func(format string, a ...any) { _, _ = fmt.Fprintf(&sb, format, a...) }
+	inUse, expInUse := st.slotsInUse()
+	now := st.Clock.Now()
+	w(`<div id="movementcomponent">`)
+	w(`<div class="fleetStatus"><span class="fleetSlots">Fleets: <span class="current">%d</span>/<span class="all">%d</span></span>`, inUse, st.FleetSlots)
+	w(`<span class="expSlots">Expeditions: <span class="current">%d</span>/<span class="all">%d</span></span></div>`, expInUse, st.ExpeditionSlots)
+	var script strings.Builder
+	for _, f := range st.Fleets {
+		arriveIn := max(int64(f.ArrivalTime.Sub(now).Seconds()), 0)
+		if f.ReturnFlight {
+			arriveIn = max(int64(f.BackTime.Sub(now).Seconds()), 0)
+		}
+		w(`<div id="fleet%d" class="fleetDetails detailsOpened" data-mission-type="%d" data-return-flight="%t" data-arrival-time="%d">`,
+			f.ID, f.Mission, f.ReturnFlight, f.BackTime.Unix())
+		w(`<span class="timer" id="timer_%d"></span>`, f.ID)
+		w(`<span class="originCoords"><a href="#">[%s]</a></span><span class="originPlanet"><figure class="planetIcon planet"></figure></span>`, coordStr(f.Origin))
+		destFigure := "planet"
+		if f.Destination.Type == ogame.MoonType {
+			destFigure = "moon"
+		} else if f.Destination.Type == ogame.DebrisType {
+			destFigure = "tf"
+		}
+		w(`<span class="destinationCoords"><a href="#">[%s]</a></span><span class="destinationPlanet"><figure class="planetIcon %s"></figure></span>`, coordStr(f.Destination), destFigure)
+		w(`<div class="origin"><img title="Start time:| %s"/></div>`, html.EscapeString(f.StartTime.UTC().Format("02.01.2006<br>15:04:05")))
+		if !f.ReturnFlight {
+			w(`<span class="reversal" ref="%d"><a class="icon_link" href="https://%s/game/index.php?page=ingame&amp;component=movement&amp;return=%d&amp;token=%s"></a></span>`,
+				f.ID, s.gameHost(st), f.ID, st.token)
+		}
+		w(`<a class="openCloseDetails" data-mission-id="%d" data-end-time="%d"></a>`, f.ID, f.ArrivalTime.Unix())
+		w(`<table class="fleetinfo"><tr><th colspan="2">Ships:</th></tr>`)
+		for shipID, nb := range f.Ships.Iter() {
+			if nb > 0 {
+				w(`<tr><td>%s:</td><td class="value">%s</td></tr>`, html.EscapeString(ogame.Objs.ByID(shipID).GetName()), utils.FI64(nb))
+			}
+		}
+		w(`<tr><td colspan="2">&nbsp;</td></tr><tr><th colspan="2">Shipment:</th></tr>`)
+		w(`<tr><td>Metal:</td><td class="value">%s</td></tr>`, utils.FI64(f.Resources.Metal))
+		w(`<tr><td>Crystal:</td><td class="value">%s</td></tr>`, utils.FI64(f.Resources.Crystal))
+		w(`<tr><td>Deuterium:</td><td class="value">%s</td></tr>`, utils.FI64(f.Resources.Deuterium))
+		w(`</table>`)
+		w(`</div>`)
+		_, _ = fmt.Fprintf(&script, `new SimpleCountdownTimer("#timer_%d", %d, "");`+"\n", f.ID, arriveIn)
+	}
+	w(`</div>`)
+	w(`<script>%s</script>`, script.String())
+	return sb.String()
+}
+
+func (s *Server) fleetdispatchContent(st *State, planet *Planet) string {
+	type shipOnPlanet struct {
+		ID     int64 `json:"id"`
+		Number int64 `json:"number"`
+	}
+	shipsOnPlanet := make([]shipOnPlanet, 0)
+	for shipID, nb := range planet.Ships.Iter() {
+		if nb > 0 {
+			shipsOnPlanet = append(shipsOnPlanet, shipOnPlanet{ID: int64(shipID), Number: nb})
+		}
+	}
+	by, _ := json.Marshal(shipsOnPlanet)
+	inUse, expInUse := st.slotsInUse()
+	return fmt.Sprintf(`<div id="fleetdispatchcomponent">`+
+		`<div id="slots"><div class="fleft"><span>Fleets:</span> %d/%d</div><div class="fleft"><span>Expeditions:</span> %d/%d</div></div>`+
+		`<form name="sendForm"><input type="hidden" name="token" value="%s"/><input type="hidden" name="expeditionFleetTemplateId" value=""/></form>`+
+		`<script>var shipsOnPlanet = %s;</script>`+
+		`</div>`, inUse, st.FleetSlots, expInUse, st.ExpeditionSlots, st.token, string(by))
+}
+
+// resourcesJSON renders the "fetchResources" json for a planet
+func resourcesJSON(st *State, planet *Planet) []byte {
+	type amountStorage struct {
+		Amount  float64 `json:"amount"`
+		Storage float64 `json:"storage"`
+		Tooltip string  `json:"tooltip"`
+	}
+	type amount struct {
+		Amount  float64 `json:"amount"`
+		Tooltip string  `json:"tooltip"`
+	}
+	res := planet.Resources
+	var out struct {
+		Resources struct {
+			Metal      amountStorage `json:"metal"`
+			Crystal    amountStorage `json:"crystal"`
+			Deuterium  amountStorage `json:"deuterium"`
+			Energy     amount        `json:"energy"`
+			Darkmatter amount        `json:"darkmatter"`
+			Population amount        `json:"population"`
+			Food       amount        `json:"food"`
+		} `json:"resources"`
+		HonorScore int64 `json:"honorScore"`
+	}
+	metalStorage := ogame.MetalStorage.Capacity(planet.Levels[ogame.MetalStorageID])
+	crystalStorage := ogame.CrystalStorage.Capacity(planet.Levels[ogame.CrystalStorageID])
+	deuteriumStorage := ogame.DeuteriumTank.Capacity(planet.Levels[ogame.DeuteriumTankID])
+	out.Resources.Metal = amountStorage{float64(res.Metal), float64(metalStorage), resourceTooltip(res.Metal, metalStorage, 0)}
+	out.Resources.Crystal = amountStorage{float64(res.Crystal), float64(crystalStorage), resourceTooltip(res.Crystal, crystalStorage, 0)}
+	out.Resources.Deuterium = amountStorage{float64(res.Deuterium), float64(deuteriumStorage), resourceTooltip(res.Deuterium, deuteriumStorage, 0)}
+	out.Resources.Energy = amount{float64(res.Energy), resourceTooltip(res.Energy, 0, 0)}
+	out.Resources.Darkmatter = amount{float64(st.Darkmatter), resourceTooltip(st.Darkmatter, 0, 0)}
+	out.Resources.Population = amount{float64(res.Population), resourceTooltip(res.Population)}
+	out.Resources.Food = amount{float64(res.Food), resourceTooltip(res.Food)}
+	by, _ := json.Marshal(out)
+	return by
+}
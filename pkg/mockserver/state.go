@@ -0,0 +1,437 @@
+package mockserver
+
+import (
+	"errors"
+	"math"
+	"sync"
+	"time"
+
+	"github.com/alaingilbert/clockwork"
+	"github.com/alaingilbert/ogame/pkg/ogame"
+)
+
+// Errors returned by the in-memory game when an action is refused.
+// Their messages and codes mimic what ogame answers in the same situation.
+var (
+	ErrNotEnoughResources = errors.New("not enough resources")
+	ErrQueueBusy          = errors.New("queue is busy")
+	ErrNoShipsSelected    = errors.New("no ships selected")
+	ErrNotEnoughShips     = errors.New("not enough ships")
+	ErrNoSlotsAvailable   = errors.New("no fleet slots available")
+	ErrFleetNotFound      = errors.New("fleet not found")
+	ErrCelestialNotFound  = errors.New("celestial not found")
+	ErrInvalidTechnology  = errors.New("invalid technology")
+)
+
+// Planet in-memory state of a planet owned by the mock player
+type Planet struct {
+	ID          ogame.CelestialID
+	Name        string
+	Coordinate  ogame.Coordinate
+	Diameter    int64
+	Fields      ogame.Fields
+	Temperature ogame.Temperature
+	Resources   ogame.Resources
+	Levels      map[ogame.ID]int64 // Buildings and facilities levels
+	Ships       ogame.ShipsInfos
+	Defenses    ogame.DefensesInfos
+
+	building *construction
+}
+
+// Fleet in-memory state of a fleet in flight
+type Fleet struct {
+	ID           ogame.FleetID
+	Origin       ogame.Coordinate
+	Destination  ogame.Coordinate
+	Mission      ogame.MissionID
+	Ships        ogame.ShipsInfos
+	Resources    ogame.Resources
+	StartTime    time.Time
+	ArrivalTime  time.Time
+	BackTime     time.Time
+	ReturnFlight bool
+}
+
+type construction struct {
+	ID       ogame.ID
+	Level    int64
+	ListID   int64
+	PlanetID ogame.CelestialID
+	End      time.Time
+}
+
+// State mutable game state served by the mock server.
+// Every exported field can be modified before the server starts, afterward use Do to get exclusive access.
+type State struct {
+	sync.Mutex
+	Clock           clockwork.Clock
+	Username        string
+	Password        string
+	PlayerID        int64
+	PlayerName      string
+	ServerName      string
+	ServerNumber    int64
+	Lang            string
+	Version         string
+	Speed           int64
+	FleetSpeed      int64
+	Galaxies        int64
+	Systems         int64
+	Darkmatter      int64
+	FleetSlots      int64
+	ExpeditionSlots int64
+	Researches      map[ogame.ID]int64
+	Planets         []*Planet
+	Fleets          []*Fleet
+
+	research    *construction
+	current     ogame.CelestialID
+	token       string
+	nextFleetID ogame.FleetID
+	nextListID  int64
+}
+
+// NewState creates a new game state with a single homeworld on server "Bellatrix" (s1-en)
+func NewState() *State {
+	return &State{
+		Clock:           clockwork.NewRealClock(),
+		Username:        "user@example.com",
+		Password:        "password",
+		PlayerID:        100001,
+		PlayerName:      "Commander",
+		ServerName:      "Bellatrix",
+		ServerNumber:    1,
+		Lang:            "en",
+		Version:         "12.0.0",
+		Speed:           1,
+		FleetSpeed:      1,
+		Galaxies:        9,
+		Systems:         499,
+		Darkmatter:      8000,
+		FleetSlots:      2,
+		ExpeditionSlots: 1,
+		Researches: map[ogame.ID]int64{
+			ogame.EnergyTechnologyID:   1,
+			ogame.CombustionDriveID:    2,
+			ogame.ComputerTechnologyID: 1,
+		},
+		Planets: []*Planet{{
+			ID:          33620000,
+			Name:        "Homeworld",
+			Coordinate:  ogame.Coordinate{Galaxy: 1, System: 1, Position: 8, Type: ogame.PlanetType},
+			Diameter:    12800,
+			Fields:      ogame.Fields{Built: 10, Total: 193},
+			Temperature: ogame.Temperature{Min: 14, Max: 54},
+			Resources:   ogame.Resources{Metal: 100000, Crystal: 50000, Deuterium: 25000, Energy: 20},
+			Levels: map[ogame.ID]int64{
+				ogame.MetalMineID:       5,
+				ogame.CrystalMineID:     3,
+				ogame.SolarPlantID:      4,
+				ogame.RoboticsFactoryID: 2,
+				ogame.ShipyardID:        2,
+				ogame.ResearchLabID:     1,
+			},
+			Ships: ogame.ShipsInfos{SmallCargo: 10, LightFighter: 20, EspionageProbe: 5},
+		}},
+		token:       "0123456789abcdef0123456789abcdef",
+		nextFleetID: 1000,
+		nextListID:  3000,
+	}
+}
+
+// Do executes clb with exclusive access to the state.
+// Pending constructions and fleets are resolved before clb is called.
+func (s *State) Do(clb func(*State)) {
+	s.Lock()
+	defer s.Unlock()
+	s.update()
+	clb(s)
+}
+
+// GetPlanet returns the planet with the given id
+func (s *State) GetPlanet(id ogame.CelestialID) *Planet {
+	for _, p := range s.Planets {
+		if p.ID == id {
+			return p
+		}
+	}
+	return nil
+}
+
+func (s *State) planetAt(coord ogame.Coordinate) *Planet {
+	for _, p := range s.Planets {
+		if p.Coordinate.Equal(coord) {
+			return p
+		}
+	}
+	return nil
+}
+
+func (s *State) getFleet(id ogame.FleetID) *Fleet {
+	for _, f := range s.Fleets {
+		if f.ID == id {
+			return f
+		}
+	}
+	return nil
+}
+
+func (s *State) researchLevel(id ogame.ID) int64 {
+	return s.Researches[id]
+}
+
+func (s *State) getResearches() (out ogame.Researches) {
+	out.EspionageTechnology = s.Researches[ogame.EspionageTechnologyID]
+	out.ComputerTechnology = s.Researches[ogame.ComputerTechnologyID]
+	out.WeaponsTechnology = s.Researches[ogame.WeaponsTechnologyID]
+	out.ShieldingTechnology = s.Researches[ogame.ShieldingTechnologyID]
+	out.ArmourTechnology = s.Researches[ogame.ArmourTechnologyID]
+	out.EnergyTechnology = s.Researches[ogame.EnergyTechnologyID]
+	out.HyperspaceTechnology = s.Researches[ogame.HyperspaceTechnologyID]
+	out.CombustionDrive = s.Researches[ogame.CombustionDriveID]
+	out.ImpulseDrive = s.Researches[ogame.ImpulseDriveID]
+	out.HyperspaceDrive = s.Researches[ogame.HyperspaceDriveID]
+	out.LaserTechnology = s.Researches[ogame.LaserTechnologyID]
+	out.IonTechnology = s.Researches[ogame.IonTechnologyID]
+	out.PlasmaTechnology = s.Researches[ogame.PlasmaTechnologyID]
+	out.IntergalacticResearchNetwork = s.Researches[ogame.IntergalacticResearchNetworkID]
+	out.Astrophysics = s.Researches[ogame.AstrophysicsID]
+	out.GravitonTechnology = s.Researches[ogame.GravitonTechnologyID]
+	return
+}
+
+func (p *Planet) getFacilities() (out ogame.Facilities) {
+	out.RoboticsFactory = p.Levels[ogame.RoboticsFactoryID]
+	out.Shipyard = p.Levels[ogame.ShipyardID]
+	out.ResearchLab = p.Levels[ogame.ResearchLabID]
+	out.AllianceDepot = p.Levels[ogame.AllianceDepotID]
+	out.MissileSilo = p.Levels[ogame.MissileSiloID]
+	out.NaniteFactory = p.Levels[ogame.NaniteFactoryID]
+	out.Terraformer = p.Levels[ogame.TerraformerID]
+	out.SpaceDock = p.Levels[ogame.SpaceDockID]
+	out.LunarBase = p.Levels[ogame.LunarBaseID]
+	out.SensorPhalanx = p.Levels[ogame.SensorPhalanxID]
+	out.JumpGate = p.Levels[ogame.JumpGateID]
+	return
+}
+
+// Resolve constructions and fleets that are due.
+func (s *State) update() {
+	now := s.Clock.Now()
+	for _, p := range s.Planets {
+		if p.building != nil && !now.Before(p.building.End) {
+			if p.Levels == nil {
+				p.Levels = make(map[ogame.ID]int64)
+			}
+			p.Levels[p.building.ID] = p.building.Level
+			p.Fields.Built++
+			p.building = nil
+		}
+	}
+	if s.research != nil && !now.Before(s.research.End) {
+		if s.Researches == nil {
+			s.Researches = make(map[ogame.ID]int64)
+		}
+		s.Researches[s.research.ID] = s.research.Level
+		s.research = nil
+	}
+	fleets := make([]*Fleet, 0, len(s.Fleets))
+	for _, f := range s.Fleets {
+		if !f.ReturnFlight && !now.Before(f.ArrivalTime) {
+			s.fleetArrived(f)
+		}
+		if f.ReturnFlight && !now.Before(f.BackTime) {
+			if origin := s.planetAt(f.Origin); origin != nil {
+				origin.Ships.Add(f.Ships)
+				origin.Resources = origin.Resources.Add(f.Resources)
+			}
+			continue
+		}
+		fleets = append(fleets, f)
+	}
+	s.Fleets = fleets
+}
+
+func (s *State) fleetArrived(f *Fleet) {
+	f.ReturnFlight = true
+	dest := s.planetAt(f.Destination)
+	if dest == nil {
+		return
+	}
+	switch f.Mission {
+	case ogame.Transport:
+		dest.Resources = dest.Resources.Add(f.Resources)
+		f.Resources = ogame.Resources{}
+	case ogame.Park:
+		dest.Resources = dest.Resources.Add(f.Resources)
+		dest.Ships.Add(f.Ships)
+		f.Resources = ogame.Resources{}
+		f.Ships = ogame.ShipsInfos{}
+		f.BackTime = f.ArrivalTime
+	}
+}
+
+// build schedules the construction of a building/research, or instantly adds ships/defenses to the planet.
+func (s *State) build(planetID ogame.CelestialID, id ogame.ID, nbr int64) error {
+	p := s.GetPlanet(planetID)
+	if p == nil {
+		return ErrCelestialNotFound
+	}
+	obj := ogame.Objs.ByID(id)
+	if obj == nil {
+		return ErrInvalidTechnology
+	}
+	lfBonuses := ogame.NewLfBonuses()
+	facilities := p.getFacilities()
+	now := s.Clock.Now()
+	switch {
+	case id.IsShip(), id.IsDefense():
+		nbr = max(nbr, 1)
+		price := obj.GetPrice(nbr, *lfBonuses)
+		if !p.Resources.CanAfford(price) {
+			return ErrNotEnoughResources
+		}
+		p.Resources = p.Resources.Sub(price)
+		if id.IsShip() {
+			p.Ships.AddShips(id, nbr)
+		} else {
+			p.Defenses.Set(id, p.Defenses.ByID(id)+nbr)
+		}
+	case id.IsBuilding():
+		if p.building != nil {
+			return ErrQueueBusy
+		}
+		level := p.Levels[id] + 1
+		price := obj.GetPrice(level, *lfBonuses)
+		if !p.Resources.CanAfford(price) {
+			return ErrNotEnoughResources
+		}
+		p.Resources = p.Resources.Sub(price)
+		duration := obj.ConstructionTime(level, s.Speed, facilities, *lfBonuses, ogame.NoClass, false)
+		s.nextListID++
+		p.building = &construction{ID: id, Level: level, ListID: s.nextListID, PlanetID: p.ID, End: now.Add(duration)}
+	case id.IsTech():
+		if s.research != nil {
+			return ErrQueueBusy
+		}
+		level := s.researchLevel(id) + 1
+		price := obj.GetPrice(level, *lfBonuses)
+		if !p.Resources.CanAfford(price) {
+			return ErrNotEnoughResources
+		}
+		p.Resources = p.Resources.Sub(price)
+		duration := obj.ConstructionTime(level, s.Speed, facilities, *lfBonuses, ogame.NoClass, false)
+		s.nextListID++
+		s.research = &construction{ID: id, Level: level, ListID: s.nextListID, PlanetID: p.ID, End: now.Add(duration)}
+	default:
+		return ErrInvalidTechnology
+	}
+	return nil
+}
+
+// cancel cancels the construction identified by techID/listID and refunds its price.
+func (s *State) cancel(techID ogame.ID, listID int64) error {
+	refund := func(c *construction) {
+		if p := s.GetPlanet(c.PlanetID); p != nil {
+			p.Resources = p.Resources.Add(ogame.Objs.ByID(c.ID).GetPrice(c.Level, *ogame.NewLfBonuses()))
+		}
+	}
+	for _, p := range s.Planets {
+		if c := p.building; c != nil && c.ID == techID && c.ListID == listID {
+			refund(c)
+			p.building = nil
+			return nil
+		}
+	}
+	if c := s.research; c != nil && c.ID == techID && c.ListID == listID {
+		refund(c)
+		s.research = nil
+		return nil
+	}
+	return ErrInvalidTechnology
+}
+
+func (s *State) slotsInUse() (fleets, expeditions int64) {
+	for _, f := range s.Fleets {
+		fleets++
+		if f.Mission == ogame.Expedition {
+			expeditions++
+		}
+	}
+	return
+}
+
+// sendFleet takes ships and resources off the origin planet and creates a new fleet in flight.
+func (s *State) sendFleet(planetID ogame.CelestialID, ships ogame.ShipsInfos, where ogame.Coordinate, mission ogame.MissionID,
+	resources ogame.Resources, speed ogame.Speed) (*Fleet, error) {
+	p := s.GetPlanet(planetID)
+	if p == nil {
+		return nil, ErrCelestialNotFound
+	}
+	if !ships.HasShips() {
+		return nil, ErrNoShipsSelected
+	}
+	if !p.Ships.Has(ships) {
+		return nil, ErrNotEnoughShips
+	}
+	if !p.Resources.CanAfford(resources) {
+		return nil, ErrNotEnoughResources
+	}
+	inUse, expInUse := s.slotsInUse()
+	if inUse >= s.FleetSlots || (mission == ogame.Expedition && expInUse >= s.ExpeditionSlots) {
+		return nil, ErrNoSlotsAvailable
+	}
+	p.Ships.Sub(ships)
+	p.Resources = p.Resources.Sub(resources)
+	now := s.Clock.Now()
+	flightTime := s.flightTime(p.Coordinate, where, ships, speed)
+	s.nextFleetID++
+	fleet := &Fleet{
+		ID:          s.nextFleetID,
+		Origin:      p.Coordinate,
+		Destination: where,
+		Mission:     mission,
+		Ships:       ships,
+		Resources:   resources,
+		StartTime:   now,
+		ArrivalTime: now.Add(flightTime),
+		BackTime:    now.Add(2 * flightTime),
+	}
+	s.Fleets = append(s.Fleets, fleet)
+	return fleet, nil
+}
+
+// cancelFleet recalls a fleet, it comes back home in the same amount of time it has been flying.
+func (s *State) cancelFleet(fleetID ogame.FleetID) error {
+	f := s.getFleet(fleetID)
+	if f == nil || f.ReturnFlight {
+		return ErrFleetNotFound
+	}
+	now := s.Clock.Now()
+	f.ReturnFlight = true
+	f.BackTime = now.Add(now.Sub(f.StartTime))
+	return nil
+}
+
+// Simplified version of the ogame flight time formula, fuel consumption is not emulated.
+func (s *State) flightTime(origin, destination ogame.Coordinate, ships ogame.ShipsInfos, speed ogame.Speed) time.Duration {
+	var distance float64
+	abs := func(v int64) float64 { return math.Abs(float64(v)) }
+	if origin.Galaxy != destination.Galaxy {
+		distance = 20_000 * abs(origin.Galaxy-destination.Galaxy)
+	} else if origin.System != destination.System {
+		distance = 2_700 + 95*abs(origin.System-destination.System)
+	} else if origin.Position != destination.Position {
+		distance = 1_000 + 5*abs(origin.Position-destination.Position)
+	} else {
+		distance = 5
+	}
+	shipSpeed := ships.Speed(s.getResearches(), *ogame.NewLfBonuses(), ogame.NoClass, ogame.NoAllianceClass)
+	if shipSpeed <= 0 {
+		shipSpeed = 1
+	}
+	speedPct := max(float64(speed), 1) * 10
+	secs := (35_000/speedPct*math.Sqrt(distance*10/float64(shipSpeed)) + 10) / float64(max(s.FleetSpeed, 1))
+	return time.Duration(math.Round(secs)) * time.Second
+}
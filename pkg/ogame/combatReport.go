@@ -0,0 +1,91 @@
+package ogame
+
+import "time"
+
+// Winner of a combat
+const (
+	AttackerWinner = "attacker"
+	DefenderWinner = "defender"
+	DrawWinner     = "draw"
+)
+
+// CombatReport detailed combat report
+type CombatReport struct {
+	ID                  int64
+	Date                time.Time
+	Destination         Coordinate
+	Winner              string // attacker | defender | draw
+	Attackers           []CombatReportParticipant
+	Defenders           []CombatReportParticipant
+	Rounds              []CombatReportRound
+	Loot                Resources
+	LootPercentage      int64
+	Debris              Resources
+	AttackerLostValue   int64 // Value of the units lost by the attackers
+	DefenderLostValue   int64 // Value of the units lost by the defenders
+	AttackerHonorPoints int64
+	DefenderHonorPoints int64
+	MoonChance          int64 // Percentage
+	MoonCreated         bool
+	RepairedDefenses    DefensesInfos
+}
+
+// CombatReportParticipant fleet of a player taking part in the combat, as it was before the first round
+type CombatReportParticipant struct {
+	FleetID          FleetID // 0 for the units stationed on the defender celestial
+	PlayerID         int64
+	PlayerName       string
+	AllianceTag      string
+	CharacterClass   CharacterClass
+	Origin           Coordinate
+	WeaponPercentage int64 // Bonus of the weapons technology (10 per level, plus class and lifeform bonuses)
+	ShieldPercentage int64
+	ArmourPercentage int64
+	Ships            ShipsInfos
+	Defenses         DefensesInfos
+}
+
+// CombatReportRound state of the fleets at the end of a round
+type CombatReportRound struct {
+	Attackers              []CombatReportRoundFleet
+	Defenders              []CombatReportRoundFleet
+	AttackerHits           int64
+	DefenderHits           int64
+	AttackerAbsorbedDamage int64
+	DefenderAbsorbedDamage int64
+	AttackerFullStrength   int64
+	DefenderFullStrength   int64
+}
+
+// CombatReportRoundFleet units of a participant left at the end of a round, and the ones lost during the round
+type CombatReportRoundFleet struct {
+	FleetID      FleetID
+	Ships        ShipsInfos
+	Defenses     DefensesInfos
+	LostShips    ShipsInfos
+	LostDefenses DefensesInfos
+}
+
+// setCombatUnit sets the number of ships or defenses of the given id
+func setCombatUnit(ships *ShipsInfos, defenses *DefensesInfos, id ID, nbr int64) {
+	if id.IsShip() {
+		ships.Set(id, nbr)
+	} else if id.IsDefense() {
+		defenses.Set(id, nbr)
+	}
+}
+
+// SetUnit sets the number of ships or defenses of the given id
+func (p *CombatReportParticipant) SetUnit(id ID, nbr int64) {
+	setCombatUnit(&p.Ships, &p.Defenses, id, nbr)
+}
+
+// SetUnit sets the number of ships or defenses of the given id left at the end of the round
+func (f *CombatReportRoundFleet) SetUnit(id ID, nbr int64) {
+	setCombatUnit(&f.Ships, &f.Defenses, id, nbr)
+}
+
+// SetLostUnit sets the number of ships or defenses of the given id lost during the round
+func (f *CombatReportRoundFleet) SetLostUnit(id ID, nbr int64) {
+	setCombatUnit(&f.LostShips, &f.LostDefenses, id, nbr)
+}
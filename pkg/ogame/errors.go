@@ -15,6 +15,9 @@ var ErrMobileView = errors.New("mobile view not supported")
 // ErrInvalidPlanetID returned when a planet id is invalid
 var ErrInvalidPlanetID = errors.New("invalid planet id")
 
+// ErrCelestialNotFound returned when the planet or moon is not one of the celestials of the player
+var ErrCelestialNotFound = errors.New("celestial not found")
+
 // ErrAllSlotsInUse returned when all slots are in use
 var ErrAllSlotsInUse = errors.New("all slots are in use")
 
@@ -0,0 +1,272 @@
+package ogame
+
+import (
+	"sort"
+	"strings"
+
+	"github.com/alaingilbert/ogame/pkg/utils"
+)
+
+// ExpeditionOutcome result of an expedition
+type ExpeditionOutcome int64
+
+// Expedition outcomes
+const (
+	UnknownOutcome ExpeditionOutcome = iota
+	ResourcesOutcome
+	ShipsOutcome
+	DarkMatterOutcome
+	ItemOutcome
+	PiratesOutcome
+	AliensOutcome
+	BlackHoleOutcome
+	DelayOutcome
+	EarlyReturnOutcome
+	MerchantOutcome
+	NothingOutcome
+)
+
+func (o ExpeditionOutcome) String() string {
+	switch o {
+	case ResourcesOutcome:
+		return "Resources"
+	case ShipsOutcome:
+		return "Ships"
+	case DarkMatterOutcome:
+		return "DarkMatter"
+	case ItemOutcome:
+		return "Item"
+	case PiratesOutcome:
+		return "Pirates"
+	case AliensOutcome:
+		return "Aliens"
+	case BlackHoleOutcome:
+		return "BlackHole"
+	case DelayOutcome:
+		return "Delay"
+	case EarlyReturnOutcome:
+		return "EarlyReturn"
+	case MerchantOutcome:
+		return "Merchant"
+	case NothingOutcome:
+		return "Nothing"
+	default:
+		return "Unknown"
+	}
+}
+
+// ExpeditionOutcomePhrases lowercase phrases of the expedition messages of the game, by language and outcome.
+// Outcomes are tested in the order of ExpeditionOutcomesOrder, the first one having a phrase in the message wins.
+// Single words are avoided, they are found in the messages of other outcomes
+// (eg: "alien" in the merchant message and in a message of an expedition that found nothing).
+var ExpeditionOutcomePhrases = map[string]map[ExpeditionOutcome][]string{
+	"en": {
+		ItemOutcome:        {"has been added to the inventory", "have been added to the inventory"},
+		ShipsOutcome:       {"the following ships are now part of the fleet"},
+		DarkMatterOutcome:  {"small amount of dark matter", "extract the dark matter"},
+		ResourcesOutcome:   {"resources could be harvested", "have been captured"},
+		BlackHoleOutcome:   {"the only thing left from the expedition", "core meltdown of the lead ship", "opening of a black hole", "the fleet is lost forever"},
+		AliensOutcome:      {"exotic looking ships attacked", "first contact with an unknown species", "an unknown species attacks"},
+		PiratesOutcome:     {"space pirates", "primitive barbarians", "very drunk pirates", "fight some pirates"},
+		MerchantOutcome:    {"representative with goods to trade"},
+		DelayOutcome:       {"return with a big delay", "a lot more time than originally planned", "return later than expected", "take longer than expected"},
+		EarlyReturnOutcome: {"returns home earlier than expected", "return trip being expedited", "returns home a bit earlier", "shorten the flight back"},
+	},
+	"de": {
+		ItemOutcome:        {"dem inventar hinzugefügt"},
+		ShipsOutcome:       {"folgende schiffe schlossen sich der flotte an"},
+		DarkMatterOutcome:  {"dunkle materie", "dunkler materie"},
+		ResourcesOutcome:   {"wurden erbeutet", "wurde erbeutet"},
+		BlackHoleOutcome:   {"nur noch folgender funkspruch", "kernschmelze des führungsschiffes", "eines sich öffnenden schwarzen lochs"},
+		AliensOutcome:      {"fremdartig anmutende schiffe", "mit einer unbekannten spezies", "eine unbekannte spezies greift"},
+		PiratesOutcome:     {"weltraumpiraten", "primitive barbaren", "betrunkenen piraten", "ein paar piraten"},
+		MerchantOutcome:    {"repräsentanten mit handelswaren"},
+		DelayOutcome:       {"mit großer verspätung", "länger dauern als", "später als erwartet"},
+		EarlyReturnOutcome: {"früher als erwartet", "etwas früher zurück"},
+	},
+	"fr": {
+		ItemOutcome:        {"ajouté à l'inventaire", "ajoutés à l'inventaire"},
+		ShipsOutcome:       {"font maintenant partie de la flotte"},
+		DarkMatterOutcome:  {"antimatière"},
+		ResourcesOutcome:   {"ont été récupéré", "a été récupéré"},
+		BlackHoleOutcome:   {"seule chose qui reste de l'expédition", "fusion du cœur du vaisseau amiral", "ouverture d'un trou noir"},
+		AliensOutcome:      {"vaisseaux d'apparence exotique", "avec une espèce inconnue", "une espèce inconnue attaque"},
+		PiratesOutcome:     {"pirates de l'espace", "barbares primitifs", "pirates ivres"},
+		MerchantOutcome:    {"représentant avec des marchandises"},
+		DelayOutcome:       {"avec un retard important", "plus de temps que prévu", "plus tard que prévu"},
+		EarlyReturnOutcome: {"plus tôt que prévu"},
+	},
+	"es": {
+		ItemOutcome:        {"añadido al inventario"},
+		ShipsOutcome:       {"ahora forman parte de la flota"},
+		DarkMatterOutcome:  {"materia oscura"},
+		ResourcesOutcome:   {"sido capturad"},
+		BlackHoleOutcome:   {"lo único que queda de la expedición", "fusión del núcleo de la nave insignia", "apertura de un agujero negro"},
+		AliensOutcome:      {"naves de aspecto exótico", "con una especie desconocida", "una especie desconocida ataca"},
+		PiratesOutcome:     {"piratas espaciales", "bárbaros primitivos", "piratas borrachos"},
+		MerchantOutcome:    {"representante con mercancías"},
+		DelayOutcome:       {"con un gran retraso", "más tiempo de lo previsto", "más tarde de lo previsto"},
+		EarlyReturnOutcome: {"antes de lo previsto"},
+	},
+	"it": {
+		ItemOutcome:        {"aggiunto all'inventario"},
+		ShipsOutcome:       {"fanno ora parte della flotta"},
+		DarkMatterOutcome:  {"materia oscura"},
+		ResourcesOutcome:   {"stati catturati", "stato catturato"},
+		BlackHoleOutcome:   {"l'unica cosa rimasta della spedizione", "fusione del nucleo della nave ammiraglia", "apertura di un buco nero"},
+		AliensOutcome:      {"navi dall'aspetto esotico", "con una specie sconosciuta", "una specie sconosciuta attacca"},
+		PiratesOutcome:     {"pirati spaziali", "barbari primitivi", "pirati ubriachi"},
+		MerchantOutcome:    {"rappresentante con delle merci"},
+		DelayOutcome:       {"con un grande ritardo", "più tempo del previsto", "più tardi del previsto"},
+		EarlyReturnOutcome: {"prima del previsto"},
+	},
+}
+
+// ExpeditionOutcomesOrder order in which the outcomes phrases are tested
+var ExpeditionOutcomesOrder = []ExpeditionOutcome{ItemOutcome, ShipsOutcome, DarkMatterOutcome, ResourcesOutcome,
+	BlackHoleOutcome, AliensOutcome, PiratesOutcome, MerchantOutcome, DelayOutcome, EarlyReturnOutcome}
+
+// ClassifyExpeditionMessage returns the outcome of an expedition.
+// The resources/ships gained are used when known, the content of the message otherwise.
+// A message that does not have any phrase of any language is considered to be an expedition that found nothing.
+func ClassifyExpeditionMessage(msg ExpeditionMessage) ExpeditionOutcome {
+	if msg.Ships.HasShips() {
+		return ShipsOutcome
+	} else if msg.Resources.Darkmatter > 0 {
+		return DarkMatterOutcome
+	} else if msg.Resources.Metal > 0 || msg.Resources.Crystal > 0 || msg.Resources.Deuterium > 0 {
+		return ResourcesOutcome
+	}
+	// The game uses different apostrophes depending on the message
+	content := strings.NewReplacer("`", "'", "’", "'").Replace(strings.ToLower(msg.Content))
+	if strings.TrimSpace(content) == "" {
+		return UnknownOutcome
+	}
+	for _, outcome := range ExpeditionOutcomesOrder {
+		for _, phrases := range ExpeditionOutcomePhrases {
+			for _, phrase := range phrases[outcome] {
+				if strings.Contains(content, phrase) {
+					return outcome
+				}
+			}
+		}
+	}
+	return NothingOutcome
+}
+
+// ExpeditionYield aggregated results of expeditions
+type ExpeditionYield struct {
+	Count      int64
+	Outcomes   map[ExpeditionOutcome]int64
+	Resources  Resources  // Resources and dark matter found
+	Ships      ShipsInfos // Ships found
+	ShipsValue int64      // Value of the ships found
+}
+
+func newExpeditionYield() *ExpeditionYield {
+	return &ExpeditionYield{Outcomes: make(map[ExpeditionOutcome]int64)}
+}
+
+func (y *ExpeditionYield) add(msg ExpeditionMessage) {
+	y.Count++
+	y.Outcomes[msg.Outcome]++
+	darkmatter := y.Resources.Darkmatter + msg.Resources.Darkmatter // Not summed by Resources.Add
+	y.Resources = y.Resources.Add(msg.Resources)
+	y.Resources.Darkmatter = darkmatter
+	y.Ships.Add(msg.Ships)
+	y.ShipsValue += msg.Ships.FleetValue(LfBonuses{})
+}
+
+// Value returns the total value found (metal+crystal+deuterium of the resources and the ships)
+func (y ExpeditionYield) Value() int64 {
+	return y.Resources.Metal + y.Resources.Crystal + y.Resources.Deuterium + y.ShipsValue
+}
+
+// AverageValue returns the average value found per expedition
+func (y ExpeditionYield) AverageValue() float64 {
+	if y.Count == 0 {
+		return 0
+	}
+	return float64(y.Value()) / float64(y.Count)
+}
+
+// Rate returns the percentage of expeditions that had the given outcome
+func (y ExpeditionYield) Rate(outcome ExpeditionOutcome) float64 {
+	if y.Count == 0 {
+		return 0
+	}
+	return float64(y.Outcomes[outcome]) * 100 / float64(y.Count)
+}
+
+// FleetExpeditionYield aggregated results of the expeditions sent with a fleet composition
+type FleetExpeditionYield struct {
+	Ships ShipsInfos
+	ExpeditionYield
+}
+
+// PositionExpeditionYield aggregated results of the expeditions sent to a coordinate
+type PositionExpeditionYield struct {
+	Coordinate Coordinate
+	ExpeditionYield
+}
+
+// ExpeditionStats expeditions results aggregated by destination and by fleet composition
+type ExpeditionStats struct {
+	Total      ExpeditionYield
+	ByPosition []PositionExpeditionYield // Ordered by coordinate
+	ByFleet    []FleetExpeditionYield    // Ordered by average value, best first
+	Unmatched  int64                     // Number of messages for which the fleet composition is not known
+}
+
+// NewExpeditionStats aggregates expedition messages.
+// fleetOf returns the ships that were sent on the expedition of a message, false if unknown (can be nil).
+func NewExpeditionStats(msgs []ExpeditionMessage, fleetOf func(ExpeditionMessage) (ShipsInfos, bool)) ExpeditionStats {
+	total := newExpeditionYield()
+	byPosition := make(map[Coordinate]*ExpeditionYield)
+	byFleet := make(map[string]*FleetExpeditionYield)
+	var unmatched int64
+	for _, msg := range msgs {
+		if msg.Outcome == UnknownOutcome {
+			msg.Outcome = ClassifyExpeditionMessage(msg)
+		}
+		total.add(msg)
+		if _, ok := byPosition[msg.Coordinate]; !ok {
+			byPosition[msg.Coordinate] = newExpeditionYield()
+		}
+		byPosition[msg.Coordinate].add(msg)
+		if fleetOf == nil {
+			unmatched++
+			continue
+		}
+		ships, ok := fleetOf(msg)
+		if !ok {
+			unmatched++
+			continue
+		}
+		key := shipsCompositionKey(ships)
+		if _, ok := byFleet[key]; !ok {
+			byFleet[key] = &FleetExpeditionYield{Ships: ships, ExpeditionYield: *newExpeditionYield()}
+		}
+		byFleet[key].add(msg)
+	}
+	out := ExpeditionStats{Total: *total, Unmatched: unmatched}
+	for coord, yield := range byPosition {
+		out.ByPosition = append(out.ByPosition, PositionExpeditionYield{Coordinate: coord, ExpeditionYield: *yield})
+	}
+	sort.Slice(out.ByPosition, func(i, j int) bool { return out.ByPosition[i].Coordinate.Cmp(out.ByPosition[j].Coordinate) < 0 })
+	for _, yield := range byFleet {
+		out.ByFleet = append(out.ByFleet, *yield)
+	}
+	sort.Slice(out.ByFleet, func(i, j int) bool { return out.ByFleet[i].AverageValue() > out.ByFleet[j].AverageValue() })
+	return out
+}
+
+func shipsCompositionKey(ships ShipsInfos) string {
+	parts := make([]string, 0)
+	for id, nb := range ships.Iter() {
+		if nb > 0 {
+			parts = append(parts, utils.FI64(int64(id))+":"+utils.FI64(nb))
+		}
+	}
+	return strings.Join(parts, ",")
+}
@@ -0,0 +1,78 @@
+package ogame
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestClassifyExpeditionMessage(t *testing.T) {
+	assert.Equal(t, UnknownOutcome, ClassifyExpeditionMessage(ExpeditionMessage{}))
+	assert.Equal(t, ShipsOutcome, ClassifyExpeditionMessage(ExpeditionMessage{Ships: ShipsInfos{SmallCargo: 1}}))
+	assert.Equal(t, DarkMatterOutcome, ClassifyExpeditionMessage(ExpeditionMessage{Resources: Resources{Darkmatter: 1}}))
+	assert.Equal(t, ResourcesOutcome, ClassifyExpeditionMessage(ExpeditionMessage{Resources: Resources{Crystal: 1}}))
+	classify := func(content string) ExpeditionOutcome {
+		return ClassifyExpeditionMessage(ExpeditionMessage{Content: content})
+	}
+	assert.Equal(t, BlackHoleOutcome, classify("The last transmission we received from the expedition fleet was this magnificent picture of the opening of a black hole."))
+	assert.Equal(t, AliensOutcome, classify("Some exotic looking ships attacked the expedition fleet without warning!"))
+	assert.Equal(t, MerchantOutcome, classify("Your expedition fleet made contact with a friendly alien race. They announced that they would send a representative with goods to trade to your worlds."))
+	assert.Equal(t, EarlyReturnOutcome, classify("An unexpected back coupling in the energy spools of the engines hastened the expeditions return, it returns home earlier than expected."))
+	assert.Equal(t, DelayOutcome, classify("The solar wind of a red giant ruined the expeditions jump and it will take quite some time to calculate the return jump. The fleet will return later than expected."))
+	assert.Equal(t, PiratesOutcome, classify("Ein paar anscheinend sehr verzweifelte Weltraumpiraten haben versucht, unsere Expeditionsflotte zu kapern."))
+	assert.Equal(t, ShipsOutcome, classify("We found a deserted pirate station. The following ships are now part of the fleet: Light Fighter: 149"))
+	assert.Equal(t, ItemOutcome, classify("Ein Gegenstand wurde dem Inventar hinzugefügt."))
+	assert.Equal(t, NothingOutcome, classify("Besides some quaint, small pets from a unknown marsh planet, this expedition brings nothing thrilling back from the trip."))
+
+	// Messages sharing words with the phrases of other outcomes
+	assert.Equal(t, NothingOutcome, classify("Our expedition team came across a strange colony that had been abandoned eons ago. "+
+		"After landing, our crew started to suffer from a high fever caused by an alien virus."))
+	assert.Equal(t, NothingOutcome, classify("Your expedition nearly ran into a neutron stars gravitation field and needed some time to free itself. "+
+		"Because of that a lot of Deuterium was consumed and the expedition fleet had to come back without any results."))
+	assert.Equal(t, NothingOutcome, classify("We found the wreck of an old trader, the cargo bay was empty."))
+	assert.Equal(t, NothingOutcome, classify("The scans arrived later than the fleet, the sector was empty."))
+	assert.Equal(t, NothingOutcome, classify("Außer einem seltsamen Gegenstand, der sich als wertlos herausstellte, bringt die Expedition nichts mit."))
+	assert.Equal(t, NothingOutcome, classify("Die Expedition konnte nicht länger fortgesetzt werden und kehrt ohne Ergebnis zurück."))
+	assert.Equal(t, NothingOutcome, classify("L'expédition a trouvé un objet étrange, sans aucune valeur."))
+}
+
+func TestNewExpeditionStats(t *testing.T) {
+	at := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	pos1 := Coordinate{Galaxy: 1, System: 1, Position: 16, Type: PlanetType}
+	pos2 := Coordinate{Galaxy: 1, System: 2, Position: 16, Type: PlanetType}
+	msgs := []ExpeditionMessage{
+		{ID: 1, Coordinate: pos2, Resources: Resources{Metal: 1000, Darkmatter: 0}, CreatedAt: at},
+		{ID: 2, Coordinate: pos1, Content: "Some really desperate space pirates tried to capture our expedition fleet."},
+		{ID: 3, Coordinate: pos1, Resources: Resources{Darkmatter: 300}, Outcome: DarkMatterOutcome},
+		{ID: 4, Coordinate: pos1, Ships: ShipsInfos{LightFighter: 2}, Outcome: ShipsOutcome},
+	}
+	fleetOf := func(msg ExpeditionMessage) (ShipsInfos, bool) {
+		if msg.ID == 1 {
+			return ShipsInfos{LargeCargo: 10}, true
+		}
+		if msg.ID == 2 || msg.ID == 3 {
+			return ShipsInfos{SmallCargo: 10}, true
+		}
+		return ShipsInfos{}, false
+	}
+	stats := NewExpeditionStats(msgs, fleetOf)
+	assert.Equal(t, int64(4), stats.Total.Count)
+	assert.Equal(t, int64(300), stats.Total.Resources.Darkmatter)
+	assert.Equal(t, int64(1000), stats.Total.Resources.Metal)
+	assert.Equal(t, int64(2), stats.Total.Ships.LightFighter)
+	assert.Equal(t, int64(8000), stats.Total.ShipsValue)
+	assert.Equal(t, int64(1), stats.Total.Outcomes[PiratesOutcome])
+	assert.Equal(t, float64(25), stats.Total.Rate(ResourcesOutcome))
+	assert.Equal(t, int64(1), stats.Unmatched)
+
+	assert.Len(t, stats.ByPosition, 2)
+	assert.Equal(t, pos1, stats.ByPosition[0].Coordinate)
+	assert.Equal(t, int64(3), stats.ByPosition[0].Count)
+	assert.Equal(t, int64(1000), stats.ByPosition[1].Resources.Metal)
+
+	assert.Len(t, stats.ByFleet, 2)
+	assert.Equal(t, ShipsInfos{LargeCargo: 10}, stats.ByFleet[0].Ships)
+	assert.Equal(t, float64(1000), stats.ByFleet[0].AverageValue())
+	assert.Equal(t, int64(2), stats.ByFleet[1].Count)
+}
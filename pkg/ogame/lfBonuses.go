@@ -40,6 +40,14 @@ type LfShipBonus struct {
 
 type LfResourceBonuses struct {
 	ResourcesExpedition float64
+
+	// Production and storage capacity bonuses of the lifeform researches, in percent (0.01 -> 1%)
+	MetalProduction          float64
+	CrystalProduction        float64
+	DeuteriumProduction      float64
+	MetalStorageCapacity     float64
+	CrystalStorageCapacity   float64
+	DeuteriumStorageCapacity float64
 }
 
 type CharacterClassesBonuses struct {
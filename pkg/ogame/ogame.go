@@ -204,6 +204,7 @@ type ExpeditionMessage struct {
 	Content    string
 	Resources  Resources
 	Ships      ShipsInfos
+	Outcome    ExpeditionOutcome
 	CreatedAt  time.Time
 }
 
@@ -64,19 +64,22 @@ func (s SystemInfos) Each(clb func(planetInfo *PlanetInfos)) {
 	}
 }
 
+// systemInfosJSON json encoding of SystemInfos
+type systemInfosJSON struct {
+	Galaxy           int64
+	System           int64
+	Planets          [15]*PlanetInfos
+	ExpeditionDebris struct {
+		Metal             int64
+		Crystal           int64
+		Deuterium         int64
+		PathfindersNeeded int64
+	}
+}
+
 // MarshalJSON export private fields to json for ogamed
 func (s SystemInfos) MarshalJSON() ([]byte, error) {
-	var tmp struct {
-		Galaxy           int64
-		System           int64
-		Planets          [15]*PlanetInfos
-		ExpeditionDebris struct {
-			Metal             int64
-			Crystal           int64
-			Deuterium         int64
-			PathfindersNeeded int64
-		}
-	}
+	var tmp systemInfosJSON
 	tmp.Galaxy = s.galaxy
 	tmp.System = s.system
 	tmp.Planets = s.planets
@@ -87,6 +90,20 @@ func (s SystemInfos) MarshalJSON() ([]byte, error) {
 	return json.Marshal(tmp)
 }
 
+// UnmarshalJSON import the private fields exported by MarshalJSON, used by the ogamed client
+func (s *SystemInfos) UnmarshalJSON(data []byte) error {
+	var tmp systemInfosJSON
+	if err := json.Unmarshal(data, &tmp); err != nil {
+		return err
+	}
+	*s = SystemInfos{galaxy: tmp.Galaxy, system: tmp.System, planets: tmp.Planets}
+	s.ExpeditionDebris.Metal = tmp.ExpeditionDebris.Metal
+	s.ExpeditionDebris.Crystal = tmp.ExpeditionDebris.Crystal
+	s.ExpeditionDebris.Deuterium = tmp.ExpeditionDebris.Deuterium
+	s.ExpeditionDebris.PathfindersNeeded = tmp.ExpeditionDebris.PathfindersNeeded
+	return nil
+}
+
 // MoonInfos public information of a moon in the galaxy page
 type MoonInfos struct {
 	ID       int64
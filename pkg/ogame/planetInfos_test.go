@@ -50,3 +50,17 @@ func TestSystemInfos_MarshalJSON(t *testing.T) {
 		`null,null,null,null,null,null,null,null,null,null,null,null,null],"ExpeditionDebris":{"Metal":0,"Crystal":0,"Deuterium":0,"PathfindersNeeded":0}}`
 	assert.Equal(t, expected, string(by))
 }
+
+func TestSystemInfos_UnmarshalJSON(t *testing.T) {
+	si := SystemInfos{}
+	si.SetGalaxy(1)
+	si.SetSystem(2)
+	si.SetPlanet(2, &PlanetInfos{ID: 1, Name: "name", Coordinate: Coordinate{1, 2, 3, PlanetType}})
+	si.ExpeditionDebris.Metal = 4
+	by, err := json.Marshal(si)
+	assert.NoError(t, err)
+	var decoded SystemInfos
+	assert.NoError(t, json.Unmarshal(by, &decoded))
+	assert.Equal(t, si, decoded)
+	assert.Equal(t, "name", decoded.Position(3).Name)
+}
@@ -0,0 +1,45 @@
+package ogame
+
+// EnergyProduced returns the energy produced by the solar plant, fusion reactor and solar satellites
+func EnergyProduced(temp Temperature, resourcesBuildings ResourcesBuildings, resSettings ResourceSettings, energyTechnology int64, isCollector bool) int64 {
+	energyProduced := int64(float64(SolarPlant.Production(resourcesBuildings.SolarPlant)) * (float64(resSettings.SolarPlant) / 100))
+	energyProduced += int64(float64(FusionReactor.Production(energyTechnology, resourcesBuildings.FusionReactor)) * (float64(resSettings.FusionReactor) / 100))
+	energyProduced += int64(float64(SolarSatellite.Production(temp, resourcesBuildings.SolarSatellite, isCollector)) * (float64(resSettings.SolarSatellite) / 100))
+	return energyProduced
+}
+
+// EnergyNeeded returns the energy consumed by the mines
+func EnergyNeeded(resourcesBuildings ResourcesBuildings, resSettings ResourceSettings) int64 {
+	energyNeeded := int64(float64(MetalMine.EnergyConsumption(resourcesBuildings.MetalMine)) * (float64(resSettings.MetalMine) / 100))
+	energyNeeded += int64(float64(CrystalMine.EnergyConsumption(resourcesBuildings.CrystalMine)) * (float64(resSettings.CrystalMine) / 100))
+	energyNeeded += int64(float64(DeuteriumSynthesizer.EnergyConsumption(resourcesBuildings.DeuteriumSynthesizer)) * (float64(resSettings.DeuteriumSynthesizer) / 100))
+	return energyNeeded
+}
+
+// ProductionRatio returns the ratio at which the mines produce, lower than 1 when the energy is insufficient
+func ProductionRatio(temp Temperature, resourcesBuildings ResourcesBuildings, resSettings ResourceSettings, energyTechnology int64, isCollector bool) float64 {
+	energyProduced := EnergyProduced(temp, resourcesBuildings, resSettings, energyTechnology, isCollector)
+	energyNeeded := EnergyNeeded(resourcesBuildings, resSettings)
+	ratio := 1.0
+	if energyNeeded > energyProduced {
+		ratio = float64(energyProduced) / float64(energyNeeded)
+	}
+	return ratio
+}
+
+// Productions returns the resources produced per hour by the mines, with the plasma technology but without
+// class and lifeform bonuses, and the energy balance
+func Productions(resBuildings ResourcesBuildings, resSettings ResourceSettings, researches Researches, universeSpeed int64,
+	temp Temperature, globalRatio float64, isCollector bool) Resources {
+	energyProduced := EnergyProduced(temp, resBuildings, resSettings, researches.EnergyTechnology, isCollector)
+	energyNeeded := EnergyNeeded(resBuildings, resSettings)
+	metalSetting := float64(resSettings.MetalMine) / 100
+	crystalSetting := float64(resSettings.CrystalMine) / 100
+	deutSetting := float64(resSettings.DeuteriumSynthesizer) / 100
+	return Resources{
+		Metal:     MetalMine.Production(universeSpeed, metalSetting, globalRatio, researches.PlasmaTechnology, resBuildings.MetalMine),
+		Crystal:   CrystalMine.Production(universeSpeed, crystalSetting, globalRatio, researches.PlasmaTechnology, resBuildings.CrystalMine),
+		Deuterium: DeuteriumSynthesizer.Production(universeSpeed, temp.Mean(), deutSetting, globalRatio, researches.PlasmaTechnology, resBuildings.DeuteriumSynthesizer) - FusionReactor.GetFuelConsumption(universeSpeed, float64(resSettings.FusionReactor)/100, resBuildings.FusionReactor),
+		Energy:    energyProduced - energyNeeded,
+	}
+}
@@ -0,0 +1,567 @@
+// Package ogamedClient is a typed client of the ogamed REST API.
+// Its methods mirror the wrapper.Prioritizable interface, the routes being documented on /openapi.json.
+package ogamedClient
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/alaingilbert/ogame/pkg/ogame"
+	"github.com/alaingilbert/ogame/pkg/wrapper"
+)
+
+// APIError error returned by ogamed
+type APIError struct {
+	StatusCode int // Http status code
+	Code       int
+	ErrorCode  string // Machine-readable error code, eg: not_enough_deuterium
+	Message    string
+}
+
+// Error implements the error interface
+func (e *APIError) Error() string {
+	if e.ErrorCode != "" {
+		return fmt.Sprintf("ogamed error %d %s: %s", e.Code, e.ErrorCode, e.Message)
+	}
+	return fmt.Sprintf("ogamed error %d: %s", e.Code, e.Message)
+}
+
+// apiResp envelope of the ogamed json responses
+type apiResp struct {
+	Status    string
+	Code      int
+	Message   string
+	ErrorCode string
+	Result    json.RawMessage
+}
+
+// Client of an ogamed server
+type Client struct {
+	baseURL    string
+	httpClient *http.Client
+	username   string
+	password   string
+	apiKey     string
+}
+
+// New creates a client of the ogamed server at baseURL (eg: http://127.0.0.1:8080), using its default account
+func New(baseURL string) *Client {
+	return &Client{baseURL: strings.TrimSuffix(baseURL, "/"), httpClient: http.DefaultClient}
+}
+
+// SetHTTPClient sets the http client used for the requests
+func (c *Client) SetHTTPClient(httpClient *http.Client) {
+	c.httpClient = httpClient
+}
+
+// SetBasicAuth sets the credentials of the ogamed basic auth
+func (c *Client) SetBasicAuth(username, password string) {
+	c.username = username
+	c.password = password
+}
+
+// SetAPIKey sets the token of the ogamed api key, sent as a Bearer token instead of the basic auth credentials
+func (c *Client) SetAPIKey(token string) {
+	c.apiKey = token
+}
+
+// Account returns a client of the account named name, served on /accounts/:name
+func (c *Client) Account(name string) *Client {
+	account := *c
+	account.baseURL = c.baseURL + "/accounts/" + url.PathEscape(name)
+	return &account
+}
+
+// do sends a request, with form as url encoded body or body as json if not nil, and decodes the Result of the response into result if not nil
+func (c *Client) do(method, path string, form url.Values, body, result any) error {
+	var reqBody io.Reader
+	contentType := ""
+	if form != nil {
+		reqBody = strings.NewReader(form.Encode())
+		contentType = "application/x-www-form-urlencoded"
+	} else if body != nil {
+		by, err := json.Marshal(body)
+		if err != nil {
+			return err
+		}
+		reqBody = bytes.NewReader(by)
+		contentType = "application/json"
+	}
+	req, err := http.NewRequest(method, c.baseURL+path, reqBody)
+	if err != nil {
+		return err
+	}
+	if contentType != "" {
+		req.Header.Set("Content-Type", contentType)
+	}
+	if c.apiKey != "" {
+		req.Header.Set("Authorization", "Bearer "+c.apiKey)
+	} else if c.username != "" || c.password != "" {
+		req.SetBasicAuth(c.username, c.password)
+	}
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	by, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return err
+	}
+	var res apiResp
+	if err := json.Unmarshal(by, &res); err != nil {
+		return &APIError{StatusCode: resp.StatusCode, Code: resp.StatusCode, Message: strings.TrimSpace(string(by))}
+	}
+	if resp.StatusCode != http.StatusOK || res.Status != "ok" {
+		return &APIError{StatusCode: resp.StatusCode, Code: res.Code, ErrorCode: res.ErrorCode, Message: res.Message}
+	}
+	if result == nil || len(res.Result) == 0 {
+		return nil
+	}
+	return json.Unmarshal(res.Result, result)
+}
+
+func (c *Client) get(path string, result any) error {
+	return c.do(http.MethodGet, path, nil, nil, result)
+}
+
+func (c *Client) post(path string) error {
+	return c.do(http.MethodPost, path, url.Values{}, nil, nil)
+}
+
+func (c *Client) postJSON(path string, body, result any) error {
+	return c.do(http.MethodPost, path, nil, body, result)
+}
+
+func i64(v int64) string {
+	return strconv.FormatInt(v, 10)
+}
+
+func coordPath(coord ogame.Coordinate) string {
+	return "/" + i64(coord.Galaxy) + "/" + i64(coord.System) + "/" + i64(coord.Position)
+}
+
+func coordRequest(coord ogame.Coordinate) wrapper.CoordinateRequest {
+	return wrapper.CoordinateRequest{Galaxy: coord.Galaxy, System: coord.System, Position: coord.Position, Type: coord.Type}
+}
+
+// celestialPath path of a celestial, by id or by coordinate (ogame.Coordinate or string eg: 1:2:3)
+func celestialPath(prefix string, v any) (string, error) {
+	switch vv := v.(type) {
+	case ogame.Coordinate:
+		return prefix + coordPath(vv), nil
+	case string:
+		coord, err := ogame.ParseCoord(vv)
+		if err != nil {
+			return "", err
+		}
+		return prefix + coordPath(coord), nil
+	case ogame.CelestialID:
+		return prefix + "/" + i64(int64(vv)), nil
+	case ogame.PlanetID:
+		return prefix + "/" + i64(int64(vv)), nil
+	case ogame.MoonID:
+		return prefix + "/" + i64(int64(vv)), nil
+	case int64:
+		return prefix + "/" + i64(vv), nil
+	case int:
+		return prefix + "/" + strconv.Itoa(vv), nil
+	}
+	return "", errors.New("invalid type")
+}
+
+// Login logins, using the existing cookies if possible
+func (c *Client) Login() error {
+	return c.get("/bot/login", nil)
+}
+
+// Logout logouts
+func (c *Client) Logout() error {
+	return c.get("/bot/logout", nil)
+}
+
+// ServerTime returns the time of the server
+func (c *Client) ServerTime() (out time.Time, err error) {
+	err = c.get("/bot/server/time", &out)
+	return
+}
+
+// GetUserInfos returns the name, points and rank of the player
+func (c *Client) GetUserInfos() (out ogame.UserInfos, err error) {
+	err = c.get("/bot/user-infos", &out)
+	return
+}
+
+// GetResearch returns the research levels
+func (c *Client) GetResearch() (out ogame.Researches, err error) {
+	err = c.get("/bot/get-research", &out)
+	return
+}
+
+// IsUnderAttack returns true if hostile fleets are coming
+func (c *Client) IsUnderAttack() (out bool, err error) {
+	err = c.get("/bot/is-under-attack", &out)
+	return
+}
+
+// GetAttacks returns the hostile fleets coming
+func (c *Client) GetAttacks() (out []ogame.AttackEvent, err error) {
+	err = c.get("/bot/attacks", &out)
+	return
+}
+
+// GetSlots returns the fleet and expedition slots
+func (c *Client) GetSlots() (out ogame.Slots, err error) {
+	err = c.get("/bot/fleets/slots", &out)
+	return
+}
+
+// GetFleets returns the own fleets in flight and the slots, with two requests
+func (c *Client) GetFleets() ([]ogame.Fleet, ogame.Slots, error) {
+	var fleets []ogame.Fleet
+	if err := c.get("/bot/fleets", &fleets); err != nil {
+		return nil, ogame.Slots{}, err
+	}
+	slots, err := c.GetSlots()
+	return fleets, slots, err
+}
+
+// CancelFleet recalls a fleet
+func (c *Client) CancelFleet(fleetID ogame.FleetID) error {
+	return c.post("/bot/fleets/" + i64(int64(fleetID)) + "/cancel")
+}
+
+// GalaxyInfos returns the galaxy page of a system
+func (c *Client) GalaxyInfos(galaxy, system int64) (out ogame.SystemInfos, err error) {
+	err = c.get("/bot/galaxy-infos/"+i64(galaxy)+"/"+i64(system), &out)
+	return
+}
+
+// GetEspionageReport returns an espionage report
+func (c *Client) GetEspionageReport(msgID int64) (out ogame.EspionageReport, err error) {
+	err = c.get("/bot/espionage-report/"+i64(msgID), &out)
+	return
+}
+
+// GetEspionageReportFor returns the latest espionage report of a planet
+func (c *Client) GetEspionageReportFor(coord ogame.Coordinate) (out ogame.EspionageReport, err error) {
+	err = c.get("/bot/espionage-report"+coordPath(coord), &out)
+	return
+}
+
+// GetEspionageReportMessages returns the summaries of all the espionage reports, ogamed always reads every page
+func (c *Client) GetEspionageReportMessages() (out []ogame.EspionageReportSummary, err error) {
+	err = c.get("/bot/espionage-report", &out)
+	return
+}
+
+// GetCombatReport returns a combat report
+func (c *Client) GetCombatReport(msgID int64) (out ogame.CombatReport, err error) {
+	err = c.get("/bot/combat-report/"+i64(msgID), &out)
+	return
+}
+
+// DeleteMessage deletes a message
+func (c *Client) DeleteMessage(msgID int64) error {
+	return c.post("/bot/delete-report/" + i64(msgID))
+}
+
+// DeleteAllMessagesFromTab deletes all the messages of a tab
+func (c *Client) DeleteAllMessagesFromTab(tabID ogame.MessagesTabID) error {
+	return c.post("/bot/delete-all-reports/" + i64(int64(tabID)))
+}
+
+// SendMessage sends a message to a player
+func (c *Client) SendMessage(playerID int64, message string) error {
+	return c.postJSON("/bot/send-message", wrapper.SendMessageRequest{PlayerID: playerID, Message: message}, nil)
+}
+
+// GetAuction returns the current auction
+func (c *Client) GetAuction() (out ogame.Auction, err error) {
+	err = c.get("/bot/get-auction", &out)
+	return
+}
+
+// DoAuction bids on the current auction
+func (c *Client) DoAuction(bid map[ogame.CelestialID]ogame.Resources) error {
+	return c.postJSON("/bot/do-auction", wrapper.AuctionBidRequest(bid), nil)
+}
+
+// BuyOfferOfTheDay buys the offer of the day of the trader
+func (c *Client) BuyOfferOfTheDay() error {
+	return c.get("/bot/buy-offer-of-the-day", nil)
+}
+
+// GetPageContent returns the html of a game page
+func (c *Client) GetPageContent(vals url.Values) (out []byte, err error) {
+	req := make(wrapper.PageContentRequest)
+	for key := range vals {
+		req[key] = vals.Get(key)
+	}
+	err = c.postJSON("/bot/page-content", req, &out)
+	return
+}
+
+// GetEmpireJSON returns the empire page of the planets (0) or of the moons (1)
+func (c *Client) GetEmpireJSON(celestialType ogame.CelestialType) (out any, err error) {
+	typeID := int64(0)
+	if celestialType == ogame.MoonType {
+		typeID = 1
+	}
+	err = c.get("/bot/empire/type/"+i64(typeID), &out)
+	return
+}
+
+// GetPlanets returns the planets of the player
+func (c *Client) GetPlanets() (out []ogame.Planet, err error) {
+	err = c.get("/bot/planets", &out)
+	return
+}
+
+// GetPlanet returns a planet of the player, by id or by coordinate (ogame.Coordinate or string eg: 1:2:3)
+func (c *Client) GetPlanet(v any) (out ogame.Planet, err error) {
+	path, err := celestialPath("/bot/planets", v)
+	if err != nil {
+		return
+	}
+	err = c.get(path, &out)
+	return
+}
+
+// GetMoons returns the moons of the player
+func (c *Client) GetMoons() (out []ogame.Moon, err error) {
+	err = c.get("/bot/moons", &out)
+	return
+}
+
+// GetMoon returns a moon of the player, by id or by coordinate (ogame.Coordinate or string eg: 1:2:3)
+func (c *Client) GetMoon(v any) (out ogame.Moon, err error) {
+	path, err := celestialPath("/bot/moons", v)
+	if err != nil {
+		return
+	}
+	err = c.get(path, &out)
+	return
+}
+
+// GetItems returns the items available on a celestial
+func (c *Client) GetItems(celestialID ogame.CelestialID) (out []ogame.Item, err error) {
+	err = c.get("/bot/celestials/"+i64(int64(celestialID))+"/items", &out)
+	return
+}
+
+// ActivateItem activates an item on a celestial
+func (c *Client) ActivateItem(ref string, celestialID ogame.CelestialID) error {
+	return c.get("/bot/celestials/"+i64(int64(celestialID))+"/items/"+url.PathEscape(ref)+"/activate", nil)
+}
+
+// GetTechs returns all the levels of a celestial
+func (c *Client) GetTechs(celestialID ogame.CelestialID) (ogame.Techs, error) {
+	var out wrapper.TechsResult
+	if err := c.get("/bot/celestials/"+i64(int64(celestialID))+"/techs", &out); err != nil {
+		return ogame.Techs{}, err
+	}
+	return ogame.Techs{
+		ResourcesBuildings: out.Supplies,
+		Facilities:         out.Facilities,
+		ShipsInfos:         out.Ships,
+		DefensesInfos:      out.Defenses,
+		Researches:         out.Researches,
+		LfBuildings:        out.LfBuildings,
+		LfResearches:       out.LfResearches,
+	}, nil
+}
+
+// Abandon abandons a celestial
+func (c *Client) Abandon(celestialID ogame.CelestialID) error {
+	return c.get("/bot/celestials/"+i64(int64(celestialID))+"/abandon", nil)
+}
+
+func planetPath(celestialID ogame.CelestialID, suffix string) string {
+	return "/bot/planets/" + i64(int64(celestialID)) + suffix
+}
+
+// GetResources returns the resources of a celestial
+func (c *Client) GetResources(celestialID ogame.CelestialID) (out ogame.Resources, err error) {
+	err = c.get(planetPath(celestialID, "/resources"), &out)
+	return
+}
+
+// GetResourcesDetails returns the resources, storage and production of a celestial
+func (c *Client) GetResourcesDetails(celestialID ogame.CelestialID) (out ogame.ResourcesDetails, err error) {
+	err = c.get(planetPath(celestialID, "/resources-details"), &out)
+	return
+}
+
+// GetResourcesBuildings returns the resources buildings levels of a celestial
+func (c *Client) GetResourcesBuildings(celestialID ogame.CelestialID) (out ogame.ResourcesBuildings, err error) {
+	err = c.get(planetPath(celestialID, "/resources-buildings"), &out)
+	return
+}
+
+// GetLfBuildings returns the lifeform buildings levels of a celestial
+func (c *Client) GetLfBuildings(celestialID ogame.CelestialID) (out ogame.LfBuildings, err error) {
+	err = c.get(planetPath(celestialID, "/lifeform-buildings"), &out)
+	return
+}
+
+// GetLfResearch returns the lifeform researches levels of a celestial
+func (c *Client) GetLfResearch(celestialID ogame.CelestialID) (out ogame.LfResearches, err error) {
+	err = c.get(planetPath(celestialID, "/lifeform-techs"), &out)
+	return
+}
+
+// GetDefense returns the defenses of a celestial
+func (c *Client) GetDefense(celestialID ogame.CelestialID) (out ogame.DefensesInfos, err error) {
+	err = c.get(planetPath(celestialID, "/defence"), &out)
+	return
+}
+
+// GetShips returns the ships of a celestial
+func (c *Client) GetShips(celestialID ogame.CelestialID) (out ogame.ShipsInfos, err error) {
+	err = c.get(planetPath(celestialID, "/ships"), &out)
+	return
+}
+
+// GetFacilities returns the facilities levels of a celestial
+func (c *Client) GetFacilities(celestialID ogame.CelestialID) (out ogame.Facilities, err error) {
+	err = c.get(planetPath(celestialID, "/facilities"), &out)
+	return
+}
+
+// GetResourceSettings returns the production percentages of a planet
+func (c *Client) GetResourceSettings(planetID ogame.PlanetID) (out ogame.ResourceSettings, err error) {
+	err = c.get(planetPath(planetID.Celestial(), "/resource-settings"), &out)
+	return
+}
+
+// SetResourceSettings sets the production percentages of a planet
+func (c *Client) SetResourceSettings(planetID ogame.PlanetID, settings ogame.ResourceSettings) error {
+	return c.postJSON(planetPath(planetID.Celestial(), "/resource-settings"), wrapper.ResourceSettingsRequest{ResourceSettings: settings}, nil)
+}
+
+// Build builds any object, nbr is ignored for buildings and researches
+func (c *Client) Build(celestialID ogame.CelestialID, id ogame.ID, nbr int64) error {
+	return c.post(planetPath(celestialID, "/build/"+i64(int64(id))+"/"+i64(nbr)))
+}
+
+// BuildCancelable builds a building or a research
+func (c *Client) BuildCancelable(celestialID ogame.CelestialID, id ogame.ID) error {
+	return c.post(planetPath(celestialID, "/build/cancelable/"+i64(int64(id))))
+}
+
+// BuildProduction builds ships or defenses
+func (c *Client) BuildProduction(celestialID ogame.CelestialID, id ogame.ID, nbr int64) error {
+	return c.post(planetPath(celestialID, "/build/production/"+i64(int64(id))+"/"+i64(nbr)))
+}
+
+// BuildBuilding builds a building
+func (c *Client) BuildBuilding(celestialID ogame.CelestialID, buildingID ogame.ID) error {
+	return c.post(planetPath(celestialID, "/build/building/"+i64(int64(buildingID))))
+}
+
+// BuildTechnology starts a research
+func (c *Client) BuildTechnology(celestialID ogame.CelestialID, technologyID ogame.ID) error {
+	return c.post(planetPath(celestialID, "/build/technology/"+i64(int64(technologyID))))
+}
+
+// BuildDefense builds defenses
+func (c *Client) BuildDefense(celestialID ogame.CelestialID, defenseID ogame.ID, nbr int64) error {
+	return c.post(planetPath(celestialID, "/build/defence/"+i64(int64(defenseID))+"/"+i64(nbr)))
+}
+
+// BuildShips builds ships
+func (c *Client) BuildShips(celestialID ogame.CelestialID, shipID ogame.ID, nbr int64) error {
+	return c.post(planetPath(celestialID, "/build/ships/"+i64(int64(shipID))+"/"+i64(nbr)))
+}
+
+// TearDown tears down a level of a building
+func (c *Client) TearDown(celestialID ogame.CelestialID, id ogame.ID) error {
+	return c.post(planetPath(celestialID, "/teardown/"+i64(int64(id))))
+}
+
+// CancelBuilding cancels the building being built
+func (c *Client) CancelBuilding(celestialID ogame.CelestialID) error {
+	return c.post(planetPath(celestialID, "/cancel-building"))
+}
+
+// CancelResearch cancels the research in progress
+func (c *Client) CancelResearch(celestialID ogame.CelestialID) error {
+	return c.post(planetPath(celestialID, "/cancel-research"))
+}
+
+// GetProduction returns the ships and defenses being built.
+// Unlike wrapper.Prioritizable, the remaining time is not returned by ogamed.
+func (c *Client) GetProduction(celestialID ogame.CelestialID) (out []ogame.Quantifiable, err error) {
+	err = c.get(planetPath(celestialID, "/production"), &out)
+	return
+}
+
+// ConstructionsBeingBuilt returns the building and researches being built, countdowns have a one second precision
+func (c *Client) ConstructionsBeingBuilt(celestialID ogame.CelestialID) (ogame.Constructions, error) {
+	var out wrapper.ConstructionsResult
+	if err := c.get(planetPath(celestialID, "/constructions"), &out); err != nil {
+		return ogame.Constructions{}, err
+	}
+	var constructions ogame.Constructions
+	constructions.Building.ID = ogame.ID(out.BuildingID)
+	constructions.Building.Countdown = time.Duration(out.BuildingCountdown) * time.Second
+	constructions.Research.ID = ogame.ID(out.ResearchID)
+	constructions.Research.Countdown = time.Duration(out.ResearchCountdown) * time.Second
+	constructions.LfBuilding.ID = ogame.ID(out.LfBuildingID)
+	constructions.LfBuilding.Countdown = time.Duration(out.LfBuildingCountdown) * time.Second
+	constructions.LfResearch.ID = ogame.ID(out.LfResearchID)
+	constructions.LfResearch.Countdown = time.Duration(out.LfResearchCountdown) * time.Second
+	return constructions, nil
+}
+
+// SendFleet sends a fleet
+func (c *Client) SendFleet(celestialID ogame.CelestialID, ships ogame.ShipsInfos, speed ogame.Speed, where ogame.Coordinate,
+	mission ogame.MissionID, resources ogame.Resources, holdingTime, unionID int64) (out ogame.Fleet, err error) {
+	req := wrapper.SendFleetRequest{
+		CoordinateRequest: coordRequest(where),
+		Ships:             ships,
+		Speed:             speed,
+		Mission:           mission,
+		Duration:          holdingTime,
+		Union:             unionID,
+		Metal:             resources.Metal,
+		Crystal:           resources.Crystal,
+		Deuterium:         resources.Deuterium,
+	}
+	err = c.postJSON(planetPath(celestialID, "/send-fleet"), req, &out)
+	return
+}
+
+// SendDiscoveryFleet sends a discovery fleet
+func (c *Client) SendDiscoveryFleet(celestialID ogame.CelestialID, coord ogame.Coordinate) error {
+	return c.postJSON(planetPath(celestialID, "/send-discovery"), coordRequest(coord), nil)
+}
+
+// SendIPM sends interplanetary missiles, returns the flight duration in seconds
+func (c *Client) SendIPM(planetID ogame.PlanetID, coord ogame.Coordinate, nbr int64, priority ogame.ID) (out int64, err error) {
+	req := wrapper.SendIPMRequest{CoordinateRequest: coordRequest(coord), IpmAmount: nbr, Priority: priority}
+	err = c.postJSON(planetPath(planetID.Celestial(), "/send-ipm"), req, &out)
+	return
+}
+
+// Phalanx returns the fleets seen by the phalanx of a moon
+func (c *Client) Phalanx(moonID ogame.MoonID, coord ogame.Coordinate) (out []ogame.PhalanxFleet, err error) {
+	err = c.get("/bot/moons/"+i64(int64(moonID))+"/phalanx"+coordPath(coord), &out)
+	return
+}
+
+// JumpGate jumps ships to another moon, returns the success and the recharge countdown in seconds
+func (c *Client) JumpGate(origin, dest ogame.MoonID, ships ogame.ShipsInfos) (bool, int64, error) {
+	var out wrapper.JumpGateResult
+	err := c.postJSON("/bot/moons/"+i64(int64(origin))+"/jump-gate", wrapper.JumpGateRequest{MoonDestination: dest, Ships: ships}, &out)
+	return out.Success, out.RechargeCountdown, err
+}
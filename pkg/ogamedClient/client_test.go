@@ -0,0 +1,82 @@
+package ogamedClient
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/alaingilbert/ogame/pkg/mockserver"
+	"github.com/alaingilbert/ogame/pkg/ogame"
+	"github.com/alaingilbert/ogame/pkg/wrapper"
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newClient serves the wrapper routes of a bot logged on the mock game server
+func newClient(t *testing.T) *Client {
+	srv := mockserver.New(nil)
+	t.Cleanup(srv.Close)
+	dev, err := srv.NewDevice("ogamedClient_test")
+	require.NoError(t, err)
+	bot, err := wrapper.NewWithParams(wrapper.Params{
+		Device:    dev,
+		Universe:  srv.State.ServerName,
+		Lang:      srv.State.Lang,
+		Username:  srv.State.Username,
+		Password:  srv.State.Password,
+		AutoLogin: true,
+		Quiet:     true,
+	})
+	require.NoError(t, err)
+	e := echo.New()
+	e.Use(func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			c.Set("bot", bot)
+			return next(c)
+		}
+	})
+	for _, route := range wrapper.Routes {
+		e.Add(route.Method, route.Path, route.Handler)
+	}
+	ts := httptest.NewServer(e)
+	t.Cleanup(ts.Close)
+	return New(ts.URL)
+}
+
+func TestClient(t *testing.T) {
+	client := newClient(t)
+	planets, err := client.GetPlanets()
+	require.NoError(t, err)
+	require.Len(t, planets, 1)
+	assert.Equal(t, ogame.PlanetID(33620000), planets[0].ID)
+	planet, err := client.GetPlanet(planets[0].Coordinate)
+	require.NoError(t, err)
+	assert.Equal(t, planets[0].ID, planet.ID)
+
+	res, err := client.GetResources(33620000)
+	require.NoError(t, err)
+	assert.Equal(t, int64(100000), res.Metal)
+
+	where := ogame.Coordinate{Galaxy: 1, System: 2, Position: 8, Type: ogame.PlanetType}
+	fleet, err := client.SendFleet(33620000, ogame.ShipsInfos{SmallCargo: 5}, ogame.HundredPercent, where, ogame.Transport,
+		ogame.Resources{Metal: 1000}, 0, 0)
+	require.NoError(t, err)
+	assert.NotZero(t, fleet.ID)
+	assert.Equal(t, where, fleet.Destination)
+	fleets, slots, err := client.GetFleets()
+	require.NoError(t, err)
+	require.Len(t, fleets, 1)
+	assert.Equal(t, int64(5), fleets[0].Ships.SmallCargo)
+	assert.Equal(t, int64(1), slots.InUse)
+
+	_, err = client.GetPlanet(ogame.PlanetID(1))
+	var apiErr *APIError
+	require.True(t, errors.As(err, &apiErr))
+	assert.Equal(t, http.StatusNotFound, apiErr.StatusCode)
+	assert.Equal(t, "celestial_not_found", apiErr.ErrorCode)
+	_, err = client.Account("unknown").GetPlanets()
+	require.True(t, errors.As(err, &apiErr))
+	assert.Equal(t, http.StatusNotFound, apiErr.StatusCode)
+}
@@ -0,0 +1,382 @@
+// Package openapi builds an OpenAPI 3 specification from the description of echo routes.
+// Schemas are generated by reflection on the go types of the request bodies and results.
+package openapi
+
+import (
+	"encoding"
+	"encoding/json"
+	"net/http"
+	"reflect"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/labstack/echo/v4"
+)
+
+// Version version of the OpenAPI specification generated
+const Version = "3.0.3"
+
+// Param in
+const (
+	InPath  = "path"
+	InQuery = "query"
+	InForm  = "form" // application/x-www-form-urlencoded body
+)
+
+// Param parameter of a route
+type Param struct {
+	Name        string
+	In          string // InPath, InQuery or InForm
+	Type        any    // Zero value of the type of the param, int64 if nil
+	Required    bool
+	Repeated    bool // Param can be given multiple times
+	Description string
+}
+
+// Route documentation of an echo route, and the handler serving it
+type Route struct {
+	Method      string
+	Path        string // Echo syntax, eg: /bot/planets/:planetID
+	Handler     echo.HandlerFunc
+	Summary     string
+	Tag         string
+	Params      []Param // Path params not listed are integers
+	Body        any     // Zero value of the json request body, nil if none. Can be given with form Params
+	Result      any     // Zero value of the Result of the APIResp, nil if the route has no result
+	ContentType string  // Response content type when the response is not a json APIResp (eg: text/html)
+	Root        bool    // Only served on the root of the server, not for every account
+	Scope       string  // Scope of the api key required to call the route, documented as x-scope
+}
+
+// Spec OpenAPI specification
+type Spec struct {
+	OpenAPI    string                `json:"openapi"`
+	Info       Info                  `json:"info"`
+	Servers    []Server              `json:"servers,omitempty"`
+	Paths      map[string]PathItem   `json:"paths"`
+	Components Components            `json:"components"`
+	Security   []SecurityRequirement `json:"security,omitempty"`
+}
+
+// Info information about the API
+type Info struct {
+	Title       string `json:"title"`
+	Description string `json:"description,omitempty"`
+	Version     string `json:"version"`
+}
+
+// Server base url of the API
+type Server struct {
+	URL         string                    `json:"url"`
+	Description string                    `json:"description,omitempty"`
+	Variables   map[string]ServerVariable `json:"variables,omitempty"`
+}
+
+// ServerVariable variable of a server url
+type ServerVariable struct {
+	Default     string `json:"default"`
+	Description string `json:"description,omitempty"`
+}
+
+// PathItem operations of a path by lower case http method
+type PathItem map[string]*Operation
+
+// Operation one route
+type Operation struct {
+	OperationID string              `json:"operationId,omitempty"`
+	Summary     string              `json:"summary,omitempty"`
+	Tags        []string            `json:"tags,omitempty"`
+	Servers     []Server            `json:"servers,omitempty"`
+	Parameters  []Parameter         `json:"parameters,omitempty"`
+	RequestBody *RequestBody        `json:"requestBody,omitempty"`
+	Responses   map[string]Response `json:"responses"`
+	XScope      string              `json:"x-scope,omitempty"`
+}
+
+// Parameter path or query parameter
+type Parameter struct {
+	Name        string  `json:"name"`
+	In          string  `json:"in"`
+	Description string  `json:"description,omitempty"`
+	Required    bool    `json:"required,omitempty"`
+	Schema      *Schema `json:"schema"`
+}
+
+// RequestBody body of an operation by content type
+type RequestBody struct {
+	Required bool                 `json:"required,omitempty"`
+	Content  map[string]MediaType `json:"content"`
+}
+
+// Response response of an operation
+type Response struct {
+	Description string               `json:"description"`
+	Content     map[string]MediaType `json:"content,omitempty"`
+}
+
+// MediaType schema of a content type
+type MediaType struct {
+	Schema *Schema `json:"schema"`
+}
+
+// Components reusable schemas and security schemes
+type Components struct {
+	Schemas         map[string]*Schema        `json:"schemas"`
+	SecuritySchemes map[string]SecurityScheme `json:"securitySchemes,omitempty"`
+}
+
+// SecurityScheme http authentication of the API
+type SecurityScheme struct {
+	Type        string `json:"type"`             // http
+	Scheme      string `json:"scheme,omitempty"` // basic or bearer
+	Description string `json:"description,omitempty"`
+}
+
+// SecurityRequirement security schemes accepted, by name
+type SecurityRequirement map[string][]string
+
+// Schema json schema of a type
+type Schema struct {
+	Ref                  string             `json:"$ref,omitempty"`
+	Type                 string             `json:"type,omitempty"`
+	Format               string             `json:"format,omitempty"`
+	Description          string             `json:"description,omitempty"`
+	Nullable             bool               `json:"nullable,omitempty"`
+	Items                *Schema            `json:"items,omitempty"`
+	Properties           map[string]*Schema `json:"properties,omitempty"`
+	AdditionalProperties *Schema            `json:"additionalProperties,omitempty"`
+	AllOf                []*Schema          `json:"allOf,omitempty"`
+}
+
+// APIRespSchema name of the component of the response envelope
+const APIRespSchema = "APIResp"
+
+var (
+	timeType          = reflect.TypeOf(time.Time{})
+	durationType      = reflect.TypeOf(time.Duration(0))
+	jsonMarshalerType = reflect.TypeOf((*json.Marshaler)(nil)).Elem()
+	textMarshalerType = reflect.TypeOf((*encoding.TextMarshaler)(nil)).Elem()
+	paramRgx          = regexp.MustCompile(`:([a-zA-Z0-9_]+)`)
+	componentRgx      = regexp.MustCompile(`[^a-zA-Z0-9._-]`)
+)
+
+// New creates a specification without routes
+func New(info Info) *Spec {
+	s := &Spec{
+		OpenAPI:    Version,
+		Info:       info,
+		Paths:      make(map[string]PathItem),
+		Components: Components{Schemas: make(map[string]*Schema)},
+	}
+	s.Components.Schemas[APIRespSchema] = &Schema{
+		Type:        "object",
+		Description: "Envelope of every json response",
+		Properties: map[string]*Schema{
+			"Status":    {Type: "string", Description: "ok or error"},
+			"Code":      {Type: "integer", Format: "int64"},
+			"Message":   {Type: "string", Description: "Error message"},
+			"ErrorCode": {Type: "string", Description: "Machine-readable error code, eg: not_enough_deuterium"},
+			"Result":    {Description: "Result of the request, see each operation"},
+		},
+	}
+	return s
+}
+
+// AddRoutes documents the routes
+func (s *Spec) AddRoutes(routes ...Route) *Spec {
+	for _, r := range routes {
+		path := paramRgx.ReplaceAllString(r.Path, "{$1}")
+		path = strings.Replace(path, "*", "{path}", 1)
+		if s.Paths[path] == nil {
+			s.Paths[path] = make(PathItem)
+		}
+		s.Paths[path][strings.ToLower(r.Method)] = s.operation(r)
+	}
+	return s
+}
+
+// operationID derives a unique id from the method and path of a route, eg: GET /bot/planets/:planetID -> getBotPlanetsPlanetID
+func operationID(method, path string) string {
+	out := strings.ToLower(method)
+	for _, part := range strings.FieldsFunc(path, func(r rune) bool { return r == '/' || r == '-' || r == ':' || r == '.' || r == '*' }) {
+		out += strings.ToUpper(part[:1]) + part[1:]
+	}
+	return out
+}
+
+func (s *Spec) operation(r Route) *Operation {
+	op := &Operation{
+		OperationID: operationID(r.Method, r.Path),
+		Summary:     r.Summary,
+		Responses:   make(map[string]Response),
+		XScope:      r.Scope,
+	}
+	if r.Tag != "" {
+		op.Tags = []string{r.Tag}
+	}
+	if r.Root {
+		op.Servers = []Server{{URL: "/"}}
+	}
+	declared := make(map[string]Param)
+	for _, p := range r.Params {
+		declared[p.In+":"+p.Name] = p
+	}
+	for _, m := range paramRgx.FindAllStringSubmatch(r.Path, -1) {
+		p, ok := declared[InPath+":"+m[1]]
+		if !ok {
+			p = Param{Name: m[1], In: InPath}
+		}
+		op.Parameters = append(op.Parameters, Parameter{Name: p.Name, In: InPath, Required: true, Description: p.Description, Schema: s.paramSchema(p)})
+	}
+	if strings.Contains(r.Path, "*") {
+		op.Parameters = append(op.Parameters, Parameter{Name: "path", In: InPath, Required: true, Schema: &Schema{Type: "string"}})
+	}
+	form := &Schema{Type: "object", Properties: make(map[string]*Schema)}
+	for _, p := range r.Params {
+		switch p.In {
+		case InQuery:
+			op.Parameters = append(op.Parameters, Parameter{Name: p.Name, In: InQuery, Required: p.Required, Description: p.Description, Schema: s.paramSchema(p)})
+		case InForm:
+			schema := s.paramSchema(p)
+			schema.Description = p.Description
+			form.Properties[p.Name] = schema
+		}
+	}
+	if len(form.Properties) > 0 || r.Body != nil {
+		op.RequestBody = &RequestBody{Content: make(map[string]MediaType)}
+	}
+	if len(form.Properties) > 0 {
+		op.RequestBody.Content[echo.MIMEApplicationForm] = MediaType{Schema: form}
+	}
+	if r.Body != nil {
+		op.RequestBody.Content[echo.MIMEApplicationJSON] = MediaType{Schema: s.SchemaOf(reflect.TypeOf(r.Body))}
+	}
+	if r.ContentType != "" {
+		op.Responses["200"] = Response{Description: "OK", Content: map[string]MediaType{r.ContentType: {Schema: &Schema{Type: "string"}}}}
+		return op
+	}
+	ok := &Schema{Ref: "#/components/schemas/" + APIRespSchema}
+	if r.Result != nil {
+		ok = &Schema{AllOf: []*Schema{ok, {Type: "object", Properties: map[string]*Schema{"Result": s.SchemaOf(reflect.TypeOf(r.Result))}}}}
+	}
+	errResp := MediaType{Schema: &Schema{Ref: "#/components/schemas/" + APIRespSchema}}
+	op.Responses["200"] = Response{Description: "OK", Content: map[string]MediaType{echo.MIMEApplicationJSON: {Schema: ok}}}
+	op.Responses["default"] = Response{Description: "Error", Content: map[string]MediaType{echo.MIMEApplicationJSON: errResp}}
+	return op
+}
+
+func (s *Spec) paramSchema(p Param) *Schema {
+	var schema *Schema
+	if p.Type == nil {
+		schema = &Schema{Type: "integer", Format: "int64"}
+	} else {
+		schema = s.SchemaOf(reflect.TypeOf(p.Type))
+	}
+	if p.Repeated {
+		return &Schema{Type: "array", Items: schema}
+	}
+	return schema
+}
+
+// componentName name of the component of a named type, eg: ogame.Fleet
+func componentName(t reflect.Type) string {
+	pkg := t.PkgPath()
+	if idx := strings.LastIndex(pkg, "/"); idx >= 0 {
+		pkg = pkg[idx+1:]
+	}
+	return componentRgx.ReplaceAllString(pkg+"."+t.Name(), "_")
+}
+
+// SchemaOf returns the schema of a type, structs are added to the components and referenced
+func (s *Spec) SchemaOf(t reflect.Type) *Schema {
+	switch {
+	case t == timeType:
+		return &Schema{Type: "string", Format: "date-time"}
+	case t == durationType:
+		return &Schema{Type: "integer", Format: "int64", Description: "Nanoseconds"}
+	case t.Kind() != reflect.Interface && t.Implements(jsonMarshalerType) && t.Kind() != reflect.Struct:
+		return &Schema{Description: "Custom json encoding"}
+	case t.Kind() != reflect.Interface && t.Implements(textMarshalerType):
+		return &Schema{Type: "string"}
+	}
+	switch t.Kind() {
+	case reflect.Bool:
+		return &Schema{Type: "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return &Schema{Type: "integer", Format: "int64"}
+	case reflect.Float32, reflect.Float64:
+		return &Schema{Type: "number", Format: "double"}
+	case reflect.String:
+		return &Schema{Type: "string"}
+	case reflect.Pointer:
+		schema := s.SchemaOf(t.Elem())
+		if schema.Ref != "" {
+			return &Schema{AllOf: []*Schema{schema}, Nullable: true}
+		}
+		schema.Nullable = true
+		return schema
+	case reflect.Slice, reflect.Array:
+		if t.Elem().Kind() == reflect.Uint8 {
+			return &Schema{Type: "string", Format: "byte"}
+		}
+		return &Schema{Type: "array", Items: s.SchemaOf(t.Elem())}
+	case reflect.Map:
+		return &Schema{Type: "object", AdditionalProperties: s.SchemaOf(t.Elem())}
+	case reflect.Struct:
+		if t.Name() == "" {
+			return s.structSchema(t)
+		}
+		name := componentName(t)
+		if _, ok := s.Components.Schemas[name]; !ok {
+			s.Components.Schemas[name] = &Schema{Type: "object"} // Placeholder for recursive types
+			s.Components.Schemas[name] = s.structSchema(t)
+		}
+		return &Schema{Ref: "#/components/schemas/" + name}
+	}
+	return &Schema{} // any
+}
+
+// structSchema schema of the exported fields of a struct, following the encoding/json rules
+func (s *Spec) structSchema(t reflect.Type) *Schema {
+	if t.Implements(jsonMarshalerType) {
+		return &Schema{Type: "object", Description: "Custom json encoding"}
+	}
+	schema := &Schema{Type: "object", Properties: make(map[string]*Schema)}
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		tag := f.Tag.Get("json")
+		if tag == "-" {
+			continue
+		}
+		name, _, _ := strings.Cut(tag, ",")
+		if f.Anonymous && name == "" {
+			ft := f.Type
+			if ft.Kind() == reflect.Pointer {
+				ft = ft.Elem()
+			}
+			if ft.Kind() == reflect.Struct {
+				for k, v := range s.structSchema(ft).Properties {
+					if _, ok := schema.Properties[k]; !ok { // Shallower fields win
+						schema.Properties[k] = v
+					}
+				}
+				continue
+			}
+		}
+		if !f.IsExported() {
+			continue
+		}
+		if name == "" {
+			name = f.Name
+		}
+		schema.Properties[name] = s.SchemaOf(f.Type)
+	}
+	return schema
+}
+
+// Handler serves the specification as json
+func (s *Spec) Handler(c echo.Context) error {
+	return c.JSON(http.StatusOK, s)
+}
@@ -0,0 +1,78 @@
+package openapi
+
+import (
+	"encoding/json"
+	"net/http"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type embedded struct {
+	Level int64
+}
+
+type item struct {
+	embedded
+	Name    string `json:"name"`
+	Hidden  string `json:"-"`
+	Date    time.Time
+	Parent  *item
+	Tags    []string
+	Counts  map[string]int64
+	private int64
+}
+
+func TestSpec_SchemaOf(t *testing.T) {
+	s := New(Info{Title: "test", Version: "1"})
+	schema := s.SchemaOf(reflect.TypeOf([]item{}))
+	assert.Equal(t, "array", schema.Type)
+	assert.Equal(t, "#/components/schemas/openapi.item", schema.Items.Ref)
+	component := s.Components.Schemas["openapi.item"]
+	require.NotNil(t, component)
+	assert.Len(t, component.Properties, 6)
+	assert.Equal(t, "integer", component.Properties["Level"].Type)
+	assert.Equal(t, "string", component.Properties["name"].Type)
+	assert.Equal(t, "date-time", component.Properties["Date"].Format)
+	assert.True(t, component.Properties["Parent"].Nullable)
+	assert.Equal(t, "#/components/schemas/openapi.item", component.Properties["Parent"].AllOf[0].Ref)
+	assert.Equal(t, "string", component.Properties["Tags"].Items.Type)
+	assert.Equal(t, "integer", component.Properties["Counts"].AdditionalProperties.Type)
+	assert.Equal(t, "byte", s.SchemaOf(reflect.TypeOf([]byte{})).Format)
+}
+
+func TestSpec_AddRoutes(t *testing.T) {
+	s := New(Info{Title: "test", Version: "1"}).AddRoutes(
+		Route{Method: http.MethodPost, Path: "/items/:itemID/send", Tag: "items", Scope: "fleet",
+			Params: []Param{{Name: "ships", In: InForm, Type: "", Repeated: true}, {Name: "speed", In: InForm}}, Result: item{}},
+		Route{Method: http.MethodGet, Path: "/items/:name", Params: []Param{{Name: "name", In: InPath, Type: ""}, {Name: "since", In: InQuery}}},
+		Route{Method: http.MethodGet, Path: "/static/*", ContentType: "text/html", Root: true},
+	)
+	op := s.Paths["/items/{itemID}/send"]["post"]
+	require.NotNil(t, op)
+	assert.Equal(t, "postItemsItemIDSend", op.OperationID)
+	assert.Equal(t, "fleet", op.XScope)
+	assert.Equal(t, "integer", op.Parameters[0].Schema.Type)
+	form := op.RequestBody.Content["application/x-www-form-urlencoded"].Schema
+	assert.Equal(t, "array", form.Properties["ships"].Type)
+	assert.Equal(t, "integer", form.Properties["speed"].Type)
+	result := op.Responses["200"].Content["application/json"].Schema.AllOf
+	assert.Equal(t, "#/components/schemas/APIResp", result[0].Ref)
+	assert.Equal(t, "#/components/schemas/openapi.item", result[1].Properties["Result"].Ref)
+
+	op = s.Paths["/items/{name}"]["get"]
+	require.Len(t, op.Parameters, 2)
+	assert.Equal(t, "string", op.Parameters[0].Schema.Type)
+	assert.Equal(t, InQuery, op.Parameters[1].In)
+
+	op = s.Paths["/static/{path}"]["get"]
+	assert.Equal(t, "/", op.Servers[0].URL)
+	assert.Contains(t, op.Responses["200"].Content, "text/html")
+
+	by, err := json.Marshal(s)
+	require.NoError(t, err)
+	assert.Contains(t, string(by), `"openapi":"3.0.3"`)
+}
@@ -0,0 +1,331 @@
+package planner
+
+import (
+	"math"
+	"sort"
+	"time"
+
+	"github.com/alaingilbert/ogame/pkg/ogame"
+)
+
+// Params account-wide settings used to compute productions, prices and construction times
+type Params struct {
+	UniverseSpeed  int64 // Economy speed of the universe (default 1)
+	CharacterClass ogame.CharacterClass
+	LfBonuses      ogame.LfBonuses
+	HasTechnocrat  bool
+}
+
+func (p Params) speed() int64 {
+	return max(p.UniverseSpeed, 1)
+}
+
+// Upgrade a construction that increases the production of the account
+type Upgrade struct {
+	CelestialID ogame.CelestialID // Celestial the construction is built on (paying for the research for technologies)
+	ID          ogame.ID
+	Level       int64 // Level reached once the construction is over
+	Price       ogame.Resources
+	Duration    time.Duration
+	Gain        ogame.Resources // Production per hour added to the account
+	ROI         float64         // Value of the hourly production gained per value spent (see ogame.Resources.Value)
+}
+
+// Payback returns the time it takes for the production gained to pay the price back
+func (u Upgrade) Payback() time.Duration {
+	if u.ROI <= 0 {
+		return math.MaxInt64
+	}
+	return time.Duration(float64(time.Hour) / u.ROI)
+}
+
+// Step upgrade of the build queue
+type Step struct {
+	Upgrade
+	AvailableAt time.Time // The celestial has the resources to pay the price
+	StartAt     time.Time // Resources are available and the queue is free
+	EndAt       time.Time
+}
+
+// lfProductionBonus production bonus per level of a lifeform building
+type lfProductionBonus struct {
+	lifeform                  ogame.LifeformType
+	metal, crystal, deuterium float64
+}
+
+var lfProductionBonuses = map[ogame.ID]lfProductionBonus{
+	ogame.HighEnergySmeltingID:         {lifeform: ogame.Humans, metal: 0.015},
+	ogame.FusionPoweredProductionID:    {lifeform: ogame.Humans, crystal: 0.015, deuterium: 0.015},
+	ogame.MagmaForgeID:                 {lifeform: ogame.Rocktal, metal: 0.02},
+	ogame.CrystalRefineryID:            {lifeform: ogame.Rocktal, crystal: 0.02},
+	ogame.DeuteriumSynthesiserID:       {lifeform: ogame.Rocktal, deuterium: 0.02},
+	ogame.HighPerformanceSynthesiserID: {lifeform: ogame.Mechas, deuterium: 0.02},
+}
+
+var minesIDs = []ogame.ID{ogame.MetalMineID, ogame.CrystalMineID, ogame.DeuteriumSynthesizerID}
+
+var energyIDs = []ogame.ID{ogame.SolarPlantID, ogame.FusionReactorID}
+
+// fullSettings production settings of the planner, everything at 100%
+var fullSettings = ogame.ResourceSettings{MetalMine: 100, CrystalMine: 100, DeuteriumSynthesizer: 100, SolarPlant: 100,
+	FusionReactor: 100, SolarSatellite: 100}
+
+// productionBonus returns the bonuses on the production of the mines, from the lifeform buildings of the planet,
+// the lifeform researches and the collector class
+func productionBonus(c ogame.EmpireCelestial, params Params) (bonus lfProductionBonus) {
+	for id, b := range lfProductionBonuses {
+		lvl := float64(c.LfBuildings.ByID(id))
+		bonus.metal += b.metal * lvl
+		bonus.crystal += b.crystal * lvl
+		bonus.deuterium += b.deuterium * lvl
+	}
+	lfResourceBonuses := params.LfBonuses.LfResourceBonuses
+	bonus.metal += lfResourceBonuses.MetalProduction
+	bonus.crystal += lfResourceBonuses.CrystalProduction
+	bonus.deuterium += lfResourceBonuses.DeuteriumProduction
+	if params.CharacterClass.IsCollector() {
+		bonus.metal += 0.25
+		bonus.crystal += 0.25
+		bonus.deuterium += 0.25
+	}
+	return
+}
+
+func energy(c ogame.EmpireCelestial, researches ogame.Researches, params Params) int64 {
+	return ogame.EnergyProduced(c.Temperature, c.Supplies, fullSettings, researches.EnergyTechnology, params.CharacterClass.IsCollector()) -
+		ogame.EnergyNeeded(c.Supplies, fullSettings)
+}
+
+func productionRatio(c ogame.EmpireCelestial, researches ogame.Researches, params Params) float64 {
+	return ogame.ProductionRatio(c.Temperature, c.Supplies, fullSettings, researches.EnergyTechnology, params.CharacterClass.IsCollector())
+}
+
+func productionWithRatio(c ogame.EmpireCelestial, researches ogame.Researches, params Params, ratio float64) ogame.Resources {
+	speed := params.speed()
+	bonus := productionBonus(c, params)
+	supplies := c.Supplies
+	out := ogame.Productions(supplies, fullSettings, researches, speed, c.Temperature, ratio, params.CharacterClass.IsCollector())
+	// Bonuses apply to the production of the mines, without the base production of the planet
+	metalMine := ogame.MetalMine.Production(speed, 1, ratio, 0, supplies.MetalMine) - ogame.MetalMine.Production(speed, 1, ratio, 0, 0)
+	crystalMine := ogame.CrystalMine.Production(speed, 1, ratio, 0, supplies.CrystalMine) - ogame.CrystalMine.Production(speed, 1, ratio, 0, 0)
+	deuteriumMine := ogame.DeuteriumSynthesizer.Production(speed, c.Temperature.Mean(), 1, ratio, 0, supplies.DeuteriumSynthesizer)
+	out.Metal += int64(float64(metalMine) * bonus.metal)
+	out.Crystal += int64(float64(crystalMine) * bonus.crystal)
+	out.Deuterium += int64(float64(deuteriumMine) * bonus.deuterium)
+	return out
+}
+
+// StorageCapacity returns the resources a planet can store, with the lifeform research bonuses
+func StorageCapacity(c ogame.EmpireCelestial, params Params) ogame.Resources {
+	lfResourceBonuses := params.LfBonuses.LfResourceBonuses
+	return ogame.Resources{
+		Metal:     int64(float64(ogame.MetalStorage.Capacity(c.Supplies.MetalStorage)) * (1 + lfResourceBonuses.MetalStorageCapacity)),
+		Crystal:   int64(float64(ogame.CrystalStorage.Capacity(c.Supplies.CrystalStorage)) * (1 + lfResourceBonuses.CrystalStorageCapacity)),
+		Deuterium: int64(float64(ogame.DeuteriumTank.Capacity(c.Supplies.DeuteriumTank)) * (1 + lfResourceBonuses.DeuteriumStorageCapacity)),
+	}
+}
+
+// Production returns the resources produced per hour by a planet, all production settings being at 100%
+func Production(c ogame.EmpireCelestial, researches ogame.Researches, params Params) ogame.Resources {
+	if c.Type != ogame.PlanetType {
+		return ogame.Resources{}
+	}
+	return productionWithRatio(c, researches, params, productionRatio(c, researches, params))
+}
+
+func levelOf(c ogame.EmpireCelestial, researches ogame.Researches, id ogame.ID) int64 {
+	if id.IsTech() {
+		return researches.ByID(id)
+	} else if id.IsLfBuilding() {
+		return c.LfBuildings.ByID(id)
+	}
+	return c.Supplies.ByID(id)
+}
+
+func setLevel(c *ogame.EmpireCelestial, researches *ogame.Researches, id ogame.ID, lvl int64) {
+	switch id {
+	case ogame.MetalMineID:
+		c.Supplies.MetalMine = lvl
+	case ogame.CrystalMineID:
+		c.Supplies.CrystalMine = lvl
+	case ogame.DeuteriumSynthesizerID:
+		c.Supplies.DeuteriumSynthesizer = lvl
+	case ogame.SolarPlantID:
+		c.Supplies.SolarPlant = lvl
+	case ogame.FusionReactorID:
+		c.Supplies.FusionReactor = lvl
+	case ogame.HighEnergySmeltingID:
+		c.LfBuildings.HighEnergySmelting = lvl
+	case ogame.FusionPoweredProductionID:
+		c.LfBuildings.FusionPoweredProduction = lvl
+	case ogame.MagmaForgeID:
+		c.LfBuildings.MagmaForge = lvl
+	case ogame.CrystalRefineryID:
+		c.LfBuildings.CrystalRefinery = lvl
+	case ogame.DeuteriumSynthesiserID:
+		c.LfBuildings.DeuteriumSynthesiser = lvl
+	case ogame.HighPerformanceSynthesiserID:
+		c.LfBuildings.HighPerformanceSynthesiser = lvl
+	case ogame.PlasmaTechnologyID:
+		researches.PlasmaTechnology = lvl
+	}
+}
+
+func isAvailable(c ogame.EmpireCelestial, researches ogame.Researches, id ogame.ID, params Params) bool {
+	if bonus, ok := lfProductionBonuses[id]; ok && bonus.lifeform != c.LfBuildings.LifeformType {
+		return false
+	}
+	return ogame.Objs.ByID(id).IsAvailable(c.Type, c.Supplies, c.LfBuildings, c.LfResearches, c.Facilities, researches,
+		energy(c, researches, params), params.CharacterClass)
+}
+
+// account planned state of the account, with the constructions of all the steps already planned
+type account struct {
+	celestials []ogame.EmpireCelestial
+	researches ogame.Researches
+	params     Params
+}
+
+func newAccount(celestials []ogame.EmpireCelestial, params Params) *account {
+	a := &account{params: params}
+	for _, c := range celestials {
+		if c.Type == ogame.PlanetType {
+			a.celestials = append(a.celestials, c)
+		}
+	}
+	if len(a.celestials) > 0 {
+		a.researches = a.celestials[0].Researches
+	}
+	return a
+}
+
+// researchCelestial returns the index of the planet having the best research lab, -1 if none
+func (a *account) researchCelestial() int {
+	idx := -1
+	for i, c := range a.celestials {
+		if c.Facilities.ResearchLab > 0 && (idx == -1 || c.Facilities.ResearchLab > a.celestials[idx].Facilities.ResearchLab) {
+			idx = i
+		}
+	}
+	return idx
+}
+
+func (a *account) newUpgrade(idx int, id ogame.ID, gain ogame.Resources) (Upgrade, bool) {
+	c := a.celestials[idx]
+	obj := ogame.Objs.ByID(id)
+	level := levelOf(c, a.researches, id) + 1
+	price := obj.GetPrice(level, a.params.LfBonuses)
+	cost := price.Value()
+	if cost <= 0 || gain.Value() <= 0 {
+		return Upgrade{}, false
+	}
+	return Upgrade{
+		CelestialID: c.ID,
+		ID:          id,
+		Level:       level,
+		Price:       price,
+		Duration:    obj.ConstructionTime(level, a.params.speed(), c.Facilities, a.params.LfBonuses, a.params.CharacterClass, a.params.HasTechnocrat),
+		Gain:        gain,
+		ROI:         float64(gain.Value()) / float64(cost),
+	}, true
+}
+
+func diff(after, before ogame.Resources) ogame.Resources {
+	return ogame.Resources{Metal: after.Metal - before.Metal, Crystal: after.Crystal - before.Crystal, Deuterium: after.Deuterium - before.Deuterium}
+}
+
+// upgrades returns every upgrade that increases the production of the account.
+// A planet lacking energy can only upgrade its energy buildings. Otherwise, the gain of the mines
+// and lifeform buildings is computed as if the energy will follow.
+func (a *account) upgrades() []Upgrade {
+	out := make([]Upgrade, 0)
+	for idx, c := range a.celestials {
+		ratio := productionRatio(c, a.researches, a.params)
+		ids := energyIDs
+		if ratio == 1 {
+			ids = append(append([]ogame.ID{}, minesIDs...), ogame.HighEnergySmeltingID, ogame.FusionPoweredProductionID,
+				ogame.MagmaForgeID, ogame.CrystalRefineryID, ogame.DeuteriumSynthesiserID, ogame.HighPerformanceSynthesiserID)
+		}
+		before := productionWithRatio(c, a.researches, a.params, ratio)
+		for _, id := range ids {
+			if !isAvailable(c, a.researches, id, a.params) {
+				continue
+			}
+			next, researches := c, a.researches
+			setLevel(&next, &researches, id, levelOf(c, a.researches, id)+1)
+			after := productionWithRatio(next, researches, a.params, ratio)
+			if ratio < 1 {
+				after = Production(next, researches, a.params)
+			}
+			if upgrade, ok := a.newUpgrade(idx, id, diff(after, before)); ok {
+				out = append(out, upgrade)
+			}
+		}
+	}
+	if idx := a.researchCelestial(); idx != -1 && isAvailable(a.celestials[idx], a.researches, ogame.PlasmaTechnologyID, a.params) {
+		researches := a.researches
+		researches.PlasmaTechnology++
+		var gain ogame.Resources
+		for _, c := range a.celestials {
+			gain = gain.Add(diff(Production(c, researches, a.params), Production(c, a.researches, a.params)))
+		}
+		if upgrade, ok := a.newUpgrade(idx, ogame.PlasmaTechnologyID, gain); ok {
+			out = append(out, upgrade)
+		}
+	}
+	sort.SliceStable(out, func(i, j int) bool { return out[i].ROI > out[j].ROI })
+	return out
+}
+
+func (a *account) apply(u Upgrade) {
+	for i := range a.celestials {
+		if a.celestials[i].ID == u.CelestialID {
+			setLevel(&a.celestials[i], &a.researches, u.ID, u.Level)
+		}
+	}
+}
+
+// Upgrades returns the upgrades that increase the production of the account, best return on investment first
+func Upgrades(celestials []ogame.EmpireCelestial, params Params) []Upgrade {
+	return newAccount(celestials, params).upgrades()
+}
+
+// BestUpgrade returns the upgrade having the best return on investment, false if there is none
+func BestUpgrade(celestials []ogame.EmpireCelestial, params Params) (Upgrade, bool) {
+	upgrades := Upgrades(celestials, params)
+	if len(upgrades) == 0 {
+		return Upgrade{}, false
+	}
+	return upgrades[0], true
+}
+
+// Plan returns the n next upgrades by return on investment, each one with the time at which it can be built.
+// Celestials start with their Resources at time now, and accumulate their production.
+// Buildings, lifeform buildings and researches have their own queue, a step starts once its queue is free,
+// and never before a previous step paid with the resources of the same celestial.
+// The production of a step is accounted for once it is over.
+func Plan(celestials []ogame.EmpireCelestial, params Params, now time.Time, n int) []Step {
+	acc := newAccount(celestials, params)
+	sim := newSimulation(acc.celestials, acc.researches, params, now)
+	steps := make([]Step, 0, n)
+	excluded := make(map[Upgrade]struct{})
+	for len(steps) < n {
+		var step Step
+		var found bool
+		for _, upgrade := range acc.upgrades() {
+			if _, ok := excluded[upgrade]; ok {
+				continue
+			}
+			if step, found = sim.schedule(upgrade); found {
+				break
+			}
+			excluded[upgrade] = struct{}{}
+		}
+		if !found {
+			break
+		}
+		acc.apply(step.Upgrade)
+		steps = append(steps, step)
+	}
+	return steps
+}
@@ -0,0 +1,131 @@
+package planner
+
+import (
+	"testing"
+	"time"
+
+	"github.com/alaingilbert/ogame/pkg/ogame"
+	"github.com/stretchr/testify/assert"
+)
+
+func newPlanet(id ogame.CelestialID, supplies ogame.ResourcesBuildings) ogame.EmpireCelestial {
+	return ogame.EmpireCelestial{
+		ID:          id,
+		Type:        ogame.PlanetType,
+		Temperature: ogame.Temperature{Min: 10, Max: 50},
+		Supplies:    supplies,
+		Facilities:  ogame.Facilities{RoboticsFactory: 2},
+	}
+}
+
+func TestProduction(t *testing.T) {
+	planet := newPlanet(1, ogame.ResourcesBuildings{MetalMine: 10, CrystalMine: 8, DeuteriumSynthesizer: 5, SolarPlant: 12})
+	params := Params{UniverseSpeed: 1}
+	prod := Production(planet, ogame.Researches{}, params)
+	assert.Equal(t, ogame.MetalMine.Production(1, 1, 1, 0, 10), prod.Metal)
+	assert.Equal(t, ogame.CrystalMine.Production(1, 1, 1, 0, 8), prod.Crystal)
+	assert.Equal(t, ogame.DeuteriumSynthesizer.Production(1, 30, 1, 1, 0, 5), prod.Deuterium)
+	assert.True(t, prod.Energy > 0)
+
+	planet.LfBuildings = ogame.LfBuildings{LifeformType: ogame.Humans, HighEnergySmelting: 2}
+	params.CharacterClass = ogame.Collector
+	boosted := Production(planet, ogame.Researches{}, params)
+	mine := ogame.MetalMine.Production(1, 1, 1, 0, 10) - ogame.MetalMine.Production(1, 1, 1, 0, 0)
+	assert.Equal(t, prod.Metal+int64(float64(mine)*0.28), boosted.Metal)
+
+	// Lifeform research bonuses
+	params.LfBonuses.LfResourceBonuses.MetalProduction = 0.1
+	boosted = Production(planet, ogame.Researches{}, params)
+	assert.Equal(t, prod.Metal+int64(float64(mine)*0.38), boosted.Metal)
+
+	assert.Equal(t, ogame.Resources{}, Production(ogame.EmpireCelestial{Type: ogame.MoonType}, ogame.Researches{}, params))
+}
+
+func TestBestUpgrade_EnergyDeficit(t *testing.T) {
+	planet := newPlanet(1, ogame.ResourcesBuildings{MetalMine: 10, CrystalMine: 8, DeuteriumSynthesizer: 5, SolarPlant: 5})
+	upgrade, ok := BestUpgrade([]ogame.EmpireCelestial{planet}, Params{})
+	assert.True(t, ok)
+	assert.Equal(t, ogame.SolarPlantID, upgrade.ID)
+	assert.Equal(t, int64(6), upgrade.Level)
+	assert.Equal(t, ogame.SolarPlant.GetPrice(6, ogame.LfBonuses{}), upgrade.Price)
+}
+
+func TestUpgrades(t *testing.T) {
+	planet := newPlanet(1, ogame.ResourcesBuildings{MetalMine: 20, CrystalMine: 16, DeuteriumSynthesizer: 12, SolarPlant: 22})
+	planet.Facilities.ResearchLab = 5
+	planet.Researches = ogame.Researches{EnergyTechnology: 8, LaserTechnology: 10, IonTechnology: 5}
+	planet.LfBuildings = ogame.LfBuildings{LifeformType: ogame.Humans}
+	upgrades := Upgrades([]ogame.EmpireCelestial{planet, {ID: 2, Type: ogame.MoonType}}, Params{})
+	ids := make([]ogame.ID, 0)
+	for i, upgrade := range upgrades {
+		assert.Equal(t, ogame.CelestialID(1), upgrade.CelestialID)
+		assert.True(t, upgrade.ROI > 0)
+		if i > 0 {
+			assert.True(t, upgrades[i-1].ROI >= upgrade.ROI)
+		}
+		ids = append(ids, upgrade.ID)
+	}
+	assert.ElementsMatch(t, []ogame.ID{ogame.MetalMineID, ogame.CrystalMineID, ogame.DeuteriumSynthesizerID, ogame.PlasmaTechnologyID}, ids)
+}
+
+func TestPlan(t *testing.T) {
+	now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	planet1 := newPlanet(1, ogame.ResourcesBuildings{MetalMine: 10, CrystalMine: 8, DeuteriumSynthesizer: 5, SolarPlant: 12})
+	planet1.Resources = ogame.Resources{Metal: 100000, Crystal: 100000, Deuterium: 100000}
+	planet2 := newPlanet(2, ogame.ResourcesBuildings{MetalMine: 12, CrystalMine: 10, DeuteriumSynthesizer: 8, SolarPlant: 15})
+	steps := Plan([]ogame.EmpireCelestial{planet1, planet2}, Params{UniverseSpeed: 2}, now, 20)
+	assert.Len(t, steps, 20)
+	assert.Equal(t, now, steps[0].AvailableAt)
+	lastEnd := make(map[ogame.CelestialID]time.Time)
+	levels := make(map[ogame.CelestialID]map[ogame.ID]int64)
+	for _, step := range steps {
+		assert.False(t, step.StartAt.Before(step.AvailableAt))
+		assert.Equal(t, step.StartAt.Add(step.Duration), step.EndAt)
+		assert.False(t, step.StartAt.Before(lastEnd[step.CelestialID]), "building queue is busy")
+		lastEnd[step.CelestialID] = step.EndAt
+		if levels[step.CelestialID] == nil {
+			levels[step.CelestialID] = make(map[ogame.ID]int64)
+		}
+		if prev, ok := levels[step.CelestialID][step.ID]; ok {
+			assert.Equal(t, prev+1, step.Level)
+		}
+		levels[step.CelestialID][step.ID] = step.Level
+	}
+	assert.Contains(t, lastEnd, ogame.CelestialID(2))
+}
+
+func TestPlan_NoProduction(t *testing.T) {
+	planet := newPlanet(1, ogame.ResourcesBuildings{})
+	planet.Type = ogame.MoonType
+	assert.Empty(t, Plan([]ogame.EmpireCelestial{planet}, Params{}, time.Now(), 5))
+}
+
+func TestStorageCapacity(t *testing.T) {
+	planet := newPlanet(1, ogame.ResourcesBuildings{MetalStorage: 2})
+	params := Params{}
+	params.LfBonuses.LfResourceBonuses.MetalStorageCapacity = 0.5
+	capacity := StorageCapacity(planet, params)
+	assert.Equal(t, int64(float64(ogame.MetalStorage.Capacity(2))*1.5), capacity.Metal)
+	assert.Equal(t, ogame.CrystalStorage.Capacity(0), capacity.Crystal)
+}
+
+func TestPlan_StorageCapacity(t *testing.T) {
+	now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	planet := newPlanet(1, ogame.ResourcesBuildings{MetalMine: 22, CrystalMine: 18, DeuteriumSynthesizer: 12, SolarPlant: 24})
+	capacity := StorageCapacity(planet, Params{})
+	// The upgrades cost more than the storages can hold
+	assert.Empty(t, Plan([]ogame.EmpireCelestial{planet}, Params{}, now, 5))
+
+	bigger := planet
+	bigger.Supplies.MetalStorage, bigger.Supplies.CrystalStorage = 8, 8
+	steps := Plan([]ogame.EmpireCelestial{bigger}, Params{}, now, 5)
+	assert.Len(t, steps, 5)
+	for _, step := range steps {
+		assert.LessOrEqual(t, step.Price.Metal, StorageCapacity(bigger, Params{}).Metal)
+	}
+
+	// Production stops once the storage is full
+	state := newSimulation([]ogame.EmpireCelestial{planet}, ogame.Researches{}, Params{}, now).celestials[1]
+	state.advance(now.Add(24*time.Hour), Params{})
+	assert.Equal(t, float64(capacity.Metal), state.resources.metal)
+}
@@ -0,0 +1,186 @@
+package planner
+
+import (
+	"math"
+	"sort"
+	"time"
+
+	"github.com/alaingilbert/ogame/pkg/ogame"
+)
+
+// maxWait upgrades that cannot be paid within this duration are not planned
+const maxWait = 5 * 365 * 24 * time.Hour
+
+type amounts struct {
+	metal, crystal, deuterium float64
+}
+
+// pendingChange level reached by a construction, effective once it is over
+type pendingChange struct {
+	at    time.Time
+	id    ogame.ID
+	level int64
+}
+
+// celestialState resources of a celestial at a given time, with the constructions that are not over yet
+type celestialState struct {
+	celestial  ogame.EmpireCelestial
+	researches ogame.Researches
+	time       time.Time
+	resources  amounts
+	production ogame.Resources
+	capacity   ogame.Resources // Production stops once the storage is full
+	pending    []pendingChange
+	buildings  time.Time // Building queue is busy until
+	lfBuilding time.Time // Lifeform building queue is busy until
+}
+
+func (s *celestialState) clone() *celestialState {
+	out := *s
+	out.pending = append([]pendingChange{}, s.pending...)
+	return &out
+}
+
+func (s *celestialState) accumulate(t time.Time) {
+	if !t.After(s.time) {
+		return
+	}
+	hours := t.Sub(s.time).Hours()
+	s.resources.metal = produce(s.resources.metal, float64(s.production.Metal)*hours, float64(s.capacity.Metal))
+	s.resources.crystal = produce(s.resources.crystal, float64(s.production.Crystal)*hours, float64(s.capacity.Crystal))
+	s.resources.deuterium = max(produce(s.resources.deuterium, float64(s.production.Deuterium)*hours, float64(s.capacity.Deuterium)), 0)
+	s.time = t
+}
+
+// produce adds the production to the amount, without exceeding the capacity.
+// An amount already over the capacity (eg: loot) is kept.
+func produce(amount, production, capacity float64) float64 {
+	if production <= 0 {
+		return amount + production
+	}
+	return min(amount+production, max(amount, capacity))
+}
+
+// advance accumulates the production until t, applying the constructions that are over before
+func (s *celestialState) advance(t time.Time, params Params) {
+	for len(s.pending) > 0 && !s.pending[0].at.After(t) {
+		change := s.pending[0]
+		s.pending = s.pending[1:]
+		s.accumulate(change.at)
+		setLevel(&s.celestial, &s.researches, change.id, change.level)
+		s.production = Production(s.celestial, s.researches, params)
+	}
+	s.accumulate(t)
+}
+
+func (s *celestialState) addPending(change pendingChange) {
+	s.pending = append(s.pending, change)
+	sort.SliceStable(s.pending, func(i, j int) bool { return s.pending[i].at.Before(s.pending[j].at) })
+}
+
+// waitFor advances the state until the celestial has the price, false if it never will
+func (s *celestialState) waitFor(price ogame.Resources, params Params) bool {
+	deadline := s.time.Add(maxWait)
+	for {
+		wait := 0.0
+		for _, r := range []struct{ price, amount, production, capacity float64 }{
+			{float64(price.Metal), s.resources.metal, float64(s.production.Metal), float64(s.capacity.Metal)},
+			{float64(price.Crystal), s.resources.crystal, float64(s.production.Crystal), float64(s.capacity.Crystal)},
+			{float64(price.Deuterium), s.resources.deuterium, float64(s.production.Deuterium), float64(s.capacity.Deuterium)},
+		} {
+			missing := r.price - r.amount
+			if missing <= 0 {
+				continue
+			}
+			if r.production <= 0 || r.price > r.capacity { // Storage is too small to ever have the price
+				wait = math.Inf(1)
+				break
+			}
+			wait = max(wait, missing/r.production)
+		}
+		if len(s.pending) > 0 && (math.IsInf(wait, 1) || s.pending[0].at.Before(s.time.Add(hoursToDuration(wait)))) {
+			s.advance(s.pending[0].at, params)
+			continue
+		}
+		if math.IsInf(wait, 1) {
+			return false
+		}
+		at := s.time.Add(hoursToDuration(wait))
+		if at.After(deadline) {
+			return false
+		}
+		s.advance(at, params)
+		return true
+	}
+}
+
+func (s *celestialState) pay(price ogame.Resources) {
+	s.resources.metal = max(s.resources.metal-float64(price.Metal), 0)
+	s.resources.crystal = max(s.resources.crystal-float64(price.Crystal), 0)
+	s.resources.deuterium = max(s.resources.deuterium-float64(price.Deuterium), 0)
+}
+
+func hoursToDuration(hours float64) time.Duration {
+	return time.Duration(math.Ceil(hours*float64(time.Hour)/float64(time.Second))) * time.Second
+}
+
+// simulation resources and queues of all the planets over time
+type simulation struct {
+	params     Params
+	celestials map[ogame.CelestialID]*celestialState
+	research   time.Time // Research queue is busy until
+}
+
+func newSimulation(celestials []ogame.EmpireCelestial, researches ogame.Researches, params Params, now time.Time) *simulation {
+	s := &simulation{params: params, celestials: make(map[ogame.CelestialID]*celestialState), research: now}
+	for _, c := range celestials {
+		s.celestials[c.ID] = &celestialState{
+			celestial:  c,
+			researches: researches,
+			time:       now,
+			resources:  amounts{metal: float64(c.Resources.Metal), crystal: float64(c.Resources.Crystal), deuterium: float64(c.Resources.Deuterium)},
+			production: Production(c, researches, params),
+			capacity:   StorageCapacity(c, params),
+			buildings:  now,
+			lfBuilding: now,
+		}
+	}
+	return s
+}
+
+// schedule pays the upgrade as soon as possible, false if the celestial can never pay it
+func (s *simulation) schedule(upgrade Upgrade) (Step, bool) {
+	orig, ok := s.celestials[upgrade.CelestialID]
+	if !ok {
+		return Step{}, false
+	}
+	state := orig.clone()
+	if !state.waitFor(upgrade.Price, s.params) {
+		return Step{}, false
+	}
+	step := Step{Upgrade: upgrade, AvailableAt: state.time}
+	queue := &state.buildings
+	if upgrade.ID.IsTech() {
+		queue = &s.research
+	} else if upgrade.ID.IsLfBuilding() {
+		queue = &state.lfBuilding
+	}
+	step.StartAt = step.AvailableAt
+	if queue.After(step.StartAt) {
+		step.StartAt = *queue
+	}
+	step.EndAt = step.StartAt.Add(upgrade.Duration)
+	state.advance(step.StartAt, s.params)
+	state.pay(upgrade.Price)
+	*queue = step.EndAt
+	s.celestials[upgrade.CelestialID] = state
+	change := pendingChange{at: step.EndAt, id: upgrade.ID, level: upgrade.Level}
+	if upgrade.ID.IsTech() {
+		for _, c := range s.celestials {
+			c.addPending(change)
+		}
+	} else {
+		state.addPending(change)
+	}
+	return step, true
+}
@@ -0,0 +1,89 @@
+package simulator
+
+import (
+	"github.com/alaingilbert/ogame/pkg/ogame"
+)
+
+// RaidParams our side of an attack on the target of an espionage report
+type RaidParams struct {
+	Ships          ogame.ShipsInfos
+	Researches     ogame.Researches
+	LfBonuses      ogame.LfBonuses
+	CharacterClass ogame.CharacterClass
+	AllianceClass  ogame.AllianceClass
+	FlightTime     int64 // Flight time to the target in seconds (eg: using logistics.Params.RaidParams)
+	Fuel           int64 // Deuterium consumed by the fleet (eg: using logistics.Params.RaidParams)
+
+	// DefenderLfBonuses lifeform bonuses of the target, they are not in espionage reports (eg: from a combat report)
+	DefenderLfBonuses ogame.LfBonuses
+}
+
+// ReportSimulation result of the simulation of an attack on the target of an espionage report
+type ReportSimulation struct {
+	SimulatorResult
+	FlightTime int64           // Flight time to the target in seconds
+	Fuel       int64           // Deuterium consumed by the fleet
+	Profit     ogame.Resources // Average loot minus average attacker losses minus fuel
+
+	// Uncertain is true when the report does not contain the fleet, defenses or researches of the target.
+	// The missing sections are simulated as empty, so the target may be stronger than simulated.
+	Uncertain       bool
+	MissingSections []string
+}
+
+// SimulateEspionageReport simulates an attack of our fleet on the target of an espionage report,
+// and computes the expected profit of the raid.
+// params.CargoHyperspaceTechMultiplier and params.FleetToDebris should be set from the server data.
+func SimulateEspionageReport(report ogame.EspionageReport, raid RaidParams, params SimulatorParams) ReportSimulation {
+	out := ReportSimulation{MissingSections: make([]string, 0)}
+
+	// The defender only has the resources that can be plundered, all of them are looted if the cargo allows it
+	loot := report.Loot(raid.CharacterClass)
+	params.LootPercentage = 1
+	defender := Defender{
+		Metal:          int(loot.Metal),
+		Crystal:        int(loot.Crystal),
+		Deuterium:      int(loot.Deuterium),
+		LfBonuses:      raid.DefenderLfBonuses,
+		CharacterClass: report.CharacterClass,
+		AllianceClass:  report.AllianceClass,
+	}
+	if ships := report.ShipsInfos(); ships != nil {
+		defender.ShipsInfos = *ships
+	} else {
+		out.MissingSections = append(out.MissingSections, "fleet")
+	}
+	if defenses := report.DefensesInfos(); defenses != nil {
+		defender.DefensesInfos = *defenses
+	} else {
+		out.MissingSections = append(out.MissingSections, "defenses")
+	}
+	if researches := report.Researches(); researches != nil {
+		defender.Weapon = int(researches.WeaponsTechnology)
+		defender.Shield = int(researches.ShieldingTechnology)
+		defender.Armour = int(researches.ArmourTechnology)
+	} else {
+		out.MissingSections = append(out.MissingSections, "researches")
+	}
+	out.Uncertain = len(out.MissingSections) > 0
+
+	attacker := Attacker{
+		Weapon:               int(raid.Researches.WeaponsTechnology),
+		Shield:               int(raid.Researches.ShieldingTechnology),
+		Armour:               int(raid.Researches.ArmourTechnology),
+		HyperspaceTechnology: int(raid.Researches.HyperspaceTechnology),
+		LfBonuses:            raid.LfBonuses,
+		CharacterClass:       raid.CharacterClass,
+		AllianceClass:        raid.AllianceClass,
+		ShipsInfos:           raid.Ships,
+	}
+	out.SimulatorResult = Simulate(attacker, defender, params)
+
+	out.FlightTime, out.Fuel = raid.FlightTime, raid.Fuel
+	out.Profit = ogame.Resources{
+		Metal:     int64(out.Loot.Metal - out.AttackerLosses.Metal),
+		Crystal:   int64(out.Loot.Crystal - out.AttackerLosses.Crystal),
+		Deuterium: int64(out.Loot.Deuterium-out.AttackerLosses.Deuterium) - out.Fuel,
+	}
+	return out
+}
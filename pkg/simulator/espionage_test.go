@@ -0,0 +1,44 @@
+package simulator
+
+import (
+	"testing"
+
+	"github.com/alaingilbert/ogame/pkg/ogame"
+	"github.com/alaingilbert/ogame/pkg/utils"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSimulateEspionageReport(t *testing.T) {
+	raid := RaidParams{
+		Ships:      ogame.ShipsInfos{LargeCargo: 10},
+		Researches: ogame.Researches{CombustionDrive: 6},
+		FlightTime: 3600,
+		Fuel:       500,
+	}
+	report := ogame.EspionageReport{
+		Resources:              ogame.Resources{Metal: 100000, Crystal: 50000, Deuterium: 20000},
+		Coordinate:             ogame.Coordinate{Galaxy: 1, System: 3, Position: 8, Type: ogame.PlanetType},
+		HasFleetInformation:    true,
+		HasDefensesInformation: true,
+		RocketLauncher:         utils.I64Ptr(0),
+		LargeCargo:             utils.I64Ptr(0),
+	}
+	res := SimulateEspionageReport(report, raid, SimulatorParams{Simulations: 1, Seed: 1, FleetToDebris: 0.3})
+	assert.True(t, res.Uncertain)
+	assert.Equal(t, []string{"researches"}, res.MissingSections)
+	assert.Equal(t, 100, res.AttackerWin)
+	assert.Equal(t, price{Metal: 50000, Crystal: 25000, Deuterium: 10000}, res.Loot)
+	assert.Equal(t, int64(3600), res.FlightTime)
+	assert.Equal(t, ogame.Resources{Metal: 50000, Crystal: 25000, Deuterium: 9500}, res.Profit)
+
+	// Bandits can be fully plundered
+	report.IsBandit = true
+	res = SimulateEspionageReport(report, raid, SimulatorParams{Simulations: 1, Seed: 1})
+	assert.Equal(t, price{Metal: 100000, Crystal: 50000, Deuterium: 20000}, res.Loot)
+	report.IsBandit = false
+
+	report.HasResearchesInformation = true
+	report.HasFleetInformation = false
+	res = SimulateEspionageReport(report, raid, SimulatorParams{Simulations: 1, Seed: 1})
+	assert.Equal(t, []string{"fleet"}, res.MissingSections)
+}
@@ -0,0 +1,271 @@
+package simulator
+
+import (
+	"errors"
+	"math"
+
+	"github.com/alaingilbert/ogame/pkg/ogame"
+)
+
+// ErrNoWinningFleet returned when none of the available fleets can win the battle with the requested probability
+var ErrNoWinningFleet = errors.New("no winning fleet")
+
+// Candidate fleet evaluated by the optimizer
+type Candidate struct {
+	Ships  ogame.ShipsInfos
+	Result SimulatorResult
+	Value  int64 // Fleet value (metal+crystal+deuterium)
+	Cargo  int64 // Cargo capacity of the fleet
+	Fuel   int64 // Fuel needed to send the fleet, 0 if OptimizerParams.Fuel is not set
+}
+
+// Profit returns the average loot minus the average losses and the fuel
+func (c Candidate) Profit() int64 {
+	return int64(c.Result.Loot.Total()-c.Result.AttackerLosses.Total()) - c.Fuel
+}
+
+// Objective scores a candidate, the optimizer keeps the candidate with the lowest score
+type Objective func(c Candidate) float64
+
+// Constraint returns either or not a candidate is acceptable
+type Constraint func(c Candidate) bool
+
+// MinimizeValue objective that keeps the fleet with the lowest value (default)
+func MinimizeValue(c Candidate) float64 { return float64(c.Value) }
+
+// MinimizeLosses objective that keeps the fleet with the lowest average losses
+func MinimizeLosses(c Candidate) float64 { return float64(c.Result.AttackerLosses.Total()) }
+
+// MaximizeProfit objective that keeps the fleet with the highest profit (loot - losses - fuel)
+func MaximizeProfit(c Candidate) float64 { return -float64(c.Profit()) }
+
+// MinimizeFuel objective that keeps the fleet using the least fuel
+func MinimizeFuel(c Candidate) float64 { return float64(c.Fuel) }
+
+// OptimizerParams parameters of the fleet optimizer
+type OptimizerParams struct {
+	Available        ogame.ShipsInfos             // Ships available on the celestial
+	Attacker         Attacker                     // Technologies/classes of the attacker, ShipsInfos is ignored
+	WinProbability   int                          // Minimum percentage of simulations won by the attacker (default 100)
+	CarryLoot        bool                         // Add cargo ships to carry all the loot
+	MaxShips         map[ogame.ID]int64           // Maximum number of ships of a type the fleet can use
+	IncludeRecyclers bool                         // Add enough recyclers to collect the debris field
+	Objective        Objective                    // Default MinimizeValue
+	Constraints      []Constraint                 // Candidates not satisfying all constraints are discarded
+	Fuel             func(ogame.ShipsInfos) int64 // Fuel needed to send a fleet (eg: using wrapper.CalcFlightTime)
+	Simulator        SimulatorParams
+}
+
+type optimizer struct {
+	defender  Defender
+	params    OptimizerParams
+	available ogame.ShipsInfos
+	lootNeed  int64
+}
+
+// Optimize searches the fleet that wins against the defender with the requested probability,
+// carries the loot if requested, satisfies the constraints and has the best score for the objective.
+func Optimize(defender Defender, params OptimizerParams) (Candidate, error) {
+	if params.WinProbability <= 0 {
+		params.WinProbability = 100
+	}
+	if params.Objective == nil {
+		params.Objective = MinimizeValue
+	}
+	if params.Simulator.Simulations <= 0 {
+		params.Simulator.Simulations = 10
+	}
+	o := &optimizer{defender: defender, params: params}
+	for ship := range params.Available.IterFlyable() {
+		nb := params.Available.ByID(ship)
+		if maxNb, ok := params.MaxShips[ship]; ok {
+			nb = min(nb, maxNb)
+		}
+		o.available.Set(ship, nb)
+	}
+	if params.CarryLoot {
+		lootPercentage := params.Simulator.LootPercentage
+		if lootPercentage <= 0 {
+			lootPercentage = 0.5
+		}
+		o.lootNeed = int64(float64(defender.Metal+defender.Crystal+defender.Deuterium) * lootPercentage)
+	}
+
+	var best *Candidate
+	bestScore := math.Inf(1)
+	for _, generator := range o.generators() {
+		candidate, score, ok := o.search(generator)
+		if ok && score < bestScore {
+			bestScore = score
+			best = &candidate
+		}
+	}
+	if best == nil {
+		return Candidate{}, ErrNoWinningFleet
+	}
+	return *best, nil
+}
+
+// fleetGenerator returns the combat part of a fleet of size k, and the maximum k
+type fleetGenerator struct {
+	maxK  int64
+	fleet func(k int64) ogame.ShipsInfos
+}
+
+// generators one generator per available ship type, and one using all available ships in proportion
+func (o *optimizer) generators() []fleetGenerator {
+	out := make([]fleetGenerator, 0)
+	for shipID, nb := range o.available.Iter() {
+		if nb <= 0 {
+			continue
+		}
+		out = append(out, fleetGenerator{maxK: nb, fleet: func(k int64) (ships ogame.ShipsInfos) {
+			ships.Set(shipID, k)
+			return
+		}})
+	}
+	const permille = 1000
+	out = append(out, fleetGenerator{maxK: permille, fleet: func(k int64) (ships ogame.ShipsInfos) {
+		for shipID, nb := range o.available.Iter() {
+			ships.Set(shipID, int64(math.Ceil(float64(nb*k)/permille)))
+		}
+		return
+	}})
+	return out
+}
+
+// scanSteps number of fleet sizes scored by search above the smallest winning one
+const scanSteps = 10
+
+// search finds the smallest k of the generator for which the fleet wins, using a binary search.
+// Larger fleets can have a better score (eg: fewer losses), so the k up to maxK are then scored by steps,
+// the best candidate satisfying the constraints is returned with its score.
+func (o *optimizer) search(generator fleetGenerator) (Candidate, float64, bool) {
+	var smallest *Candidate
+	minK := int64(-1)
+	lo, hi := int64(0), generator.maxK
+	for lo <= hi {
+		k := (lo + hi) / 2
+		if candidate, ok := o.winning(generator.fleet(k)); ok {
+			smallest, minK = &candidate, k
+			hi = k - 1
+		} else {
+			lo = k + 1
+		}
+	}
+	if smallest == nil {
+		return Candidate{}, 0, false
+	}
+	var best *Candidate
+	bestScore := math.Inf(1)
+	consider := func(candidate Candidate) {
+		if !o.satisfies(candidate) {
+			return
+		}
+		if score := o.params.Objective(candidate); score < bestScore {
+			bestScore = score
+			best = &candidate
+		}
+	}
+	consider(*smallest)
+	step := max((generator.maxK-minK)/scanSteps, 1)
+	for k := minK + step; k <= generator.maxK; k += step {
+		if k+step > generator.maxK {
+			k = generator.maxK // Always score the largest fleet
+		}
+		if candidate, ok := o.winning(generator.fleet(k)); ok {
+			consider(candidate)
+		}
+	}
+	if best == nil {
+		return Candidate{}, 0, false
+	}
+	return *best, bestScore, true
+}
+
+// winning evaluates the fleet, returns false if it cannot be sent or does not win with the requested probability
+func (o *optimizer) winning(ships ogame.ShipsInfos) (Candidate, bool) {
+	candidate, ok := o.evaluate(ships)
+	return candidate, ok && candidate.Result.AttackerWin >= o.params.WinProbability
+}
+
+func (o *optimizer) satisfies(candidate Candidate) bool {
+	for _, constraint := range o.params.Constraints {
+		if !constraint(candidate) {
+			return false
+		}
+	}
+	return true
+}
+
+// evaluate completes the fleet with cargo ships and recyclers, and simulates it.
+// Returns false if there are not enough ships available.
+func (o *optimizer) evaluate(ships ogame.ShipsInfos) (Candidate, bool) {
+	if !o.addCargo(&ships) || !ships.HasShips() {
+		return Candidate{}, false
+	}
+	candidate := o.simulate(ships)
+	if o.params.IncludeRecyclers {
+		// Recyclers are also targets during the battle, the debris field can change once they are added
+		for i := 0; i < 3; i++ {
+			missing := int64(candidate.Result.Recycler) - candidate.Ships.Recycler
+			if missing <= 0 {
+				break
+			}
+			if candidate.Ships.Recycler+missing > o.available.Recycler {
+				return Candidate{}, false
+			}
+			ships.AddShips(ogame.RecyclerID, missing)
+			candidate = o.simulate(ships)
+		}
+		if int64(candidate.Result.Recycler) > candidate.Ships.Recycler {
+			return Candidate{}, false
+		}
+	}
+	return candidate, true
+}
+
+// addCargo adds the cargo ships needed to carry the loot, the cheapest capacity first
+func (o *optimizer) addCargo(ships *ogame.ShipsInfos) bool {
+	missing := o.lootNeed - o.cargo(*ships)
+	for _, shipID := range []ogame.ID{ogame.LargeCargoID, ogame.SmallCargoID, ogame.PathfinderID} {
+		if missing <= 0 {
+			break
+		}
+		var one ogame.ShipsInfos
+		one.Set(shipID, 1)
+		capacity := o.cargo(one)
+		if capacity <= 0 {
+			continue
+		}
+		free := o.available.ByID(shipID) - ships.ByID(shipID)
+		nb := min(free, int64(math.Ceil(float64(missing)/float64(capacity))))
+		if nb <= 0 {
+			continue
+		}
+		ships.AddShips(shipID, nb)
+		missing -= nb * capacity
+	}
+	return missing <= 0
+}
+
+func (o *optimizer) cargo(ships ogame.ShipsInfos) int64 {
+	techs := ogame.Researches{HyperspaceTechnology: int64(o.params.Attacker.HyperspaceTechnology)}
+	return ships.Cargo(techs, o.params.Attacker.LfBonuses, o.params.Attacker.CharacterClass,
+		o.params.Simulator.CargoHyperspaceTechMultiplier, false)
+}
+
+func (o *optimizer) simulate(ships ogame.ShipsInfos) Candidate {
+	attacker := o.params.Attacker
+	attacker.ShipsInfos = ships
+	c := Candidate{
+		Ships:  ships,
+		Result: Simulate(attacker, o.defender, o.params.Simulator),
+		Value:  ships.FleetValue(o.params.Attacker.LfBonuses),
+		Cargo:  o.cargo(ships),
+	}
+	if o.params.Fuel != nil {
+		c.Fuel = o.params.Fuel(ships)
+	}
+	return c
+}
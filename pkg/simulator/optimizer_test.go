@@ -0,0 +1,58 @@
+package simulator
+
+import (
+	"testing"
+
+	"github.com/alaingilbert/ogame/pkg/ogame"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestOptimize(t *testing.T) {
+	defender := Defender{Metal: 300000, Crystal: 200000, Deuterium: 100000, DefensesInfos: ogame.DefensesInfos{RocketLauncher: 50}}
+	params := OptimizerParams{
+		Available: ogame.ShipsInfos{LightFighter: 1000, Cruiser: 200, LargeCargo: 50, Recycler: 50},
+		CarryLoot: true,
+		Simulator: SimulatorParams{Simulations: 5, Seed: 1, FleetToDebris: 0.3},
+	}
+	best, err := Optimize(defender, params)
+	require.NoError(t, err)
+	assert.Equal(t, 100, best.Result.AttackerWin)
+	assert.GreaterOrEqual(t, best.Cargo, int64(300000))
+	assert.Equal(t, best.Ships.FleetValue(ogame.LfBonuses{}), best.Value)
+	assert.True(t, params.Available.Has(best.Ships))
+
+	params.MaxShips = map[ogame.ID]int64{ogame.CruiserID: 0}
+	params.IncludeRecyclers = true
+	best, err = Optimize(defender, params)
+	require.NoError(t, err)
+	assert.Equal(t, int64(0), best.Ships.Cruiser)
+	assert.GreaterOrEqual(t, best.Ships.Recycler, int64(best.Result.Recycler))
+
+	// Constraints are applied to every fleet size, not only the smallest winning fleet
+	params.Constraints = []Constraint{func(c Candidate) bool { return c.Ships.LightFighter >= 900 }}
+	best, err = Optimize(defender, params)
+	require.NoError(t, err)
+	assert.GreaterOrEqual(t, best.Ships.LightFighter, int64(900))
+
+	params.Constraints = []Constraint{func(c Candidate) bool { return c.Ships.LightFighter > 2000 }}
+	_, err = Optimize(defender, params)
+	assert.ErrorIs(t, err, ErrNoWinningFleet)
+}
+
+func TestOptimizeObjective(t *testing.T) {
+	defender := Defender{Metal: 100000, DefensesInfos: ogame.DefensesInfos{RocketLauncher: 20}}
+	params := OptimizerParams{
+		Available: ogame.ShipsInfos{LightFighter: 500, Battleship: 50},
+		Objective: MinimizeLosses,
+		Simulator: SimulatorParams{Simulations: 5, Seed: 1},
+	}
+	best, err := Optimize(defender, params)
+	require.NoError(t, err)
+	byValue, err := Optimize(defender, OptimizerParams{Available: params.Available, Simulator: params.Simulator})
+	require.NoError(t, err)
+	// Losses are reduced by sending more ships than the smallest winning fleet
+	assert.NotEqual(t, byValue.Ships, best.Ships)
+	assert.Less(t, best.Result.AttackerLosses.Total(), byValue.Result.AttackerLosses.Total())
+	assert.Less(t, byValue.Value, best.Value)
+}
@@ -6,8 +6,10 @@ import (
 	"math"
 	"math/rand"
 	"os"
+	"sort"
 	"strconv"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/olekukonko/tablewriter"
@@ -39,6 +41,7 @@ const (
 	reaperConst
 	pathfinderConst
 	crawlerConst
+	nbUnitTypes
 )
 
 func isAlive(unit *CombatUnit) bool {
@@ -56,6 +59,8 @@ const (
 	idMask         uint64 = 0b00000000_00000000_00000000_00000000_00000000_00000000_00000000_00011111
 	shieldMask     uint64 = 0b00000000_00000000_00000000_00000000_00000000_01111111_11111111_11100000
 	hullMask       uint64 = 0b00000000_00000000_00011111_11111111_11111111_10000000_00000000_00000000
+	ownerMask      uint64 = 0b00000000_00011111_11100000_00000000_00000000_00000000_00000000_00000000
+	maxOwners             = 256
 )
 
 func getUnitID(unit *CombatUnit) uint64 {
@@ -70,6 +75,11 @@ func getUnitHull(unit *CombatUnit) uint64 {
 	return (unit.PackedInfos & hullMask) >> 23
 }
 
+// getUnitOwner returns the index of the participant (fleet) the unit belongs to on its side of the battle
+func getUnitOwner(unit *CombatUnit) uint64 {
+	return (unit.PackedInfos & ownerMask) >> 45
+}
+
 func setUnitID(unit *CombatUnit, id uint64) {
 	unit.PackedInfos &= ^idMask
 	unit.PackedInfos |= id << 0
@@ -85,6 +95,11 @@ func setUnitHull(unit *CombatUnit, hull uint64) {
 	unit.PackedInfos |= hull << 23
 }
 
+func setUnitOwner(unit *CombatUnit, owner uint64) {
+	unit.PackedInfos &= ^ownerMask
+	unit.PackedInfos |= owner << 45
+}
+
 type price struct {
 	Metal     int
 	Crystal   int
@@ -274,6 +289,10 @@ func getUnitBaseWeapon(unitID uint64) uint64 {
 	return 0
 }
 
+// getRapidFireAgainst returns the rapid fire of the unit against the target.
+// Rapid fire does not depend on the classes nor the lifeform bonuses: the class specific rapid fires are the ones of
+// the ships only the class can build (Reaper for General, Pathfinder for Discoverer), and the lifeform bonuses only
+// change the weapon, shield, structural integrity, cargo, speed and fuel of the ships (see ogame.LfShipBonus).
 func getRapidFireAgainst(unit *CombatUnit, targetUnit *CombatUnit) int {
 	rf := 0
 	switch getUnitID(unit) {
@@ -558,15 +577,101 @@ func getUnitInitialHullPlating(armourTechno, metalPrice, crystalPrice int) uint6
 	return uint64((1 + (float64(armourTechno) / 10)) * (float64(metalPrice+crystalPrice) / 10))
 }
 
-func newUnit(entity *entity, unitID uint64) CombatUnit {
+// applyLfBonus adds the lifeform bonus (eg: 0.05 for +5%) to a value computed from the base value and technologies
+func applyLfBonus(value, base uint64, lfBonus float64) uint64 {
+	return value + uint64(float64(base)*lfBonus)
+}
+
+// getClassCombatResearchBonus returns the extra levels of weapon/shield/armour technologies given by the classes.
+// General class gives 2 levels, Warrior alliance class gives 1 level.
+func getClassCombatResearchBonus(characterClass ogame.CharacterClass, allianceClass ogame.AllianceClass) int {
+	levels := 0
+	if characterClass.IsGeneral() {
+		levels += 2
+	}
+	if allianceClass.IsWarrior() {
+		levels++
+	}
+	return levels
+}
+
+func newUnit(entity *entity, unitID, owner uint64) CombatUnit {
 	var unit CombatUnit
 	setUnitID(&unit, unitID)
-	unitPrice := getUnitPrice(unitID)
-	setUnitHull(&unit, getUnitInitialHullPlating(entity.Armour, unitPrice.Metal, unitPrice.Crystal))
-	setUnitShield(&unit, getUnitInitialShield(unitID, entity.Shield))
+	setUnitOwner(&unit, owner)
+	setUnitHull(&unit, entity.hulls[unitID])
+	setUnitShield(&unit, entity.shields[unitID])
 	return unit
 }
 
+// getUnitOgameID returns the ogame ID of a simulator unit
+func getUnitOgameID(unitID uint64) ogame.ID {
+	switch unitID {
+	case smallCargoConst:
+		return ogame.SmallCargoID
+	case largeCargoConst:
+		return ogame.LargeCargoID
+	case lightFighterConst:
+		return ogame.LightFighterID
+	case heavyFighterConst:
+		return ogame.HeavyFighterID
+	case cruiserConst:
+		return ogame.CruiserID
+	case battleshipConst:
+		return ogame.BattleshipID
+	case colonyShipConst:
+		return ogame.ColonyShipID
+	case recyclerConst:
+		return ogame.RecyclerID
+	case espionageProbeConst:
+		return ogame.EspionageProbeID
+	case bomberConst:
+		return ogame.BomberID
+	case solarSatelliteConst:
+		return ogame.SolarSatelliteID
+	case destroyerConst:
+		return ogame.DestroyerID
+	case deathstarConst:
+		return ogame.DeathstarID
+	case battlecruiserConst:
+		return ogame.BattlecruiserID
+	case rocketLauncherConst:
+		return ogame.RocketLauncherID
+	case lightLaserConst:
+		return ogame.LightLaserID
+	case heavyLaserConst:
+		return ogame.HeavyLaserID
+	case gaussCannonConst:
+		return ogame.GaussCannonID
+	case ionCannonConst:
+		return ogame.IonCannonID
+	case plasmaTurretConst:
+		return ogame.PlasmaTurretID
+	case smallShieldDomeConst:
+		return ogame.SmallShieldDomeID
+	case largeShieldDomeConst:
+		return ogame.LargeShieldDomeID
+	case reaperConst:
+		return ogame.ReaperID
+	case pathfinderConst:
+		return ogame.PathfinderID
+	case crawlerConst:
+		return ogame.CrawlerID
+	}
+	return 0
+}
+
+// getUnitCargoCapacity returns the cargo capacity of a unit, 0 for defenses
+func getUnitCargoCapacity(unitID uint64, hyperspaceTechnology int, lfBonuses ogame.LfBonuses, characterClass ogame.CharacterClass, multiplier float64) int {
+	ship := ogame.Objs.GetShip(getUnitOgameID(unitID))
+	if ship == nil {
+		return 0
+	}
+	techs := ogame.Researches{HyperspaceTechnology: int64(hyperspaceTechnology)}
+	return int(ship.GetCargoCapacity(techs, lfBonuses, characterClass, multiplier, false))
+}
+
+// entity a single participant (fleet or planet) of the battle
 type entity struct {
 	Weapon          int
 	Shield          int
@@ -574,6 +679,14 @@ type entity struct {
 	Combustion      int
 	Impulse         int
 	Hyperspace      int
+	Metal           int
+	Crystal         int
+	Deuterium       int
+	LfBonuses       ogame.LfBonuses
+	CharacterClass  ogame.CharacterClass
+	AllianceClass   ogame.AllianceClass
+	HyperspaceTech  int
+	CargoMultiplier float64
 	SmallCargo      int
 	LargeCargo      int
 	LightFighter    int
@@ -600,12 +713,35 @@ type entity struct {
 	SmallShieldDome int
 	LargeShieldDome int
 	TotalUnits      int
-	Units           []CombatUnit
 	Losses          price
+	Loot            price
+	Harvested       price
+
+	// Stats of each unit type, with technologies, classes and lifeform bonuses applied
+	weapons [nbUnitTypes]uint64
+	shields [nbUnitTypes]uint64
+	hulls   [nbUnitTypes]uint64
+	cargos  [nbUnitTypes]int
+}
+
+// computeStats computes the weapon, shield, hull and cargo capacity of every unit type for the entity
+func (e *entity) computeStats() {
+	classLevels := getClassCombatResearchBonus(e.CharacterClass, e.AllianceClass)
+	for unitID := uint64(0); unitID < nbUnitTypes; unitID++ {
+		lfBonus := e.LfBonuses.LfShipBonuses[getUnitOgameID(unitID)]
+		unitPrice := getUnitPrice(unitID)
+		baseHull := getUnitInitialHullPlating(0, unitPrice.Metal, unitPrice.Crystal)
+		e.weapons[unitID] = applyLfBonus(getUnitWeaponPower(unitID, e.Weapon+classLevels), getUnitBaseWeapon(unitID), lfBonus.WeaponPower)
+		e.shields[unitID] = applyLfBonus(getUnitInitialShield(unitID, e.Shield+classLevels), uint64(getUnitBaseShield(unitID)), lfBonus.ShieldPower)
+		e.hulls[unitID] = applyLfBonus(getUnitInitialHullPlating(e.Armour+classLevels, unitPrice.Metal, unitPrice.Crystal), baseHull, lfBonus.StructuralIntegrity)
+		e.cargos[unitID] = getUnitCargoCapacity(unitID, e.HyperspaceTech, e.LfBonuses, e.CharacterClass, e.CargoMultiplier)
+	}
 }
 
-func (e *entity) init() {
+// init creates the combat units of the entity in units, returns the number of units created
+func (e *entity) init(units []CombatUnit, owner uint64) int {
 	e.reset()
+	e.computeStats()
 	idx := 0
 	type Unit struct {
 		Nbr int
@@ -640,35 +776,80 @@ func (e *entity) init() {
 	}
 	for _, el := range unitsArr {
 		for i := 0; i < el.Nbr; i++ {
-			e.Units[idx] = newUnit(e, el.ID)
+			units[idx] = newUnit(e, el.ID, owner)
 			idx++
 		}
 	}
+	return idx
 }
 
 func newEntity() *entity {
 	return new(entity)
 }
 
+// side all the participants fighting together, attackers (ACS attack) or defenders (ACS defend)
+type side struct {
+	Participants []*entity
+	TotalUnits   int
+	Units        []CombatUnit
+	Losses       price
+}
+
+func newSide(participants []*entity) side {
+	s := side{Participants: participants}
+	for _, p := range participants {
+		p.reset()
+		s.TotalUnits += p.TotalUnits
+	}
+	s.Units = make([]CombatUnit, s.TotalUnits+1)
+	return s
+}
+
+func (s *side) init() {
+	s.Losses = price{}
+	s.TotalUnits = 0
+	for owner, p := range s.Participants {
+		s.TotalUnits += p.init(s.Units[s.TotalUnits:], uint64(owner))
+	}
+}
+
+// countUnits returns the number of units alive of each type
+func (s *side) countUnits() (out [nbUnitTypes]int) {
+	for i := 0; i < s.TotalUnits; i++ {
+		out[getUnitID(&s.Units[i])]++
+	}
+	return
+}
+
+// owner returns the participant the unit belongs to
+func (s *side) owner(unit *CombatUnit) *entity {
+	return s.Participants[getUnitOwner(unit)]
+}
+
 type combatSimulator struct {
-	Attacker      entity
-	Defender      entity
-	MaxRounds     int
-	Rounds        int
-	FleetToDebris float64
-	Winner        string
-	IsLogging     bool
-	Logs          string
-	Debris        price
+	Attackers      side
+	Defenders      side
+	MaxRounds      int
+	Rounds         int
+	FleetToDebris  float64
+	LootPercentage float64
+	Winner         string
+	IsLogging      bool
+	Logs           string
+	Debris         price
+	Loot           price
+	Harvested      price
+	RoundsUnits    []roundCounts // Units alive of each type at the end of each round
+	Rebuilt        [nbUnitTypes]int
+	rng            *rand.Rand
 }
 
 func (simulator *combatSimulator) hasExploded(entity *entity, defendingUnit *CombatUnit) bool {
 	exploded := false
-	unitPrice := getUnitPrice(getUnitID(defendingUnit))
-	hullPercentage := float64(getUnitHull(defendingUnit)) / float64(getUnitInitialHullPlating(entity.Armour, unitPrice.Metal, unitPrice.Crystal))
+	hullPercentage := float64(getUnitHull(defendingUnit)) / float64(entity.hulls[getUnitID(defendingUnit)])
 	if hullPercentage <= 0.7 {
 		probabilityOfExploding := 1.0 - hullPercentage
-		dice := rand.Float64()
+		dice := simulator.rng.Float64()
 		msg := ""
 		if simulator.IsLogging {
 			msg += fmt.Sprintf("probability of exploding of %1.3f%%: dice value of %1.3f comparing with %1.3f: ", probabilityOfExploding*100, dice, 1-probabilityOfExploding)
@@ -696,7 +877,7 @@ func (simulator *combatSimulator) getAnotherShot(unit, targetUnit *CombatUnit) b
 	msg := ""
 	if rf > 0 {
 		chance := float64(rf-1) / float64(rf)
-		dice := rand.Float64()
+		dice := simulator.rng.Float64()
 		if simulator.IsLogging {
 			msg += fmt.Sprintf("dice was %1.3f, comparing with %1.3f: ", dice, chance)
 		}
@@ -727,7 +908,7 @@ func (simulator *combatSimulator) attack(attacker *entity, attackingUnit *Combat
 		simulator.Logs += fmt.Sprintf("%s fires at %s; ", getUnitName(getUnitID(attackingUnit)), getUnitName(getUnitID(defendingUnit)))
 	}
 
-	weapon := getUnitWeaponPower(getUnitID(attackingUnit), attacker.Weapon)
+	weapon := attacker.weapons[getUnitID(attackingUnit)]
 	// Check for shot bounce
 	if float64(weapon) < 0.01*float64(getUnitShield(defendingUnit)) {
 		if simulator.IsLogging {
@@ -762,33 +943,35 @@ func (simulator *combatSimulator) attack(attacker *entity, attackingUnit *Combat
 	}
 }
 
-func (simulator *combatSimulator) unitsFires(attacker, defender *entity) {
-	rand.Seed(time.Now().UnixNano())
+// unitsFires every unit of the attacking side fires at random units of the defending side,
+// using the technologies of the participant owning the unit.
+func (simulator *combatSimulator) unitsFires(attacker, defender *side) {
 	for i := 0; i < attacker.TotalUnits; i++ {
 		unit := attacker.Units[i]
+		unitOwner := attacker.owner(&unit)
 		rapidFire := true
 		for rapidFire {
 			if defender.TotalUnits == 0 {
 				break
 			}
-			targetUnit := &defender.Units[rand.Intn(defender.TotalUnits)]
+			targetUnit := &defender.Units[simulator.rng.Intn(defender.TotalUnits)]
 			rapidFire = simulator.getAnotherShot(&unit, targetUnit)
 			if isAlive(targetUnit) {
-				simulator.attack(attacker, &unit, defender, targetUnit)
+				simulator.attack(unitOwner, &unit, defender.owner(targetUnit), targetUnit)
 			}
 		}
 	}
 }
 
 func (simulator *combatSimulator) attackerFires() {
-	if simulator.Defender.TotalUnits <= 0 {
+	if simulator.Defenders.TotalUnits <= 0 {
 		return
 	}
-	simulator.unitsFires(&simulator.Attacker, &simulator.Defender)
+	simulator.unitsFires(&simulator.Attackers, &simulator.Defenders)
 }
 
 func (simulator *combatSimulator) defenderFires() {
-	simulator.unitsFires(&simulator.Defender, &simulator.Attacker)
+	simulator.unitsFires(&simulator.Defenders, &simulator.Attackers)
 }
 
 func isShip(unit *CombatUnit) bool {
@@ -831,64 +1014,49 @@ func isShip(unit *CombatUnit) bool {
 	return false
 }
 
-func (simulator *combatSimulator) removeDestroyedUnits() {
-	l := simulator.Defender.TotalUnits
+func (simulator *combatSimulator) removeDestroyedUnitsFrom(s *side) {
+	l := s.TotalUnits
 	for i := l - 1; i >= 0; i-- {
-		unit := &simulator.Defender.Units[i]
+		unit := &s.Units[i]
 		if getUnitHull(unit) == 0 {
 			unitPrice := getUnitPrice(getUnitID(unit))
 			if isShip(unit) {
 				simulator.Debris.Metal += int(simulator.FleetToDebris * float64(unitPrice.Metal))
 				simulator.Debris.Crystal += int(simulator.FleetToDebris * float64(unitPrice.Crystal))
 			}
-			simulator.Defender.Losses.add(unitPrice)
-			simulator.Defender.Units[i] = simulator.Defender.Units[simulator.Defender.TotalUnits-1]
-			simulator.Defender.TotalUnits--
-			//simulator.Defender.Units = simulator.Defender.Units[:len(simulator.Defender.Units)-1]
-			if simulator.IsLogging {
-				simulator.Logs += fmt.Sprintf("%s lost all its integrity, remove from battle\n", getUnitName(getUnitID(unit)))
-			}
-		}
-	}
-	l = simulator.Attacker.TotalUnits
-	for i := l - 1; i >= 0; i-- {
-		unit := &simulator.Attacker.Units[i]
-		if getUnitHull(unit) == 0 {
-			unitPrice := getUnitPrice(getUnitID(unit))
-			if isShip(unit) {
-				simulator.Debris.Metal += int(simulator.FleetToDebris * float64(unitPrice.Metal))
-				simulator.Debris.Crystal += int(simulator.FleetToDebris * float64(unitPrice.Crystal))
-			}
-			simulator.Attacker.Losses.add(unitPrice)
-			simulator.Attacker.Units[i] = simulator.Attacker.Units[simulator.Attacker.TotalUnits-1]
-			simulator.Attacker.TotalUnits--
-			//simulator.Attacker.Units = simulator.Attacker.Units[:len(simulator.Attacker.Units)-1]
+			s.owner(unit).Losses.add(unitPrice)
+			s.Losses.add(unitPrice)
 			if simulator.IsLogging {
 				simulator.Logs += fmt.Sprintf("%s lost all its integrity, remove from battle\n", getUnitName(getUnitID(unit)))
 			}
+			s.Units[i] = s.Units[s.TotalUnits-1]
+			s.TotalUnits--
 		}
 	}
 }
 
-func (simulator *combatSimulator) restoreShields() {
-	for i := 0; i < simulator.Attacker.TotalUnits; i++ {
-		unit := &simulator.Attacker.Units[i]
-		setUnitShield(unit, getUnitInitialShield(getUnitID(unit), simulator.Attacker.Shield))
-		if simulator.IsLogging {
-			simulator.Logs += fmt.Sprintf("%s still has integrity, restore its shield\n", getUnitName(getUnitID(unit)))
-		}
-	}
-	for i := 0; i < simulator.Defender.TotalUnits; i++ {
-		unit := &simulator.Defender.Units[i]
-		setUnitShield(unit, getUnitInitialShield(getUnitID(unit), simulator.Defender.Shield))
+func (simulator *combatSimulator) removeDestroyedUnits() {
+	simulator.removeDestroyedUnitsFrom(&simulator.Defenders)
+	simulator.removeDestroyedUnitsFrom(&simulator.Attackers)
+}
+
+func (simulator *combatSimulator) restoreShieldsOf(s *side) {
+	for i := 0; i < s.TotalUnits; i++ {
+		unit := &s.Units[i]
+		setUnitShield(unit, s.owner(unit).shields[getUnitID(unit)])
 		if simulator.IsLogging {
 			simulator.Logs += fmt.Sprintf("%s still has integrity, restore its shield\n", getUnitName(getUnitID(unit)))
 		}
 	}
 }
 
+func (simulator *combatSimulator) restoreShields() {
+	simulator.restoreShieldsOf(&simulator.Attackers)
+	simulator.restoreShieldsOf(&simulator.Defenders)
+}
+
 func (simulator *combatSimulator) isCombatDone() bool {
-	return simulator.Attacker.TotalUnits <= 0 || simulator.Defender.TotalUnits <= 0
+	return simulator.Attackers.TotalUnits <= 0 || simulator.Defenders.TotalUnits <= 0
 }
 
 func (simulator *combatSimulator) getMoonchance() int {
@@ -897,17 +1065,17 @@ func (simulator *combatSimulator) getMoonchance() int {
 }
 
 func (simulator *combatSimulator) printWinner() {
-	if simulator.Defender.TotalUnits <= 0 && simulator.Attacker.TotalUnits <= 0 {
+	if simulator.Defenders.TotalUnits <= 0 && simulator.Attackers.TotalUnits <= 0 {
 		simulator.Winner = "draw"
 		if simulator.IsLogging {
 			simulator.Logs += "The battle ended draw.\n"
 		}
-	} else if simulator.Attacker.TotalUnits <= 0 {
+	} else if simulator.Attackers.TotalUnits <= 0 {
 		simulator.Winner = "defender"
 		if simulator.IsLogging {
 			simulator.Logs += fmt.Sprintf("The battle ended after %d rounds with %s winning\n", simulator.Rounds, simulator.Winner)
 		}
-	} else if simulator.Defender.TotalUnits <= 0 {
+	} else if simulator.Defenders.TotalUnits <= 0 {
 		simulator.Winner = "attacker"
 		if simulator.IsLogging {
 			simulator.Logs += fmt.Sprintf("The battle ended after %d rounds with %s winning\n", simulator.Rounds, simulator.Winner)
@@ -920,9 +1088,98 @@ func (simulator *combatSimulator) printWinner() {
 	}
 }
 
+// plunder returns the resources a fleet with the given capacity takes from the available resources.
+// Like in ogame, a third of the capacity goes to metal, half of the remaining to crystal, then deuterium,
+// and the space left is filled with metal and crystal.
+func plunder(capacity int, available price) (out price) {
+	out.Metal = min(capacity/3, available.Metal)
+	capacity -= out.Metal
+	out.Crystal = min(capacity/2, available.Crystal)
+	capacity -= out.Crystal
+	out.Deuterium = min(capacity, available.Deuterium)
+	capacity -= out.Deuterium
+	extraMetal := min(capacity/2, available.Metal-out.Metal)
+	out.Metal += extraMetal
+	capacity -= extraMetal
+	out.Crystal += min(capacity, available.Crystal-out.Crystal)
+	return
+}
+
+// computeLoot when the attackers win, the loot of the first defender (the attacked celestial)
+// is shared between attackers in proportion to the cargo capacity of their surviving ships.
+func (simulator *combatSimulator) computeLoot() {
+	simulator.Loot = price{}
+	for _, a := range simulator.Attackers.Participants {
+		a.Loot = price{}
+	}
+	if simulator.Winner != "attacker" || len(simulator.Defenders.Participants) == 0 {
+		return
+	}
+	capacities := make([]int, len(simulator.Attackers.Participants))
+	totalCapacity := 0
+	for i := 0; i < simulator.Attackers.TotalUnits; i++ {
+		unit := &simulator.Attackers.Units[i]
+		capacity := simulator.Attackers.owner(unit).cargos[getUnitID(unit)]
+		capacities[getUnitOwner(unit)] += capacity
+		totalCapacity += capacity
+	}
+	if totalCapacity == 0 {
+		return
+	}
+	planet := simulator.Defenders.Participants[0]
+	available := price{
+		Metal:     int(float64(planet.Metal) * simulator.LootPercentage),
+		Crystal:   int(float64(planet.Crystal) * simulator.LootPercentage),
+		Deuterium: int(float64(planet.Deuterium) * simulator.LootPercentage),
+	}
+	simulator.Loot = plunder(totalCapacity, available)
+	for i, a := range simulator.Attackers.Participants {
+		share := float64(capacities[i]) / float64(totalCapacity)
+		a.Loot = price{
+			Metal:     int(float64(simulator.Loot.Metal) * share),
+			Crystal:   int(float64(simulator.Loot.Crystal) * share),
+			Deuterium: int(float64(simulator.Loot.Deuterium) * share),
+		}
+	}
+}
+
+// harvestDebris surviving attacking reapers directly collect up to 30% of the debris field created by the battle,
+// within the limit of their cargo capacity.
+func (simulator *combatSimulator) harvestDebris() {
+	simulator.Harvested = price{}
+	for _, a := range simulator.Attackers.Participants {
+		a.Harvested = price{}
+	}
+	capacities := make([]int, len(simulator.Attackers.Participants))
+	totalCapacity := 0
+	for i := 0; i < simulator.Attackers.TotalUnits; i++ {
+		unit := &simulator.Attackers.Units[i]
+		if getUnitID(unit) != reaperConst {
+			continue
+		}
+		capacity := simulator.Attackers.owner(unit).cargos[reaperConst]
+		capacities[getUnitOwner(unit)] += capacity
+		totalCapacity += capacity
+	}
+	if totalCapacity == 0 {
+		return
+	}
+	harvestable := price{Metal: int(float64(simulator.Debris.Metal) * 0.3), Crystal: int(float64(simulator.Debris.Crystal) * 0.3)}
+	if harvestable.Metal+harvestable.Crystal > totalCapacity {
+		ratio := float64(totalCapacity) / float64(harvestable.Metal+harvestable.Crystal)
+		harvestable.Metal = int(float64(harvestable.Metal) * ratio)
+		harvestable.Crystal = int(float64(harvestable.Crystal) * ratio)
+	}
+	for i, a := range simulator.Attackers.Participants {
+		share := float64(capacities[i]) / float64(totalCapacity)
+		a.Harvested = price{Metal: int(float64(harvestable.Metal) * share), Crystal: int(float64(harvestable.Crystal) * share)}
+		simulator.Harvested.add(a.Harvested)
+	}
+}
+
 func (simulator *combatSimulator) Simulate() {
-	simulator.Attacker.init()
-	simulator.Defender.init()
+	simulator.Attackers.init()
+	simulator.Defenders.init()
 	for currentRound := 1; currentRound <= simulator.MaxRounds; currentRound++ {
 		simulator.Rounds = currentRound
 		if simulator.IsLogging {
@@ -934,19 +1191,55 @@ func (simulator *combatSimulator) Simulate() {
 		simulator.defenderFires()
 		simulator.removeDestroyedUnits()
 		simulator.restoreShields()
+		simulator.RoundsUnits[currentRound-1] = roundCounts{attackers: simulator.Attackers.countUnits(), defenders: simulator.Defenders.countUnits()}
 		if simulator.isCombatDone() {
 			break
 		}
 	}
+	// Rounds that did not happen keep the units of the last round
+	for i := simulator.Rounds; i < simulator.MaxRounds; i++ {
+		simulator.RoundsUnits[i] = simulator.RoundsUnits[simulator.Rounds-1]
+	}
 	simulator.printWinner()
+	simulator.computeLoot()
+	simulator.harvestDebris()
+	simulator.rebuildDefenses()
 }
 
-func newCombatSimulator(attacker *entity, defender *entity) *combatSimulator {
+// rebuildDefenses each destroyed defense has a 70% chance to be rebuilt after the battle
+func (simulator *combatSimulator) rebuildDefenses() {
+	simulator.Rebuilt = [nbUnitTypes]int{}
+	survivors := simulator.Defenders.countUnits()
+	initial := [nbUnitTypes]int{}
+	for _, d := range simulator.Defenders.Participants {
+		initial[rocketLauncherConst] += d.RocketLauncher
+		initial[lightLaserConst] += d.LightLaser
+		initial[heavyLaserConst] += d.HeavyLaser
+		initial[gaussCannonConst] += d.GaussCannon
+		initial[ionCannonConst] += d.IonCannon
+		initial[plasmaTurretConst] += d.PlasmaTurret
+		initial[smallShieldDomeConst] += d.SmallShieldDome
+		initial[largeShieldDomeConst] += d.LargeShieldDome
+	}
+	for unitID := rocketLauncherConst; unitID <= largeShieldDomeConst; unitID++ {
+		destroyed := initial[unitID] - survivors[unitID]
+		for i := 0; i < destroyed; i++ {
+			if simulator.rng.Float64() < 0.7 {
+				simulator.Rebuilt[unitID]++
+			}
+		}
+	}
+}
+
+func newCombatSimulator(attackers, defenders []*entity) *combatSimulator {
 	cs := new(combatSimulator)
-	cs.Attacker = *attacker
-	cs.Defender = *defender
+	cs.Attackers = newSide(attackers)
+	cs.Defenders = newSide(defenders)
 	cs.IsLogging = false
 	cs.MaxRounds = 6
+	cs.RoundsUnits = make([]roundCounts, cs.MaxRounds)
+	cs.LootPercentage = 0.5
+	cs.rng = rand.New(rand.NewSource(time.Now().UnixNano()))
 	return cs
 }
 
@@ -1049,6 +1342,8 @@ func printResult(result SimulatorResult) {
 
 func (e *entity) reset() {
 	e.Losses = price{Metal: 0, Crystal: 0, Deuterium: 0}
+	e.Loot = price{Metal: 0, Crystal: 0, Deuterium: 0}
+	e.Harvested = price{Metal: 0, Crystal: 0, Deuterium: 0}
 	e.TotalUnits = 0
 	e.TotalUnits += e.SmallCargo
 	e.TotalUnits += e.LargeCargo
@@ -1077,152 +1372,463 @@ func (e *entity) reset() {
 	e.TotalUnits += e.LargeShieldDome
 }
 
-// Simulate ...
-func Simulate(attackerParam Attacker, defenderParam Defender, params SimulatorParams) SimulatorResult {
-	nbSimulations := params.Simulations
+func (e *entity) setShips(ships ogame.ShipsInfos) {
+	e.SmallCargo = int(ships.SmallCargo)
+	e.LargeCargo = int(ships.LargeCargo)
+	e.LightFighter = int(ships.LightFighter)
+	e.HeavyFighter = int(ships.HeavyFighter)
+	e.Cruiser = int(ships.Cruiser)
+	e.Battleship = int(ships.Battleship)
+	e.ColonyShip = int(ships.ColonyShip)
+	e.Recycler = int(ships.Recycler)
+	e.EspionageProbe = int(ships.EspionageProbe)
+	e.Bomber = int(ships.Bomber)
+	e.SolarSatellite = int(ships.SolarSatellite)
+	e.Destroyer = int(ships.Destroyer)
+	e.Deathstar = int(ships.Deathstar)
+	e.Battlecruiser = int(ships.Battlecruiser)
+	e.Reaper = int(ships.Reaper)
+	e.Pathfinder = int(ships.Pathfinder)
+	e.Crawler = int(ships.Crawler)
+}
 
-	attackerWin := 0
-	defenderWin := 0
-	draw := 0
-	attackerLosses := price{}
-	defenderLosses := price{}
-	debris := price{}
-	rounds := 0
-	moonchance := 0
+func (e *entity) setDefenses(defenses ogame.DefensesInfos) {
+	e.RocketLauncher = int(defenses.RocketLauncher)
+	e.LightLaser = int(defenses.LightLaser)
+	e.HeavyLaser = int(defenses.HeavyLaser)
+	e.GaussCannon = int(defenses.GaussCannon)
+	e.IonCannon = int(defenses.IonCannon)
+	e.PlasmaTurret = int(defenses.PlasmaTurret)
+	e.SmallShieldDome = int(defenses.SmallShieldDome)
+	e.LargeShieldDome = int(defenses.LargeShieldDome)
+}
 
+func newAttackerEntity(attackerParam Attacker) *entity {
 	attacker := newEntity()
 	attacker.Weapon = attackerParam.Weapon
 	attacker.Shield = attackerParam.Shield
 	attacker.Armour = attackerParam.Armour
-	attacker.SmallCargo = int(attackerParam.SmallCargo)
-	attacker.LargeCargo = int(attackerParam.LargeCargo)
-	attacker.LightFighter = int(attackerParam.LightFighter)
-	attacker.HeavyFighter = int(attackerParam.HeavyFighter)
-	attacker.Cruiser = int(attackerParam.Cruiser)
-	attacker.Battleship = int(attackerParam.Battleship)
-	attacker.ColonyShip = int(attackerParam.ColonyShip)
-	attacker.Recycler = int(attackerParam.Recycler)
-	attacker.EspionageProbe = int(attackerParam.EspionageProbe)
-	attacker.Bomber = int(attackerParam.Bomber)
+	attacker.LfBonuses = attackerParam.LfBonuses
+	attacker.CharacterClass = attackerParam.CharacterClass
+	attacker.AllianceClass = attackerParam.AllianceClass
+	attacker.HyperspaceTech = attackerParam.HyperspaceTechnology
+	attacker.setShips(attackerParam.ShipsInfos)
+	// Solar satellites and crawlers cannot fly
 	attacker.SolarSatellite = 0
-	attacker.Destroyer = int(attackerParam.Destroyer)
-	attacker.Deathstar = int(attackerParam.Deathstar)
-	attacker.Battlecruiser = int(attackerParam.Battlecruiser)
-	attacker.RocketLauncher = 0
-	attacker.LightLaser = 0
-	attacker.HeavyLaser = 0
-	attacker.GaussCannon = 0
-	attacker.IonCannon = 0
-	attacker.PlasmaTurret = 0
-	attacker.SmallShieldDome = 0
-	attacker.LargeShieldDome = 0
-	attacker.reset()
-	attacker.Units = make([]CombatUnit, attacker.TotalUnits+1)
+	attacker.Crawler = 0
+	return attacker
+}
 
+func newDefenderEntity(defenderParam Defender) *entity {
 	defender := newEntity()
 	defender.Weapon = defenderParam.Weapon
 	defender.Shield = defenderParam.Shield
 	defender.Armour = defenderParam.Armour
-	defender.SmallCargo = int(defenderParam.SmallCargo)
-	defender.LargeCargo = int(defenderParam.LargeCargo)
-	defender.LightFighter = int(defenderParam.LightFighter)
-	defender.HeavyFighter = int(defenderParam.HeavyFighter)
-	defender.Cruiser = int(defenderParam.Cruiser)
-	defender.Battleship = int(defenderParam.Battleship)
-	defender.ColonyShip = int(defenderParam.ColonyShip)
-	defender.Recycler = int(defenderParam.Recycler)
-	defender.EspionageProbe = int(defenderParam.EspionageProbe)
-	defender.Bomber = int(defenderParam.Bomber)
-	defender.SolarSatellite = int(defenderParam.SolarSatellite)
-	defender.Destroyer = int(defenderParam.Destroyer)
-	defender.Deathstar = int(defenderParam.Deathstar)
-	defender.Battlecruiser = int(defenderParam.Battlecruiser)
-	defender.RocketLauncher = int(defenderParam.RocketLauncher)
-	defender.LightLaser = int(defenderParam.LightLaser)
-	defender.HeavyLaser = int(defenderParam.HeavyLaser)
-	defender.GaussCannon = int(defenderParam.GaussCannon)
-	defender.IonCannon = int(defenderParam.IonCannon)
-	defender.PlasmaTurret = int(defenderParam.PlasmaTurret)
-	defender.SmallShieldDome = int(defenderParam.SmallShieldDome)
-	defender.LargeShieldDome = int(defenderParam.LargeShieldDome)
-	defender.reset()
-	defender.Units = make([]CombatUnit, defender.TotalUnits+1)
-
-	cs := newCombatSimulator(attacker, defender)
+	defender.LfBonuses = defenderParam.LfBonuses
+	defender.CharacterClass = defenderParam.CharacterClass
+	defender.AllianceClass = defenderParam.AllianceClass
+	defender.Metal = defenderParam.Metal
+	defender.Crystal = defenderParam.Crystal
+	defender.Deuterium = defenderParam.Deuterium
+	defender.setShips(defenderParam.ShipsInfos)
+	defender.setDefenses(defenderParam.DefensesInfos)
+	return defender
+}
+
+func averagePrice(p price, nbSimulations int) price {
+	return price{
+		Metal:     int(float64(p.Metal) / float64(nbSimulations)),
+		Crystal:   int(float64(p.Crystal) / float64(nbSimulations)),
+		Deuterium: int(float64(p.Deuterium) / float64(nbSimulations)),
+	}
+}
+
+// Simulate ...
+func Simulate(attackerParam Attacker, defenderParam Defender, params SimulatorParams) SimulatorResult {
+	return SimulateACS([]Attacker{attackerParam}, []Defender{defenderParam}, params)
+}
+
+// simulationsTotals sums of the results of several simulations
+type simulationsTotals struct {
+	attackerWin    int
+	defenderWin    int
+	draw           int
+	attackerLosses price
+	defenderLosses price
+	debris         price
+	loot           price
+	harvested      price
+	rounds         int
+	moonchance     int
+	attackers      []ParticipantResult
+	defenders      []ParticipantResult
+
+	attackerSurvivors [nbUnitTypes]int
+	defenderSurvivors [nbUnitTypes]int
+	rebuilt           [nbUnitTypes]int
+	roundsUnits       []roundCounts
+	logs              string // Logs of the first simulation
+
+	// Losses of every simulation, indexed by simulation number. Shared by all workers.
+	attackerLossesBySimulation []price
+	defenderLossesBySimulation []price
+}
+
+func newSimulationsTotals(nbAttackers, nbDefenders, maxRounds int) *simulationsTotals {
+	return &simulationsTotals{
+		attackers:   make([]ParticipantResult, nbAttackers),
+		defenders:   make([]ParticipantResult, nbDefenders),
+		roundsUnits: make([]roundCounts, maxRounds),
+	}
+}
+
+func addUnitsCount(dst *[nbUnitTypes]int, src [nbUnitTypes]int) {
+	for i := range src {
+		dst[i] += src[i]
+	}
+}
+
+// roundCounts number of units alive of each type on each side at the end of a round
+type roundCounts struct {
+	attackers [nbUnitTypes]int
+	defenders [nbUnitTypes]int
+}
+
+func (c *roundCounts) add(o roundCounts) {
+	addUnitsCount(&c.attackers, o.attackers)
+	addUnitsCount(&c.defenders, o.defenders)
+}
+
+// addSimulation adds the result of the last battle of the simulator
+func (t *simulationsTotals) addSimulation(simulation int, cs *combatSimulator) {
+	if cs.Winner == "attacker" {
+		t.attackerWin++
+	} else if cs.Winner == "defender" {
+		t.defenderWin++
+	} else {
+		t.draw++
+	}
+	t.attackerLosses.add(cs.Attackers.Losses)
+	t.defenderLosses.add(cs.Defenders.Losses)
+	t.debris.add(cs.Debris)
+	t.loot.add(cs.Loot)
+	t.harvested.add(cs.Harvested)
+	t.rounds += cs.Rounds
+	t.moonchance += cs.getMoonchance()
+	for i, a := range cs.Attackers.Participants {
+		t.attackers[i].Losses.add(a.Losses)
+		t.attackers[i].Loot.add(a.Loot)
+		t.attackers[i].Harvested.add(a.Harvested)
+	}
+	for i, d := range cs.Defenders.Participants {
+		t.defenders[i].Losses.add(d.Losses)
+	}
+	addUnitsCount(&t.attackerSurvivors, cs.Attackers.countUnits())
+	addUnitsCount(&t.defenderSurvivors, cs.Defenders.countUnits())
+	addUnitsCount(&t.rebuilt, cs.Rebuilt)
+	for i, roundUnits := range cs.RoundsUnits {
+		t.roundsUnits[i].add(roundUnits)
+	}
+	t.attackerLossesBySimulation[simulation] = cs.Attackers.Losses
+	t.defenderLossesBySimulation[simulation] = cs.Defenders.Losses
+	if simulation == 0 {
+		t.logs = cs.Logs
+	}
+}
+
+// merge adds the totals of another worker
+func (t *simulationsTotals) merge(o *simulationsTotals) {
+	t.attackerWin += o.attackerWin
+	t.defenderWin += o.defenderWin
+	t.draw += o.draw
+	t.attackerLosses.add(o.attackerLosses)
+	t.defenderLosses.add(o.defenderLosses)
+	t.debris.add(o.debris)
+	t.loot.add(o.loot)
+	t.harvested.add(o.harvested)
+	t.rounds += o.rounds
+	t.moonchance += o.moonchance
+	for i := range o.attackers {
+		t.attackers[i].Losses.add(o.attackers[i].Losses)
+		t.attackers[i].Loot.add(o.attackers[i].Loot)
+		t.attackers[i].Harvested.add(o.attackers[i].Harvested)
+	}
+	for i := range o.defenders {
+		t.defenders[i].Losses.add(o.defenders[i].Losses)
+	}
+	addUnitsCount(&t.attackerSurvivors, o.attackerSurvivors)
+	addUnitsCount(&t.defenderSurvivors, o.defenderSurvivors)
+	addUnitsCount(&t.rebuilt, o.rebuilt)
+	for i, roundUnits := range o.roundsUnits {
+		t.roundsUnits[i].add(roundUnits)
+	}
+	if o.logs != "" {
+		t.logs = o.logs
+	}
+}
+
+// getLossesStats returns the distribution of the losses, simulations are ordered by total value of the losses
+func getLossesStats(losses []price) LossesStats {
+	sorted := make([]price, len(losses))
+	copy(sorted, losses)
+	sort.SliceStable(sorted, func(i, j int) bool { return sorted[i].Total() < sorted[j].Total() })
+	percentile := func(p float64) price {
+		// Nearest-rank method
+		rank := int(math.Ceil(p / 100 * float64(len(sorted))))
+		if rank < 1 {
+			rank = 1
+		}
+		return sorted[rank-1]
+	}
+	return LossesStats{
+		Min:    sorted[0],
+		P10:    percentile(10),
+		P25:    percentile(25),
+		Median: percentile(50),
+		P75:    percentile(75),
+		P90:    percentile(90),
+		Max:    sorted[len(sorted)-1],
+	}
+}
+
+// averageUnits returns the average number of units of each type, rounded to the nearest unit
+func averageUnits(units [nbUnitTypes]int, nbSimulations int) (ships ogame.ShipsInfos, defenses ogame.DefensesInfos) {
+	for unitID := uint64(0); unitID < nbUnitTypes; unitID++ {
+		nb := int64(math.Round(float64(units[unitID]) / float64(nbSimulations)))
+		unit := &CombatUnit{}
+		setUnitID(unit, unitID)
+		if isShip(unit) {
+			ships.Set(getUnitOgameID(unitID), nb)
+		} else {
+			defenses.Set(getUnitOgameID(unitID), nb)
+		}
+	}
+	return
+}
+
+func sumUnits(units [nbUnitTypes]int) (out int) {
+	for _, nb := range units {
+		out += nb
+	}
+	return
+}
+
+// getSimulationSeed returns the seed of the nth simulation.
+// Every simulation has its own seed, so the results do not depend on which worker ran it.
+func getSimulationSeed(seed int64, simulation int) int64 {
+	// splitmix64 finalizer, spreads consecutive simulation numbers over the whole seed space
+	z := uint64(seed) + uint64(simulation+1)*0x9e3779b97f4a7c15
+	z = (z ^ (z >> 30)) * 0xbf58476d1ce4e5b9
+	z = (z ^ (z >> 27)) * 0x94d049bb133111eb
+	return int64(z ^ (z >> 31))
+}
+
+func newACSCombatSimulator(attackersParam []Attacker, defendersParam []Defender, params SimulatorParams) *combatSimulator {
+	attackers := make([]*entity, len(attackersParam))
+	for i, attackerParam := range attackersParam {
+		attackers[i] = newAttackerEntity(attackerParam)
+		attackers[i].CargoMultiplier = params.CargoHyperspaceTechMultiplier
+	}
+	defenders := make([]*entity, len(defendersParam))
+	for i, defenderParam := range defendersParam {
+		defenders[i] = newDefenderEntity(defenderParam)
+	}
+	cs := newCombatSimulator(attackers, defenders)
 	cs.IsLogging = false
 	cs.FleetToDebris = params.FleetToDebris
+	if params.LootPercentage > 0 {
+		cs.LootPercentage = params.LootPercentage
+	}
+	return cs
+}
 
-	for i := 0; i < nbSimulations; i++ {
+// runSimulations runs the simulations sent on the channel, and returns the totals
+func runSimulations(cs *combatSimulator, seed int64, logging bool, simulations <-chan int, totals *simulationsTotals) {
+	source := rand.NewSource(0)
+	cs.rng = rand.New(source)
+	for simulation := range simulations {
+		source.Seed(getSimulationSeed(seed, simulation))
+		cs.IsLogging = logging && simulation == 0
+		cs.Logs = ""
 		cs.Rounds = 1
 		cs.Debris = price{}
 		cs.Simulate()
-
-		if cs.Winner == "attacker" {
-			attackerWin++
-		} else if cs.Winner == "defender" {
-			defenderWin++
-		} else {
-			draw++
-		}
-		attackerLosses.add(cs.Attacker.Losses)
-		defenderLosses.add(cs.Defender.Losses)
-		debris.add(cs.Debris)
-		rounds += cs.Rounds
-		moonchance += cs.getMoonchance()
+		totals.addSimulation(simulation, cs)
 	}
+}
 
+// SimulateACS simulates a battle with several attacking fleets (ACS attack) and/or several defending fleets (ACS defend).
+// Every participant fights with its own technologies. The first defender is the attacked celestial,
+// its resources are the ones the attackers can loot.
+// For a given non-zero params.Seed, the result is always the same, whatever the number of workers.
+func SimulateACS(attackersParam []Attacker, defendersParam []Defender, params SimulatorParams) SimulatorResult {
+	nbSimulations := params.Simulations
+	if len(attackersParam) == 0 || len(defendersParam) == 0 || len(attackersParam) > maxOwners || len(defendersParam) > maxOwners {
+		return SimulatorResult{}
+	}
 	result := SimulatorResult{}
+	if nbSimulations <= 0 {
+		return result
+	}
+
+	seed := params.Seed
+	if seed == 0 {
+		seed = time.Now().UnixNano()
+	}
+	nbWorkers := params.Workers
+	if nbWorkers < 1 {
+		nbWorkers = 1
+	}
+	if nbWorkers > nbSimulations {
+		nbWorkers = nbSimulations
+	}
+
+	simulations := make(chan int, nbSimulations)
+	for i := 0; i < nbSimulations; i++ {
+		simulations <- i
+	}
+	close(simulations)
+
+	attackerLossesBySimulation := make([]price, nbSimulations)
+	defenderLossesBySimulation := make([]price, nbSimulations)
+	var wg sync.WaitGroup
+	workersTotals := make([]*simulationsTotals, nbWorkers)
+	for i := 0; i < nbWorkers; i++ {
+		cs := newACSCombatSimulator(attackersParam, defendersParam, params)
+		workersTotals[i] = newSimulationsTotals(len(attackersParam), len(defendersParam), cs.MaxRounds)
+		workersTotals[i].attackerLossesBySimulation = attackerLossesBySimulation
+		workersTotals[i].defenderLossesBySimulation = defenderLossesBySimulation
+		wg.Add(1)
+		go func(totals *simulationsTotals) {
+			defer wg.Done()
+			runSimulations(cs, seed, params.Logging, simulations, totals)
+		}(workersTotals[i])
+	}
+	wg.Wait()
+
+	totals := newSimulationsTotals(len(attackersParam), len(defendersParam), len(workersTotals[0].roundsUnits))
+	for _, workerTotals := range workersTotals {
+		totals.merge(workerTotals)
+	}
+
 	result.Simulations = nbSimulations
-	result.AttackerWin = int(math.Round(float64(attackerWin) / float64(nbSimulations) * 100))
-	result.DefenderWin = int(math.Round(float64(defenderWin) / float64(nbSimulations) * 100))
-	result.Draw = int(math.Round(float64(draw) / float64(nbSimulations) * 100))
-	result.Rounds = int(math.Round(float64(rounds) / float64(nbSimulations)))
-	result.AttackerLosses = price{}
-	result.AttackerLosses.Metal = int(float64(attackerLosses.Metal) / float64(nbSimulations))
-	result.AttackerLosses.Crystal = int(float64(attackerLosses.Crystal) / float64(nbSimulations))
-	result.AttackerLosses.Deuterium = int(float64(attackerLosses.Deuterium) / float64(nbSimulations))
-	result.DefenderLosses = price{}
-	result.DefenderLosses.Metal = int(float64(defenderLosses.Metal) / float64(nbSimulations))
-	result.DefenderLosses.Crystal = int(float64(defenderLosses.Crystal) / float64(nbSimulations))
-	result.DefenderLosses.Deuterium = int(float64(defenderLosses.Deuterium) / float64(nbSimulations))
-	result.Debris = price{}
-	result.Debris.Metal = int(float64(debris.Metal) / float64(nbSimulations))
-	result.Debris.Crystal = int(float64(debris.Crystal) / float64(nbSimulations))
-	result.Recycler = int(math.Ceil((float64(debris.Metal+debris.Crystal) / float64(nbSimulations)) / 20000.0))
-	result.Moonchance = int(float64(moonchance) / float64(nbSimulations))
-
-	result.Logs = cs.Logs
+	result.AttackerWin = int(math.Round(float64(totals.attackerWin) / float64(nbSimulations) * 100))
+	result.DefenderWin = int(math.Round(float64(totals.defenderWin) / float64(nbSimulations) * 100))
+	result.Draw = int(math.Round(float64(totals.draw) / float64(nbSimulations) * 100))
+	result.Rounds = int(math.Round(float64(totals.rounds) / float64(nbSimulations)))
+	result.AttackerLosses = averagePrice(totals.attackerLosses, nbSimulations)
+	result.DefenderLosses = averagePrice(totals.defenderLosses, nbSimulations)
+	// Debris harvested by reapers are not left in the debris field
+	debris := totals.debris
+	result.Debris = averagePrice(price{Metal: debris.Metal - totals.harvested.Metal, Crystal: debris.Crystal - totals.harvested.Crystal}, nbSimulations)
+	result.Loot = averagePrice(totals.loot, nbSimulations)
+	result.Harvested = averagePrice(totals.harvested, nbSimulations)
+	result.Recycler = int(math.Ceil(float64(result.Debris.Metal+result.Debris.Crystal) / 20000.0))
+	result.Moonchance = int(float64(totals.moonchance) / float64(nbSimulations))
+	result.Attackers = make([]ParticipantResult, len(totals.attackers))
+	for i, t := range totals.attackers {
+		result.Attackers[i] = ParticipantResult{
+			Losses:    averagePrice(t.Losses, nbSimulations),
+			Loot:      averagePrice(t.Loot, nbSimulations),
+			Harvested: averagePrice(t.Harvested, nbSimulations),
+		}
+	}
+	result.Defenders = make([]ParticipantResult, len(totals.defenders))
+	for i, t := range totals.defenders {
+		result.Defenders[i] = ParticipantResult{Losses: averagePrice(t.Losses, nbSimulations)}
+	}
+	result.AttackerLossesStats = getLossesStats(attackerLossesBySimulation)
+	result.DefenderLossesStats = getLossesStats(defenderLossesBySimulation)
+	result.AttackerShips, _ = averageUnits(totals.attackerSurvivors, nbSimulations)
+	survivorsAndRebuilt := totals.defenderSurvivors
+	addUnitsCount(&survivorsAndRebuilt, totals.rebuilt)
+	result.DefenderShips, result.DefenderDefenses = averageUnits(survivorsAndRebuilt, nbSimulations)
+	_, result.RebuiltDefenses = averageUnits(totals.rebuilt, nbSimulations)
+	result.RoundsUnits = make([]RoundUnits, len(totals.roundsUnits))
+	for i, roundUnits := range totals.roundsUnits {
+		r := RoundUnits{
+			Attackers: int(math.Round(float64(sumUnits(roundUnits.attackers)) / float64(nbSimulations))),
+			Defenders: int(math.Round(float64(sumUnits(roundUnits.defenders)) / float64(nbSimulations))),
+		}
+		r.AttackerShips, _ = averageUnits(roundUnits.attackers, nbSimulations)
+		r.DefenderShips, r.DefenderDefenses = averageUnits(roundUnits.defenders, nbSimulations)
+		result.RoundsUnits[i] = r
+	}
+	result.Logs = totals.logs
 
 	return result
 }
 
 // Attacker ...
 type Attacker struct {
-	Weapon int
-	Shield int
-	Armour int
+	Weapon               int
+	Shield               int
+	Armour               int
+	HyperspaceTechnology int             // Increases cargo capacity, see SimulatorParams.CargoHyperspaceTechMultiplier
+	LfBonuses            ogame.LfBonuses // Lifeform ship bonuses (weapon, shield, structural integrity, cargo capacity)
+	CharacterClass       ogame.CharacterClass
+	AllianceClass        ogame.AllianceClass
 	ogame.ShipsInfos
 }
 
 // Defender ...
 type Defender struct {
-	Metal     int
-	Crystal   int
-	Deuterium int
-	Weapon    int
-	Shield    int
-	Armour    int
+	Metal          int
+	Crystal        int
+	Deuterium      int
+	Weapon         int
+	Shield         int
+	Armour         int
+	LfBonuses      ogame.LfBonuses // Lifeform ship and defense bonuses (weapon, shield, structural integrity)
+	CharacterClass ogame.CharacterClass
+	AllianceClass  ogame.AllianceClass
 	ogame.ShipsInfos
 	ogame.DefensesInfos
 }
 
 // SimulatorParams ...
 type SimulatorParams struct {
-	Simulations   int
-	FleetToDebris float64
+	Simulations    int
+	FleetToDebris  float64
+	LootPercentage float64 // Part of the defender resources that can be looted (default 0.5)
+	Seed           int64   // Seed of the random number generators, 0 for a random seed
+	Workers        int     // Number of goroutines running the simulations (default 1)
+	Logging        bool    // Keep the detailed logs of the first simulation in SimulatorResult.Logs
+
+	// Cargo capacity bonus for each level of hyperspace technology (eg: 0.05 for 5%), server setting
+	CargoHyperspaceTechMultiplier float64
+}
+
+// ParticipantResult average losses and loot of one participant of the battle
+type ParticipantResult struct {
+	Losses    price
+	Loot      price
+	Harvested price // Debris collected by the reapers of the participant
+}
+
+// LossesStats distribution of the losses over all the simulations.
+// Simulations are ordered by the total value (metal+crystal+deuterium) of their losses.
+type LossesStats struct {
+	Min    price
+	P10    price
+	P25    price
+	Median price
+	P75    price
+	P90    price
+	Max    price
+}
+
+// RoundUnits average number of units alive on each side at the end of a round
+type RoundUnits struct {
+	Attackers        int                 // Total units of all attackers
+	Defenders        int                 // Total units of all defenders
+	AttackerShips    ogame.ShipsInfos    // Ships of all attackers by type
+	DefenderShips    ogame.ShipsInfos    // Ships of all defenders by type
+	DefenderDefenses ogame.DefensesInfos // Defenses of all defenders by type, rebuilt defenses are not included
 }
 
 // SimulatorResult ...
+// All fields are exported and can be serialized with encoding/json.
 type SimulatorResult struct {
 	Simulations    int
 	AttackerWin    int
@@ -1232,14 +1838,27 @@ type SimulatorResult struct {
 	AttackerLosses price
 	DefenderLosses price
 	Debris         price
+	Loot           price
+	Harvested      price // Debris collected by attacking reapers, not included in Debris
 	Recycler       int
 	Moonchance     int
-	Logs           string
+	Attackers      []ParticipantResult // Same order as the attackers given to SimulateACS
+	Defenders      []ParticipantResult // Same order as the defenders given to SimulateACS
+
+	AttackerLossesStats LossesStats
+	DefenderLossesStats LossesStats
+	AttackerShips       ogame.ShipsInfos    // Average surviving ships of all attackers
+	DefenderShips       ogame.ShipsInfos    // Average surviving ships of all defenders
+	DefenderDefenses    ogame.DefensesInfos // Average defenses after the battle, rebuilt defenses included
+	RebuiltDefenses     ogame.DefensesInfos // Average destroyed defenses that are rebuilt (70% chance each)
+	RoundsUnits         []RoundUnits        // Average units alive at the end of each round
+
+	Logs string // Logs of the first simulation, if SimulatorParams.Logging is set
 }
 
 // String ...
 func (s SimulatorResult) String() string {
-	return "" +
+	out := "" +
 		"   Simulations: " + strconv.Itoa(s.Simulations) + "\n" +
 		"   AttackerWin: " + strconv.Itoa(s.AttackerWin) + "\n" +
 		"   DefenderWin: " + strconv.Itoa(s.DefenderWin) + "\n" +
@@ -1248,6 +1867,17 @@ func (s SimulatorResult) String() string {
 		"AttackerLosses: " + s.AttackerLosses.String() + "\n" +
 		"DefenderLosses: " + s.DefenderLosses.String() + "\n" +
 		"        Debris: " + s.Debris.String() + "\n" +
+		"          Loot: " + s.Loot.String() + "\n" +
+		"     Harvested: " + s.Harvested.String() + "\n" +
 		"      Recycler: " + strconv.Itoa(s.Recycler) + "\n" +
 		"    Moonchance: " + strconv.Itoa(s.Moonchance) + "\n"
+	if len(s.Attackers) > 1 || len(s.Defenders) > 1 {
+		for i, a := range s.Attackers {
+			out += fmt.Sprintf("    Attacker %d: losses %s loot %s\n", i+1, a.Losses, a.Loot)
+		}
+		for i, d := range s.Defenders {
+			out += fmt.Sprintf("    Defender %d: losses %s\n", i+1, d.Losses)
+		}
+	}
+	return out
 }
@@ -0,0 +1,147 @@
+package simulator
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/alaingilbert/ogame/pkg/ogame"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSimulateACSLootShare(t *testing.T) {
+	attackers := []Attacker{
+		{ShipsInfos: ogame.ShipsInfos{LargeCargo: 100}},
+		{ShipsInfos: ogame.ShipsInfos{SmallCargo: 100}},
+	}
+	defenders := []Defender{{Metal: 1_000_000, Crystal: 1_000_000, Deuterium: 1_000_000}}
+	res := SimulateACS(attackers, defenders, SimulatorParams{Simulations: 5, FleetToDebris: 0.3})
+	assert.Equal(t, 100, res.AttackerWin)
+	assert.Equal(t, price{Metal: 500_000, Crystal: 500_000, Deuterium: 500_000}, res.Loot)
+	assert.Len(t, res.Attackers, 2)
+	assert.Len(t, res.Defenders, 1)
+	assert.InDelta(t, 5*res.Attackers[1].Loot.Metal, res.Attackers[0].Loot.Metal, 5)
+	assert.Equal(t, price{}, res.Attackers[0].Losses)
+}
+
+func TestSimulateACSPerParticipantLosses(t *testing.T) {
+	attackers := []Attacker{
+		{Weapon: 10, Shield: 10, Armour: 10, ShipsInfos: ogame.ShipsInfos{Battleship: 200}},
+		{ShipsInfos: ogame.ShipsInfos{LightFighter: 50}},
+	}
+	defenders := []Defender{
+		{DefensesInfos: ogame.DefensesInfos{RocketLauncher: 200}},
+		{ShipsInfos: ogame.ShipsInfos{Cruiser: 20}},
+	}
+	res := SimulateACS(attackers, defenders, SimulatorParams{Simulations: 10, FleetToDebris: 0.3})
+	assert.InDelta(t, res.AttackerLosses.Metal, res.Attackers[0].Losses.Metal+res.Attackers[1].Losses.Metal, 2)
+	assert.Greater(t, res.Defenders[1].Losses.Metal, 0)
+}
+
+func TestPlunder(t *testing.T) {
+	assert.Equal(t, price{Metal: 7499, Crystal: 7501, Deuterium: 5000}, plunder(20000, price{Metal: 10000, Crystal: 100000, Deuterium: 5000}))
+	assert.Equal(t, price{Metal: 100, Crystal: 200, Deuterium: 300}, plunder(25000, price{Metal: 100, Crystal: 200, Deuterium: 300}))
+}
+
+func TestSimulateSingle(t *testing.T) {
+	res := Simulate(Attacker{ShipsInfos: ogame.ShipsInfos{Reaper: 10}}, Defender{}, SimulatorParams{Simulations: 1})
+	assert.Equal(t, 100, res.AttackerWin)
+	assert.Equal(t, 1, res.Rounds)
+}
+
+func TestEntityStatsWithBonuses(t *testing.T) {
+	lfBonuses := ogame.NewLfBonuses()
+	lfBonuses.LfShipBonuses[ogame.BattleshipID] = ogame.LfShipBonus{WeaponPower: 0.1, ShieldPower: 0.2, StructuralIntegrity: 0.05}
+	e := newEntity()
+	e.Weapon, e.Shield, e.Armour = 10, 10, 10
+	e.LfBonuses = *lfBonuses
+	e.computeStats()
+	assert.Equal(t, uint64(2100), e.weapons[battleshipConst])
+	assert.Equal(t, uint64(440), e.shields[battleshipConst])
+	assert.Equal(t, uint64(12300), e.hulls[battleshipConst])
+
+	e.CharacterClass = ogame.General
+	e.AllianceClass = ogame.Warrior
+	e.computeStats()
+	assert.Equal(t, uint64(2400), e.weapons[battleshipConst])
+}
+
+func TestCollectorLoot(t *testing.T) {
+	defenders := []Defender{{Metal: 1_000_000, Crystal: 1_000_000, Deuterium: 1_000_000}}
+	noClass := SimulateACS([]Attacker{{ShipsInfos: ogame.ShipsInfos{SmallCargo: 10}}}, defenders, SimulatorParams{Simulations: 1})
+	collector := SimulateACS([]Attacker{{CharacterClass: ogame.Collector, ShipsInfos: ogame.ShipsInfos{SmallCargo: 10}}}, defenders, SimulatorParams{Simulations: 1})
+	assert.Equal(t, 50000, noClass.Loot.Metal+noClass.Loot.Crystal+noClass.Loot.Deuterium)
+	assert.Equal(t, 62500, collector.Loot.Metal+collector.Loot.Crystal+collector.Loot.Deuterium)
+}
+
+func TestReaperHarvest(t *testing.T) {
+	attackers := []Attacker{{CharacterClass: ogame.General, Weapon: 20, Shield: 20, Armour: 20, ShipsInfos: ogame.ShipsInfos{Reaper: 100}}}
+	defenders := []Defender{{ShipsInfos: ogame.ShipsInfos{LightFighter: 100}}}
+	res := SimulateACS(attackers, defenders, SimulatorParams{Simulations: 1, FleetToDebris: 0.3})
+	assert.Equal(t, 100, res.AttackerWin)
+	// 100 LF destroyed: 90k metal, 30k crystal of debris, 30% is harvested
+	assert.Equal(t, price{Metal: 27000, Crystal: 9000}, res.Harvested)
+	assert.Equal(t, price{Metal: 63000, Crystal: 21000}, res.Debris)
+	assert.Equal(t, res.Harvested, res.Attackers[0].Harvested)
+}
+
+func TestSimulateSeedWorkers(t *testing.T) {
+	attacker := Attacker{Weapon: 10, Shield: 10, Armour: 10, ShipsInfos: ogame.ShipsInfos{Cruiser: 300, LightFighter: 500}}
+	defender := Defender{Metal: 100000, Weapon: 10, Shield: 10, Armour: 10, DefensesInfos: ogame.DefensesInfos{RocketLauncher: 800, LightLaser: 300}}
+	params := SimulatorParams{Simulations: 20, FleetToDebris: 0.3, Seed: 42}
+	sequential := Simulate(attacker, defender, params)
+	params.Workers = 4
+	parallel := Simulate(attacker, defender, params)
+	assert.Equal(t, sequential, parallel)
+	assert.Equal(t, parallel, Simulate(attacker, defender, params))
+	params.Seed = 43
+	assert.NotEqual(t, sequential, Simulate(attacker, defender, params))
+}
+
+func TestSimulateRichResults(t *testing.T) {
+	attacker := Attacker{ShipsInfos: ogame.ShipsInfos{Battleship: 100}}
+	defender := Defender{DefensesInfos: ogame.DefensesInfos{RocketLauncher: 100}}
+	res := Simulate(attacker, defender, SimulatorParams{Simulations: 20, Seed: 1})
+	assert.Equal(t, 100, res.AttackerWin)
+	assert.Equal(t, int64(100), res.AttackerShips.Battleship)
+	assert.Equal(t, int64(0), res.DefenderShips.CountShips())
+	// Rocket launchers are all destroyed, around 70% are rebuilt
+	assert.InDelta(t, 70, res.RebuiltDefenses.RocketLauncher, 10)
+	assert.Equal(t, res.RebuiltDefenses, res.DefenderDefenses)
+	assert.Equal(t, price{Metal: 200000}, res.DefenderLossesStats.Median)
+	assert.Equal(t, res.DefenderLossesStats.Min, res.DefenderLossesStats.Max)
+	assert.Len(t, res.RoundsUnits, 6)
+	assert.Equal(t, RoundUnits{Attackers: 100, AttackerShips: ogame.ShipsInfos{Battleship: 100}}, res.RoundsUnits[5])
+	first := res.RoundsUnits[0]
+	assert.Equal(t, int64(100), first.AttackerShips.Battleship)
+	assert.Equal(t, int64(first.Defenders), first.DefenderDefenses.RocketLauncher)
+	assert.Less(t, first.DefenderDefenses.RocketLauncher, int64(100))
+
+	by, err := json.Marshal(res)
+	assert.NoError(t, err)
+	var decoded SimulatorResult
+	assert.NoError(t, json.Unmarshal(by, &decoded))
+	assert.Equal(t, res, decoded)
+}
+
+func TestSimulateLogs(t *testing.T) {
+	attacker := Attacker{ShipsInfos: ogame.ShipsInfos{Battleship: 10}}
+	defender := Defender{DefensesInfos: ogame.DefensesInfos{RocketLauncher: 10}}
+	res := Simulate(attacker, defender, SimulatorParams{Simulations: 5, Seed: 1, Workers: 2})
+	assert.Empty(t, res.Logs)
+	res = Simulate(attacker, defender, SimulatorParams{Simulations: 5, Seed: 1, Workers: 2, Logging: true})
+	assert.Contains(t, res.Logs, "The battle ended after")
+	assert.Equal(t, 1, strings.Count(res.Logs, "The battle ended")) // Only the first simulation
+}
+
+func TestGetLossesStats(t *testing.T) {
+	losses := make([]price, 0)
+	for i := 10; i >= 1; i-- {
+		losses = append(losses, price{Metal: i * 1000})
+	}
+	stats := getLossesStats(losses)
+	assert.Equal(t, 1000, stats.Min.Metal)
+	assert.Equal(t, 5000, stats.Median.Metal)
+	assert.Equal(t, 9000, stats.P90.Metal)
+	assert.Equal(t, 10000, stats.Max.Metal)
+}
@@ -0,0 +1,147 @@
+package simulator
+
+import (
+	"time"
+
+	"github.com/alaingilbert/ogame/pkg/ogame"
+)
+
+// ThreatSource where the composition of the attacking fleet comes from
+type ThreatSource string
+
+const (
+	UnknownSource   ThreatSource = "unknown"
+	EventSource     ThreatSource = "event"     // Ships visible in the event list
+	PhalanxSource   ThreatSource = "phalanx"   // Ships seen by a phalanx scan of the origin of the attack
+	EspionageSource ThreatSource = "espionage" // Ships on the origin of the attack in the latest espionage report
+)
+
+// phalanxArrivalTolerance difference allowed between the arrival time of a phalanx fleet and the attack event
+const phalanxArrivalTolerance = 5 * time.Second
+
+// DefenseParams our side of an incoming attack
+type DefenseParams struct {
+	Ships          ogame.ShipsInfos
+	Defenses       ogame.DefensesInfos
+	Resources      ogame.Resources
+	Researches     ogame.Researches
+	LfBonuses      ogame.LfBonuses
+	CharacterClass ogame.CharacterClass
+	AllianceClass  ogame.AllianceClass
+	MaxLosses      int64 // Value (metal+crystal+deuterium) of the losses and loot accepted without fleet-saving
+}
+
+// ThreatIntel what we know about the attacker, besides the attack event
+type ThreatIntel struct {
+	Phalanx []ogame.PhalanxFleet   // Fleets seen by a phalanx scan of the origin of the attack
+	Report  *ogame.EspionageReport // Latest espionage report on the origin of the attack
+}
+
+// ThreatAssessment result of the simulation of an incoming attack
+type ThreatAssessment struct {
+	SimulatorResult
+	Attack        ogame.AttackEvent
+	AttackerShips ogame.ShipsInfos
+	Source        ThreatSource
+
+	// Uncertain is true when the attacking fleet or the attacker researches are estimated.
+	// Ships from an espionage report are all the ships that were on the origin, and unknown researches
+	// are assumed to be the same as ours.
+	Uncertain bool
+
+	ProjectedLosses ogame.Resources // Average defender losses plus average loot
+	Safe            bool            // The attacker never wins and the projected losses are acceptable
+}
+
+// GhostNeeded returns either or not the fleet and resources should be fleet-saved
+func (a ThreatAssessment) GhostNeeded() bool {
+	return !a.Safe
+}
+
+// attackingShips returns the ships of the attack, from the best source available
+func attackingShips(attack ogame.AttackEvent, intel ThreatIntel) (ogame.ShipsInfos, ThreatSource) {
+	if attack.Ships != nil {
+		return *attack.Ships, EventSource
+	}
+	var ships ogame.ShipsInfos
+	for _, fleet := range intel.Phalanx {
+		if fleet.ReturnFlight || fleet.Mission != attack.MissionType || !fleet.Destination.Equal(attack.Destination) {
+			continue
+		}
+		if fleet.ArrivalTime.Sub(attack.ArrivalTime).Abs() > phalanxArrivalTolerance {
+			continue
+		}
+		ships.Add(fleet.Ships)
+	}
+	if ships.HasShips() {
+		return ships, PhalanxSource
+	}
+	if intel.Report != nil && intel.Report.Coordinate.Equal(attack.Origin) {
+		if reportShips := intel.Report.ShipsInfos(); reportShips != nil {
+			for shipID, nb := range reportShips.IterFlyable() {
+				ships.Set(shipID, nb)
+			}
+			return ships, EspionageSource
+		}
+	}
+	return ships, UnknownSource
+}
+
+func toResources(p price) ogame.Resources {
+	return ogame.Resources{Metal: int64(p.Metal), Crystal: int64(p.Crystal), Deuterium: int64(p.Deuterium)}
+}
+
+// AssessThreat simulates an incoming attack against our ships and defenses.
+// The attacking fleet is taken from the attack event when visible, otherwise from the phalanx fleets
+// arriving at the same time, otherwise from the espionage report on the origin of the attack.
+// When the attacking fleet is unknown, no simulation is done and a ghost is needed.
+func AssessThreat(attack ogame.AttackEvent, intel ThreatIntel, defense DefenseParams, params SimulatorParams) ThreatAssessment {
+	out := ThreatAssessment{Attack: attack}
+	out.AttackerShips, out.Source = attackingShips(attack, intel)
+	if out.Source == UnknownSource || !out.AttackerShips.HasShips() {
+		out.Uncertain = true
+		return out
+	}
+	out.Uncertain = out.Source == EspionageSource
+
+	attacker := Attacker{
+		Weapon:               int(defense.Researches.WeaponsTechnology),
+		Shield:               int(defense.Researches.ShieldingTechnology),
+		Armour:               int(defense.Researches.ArmourTechnology),
+		HyperspaceTechnology: int(defense.Researches.HyperspaceTechnology),
+		ShipsInfos:           out.AttackerShips,
+	}
+	var researches *ogame.Researches
+	if intel.Report != nil && intel.Report.Coordinate.Equal(attack.Origin) {
+		attacker.CharacterClass = intel.Report.CharacterClass
+		attacker.AllianceClass = intel.Report.AllianceClass
+		researches = intel.Report.Researches()
+	}
+	if researches != nil {
+		attacker.Weapon = int(researches.WeaponsTechnology)
+		attacker.Shield = int(researches.ShieldingTechnology)
+		attacker.Armour = int(researches.ArmourTechnology)
+		attacker.HyperspaceTechnology = int(researches.HyperspaceTechnology)
+	} else {
+		out.Uncertain = true
+	}
+
+	defender := Defender{
+		Metal:          int(defense.Resources.Metal),
+		Crystal:        int(defense.Resources.Crystal),
+		Deuterium:      int(defense.Resources.Deuterium),
+		Weapon:         int(defense.Researches.WeaponsTechnology),
+		Shield:         int(defense.Researches.ShieldingTechnology),
+		Armour:         int(defense.Researches.ArmourTechnology),
+		LfBonuses:      defense.LfBonuses,
+		CharacterClass: defense.CharacterClass,
+		AllianceClass:  defense.AllianceClass,
+		ShipsInfos:     defense.Ships,
+		DefensesInfos:  defense.Defenses,
+	}
+	out.SimulatorResult = Simulate(attacker, defender, params)
+
+	out.ProjectedLosses = toResources(out.DefenderLosses).Add(toResources(out.Loot))
+	out.Safe = out.Simulations > 0 && out.AttackerWin == 0 && out.ProjectedLosses.Total() <= defense.MaxLosses
+	return out
+}
@@ -0,0 +1,73 @@
+package simulator
+
+import (
+	"testing"
+	"time"
+
+	"github.com/alaingilbert/ogame/pkg/ogame"
+	"github.com/alaingilbert/ogame/pkg/utils"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAssessThreat(t *testing.T) {
+	arrival := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	attack := ogame.AttackEvent{
+		MissionType: ogame.Attack,
+		Origin:      ogame.Coordinate{Galaxy: 1, System: 2, Position: 3, Type: ogame.PlanetType},
+		Destination: ogame.Coordinate{Galaxy: 1, System: 1, Position: 1, Type: ogame.PlanetType},
+		ArrivalTime: arrival,
+	}
+	defense := DefenseParams{
+		Defenses:  ogame.DefensesInfos{RocketLauncher: 100},
+		Resources: ogame.Resources{Metal: 100000},
+	}
+	params := SimulatorParams{Simulations: 5, Seed: 1}
+
+	// Fleet unknown
+	res := AssessThreat(attack, ThreatIntel{}, defense, params)
+	assert.Equal(t, UnknownSource, res.Source)
+	assert.True(t, res.GhostNeeded())
+	assert.True(t, res.Uncertain)
+
+	// Visible in the event list
+	attack.Ships = &ogame.ShipsInfos{LightFighter: 5}
+	res = AssessThreat(attack, ThreatIntel{}, defense, params)
+	assert.Equal(t, EventSource, res.Source)
+	assert.Equal(t, 0, res.AttackerWin)
+	assert.True(t, res.Safe)
+	assert.True(t, res.Uncertain) // Attacker researches unknown
+
+	// Phalanx fleets arriving with the attack, returning and other fleets are ignored
+	attack.Ships = nil
+	intel := ThreatIntel{Phalanx: []ogame.PhalanxFleet{
+		{Fleet: ogame.Fleet{Mission: ogame.Attack, Destination: attack.Destination, ArrivalTime: arrival, Ships: ogame.ShipsInfos{Battleship: 500}}},
+		{Fleet: ogame.Fleet{Mission: ogame.Attack, Destination: attack.Destination, ArrivalTime: arrival.Add(2 * time.Second), Ships: ogame.ShipsInfos{Cruiser: 10}}},
+		{Fleet: ogame.Fleet{Mission: ogame.Attack, Destination: attack.Destination, ArrivalTime: arrival, ReturnFlight: true, Ships: ogame.ShipsInfos{Cruiser: 1}}},
+		{Fleet: ogame.Fleet{Mission: ogame.Transport, Destination: attack.Destination, ArrivalTime: arrival, Ships: ogame.ShipsInfos{LargeCargo: 1}}},
+	}}
+	res = AssessThreat(attack, intel, defense, params)
+	assert.Equal(t, PhalanxSource, res.Source)
+	assert.Equal(t, ogame.ShipsInfos{Battleship: 500, Cruiser: 10}, res.AttackerShips)
+	assert.Equal(t, 100, res.AttackerWin)
+	assert.True(t, res.GhostNeeded())
+	assert.Equal(t, int64(50000), res.ProjectedLosses.Metal-toResources(res.DefenderLosses).Metal)
+
+	// Espionage report on the origin, immobile ships are ignored
+	report := ogame.EspionageReport{
+		Coordinate:               attack.Origin,
+		HasFleetInformation:      true,
+		HasResearchesInformation: true,
+		LightFighter:             utils.I64Ptr(3),
+		SolarSatellite:           utils.I64Ptr(50),
+		WeaponsTechnology:        utils.I64Ptr(1),
+	}
+	res = AssessThreat(attack, ThreatIntel{Report: &report}, defense, params)
+	assert.Equal(t, EspionageSource, res.Source)
+	assert.Equal(t, ogame.ShipsInfos{LightFighter: 3}, res.AttackerShips)
+	assert.True(t, res.Uncertain)
+	assert.True(t, res.Safe)
+
+	defense.MaxLosses = -1
+	res = AssessThreat(attack, ThreatIntel{Report: &report}, defense, params)
+	assert.False(t, res.Safe)
+}
@@ -0,0 +1,337 @@
+package taskRunner
+
+import (
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/alaingilbert/clockwork"
+)
+
+// ErrUnknownKind returned when scheduling a task of a kind that has no registered handler
+var ErrUnknownKind = errors.New("unknown scheduled task kind")
+
+// ScheduledTaskInfo information about a task waiting for its start time
+type ScheduledTaskInfo struct {
+	ID        int64
+	Name      string
+	Kind      string // Empty for tasks scheduled with a callback, those are not persisted
+	Priority  Priority
+	StartAt   time.Time
+	CreatedAt time.Time
+	Payload   json.RawMessage `json:",omitempty"`
+}
+
+// TaskHandle allows to cancel a scheduled task, or to wait for it to be executed
+type TaskHandle struct {
+	info     ScheduledTaskInfo
+	cancelCh chan struct{}
+	doneCh   chan struct{}
+	once     sync.Once
+	remove   func(id int64) bool
+	started  bool // Waiting in the queue or being executed, the scheduler lock must be held
+}
+
+// ID returns the id of the scheduled task
+func (h *TaskHandle) ID() int64 { return h.info.ID }
+
+// Info returns the information about the scheduled task
+func (h *TaskHandle) Info() ScheduledTaskInfo { return h.info }
+
+// Done returns a channel that is closed once the task is executed
+func (h *TaskHandle) Done() <-chan struct{} { return h.doneCh }
+
+// Cancel the task, returns false if the task was already started or cancelled
+func (h *TaskHandle) Cancel() bool {
+	if !h.remove(h.info.ID) {
+		return false
+	}
+	h.once.Do(func() { close(h.cancelCh) })
+	return true
+}
+
+type scheduler[T ITask] struct {
+	sync.Mutex
+	clock        clockwork.Clock
+	lastID       int64
+	tasks        map[int64]*TaskHandle
+	kinds        map[string]func(T, json.RawMessage)
+	unknown      []ScheduledTaskInfo // Restored tasks whose kind is not registered yet, kept in the persist file
+	persistPath  string
+	persistErrFn func(error)
+}
+
+func newScheduler[T ITask]() *scheduler[T] {
+	return &scheduler[T]{
+		clock: clockwork.NewRealClock(),
+		tasks: make(map[int64]*TaskHandle),
+		kinds: make(map[string]func(T, json.RawMessage)),
+	}
+}
+
+// SetClock sets the clock used to schedule tasks
+func (r *TaskRunner[T]) SetClock(clock clockwork.Clock) {
+	r.scheduler.Lock()
+	r.scheduler.clock = clock
+	r.scheduler.Unlock()
+}
+
+// RegisterKind registers the handler that executes the persistable tasks of a kind.
+// Saved tasks of a kind registered after calling SetPersistPath are restored when it is registered.
+func (r *TaskRunner[T]) RegisterKind(kind string, handler func(T, json.RawMessage)) {
+	s := r.scheduler
+	s.Lock()
+	s.kinds[kind] = handler
+	restored := make([]ScheduledTaskInfo, 0)
+	unknown := s.unknown[:0]
+	for _, info := range s.unknown {
+		if info.Kind == kind {
+			restored = append(restored, info)
+		} else {
+			unknown = append(unknown, info)
+		}
+	}
+	s.unknown = unknown
+	s.Unlock()
+	for _, info := range restored {
+		payload := info.Payload
+		_, _ = r.schedule(info, func(t T) { handler(t, payload) })
+	}
+}
+
+// SetPersistErrorHandler sets the function called when the persist file cannot be written.
+// Errors saving a task scheduled with ScheduleKindAt are returned to the caller instead.
+func (r *TaskRunner[T]) SetPersistErrorHandler(fn func(error)) {
+	r.scheduler.Lock()
+	r.scheduler.persistErrFn = fn
+	r.scheduler.Unlock()
+}
+
+// SetPersistPath saves the scheduled tasks that have a kind in the file at path, and restores the ones already saved.
+// Tasks that should have started while the process was down are started right away.
+// Tasks stay in the file until they complete, a task interrupted by a crash is executed again on restore.
+func (r *TaskRunner[T]) SetPersistPath(path string) error {
+	r.scheduler.Lock()
+	r.scheduler.persistPath = path
+	r.scheduler.Unlock()
+	by, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	} else if err != nil {
+		return err
+	}
+	var infos []ScheduledTaskInfo
+	if err := json.Unmarshal(by, &infos); err != nil {
+		return err
+	}
+	for _, info := range infos {
+		r.scheduler.Lock()
+		handler, ok := r.scheduler.kinds[info.Kind]
+		r.scheduler.lastID = max(r.scheduler.lastID, info.ID)
+		if !ok {
+			r.scheduler.unknown = append(r.scheduler.unknown, info)
+		}
+		r.scheduler.Unlock()
+		if !ok {
+			continue
+		}
+		payload := info.Payload
+		if _, err := r.schedule(info, func(t T) { handler(t, payload) }); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// ScheduleAt executes fn with a task of the given priority at the given time.
+// Like with WithPriority, fn must complete the task it receives.
+func (r *TaskRunner[T]) ScheduleAt(at time.Time, priority Priority, name string, fn func(T)) *TaskHandle {
+	h, _ := r.schedule(ScheduledTaskInfo{Name: name, Priority: priority, StartAt: at}, fn) // Not persisted, never fails
+	return h
+}
+
+// ScheduleIn executes fn with a task of the given priority once the duration has elapsed
+func (r *TaskRunner[T]) ScheduleIn(d time.Duration, priority Priority, name string, fn func(T)) *TaskHandle {
+	return r.ScheduleAt(r.now().Add(d), priority, name, fn)
+}
+
+// ScheduleKindAt schedules a task executed by the handler registered for kind, with the json encoded payload.
+// The task is persisted if a persist path is set.
+func (r *TaskRunner[T]) ScheduleKindAt(at time.Time, priority Priority, name, kind string, payload any) (*TaskHandle, error) {
+	r.scheduler.Lock()
+	handler, ok := r.scheduler.kinds[kind]
+	r.scheduler.Unlock()
+	if !ok {
+		return nil, ErrUnknownKind
+	}
+	by, err := json.Marshal(payload)
+	if err != nil {
+		return nil, err
+	}
+	info := ScheduledTaskInfo{Name: name, Kind: kind, Priority: priority, StartAt: at, Payload: by}
+	return r.schedule(info, func(t T) { handler(t, by) })
+}
+
+// ScheduleKindIn same as ScheduleKindAt, the task starts once the duration has elapsed
+func (r *TaskRunner[T]) ScheduleKindIn(d time.Duration, priority Priority, name, kind string, payload any) (*TaskHandle, error) {
+	return r.ScheduleKindAt(r.now().Add(d), priority, name, kind, payload)
+}
+
+// CancelScheduled cancels a scheduled task by id, returns false if the task does not exist anymore
+func (r *TaskRunner[T]) CancelScheduled(id int64) bool {
+	r.scheduler.Lock()
+	h, ok := r.scheduler.tasks[id]
+	r.scheduler.Unlock()
+	return ok && h.Cancel()
+}
+
+// ScheduledTasks returns the tasks waiting for their start time, ordered by start time
+func (r *TaskRunner[T]) ScheduledTasks() []ScheduledTaskInfo {
+	r.scheduler.Lock()
+	defer r.scheduler.Unlock()
+	return r.scheduler.infos(false)
+}
+
+func (r *TaskRunner[T]) now() time.Time {
+	r.scheduler.Lock()
+	defer r.scheduler.Unlock()
+	return r.scheduler.clock.Now()
+}
+
+// schedule adds the task, and persists it if it has a kind. The task is not scheduled if it cannot be persisted.
+func (r *TaskRunner[T]) schedule(info ScheduledTaskInfo, fn func(T)) (*TaskHandle, error) {
+	s := r.scheduler
+	s.Lock()
+	if info.ID == 0 {
+		s.lastID++
+		info.ID = s.lastID
+	}
+	if info.CreatedAt.IsZero() {
+		info.CreatedAt = s.clock.Now()
+	}
+	h := &TaskHandle{info: info, cancelCh: make(chan struct{}), doneCh: make(chan struct{}), remove: r.removeScheduled}
+	s.tasks[info.ID] = h
+	if info.Kind != "" {
+		if err := s.saveLocked(); err != nil {
+			delete(s.tasks, info.ID)
+			s.Unlock()
+			return nil, err
+		}
+	}
+	timer := s.clock.NewTimer(s.clock.Until(info.StartAt))
+	s.Unlock()
+
+	go func() {
+		defer timer.Stop()
+		select {
+		case <-timer.Chan():
+		case <-h.cancelCh:
+			return
+		case <-r.ctx.Done():
+			return
+		}
+		if r.ctx.Err() != nil || !r.startScheduled(h.info.ID) {
+			return // Stopped or cancelled
+		}
+		fn(r.WithPriority(info.Priority))
+		r.finishScheduled(h.info.ID)
+		close(h.doneCh)
+	}()
+	return h, nil
+}
+
+// removeScheduled removes a task that is not started yet, returns false if it was already started or removed
+func (r *TaskRunner[T]) removeScheduled(id int64) bool {
+	s := r.scheduler
+	s.Lock()
+	defer s.Unlock()
+	if h, ok := s.tasks[id]; !ok || h.started {
+		return false
+	}
+	delete(s.tasks, id)
+	s.persistLocked()
+	return true
+}
+
+// startScheduled marks the task as started, it is kept in the persist file until it completes.
+// Returns false if the task was cancelled.
+func (r *TaskRunner[T]) startScheduled(id int64) bool {
+	s := r.scheduler
+	s.Lock()
+	defer s.Unlock()
+	h, ok := s.tasks[id]
+	if !ok || h.started {
+		return false
+	}
+	h.started = true
+	return true
+}
+
+// finishScheduled removes a completed task
+func (r *TaskRunner[T]) finishScheduled(id int64) {
+	s := r.scheduler
+	s.Lock()
+	defer s.Unlock()
+	delete(s.tasks, id)
+	s.persistLocked()
+}
+
+// infos returns the scheduled tasks ordered by start time, with the started ones if withStarted is set
+func (s *scheduler[T]) infos(withStarted bool) []ScheduledTaskInfo {
+	out := make([]ScheduledTaskInfo, 0, len(s.tasks))
+	for _, h := range s.tasks {
+		if !h.started || withStarted {
+			out = append(out, h.info)
+		}
+	}
+	sort.Slice(out, func(i, j int) bool {
+		if out[i].StartAt.Equal(out[j].StartAt) {
+			return out[i].ID < out[j].ID
+		}
+		return out[i].StartAt.Before(out[j].StartAt)
+	})
+	return out
+}
+
+// persistLocked saves the tasks, errors are reported to the persist error handler, the lock must be held
+func (s *scheduler[T]) persistLocked() {
+	if err := s.saveLocked(); err != nil && s.persistErrFn != nil {
+		s.persistErrFn(err)
+	}
+}
+
+// saveLocked writes the tasks that have a kind in the persist file, with the restored ones whose kind is not
+// registered yet, the lock must be held
+func (s *scheduler[T]) saveLocked() error {
+	if s.persistPath == "" {
+		return nil
+	}
+	persisted := append(make([]ScheduledTaskInfo, 0), s.unknown...)
+	for _, info := range s.infos(true) {
+		if info.Kind != "" {
+			persisted = append(persisted, info)
+		}
+	}
+	sort.Slice(persisted, func(i, j int) bool { return persisted[i].ID < persisted[j].ID })
+	by, err := json.MarshalIndent(persisted, "", "  ")
+	if err != nil {
+		return err
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(s.persistPath), ".scheduled-*")
+	if err != nil {
+		return err
+	}
+	_, err = tmp.Write(by)
+	if closeErr := tmp.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		_ = os.Remove(tmp.Name())
+		return err
+	}
+	return os.Rename(tmp.Name(), s.persistPath)
+}
@@ -0,0 +1,183 @@
+package taskRunner
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/alaingilbert/clockwork"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func (i *testItem) Run(out chan string, name string) {
+	defer close(i.taskDoneCh)
+	out <- name
+}
+
+func newTestRunner(t *testing.T, clock clockwork.Clock) *TaskRunner[*testItem] {
+	ctx, cancel := context.WithCancel(context.Background())
+	t.Cleanup(cancel)
+	tr := NewTaskRunner[*testItem](ctx, func() *testItem { return &testItem{} })
+	tr.SetClock(clock)
+	return tr
+}
+
+func waitFor(t *testing.T, ch chan string) string {
+	select {
+	case v := <-ch:
+		return v
+	case <-time.After(time.Second):
+		t.Fatal("timeout")
+	}
+	return ""
+}
+
+func TestScheduleIn(t *testing.T) {
+	clock := clockwork.NewFakeClock()
+	tr := newTestRunner(t, clock)
+	out := make(chan string, 10)
+	h1 := tr.ScheduleIn(time.Minute, Normal, "first", func(i *testItem) { i.Run(out, "first") })
+	h2 := tr.ScheduleIn(2*time.Minute, Normal, "second", func(i *testItem) { i.Run(out, "second") })
+	tasks := tr.GetTasks()
+	require.Len(t, tasks.Scheduled, 2)
+	assert.Equal(t, "first", tasks.Scheduled[0].Name)
+	assert.Equal(t, clock.Now().Add(time.Minute), tasks.Scheduled[0].StartAt)
+	assert.Equal(t, int64(0), tasks.Total)
+
+	assert.True(t, h2.Cancel())
+	assert.False(t, h2.Cancel())
+	clock.Advance(time.Minute)
+	assert.Equal(t, "first", waitFor(t, out))
+	<-h1.Done()
+	assert.False(t, h1.Cancel())
+	clock.Advance(time.Hour)
+	select {
+	case v := <-out:
+		t.Fatal("cancelled task executed: " + v)
+	case <-time.After(50 * time.Millisecond):
+	}
+	assert.Len(t, tr.ScheduledTasks(), 0)
+}
+
+func TestSchedulePersistence(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "tasks.json")
+	start := time.Date(2024, 1, 1, 3, 0, 0, 0, time.UTC)
+	clock := clockwork.NewFakeClockAt(start)
+	out := make(chan string, 10)
+	handler := func(i *testItem, payload json.RawMessage) {
+		var name string
+		_ = json.Unmarshal(payload, &name)
+		i.Run(out, name)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	tr := NewTaskRunner[*testItem](ctx, func() *testItem { return &testItem{} })
+	tr.SetClock(clock)
+	tr.RegisterKind("say", handler)
+	require.NoError(t, tr.SetPersistPath(path))
+	_, err := tr.ScheduleKindAt(start.Add(time.Hour), Normal, "a", "say", "hello")
+	require.NoError(t, err)
+	_, err = tr.ScheduleKindAt(start.Add(2*time.Hour), Normal, "b", "say", "world")
+	require.NoError(t, err)
+	tr.ScheduleIn(time.Minute, Normal, "not persisted", func(i *testItem) { i.Run(out, "callback") })
+	_, err = tr.ScheduleKindAt(start, Normal, "c", "unknown", nil)
+	assert.ErrorIs(t, err, ErrUnknownKind)
+	cancel() // Process stops
+
+	clock.Advance(90 * time.Minute)
+	tr2 := newTestRunner(t, clock)
+	tr2.RegisterKind("say", handler)
+	require.NoError(t, tr2.SetPersistPath(path))
+	assert.Equal(t, "hello", waitFor(t, out)) // Overdue task runs right away
+	scheduled := tr2.ScheduledTasks()
+	require.Len(t, scheduled, 1)
+	assert.Equal(t, "b", scheduled[0].Name)
+	assert.Equal(t, int64(2), scheduled[0].ID)
+
+	h, err := tr2.ScheduleKindIn(time.Hour, Normal, "d", "say", "!")
+	require.NoError(t, err)
+	assert.Equal(t, int64(3), h.ID())
+	clock.Advance(30 * time.Minute)
+	assert.Equal(t, "world", waitFor(t, out))
+}
+
+func TestSchedulePersistence_UnknownKind(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "tasks.json")
+	clock := clockwork.NewFakeClock()
+	out := make(chan string, 10)
+	handler := func(i *testItem, payload json.RawMessage) { i.Run(out, string(payload)) }
+
+	ctx, cancel := context.WithCancel(context.Background())
+	tr := NewTaskRunner[*testItem](ctx, func() *testItem { return &testItem{} })
+	tr.SetClock(clock)
+	tr.RegisterKind("late", handler)
+	tr.RegisterKind("say", handler)
+	require.NoError(t, tr.SetPersistPath(path))
+	_, err := tr.ScheduleKindIn(time.Hour, Normal, "a", "late", "a")
+	require.NoError(t, err)
+	_, err = tr.ScheduleKindIn(2*time.Hour, Normal, "b", "say", "b")
+	require.NoError(t, err)
+	cancel() // Process stops
+
+	// The task of the kind not registered yet is kept in the file when another one is saved
+	tr2 := newTestRunner(t, clock)
+	tr2.RegisterKind("say", handler)
+	require.NoError(t, tr2.SetPersistPath(path))
+	_, err = tr2.ScheduleKindIn(3*time.Hour, Normal, "c", "say", "c")
+	require.NoError(t, err)
+	by, err := os.ReadFile(path)
+	require.NoError(t, err)
+	var infos []ScheduledTaskInfo
+	require.NoError(t, json.Unmarshal(by, &infos))
+	require.Len(t, infos, 3)
+	assert.Equal(t, "late", infos[0].Kind)
+
+	// and scheduled when its kind is registered
+	tr2.RegisterKind("late", handler)
+	require.Len(t, tr2.ScheduledTasks(), 3)
+	clock.Advance(time.Hour)
+	assert.Equal(t, `"a"`, waitFor(t, out))
+}
+
+func TestSchedulePersistence_KeptUntilCompleted(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "tasks.json")
+	clock := clockwork.NewFakeClock()
+	started, release := make(chan struct{}), make(chan struct{})
+	tr := newTestRunner(t, clock)
+	tr.RegisterKind("slow", func(i *testItem, _ json.RawMessage) {
+		defer close(i.taskDoneCh)
+		close(started)
+		<-release
+	})
+	require.NoError(t, tr.SetPersistPath(path))
+	h, err := tr.ScheduleKindIn(time.Minute, Normal, "a", "slow", nil)
+	require.NoError(t, err)
+	clock.Advance(time.Minute)
+	<-started
+
+	// A started task can no longer be cancelled, and is not listed, but stays in the file until it completes
+	assert.False(t, h.Cancel())
+	assert.Len(t, tr.ScheduledTasks(), 0)
+	by, err := os.ReadFile(path)
+	require.NoError(t, err)
+	assert.Contains(t, string(by), `"slow"`)
+	close(release)
+	<-h.Done()
+	by, err = os.ReadFile(path)
+	require.NoError(t, err)
+	assert.NotContains(t, string(by), `"slow"`)
+}
+
+func TestSchedulePersistence_WriteError(t *testing.T) {
+	clock := clockwork.NewFakeClock()
+	tr := newTestRunner(t, clock)
+	tr.RegisterKind("say", func(i *testItem, _ json.RawMessage) {})
+	require.NoError(t, tr.SetPersistPath(filepath.Join(t.TempDir(), "missing", "tasks.json")))
+	_, err := tr.ScheduleKindIn(time.Minute, Normal, "a", "say", nil)
+	assert.Error(t, err)
+	assert.Len(t, tr.ScheduledTasks(), 0)
+}
@@ -2,7 +2,11 @@ package taskRunner
 
 import (
 	"context"
+	"errors"
+	"sort"
 	"sync"
+	"sync/atomic"
+	"time"
 )
 
 type Priority int64
@@ -15,12 +19,46 @@ const (
 	Critical
 )
 
+// ErrTaskCancelled returned when the context of a task expires before the task can be processed
+var ErrTaskCancelled = errors.New("task cancelled")
+
+// Item states
+const (
+	itemQueued int32 = iota
+	itemStarted
+	itemCancelled
+)
+
 // item ...
 type item struct {
 	canBeProcessedCh chan struct{}
 	isDoneCh         chan struct{}
 	priority         Priority
 	index            int // The index of the item in the heap.
+	initiator        string
+	queuedAt         time.Time
+	startedAt        time.Time
+	state            atomic.Int32
+}
+
+// info returns the information about the task, the tasks lock must be held
+func (i *item) info(now time.Time) TaskInfo {
+	out := TaskInfo{Priority: i.priority, Initiator: i.initiator, QueuedAt: i.queuedAt, StartedAt: i.startedAt}
+	if i.startedAt.IsZero() {
+		out.Age = now.Sub(i.queuedAt)
+	} else {
+		out.Age = now.Sub(i.startedAt)
+	}
+	return out
+}
+
+// TaskInfo information about a task waiting in the queue or being processed
+type TaskInfo struct {
+	Priority  Priority
+	Initiator string
+	QueuedAt  time.Time
+	StartedAt time.Time     // Zero while the task is waiting
+	Age       time.Duration // Time spent waiting in the queue, or being processed once started
 }
 
 func (i *item) GetPriority() int { return int(i.priority) }
@@ -48,6 +86,11 @@ type TaskRunner[T ITask] struct {
 	tasksPopCh  chan struct{}
 	factory     func() T
 	ctx         context.Context
+	scheduler   *scheduler[T]
+	running     *item
+
+	holdThreshold time.Duration
+	onHold        func(TaskInfo)
 }
 
 type ITask interface {
@@ -62,6 +105,7 @@ func NewTaskRunner[T ITask](ctx context.Context, factory func() T) *TaskRunner[T
 	r.tasksPushCh = make(chan *item, chanLen)
 	r.tasksPopCh = make(chan struct{}, chanLen)
 	r.ctx = ctx
+	r.scheduler = newScheduler[T]()
 	r.start()
 	return r
 }
@@ -95,32 +139,110 @@ func (r *TaskRunner[T]) start() {
 			r.tasksLock.Lock()
 			task := r.tasks.Pop()
 			r.tasksLock.Unlock()
+			if !task.state.CompareAndSwap(itemQueued, itemStarted) {
+				continue // Context of the task expired while it was waiting
+			}
+			r.tasksLock.Lock()
+			task.startedAt = r.now()
+			r.running = task
+			r.tasksLock.Unlock()
 			close(task.canBeProcessedCh)
-			select {
-			case <-task.isDoneCh:
-			case <-r.ctx.Done():
+			ok := r.waitTaskDone(task)
+			r.tasksLock.Lock()
+			r.running = nil
+			r.tasksLock.Unlock()
+			if !ok {
 				return
 			}
 		}
 	}()
 }
 
+// waitTaskDone waits for the task to be done, and reports it if it holds the runner for too long.
+// Returns false if the runner context is done.
+func (r *TaskRunner[T]) waitTaskDone(task *item) bool {
+	r.tasksLock.Lock()
+	threshold, onHold := r.holdThreshold, r.onHold
+	r.tasksLock.Unlock()
+	if threshold <= 0 || onHold == nil {
+		select {
+		case <-task.isDoneCh:
+			return true
+		case <-r.ctx.Done():
+			return false
+		}
+	}
+	r.scheduler.Lock()
+	timer := r.scheduler.clock.NewTimer(threshold)
+	r.scheduler.Unlock()
+	defer timer.Stop()
+	for {
+		select {
+		case <-task.isDoneCh:
+			return true
+		case <-r.ctx.Done():
+			return false
+		case <-timer.Chan():
+			r.tasksLock.Lock()
+			info := task.info(r.now())
+			r.tasksLock.Unlock()
+			onHold(info)
+			timer.Reset(threshold)
+		}
+	}
+}
+
+// SetHoldThreshold calls clb every time a task holds the runner for longer than threshold.
+// This detects transactions that are never released.
+func (r *TaskRunner[T]) SetHoldThreshold(threshold time.Duration, clb func(TaskInfo)) {
+	r.tasksLock.Lock()
+	r.holdThreshold = threshold
+	r.onHold = clb
+	r.tasksLock.Unlock()
+}
+
 func (r *TaskRunner[T]) WithPriority(priority Priority) T {
+	t, _ := r.WithContext(context.Background(), priority, "")
+	return t
+}
+
+// WithContext same as WithPriority, but the task is dropped from the queue if ctx expires before it can be processed,
+// in which case ErrTaskCancelled is returned.
+// The initiator is shown in the TasksOverview while the task is waiting.
+func (r *TaskRunner[T]) WithContext(ctx context.Context, priority Priority, initiator string) (T, error) {
 	canBeProcessedCh := make(chan struct{})
 	taskIsDoneCh := make(chan struct{})
 	task := &item{
 		priority:         priority,
 		canBeProcessedCh: canBeProcessedCh,
 		isDoneCh:         taskIsDoneCh,
+		initiator:        initiator,
+		queuedAt:         r.now(),
+		index:            -1,
+	}
+	select {
+	case r.tasksPushCh <- task:
+	case <-ctx.Done():
+		var zero T
+		return zero, ErrTaskCancelled
 	}
-	r.tasksPushCh <- task
 	select {
 	case <-canBeProcessedCh:
 	case <-r.ctx.Done():
+	case <-ctx.Done():
+		if task.state.CompareAndSwap(itemQueued, itemCancelled) {
+			var zero T
+			return zero, ErrTaskCancelled
+		}
+		// The task was started in the meantime, release it
+		<-canBeProcessedCh
+		close(taskIsDoneCh)
+		var zero T
+		return zero, ErrTaskCancelled
 	}
 	t := r.factory()
 	t.SetTaskDoneCh(taskIsDoneCh)
-	return t
+	return t, nil
 }
 
 // TasksOverview overview of tasks in heap
@@ -130,12 +252,21 @@ type TasksOverview struct {
 	Important int64
 	Critical  int64
 	Total     int64
+	Waiting   []TaskInfo          // Tasks waiting in the queue, by priority
+	Running   *TaskInfo           // Task being processed, nil if none
+	Scheduled []ScheduledTaskInfo // Tasks waiting for their start time, not counted in Total
 }
 
 func (r *TaskRunner[T]) GetTasks() (out TasksOverview) {
+	now := r.now()
 	r.tasksLock.Lock()
-	out.Total = int64(r.tasks.Len())
+	out.Waiting = make([]TaskInfo, 0)
 	for _, item := range r.tasks.Items() {
+		if item.state.Load() == itemCancelled {
+			continue
+		}
+		out.Total++
+		out.Waiting = append(out.Waiting, item.info(now))
 		switch item.priority {
 		case Low:
 			out.Low++
@@ -147,6 +278,17 @@ func (r *TaskRunner[T]) GetTasks() (out TasksOverview) {
 			out.Critical++
 		}
 	}
+	if r.running != nil {
+		info := r.running.info(now)
+		out.Running = &info
+	}
 	r.tasksLock.Unlock()
+	sort.SliceStable(out.Waiting, func(i, j int) bool {
+		if out.Waiting[i].Priority == out.Waiting[j].Priority {
+			return out.Waiting[i].QueuedAt.Before(out.Waiting[j].QueuedAt)
+		}
+		return out.Waiting[i].Priority > out.Waiting[j].Priority
+	})
+	out.Scheduled = r.ScheduledTasks()
 	return
 }
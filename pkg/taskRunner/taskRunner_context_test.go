@@ -0,0 +1,61 @@
+package taskRunner
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/alaingilbert/clockwork"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWithContext(t *testing.T) {
+	clock := clockwork.NewFakeClock()
+	tr := newTestRunner(t, clock)
+	holder := tr.WithPriority(Normal) // Holds the runner
+
+	ctx, cancel := context.WithCancel(context.Background())
+	errCh := make(chan error)
+	go func() {
+		_, err := tr.WithContext(ctx, Important, "waiter")
+		errCh <- err
+	}()
+	require.Eventually(t, func() bool { return tr.GetTasks().Total == 1 }, time.Second, time.Millisecond)
+	clock.Advance(time.Minute)
+	tasks := tr.GetTasks()
+	require.Len(t, tasks.Waiting, 1)
+	assert.Equal(t, "waiter", tasks.Waiting[0].Initiator)
+	assert.Equal(t, Important, tasks.Waiting[0].Priority)
+	assert.Equal(t, time.Minute, tasks.Waiting[0].Age)
+	require.NotNil(t, tasks.Running)
+	assert.Equal(t, time.Minute, tasks.Running.Age)
+
+	cancel()
+	assert.ErrorIs(t, <-errCh, ErrTaskCancelled)
+	assert.Equal(t, int64(0), tr.GetTasks().Total)
+
+	// The cancelled task is skipped once the runner is released
+	close(holder.taskDoneCh)
+	next, err := tr.WithContext(context.Background(), Low, "next")
+	require.NoError(t, err)
+	assert.Equal(t, "next", tr.GetTasks().Running.Initiator)
+	close(next.taskDoneCh)
+}
+
+func TestHoldThreshold(t *testing.T) {
+	clock := clockwork.NewFakeClock()
+	tr := newTestRunner(t, clock)
+	held := make(chan TaskInfo, 10)
+	tr.SetHoldThreshold(time.Minute, func(info TaskInfo) { held <- info })
+	task, err := tr.WithContext(context.Background(), Normal, "stuck")
+	require.NoError(t, err)
+	require.Eventually(t, func() bool {
+		clock.Advance(time.Minute)
+		return len(held) > 0
+	}, time.Second, 10*time.Millisecond)
+	info := <-held
+	assert.Equal(t, "stuck", info.Initiator)
+	assert.GreaterOrEqual(t, info.Age, time.Minute)
+	close(task.taskDoneCh)
+}
@@ -0,0 +1,119 @@
+package wrapper
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/alaingilbert/ogame/pkg/gameforge"
+	"github.com/alaingilbert/ogame/pkg/ogame"
+	echo "github.com/labstack/echo/v4"
+)
+
+// Generic error codes of the ogamed responses, by http status
+const (
+	ErrCodeBadRequest    = "bad_request"
+	ErrCodeUnauthorized  = "unauthorized"
+	ErrCodeForbidden     = "forbidden"
+	ErrCodeNotFound      = "not_found"
+	ErrCodeConflict      = "conflict"
+	ErrCodeGone          = "gone"
+	ErrCodeUnprocessable = "unprocessable"
+	ErrCodeUnavailable   = "unavailable"
+	ErrCodeInternal      = "internal_error"
+)
+
+// APIError http status and stable error code of an error returned by the library
+type APIError struct {
+	Err    error
+	Status int
+	Code   string
+}
+
+// apiErrors errors of the library, and how ogamed reports them
+var apiErrors = []APIError{
+	{ogame.ErrNotLogged, http.StatusServiceUnavailable, "not_logged"},
+	{ogame.ErrBotInactive, http.StatusServiceUnavailable, "bot_inactive"},
+	{ogame.ErrBotLoggedOut, http.StatusServiceUnavailable, "bot_logged_out"},
+	{ogame.ErrMobileView, http.StatusInternalServerError, "mobile_view"},
+	{ogame.ErrDeactivateHidePictures, http.StatusInternalServerError, "hide_pictures_activated"},
+	{ogame.ErrEventsBoxNotDisplayed, http.StatusInternalServerError, "events_box_not_displayed"},
+	{ogame.ErrInvalidPlanetID, http.StatusBadRequest, "invalid_planet_id"},
+	{ErrIntoCelestial, http.StatusNotFound, "celestial_not_found"},
+	{ogame.ErrCelestialNotFound, http.StatusNotFound, "celestial_not_found"},
+	{ErrInvalidOrigin, http.StatusBadRequest, "invalid_origin"},
+	{ErrBuild, http.StatusUnprocessableEntity, "build_failed"},
+	{ogame.ErrAllSlotsInUse, http.StatusConflict, "all_slots_in_use"},
+	{ogame.ErrNotEnoughDeuterium, http.StatusUnprocessableEntity, "not_enough_deuterium"},
+	{ogame.ErrNotEnoughFuel, http.StatusUnprocessableEntity, "not_enough_fuel"},
+	{ogame.ErrNotEnoughCargoSpace, http.StatusUnprocessableEntity, "not_enough_cargo_space"},
+	{ogame.ErrNotEnoughShips, http.StatusUnprocessableEntity, "not_enough_ships"},
+	{ogame.ErrNoShipSelected, http.StatusUnprocessableEntity, "no_ship_selected"},
+	{ogame.ErrUnionNotFound, http.StatusNotFound, "union_not_found"},
+	{ogame.ErrAccountInVacationMode, http.StatusConflict, "account_in_vacation_mode"},
+	{ogame.ErrPlayerInVacationMode, http.StatusUnprocessableEntity, "player_in_vacation_mode"},
+	{ogame.ErrUninhabitedPlanet, http.StatusUnprocessableEntity, "uninhabited_planet"},
+	{ogame.ErrNoDebrisField, http.StatusUnprocessableEntity, "no_debris_field"},
+	{ogame.ErrAdminOrGM, http.StatusUnprocessableEntity, "admin_or_gm"},
+	{ogame.ErrNoAstrophysics, http.StatusUnprocessableEntity, "no_astrophysics"},
+	{ogame.ErrNoobProtection, http.StatusUnprocessableEntity, "noob_protection"},
+	{ogame.ErrPlayerTooStrong, http.StatusUnprocessableEntity, "player_too_strong"},
+	{ogame.ErrNoMoonAvailable, http.StatusUnprocessableEntity, "no_moon_available"},
+	{ogame.ErrNoRecyclerAvailable, http.StatusUnprocessableEntity, "no_recycler_available"},
+	{ogame.ErrNoEventsRunning, http.StatusUnprocessableEntity, "no_events_running"},
+	{ogame.ErrPlanetAlreadyReservedForRelocation, http.StatusConflict, "planet_reserved_for_relocation"},
+	{ogame.ErrAttackBannedUntil, http.StatusForbidden, "attack_banned"},
+	{ogame.ErrEngagedInCombat, http.StatusConflict, "engaged_in_combat"},
+	{gameforge.ErrBadCredentials, http.StatusBadRequest, "bad_credentials"},
+	{gameforge.ErrOTPRequired, http.StatusBadRequest, "otp_required"},
+	{gameforge.ErrOTPInvalid, http.StatusBadRequest, "otp_invalid"},
+	{gameforge.ErrAccountNotFound, http.StatusNotFound, "gameforge_account_not_found"},
+	{gameforge.ErrForbidden, http.StatusForbidden, "gameforge_forbidden"},
+}
+
+// StatusErrorCode generic error code of an http status
+func StatusErrorCode(status int) string {
+	switch status {
+	case http.StatusBadRequest:
+		return ErrCodeBadRequest
+	case http.StatusUnauthorized:
+		return ErrCodeUnauthorized
+	case http.StatusForbidden:
+		return ErrCodeForbidden
+	case http.StatusNotFound:
+		return ErrCodeNotFound
+	case http.StatusConflict:
+		return ErrCodeConflict
+	case http.StatusGone:
+		return ErrCodeGone
+	case http.StatusUnprocessableEntity:
+		return ErrCodeUnprocessable
+	case http.StatusServiceUnavailable:
+		return ErrCodeUnavailable
+	}
+	return ErrCodeInternal
+}
+
+// APIErrorFor returns the http status and the error code of err.
+// Unknown errors are reported with defaultStatus and its generic error code.
+func APIErrorFor(err error, defaultStatus int) (int, string) {
+	for _, apiErr := range apiErrors {
+		if errors.Is(err, apiErr.Err) {
+			return apiErr.Status, apiErr.Code
+		}
+	}
+	var attackBlockErr *ogame.AttackBlockActivatedErr
+	if errors.As(err, &attackBlockErr) {
+		return http.StatusForbidden, "attack_block_activated"
+	}
+	var captchaErr *gameforge.CaptchaRequiredError
+	if errors.As(err, &captchaErr) {
+		return http.StatusForbidden, "captcha_required"
+	}
+	return defaultStatus, StatusErrorCode(defaultStatus)
+}
+
+// ErrorJSON responds with the http status and the error code of err, defaultStatus if the error is unknown
+func ErrorJSON(c echo.Context, defaultStatus int, err error) error {
+	status, code := APIErrorFor(err, defaultStatus)
+	return c.JSON(status, ErrorCodeResp(status, code, err.Error()))
+}
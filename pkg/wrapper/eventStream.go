@@ -0,0 +1,167 @@
+package wrapper
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	echo "github.com/labstack/echo/v4"
+	"golang.org/x/net/websocket"
+)
+
+// StreamEventTypes events that can be streamed by the events routes, the raw pages and websocket messages are not
+var StreamEventTypes = []EventType{
+	StateChangeEvent,
+	ChatMessageEvent,
+	AuctioneerEvent,
+	LoginEvent,
+	LogoutEvent,
+	AttackDetectedEvent,
+	FleetSentEvent,
+	FleetReturnedEvent,
+	ConstructionFinishedEvent,
+	ResourcesChangedEvent,
+	CaptchaRequiredEvent,
+}
+
+const (
+	eventStreamBufferSize = 100
+	eventStreamKeepAlive  = 30 * time.Second
+)
+
+// parseEventTypes parses the "types" query values, comma separated and/or repeated.
+// Returns all the StreamEventTypes if none is given.
+func parseEventTypes(values []string) ([]EventType, error) {
+	types := make([]EventType, 0)
+	for _, value := range values {
+		for _, s := range strings.Split(value, ",") {
+			if s = strings.TrimSpace(s); s == "" {
+				continue
+			}
+			typ := EventType(s)
+			if !isStreamEventType(typ) {
+				return nil, errors.New("invalid event type " + s)
+			}
+			types = append(types, typ)
+		}
+	}
+	if len(types) == 0 {
+		return StreamEventTypes, nil
+	}
+	return types, nil
+}
+
+func isStreamEventType(typ EventType) bool {
+	for _, t := range StreamEventTypes {
+		if t == typ {
+			return true
+		}
+	}
+	return false
+}
+
+// CORSContextKey key of the echo context telling if cross-origin requests are allowed
+const CORSContextKey = "cors"
+
+// checkSameOrigin rejects the requests made by a browser from another site, they would be authenticated by the cached
+// basic auth credentials. Requests without Origin are not made by a browser.
+func checkSameOrigin(req *http.Request) error {
+	origin := req.Header.Get(echo.HeaderOrigin)
+	if origin == "" {
+		return nil
+	}
+	u, err := url.Parse(origin)
+	if err != nil || !strings.EqualFold(u.Host, req.Host) {
+		return errors.New("cross-origin request not allowed: " + origin)
+	}
+	return nil
+}
+
+// EventsHandler streams the events of the bot as server-sent events, filtered by the "types" query param.
+// Each event is sent as "event: <type>" and "data: <json Event>".
+func EventsHandler(c echo.Context) error {
+	bot := c.Get("bot").(*OGame)
+	types, err := parseEventTypes(c.QueryParams()["types"])
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, ErrorResp(http.StatusBadRequest, err.Error()))
+	}
+	sub := bot.Subscribe(eventStreamBufferSize, types...)
+	defer sub.Unsubscribe()
+
+	w := c.Response()
+	w.Header().Set(echo.HeaderContentType, "text/event-stream")
+	w.Header().Set(echo.HeaderCacheControl, "no-cache")
+	w.WriteHeader(http.StatusOK)
+	w.Flush()
+	keepAlive := time.NewTicker(eventStreamKeepAlive)
+	defer keepAlive.Stop()
+	for {
+		select {
+		case <-c.Request().Context().Done():
+			return nil
+		case <-keepAlive.C:
+			if _, err := fmt.Fprint(w, ": keepalive\n\n"); err != nil {
+				return nil
+			}
+		case evt, ok := <-sub.C():
+			if !ok {
+				return nil
+			}
+			by, err := json.Marshal(evt)
+			if err != nil {
+				continue
+			}
+			if _, err := fmt.Fprintf(w, "event: %s\ndata: %s\n\n", evt.Type, by); err != nil {
+				return nil
+			}
+		}
+		w.Flush()
+	}
+}
+
+// EventsWSHandler streams the events of the bot on a websocket, one json Event per message,
+// filtered by the "types" query param
+func EventsWSHandler(c echo.Context) error {
+	bot := c.Get("bot").(*OGame)
+	types, err := parseEventTypes(c.QueryParams()["types"])
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, ErrorResp(http.StatusBadRequest, err.Error()))
+	}
+	sub := bot.Subscribe(eventStreamBufferSize, types...)
+	defer sub.Unsubscribe()
+	allowCrossOrigin, _ := c.Get(CORSContextKey).(bool)
+	websocket.Server{Handshake: func(_ *websocket.Config, req *http.Request) error {
+		if allowCrossOrigin {
+			return nil
+		}
+		return checkSameOrigin(req)
+	}, Handler: func(ws *websocket.Conn) {
+		defer ws.Close()
+		// The client does not send anything, reading only detects the connection being closed
+		closed := make(chan struct{})
+		go func() {
+			defer close(closed)
+			var msg []byte
+			for websocket.Message.Receive(ws, &msg) == nil {
+			}
+		}()
+		for {
+			select {
+			case <-closed:
+				return
+			case evt, ok := <-sub.C():
+				if !ok {
+					return
+				}
+				if err := websocket.JSON.Send(ws, evt); err != nil {
+					return
+				}
+			}
+		}
+	}}.ServeHTTP(c.Response(), c.Request())
+	return nil
+}
@@ -0,0 +1,101 @@
+package wrapper
+
+import (
+	"bufio"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/alaingilbert/ogame/pkg/device"
+	"github.com/alaingilbert/ogame/pkg/ogame"
+	echo "github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/net/websocket"
+)
+
+func newEventsServer(t *testing.T, cors bool) (*OGame, *httptest.Server) {
+	bot, _ := NewNoLogin(&device.Device{}, "", "", "", "")
+	e := echo.New()
+	e.Use(func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			c.Set("bot", bot)
+			c.Set(CORSContextKey, cors)
+			return next(c)
+		}
+	})
+	e.GET("/bot/events", EventsHandler)
+	e.GET("/bot/events/ws", EventsWSHandler)
+	ts := httptest.NewServer(e)
+	t.Cleanup(ts.Close)
+	return bot, ts
+}
+
+func TestParseEventTypes(t *testing.T) {
+	types, err := parseEventTypes(nil)
+	assert.NoError(t, err)
+	assert.Equal(t, StreamEventTypes, types)
+	types, err = parseEventTypes([]string{"attackDetected, fleetSent", "chatMessage"})
+	assert.NoError(t, err)
+	assert.Equal(t, []EventType{AttackDetectedEvent, FleetSentEvent, ChatMessageEvent}, types)
+	_, err = parseEventTypes([]string{"page"})
+	assert.EqualError(t, err, "invalid event type page")
+}
+
+func TestEventsHandler(t *testing.T) {
+	bot, ts := newEventsServer(t, false)
+	resp, err := http.Get(ts.URL + "/bot/events?types=unknown")
+	require.NoError(t, err)
+	resp.Body.Close()
+	assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+
+	resp, err = http.Get(ts.URL + "/bot/events?types=attackDetected")
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	assert.Equal(t, "text/event-stream", resp.Header.Get(echo.HeaderContentType))
+	bot.publish(ChatMessageEvent, ogame.ChatMsg{Text: "filtered"})
+	bot.trackAttacks([]ogame.AttackEvent{{ID: 1}})
+	scanner := bufio.NewScanner(resp.Body)
+	require.True(t, scanner.Scan())
+	assert.Equal(t, "event: attackDetected", scanner.Text())
+	require.True(t, scanner.Scan())
+	var evt struct {
+		Type EventType
+		Data ogame.AttackEvent
+	}
+	require.NoError(t, json.Unmarshal([]byte(strings.TrimPrefix(scanner.Text(), "data: ")), &evt))
+	assert.Equal(t, AttackDetectedEvent, evt.Type)
+	assert.Equal(t, int64(1), evt.Data.ID)
+}
+
+func TestEventsWSHandler(t *testing.T) {
+	bot, ts := newEventsServer(t, false)
+	wsURL := "ws" + strings.TrimPrefix(ts.URL, "http") + "/bot/events/ws?types=chatMessage"
+	ws, err := websocket.Dial(wsURL, "", ts.URL)
+	require.NoError(t, err)
+	defer ws.Close()
+	bot.publish(StateChangeEvent, StateChangeEventData{Locked: true})
+	bot.publish(ChatMessageEvent, ogame.ChatMsg{Text: "hello"})
+	var evt struct {
+		Type EventType
+		Data ogame.ChatMsg
+	}
+	require.NoError(t, websocket.JSON.Receive(ws, &evt))
+	assert.Equal(t, ChatMessageEvent, evt.Type)
+	assert.Equal(t, "hello", evt.Data.Text)
+}
+
+func TestEventsWSHandler_Origin(t *testing.T) {
+	_, ts := newEventsServer(t, false)
+	wsURL := "ws" + strings.TrimPrefix(ts.URL, "http") + "/bot/events/ws"
+	_, err := websocket.Dial(wsURL, "", "http://evil.example.com")
+	assert.Error(t, err)
+
+	_, ts = newEventsServer(t, true)
+	wsURL = "ws" + strings.TrimPrefix(ts.URL, "http") + "/bot/events/ws"
+	ws, err := websocket.Dial(wsURL, "", "http://evil.example.com")
+	require.NoError(t, err)
+	ws.Close()
+}
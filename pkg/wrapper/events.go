@@ -0,0 +1,316 @@
+package wrapper
+
+import (
+	"net/url"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/alaingilbert/ogame/pkg/ogame"
+)
+
+// EventType type of the events published on the event bus
+type EventType string
+
+// Event types, the comment gives the type of Event.Data
+const (
+	StateChangeEvent          EventType = "stateChange"          // StateChangeEventData
+	ChatMessageEvent          EventType = "chatMessage"          // ogame.ChatMsg
+	AuctioneerEvent           EventType = "auctioneer"           // any (see processAuctioneerMessage)
+	WSMessageEvent            EventType = "wsMessage"            // []byte
+	PageEvent                 EventType = "page"                 // PageEventData
+	LoginEvent                EventType = "login"                // nil
+	LogoutEvent               EventType = "logout"               // nil
+	AttackDetectedEvent       EventType = "attackDetected"       // ogame.AttackEvent
+	FleetSentEvent            EventType = "fleetSent"            // ogame.Fleet
+	FleetReturnedEvent        EventType = "fleetReturned"        // ogame.Fleet
+	ConstructionFinishedEvent EventType = "constructionFinished" // ConstructionFinishedEventData
+	ResourcesChangedEvent     EventType = "resourcesChanged"     // ResourcesChangedEventData
+	CaptchaRequiredEvent      EventType = "captchaRequired"      // CaptchaRequiredEventData
+)
+
+// Event published on the event bus
+type Event struct {
+	Type EventType
+	Time time.Time
+	Data any
+}
+
+// StateChangeEventData the bot got locked/unlocked
+type StateChangeEventData struct {
+	Locked bool
+	Actor  string
+}
+
+// PageEventData a page was fetched from the server
+type PageEventData struct {
+	Method   string
+	URL      string
+	Params   url.Values
+	Payload  url.Values
+	PageHTML []byte
+}
+
+// ConstructionFinishedEventData a construction is not in the queue anymore and its countdown is over
+type ConstructionFinishedEventData struct {
+	CelestialID  ogame.CelestialID
+	Construction ogame.Construction
+}
+
+// ResourcesChangedEventData resources of a celestial changed since the last time they were fetched,
+// by more than what the production of the celestial explains (eg: fleet arrival, construction started).
+// Population and food are not compared, they change with the lifeform growth and consumption.
+type ResourcesChangedEventData struct {
+	CelestialID ogame.CelestialID
+	Previous    ogame.Resources
+	Current     ogame.Resources
+}
+
+// CaptchaRequiredEventData gameforge asked for a captcha during login
+type CaptchaRequiredEventData struct {
+	ChallengeID string
+}
+
+// Subscription receives the events of the types it subscribed to.
+// Events are dropped if the subscriber does not consume them fast enough to keep the buffer from being full.
+type Subscription struct {
+	id      int64
+	types   map[EventType]struct{}
+	ch      chan Event
+	dropped atomic.Int64
+	bus     *EventBus
+	once    sync.Once
+}
+
+// C returns the channel the events are delivered on. The channel is closed on Unsubscribe.
+func (s *Subscription) C() <-chan Event { return s.ch }
+
+// Dropped returns the number of events that were dropped because the buffer was full
+func (s *Subscription) Dropped() int64 { return s.dropped.Load() }
+
+// Unsubscribe stops the delivery of events and closes the channel
+func (s *Subscription) Unsubscribe() {
+	s.once.Do(func() {
+		s.bus.mu.Lock()
+		delete(s.bus.subs, s.id)
+		close(s.ch)
+		s.bus.mu.Unlock()
+	})
+}
+
+func (s *Subscription) wants(typ EventType) bool {
+	if len(s.types) == 0 {
+		return true
+	}
+	_, ok := s.types[typ]
+	return ok
+}
+
+// EventBus delivers events to subscribers
+type EventBus struct {
+	mu     sync.RWMutex
+	lastID int64
+	subs   map[int64]*Subscription
+}
+
+// NewEventBus creates a new event bus
+func NewEventBus() *EventBus {
+	return &EventBus{subs: make(map[int64]*Subscription)}
+}
+
+// Subscribe to events of the given types (all events if none given), delivered on a channel of size bufferSize
+func (e *EventBus) Subscribe(bufferSize int, types ...EventType) *Subscription {
+	s := &Subscription{types: make(map[EventType]struct{}), ch: make(chan Event, max(bufferSize, 0)), bus: e}
+	for _, typ := range types {
+		s.types[typ] = struct{}{}
+	}
+	e.mu.Lock()
+	e.lastID++
+	s.id = e.lastID
+	e.subs[s.id] = s
+	e.mu.Unlock()
+	return s
+}
+
+// Publish delivers the event to the subscribers without blocking
+func (e *EventBus) Publish(typ EventType, data any) {
+	evt := Event{Type: typ, Time: time.Now(), Data: data}
+	e.mu.RLock()
+	defer e.mu.RUnlock()
+	for _, s := range e.subs {
+		if !s.wants(typ) {
+			continue
+		}
+		select {
+		case s.ch <- evt:
+		default:
+			s.dropped.Add(1)
+		}
+	}
+}
+
+// SubscribeFunc calls clb with the typed data of every event of type typ, until unsubscribe is called
+func SubscribeFunc[T any](bus *EventBus, typ EventType, bufferSize int, clb func(T)) (unsubscribe func()) {
+	s := bus.Subscribe(bufferSize, typ)
+	go func() {
+		for evt := range s.C() {
+			if data, ok := evt.Data.(T); ok {
+				clb(data)
+			}
+		}
+	}()
+	return s.Unsubscribe
+}
+
+// eventsTracker keeps the last known state, to publish events when it changes
+type eventsTracker struct {
+	sync.Mutex
+	attacks       map[int64]struct{}
+	fleets        map[ogame.FleetID]ogame.Fleet
+	constructions map[ogame.CelestialID]trackedConstructions
+	resources     map[ogame.CelestialID]trackedResources
+}
+
+type trackedConstructions struct {
+	constructions ogame.Constructions
+	fetchedAt     time.Time
+}
+
+type trackedResources struct {
+	details   ogame.ResourcesDetails
+	fetchedAt time.Time
+}
+
+func newEventsTracker() *eventsTracker {
+	return &eventsTracker{
+		attacks:       make(map[int64]struct{}),
+		constructions: make(map[ogame.CelestialID]trackedConstructions),
+		resources:     make(map[ogame.CelestialID]trackedResources),
+	}
+}
+
+func (b *OGame) publish(typ EventType, data any) {
+	if b.events != nil {
+		b.events.Publish(typ, data)
+	}
+}
+
+// trackAttacks publishes the attacks that were not seen yet
+func (b *OGame) trackAttacks(attacks []ogame.AttackEvent) {
+	t := b.eventsTracker
+	if t == nil {
+		return
+	}
+	t.Lock()
+	seen := make(map[int64]struct{}, len(attacks))
+	newAttacks := make([]ogame.AttackEvent, 0)
+	for _, attack := range attacks {
+		seen[attack.ID] = struct{}{}
+		if _, ok := t.attacks[attack.ID]; !ok {
+			newAttacks = append(newAttacks, attack)
+		}
+	}
+	t.attacks = seen
+	t.Unlock()
+	for _, attack := range newAttacks {
+		b.publish(AttackDetectedEvent, attack)
+	}
+}
+
+// trackFleets publishes the fleets that are not in movement anymore once their back time is over.
+// The fleet may have been outbound at the previous call, the whole round trip happened in between.
+// Deployed fleets stay at their destination, and fleets that disappear before their back time were destroyed.
+func (b *OGame) trackFleets(fleets []ogame.Fleet) {
+	if b.expeditionFleets != nil {
+		b.expeditionFleets.add(fleets...)
+	}
+	t := b.eventsTracker
+	if t == nil {
+		return
+	}
+	t.Lock()
+	current := make(map[ogame.FleetID]ogame.Fleet, len(fleets))
+	for _, fleet := range fleets {
+		current[fleet.ID] = fleet
+	}
+	now := time.Now()
+	returned := make([]ogame.Fleet, 0)
+	if t.fleets != nil {
+		for id, fleet := range t.fleets {
+			if _, ok := current[id]; ok || fleet.Mission == ogame.Park || now.Add(time.Second).Before(fleet.BackTime) {
+				continue
+			}
+			returned = append(returned, fleet)
+		}
+	}
+	t.fleets = current
+	t.Unlock()
+	for _, fleet := range returned {
+		b.publish(FleetReturnedEvent, fleet)
+	}
+}
+
+// trackConstructions publishes the constructions that left the queue after their countdown was over
+func (b *OGame) trackConstructions(celestialID ogame.CelestialID, constructions ogame.Constructions) {
+	now := time.Now()
+	t := b.eventsTracker
+	if t == nil {
+		return
+	}
+	t.Lock()
+	prev, ok := t.constructions[celestialID]
+	t.constructions[celestialID] = trackedConstructions{constructions: constructions, fetchedAt: now}
+	t.Unlock()
+	if !ok {
+		return
+	}
+	pairs := [][2]ogame.Construction{
+		{prev.constructions.Building, constructions.Building},
+		{prev.constructions.Research, constructions.Research},
+		{prev.constructions.LfBuilding, constructions.LfBuilding},
+		{prev.constructions.LfResearch, constructions.LfResearch},
+	}
+	for _, pair := range pairs {
+		before, after := pair[0], pair[1]
+		if before.ID == 0 || (before.ID == after.ID && before.Level == after.Level) {
+			continue
+		}
+		// A construction that leaves the queue before its countdown is over was cancelled
+		if now.Add(time.Second).Before(prev.fetchedAt.Add(before.Countdown)) {
+			continue
+		}
+		b.publish(ConstructionFinishedEvent, ConstructionFinishedEventData{CelestialID: celestialID, Construction: before})
+	}
+}
+
+// trackResources publishes the resources of a celestial when they changed since the last fetch,
+// changes explained by the production of the celestial are ignored
+func (b *OGame) trackResources(celestialID ogame.CelestialID, details ogame.ResourcesDetails) {
+	now := time.Now()
+	t := b.eventsTracker
+	if t == nil {
+		return
+	}
+	t.Lock()
+	prev, ok := t.resources[celestialID]
+	t.resources[celestialID] = trackedResources{details: details, fetchedAt: now}
+	t.Unlock()
+	if !ok || isProductionChange(prev.details, details, now.Sub(prev.fetchedAt)) {
+		return
+	}
+	b.publish(ResourcesChangedEvent, ResourcesChangedEventData{CelestialID: celestialID, Previous: prev.details.Available(), Current: details.Available()})
+}
+
+// isProductionChange returns either or not the resources only changed by what the production of the previous fetch
+// gives in the elapsed time
+func isProductionChange(prev, curr ogame.ResourcesDetails, elapsed time.Duration) bool {
+	produced := func(before, after, perHour int64) bool {
+		expected := before + int64(float64(perHour)*elapsed.Hours())
+		return after >= min(before, expected)-1 && after <= max(before, expected)+1 // 1 unit for rounding
+	}
+	return produced(prev.Metal.Available, curr.Metal.Available, prev.Metal.CurrentProduction) &&
+		produced(prev.Crystal.Available, curr.Crystal.Available, prev.Crystal.CurrentProduction) &&
+		produced(prev.Deuterium.Available, curr.Deuterium.Available, prev.Deuterium.CurrentProduction) &&
+		prev.Energy.Available == curr.Energy.Available &&
+		prev.Darkmatter.Available == curr.Darkmatter.Available
+}
@@ -0,0 +1,121 @@
+package wrapper
+
+import (
+	"testing"
+	"time"
+
+	"github.com/alaingilbert/ogame/pkg/device"
+	"github.com/alaingilbert/ogame/pkg/ogame"
+	"github.com/stretchr/testify/assert"
+)
+
+func drain(sub *Subscription) (out []Event) {
+	for {
+		select {
+		case evt := <-sub.C():
+			out = append(out, evt)
+		default:
+			return
+		}
+	}
+}
+
+func TestEventBus_Subscribe(t *testing.T) {
+	bus := NewEventBus()
+	all := bus.Subscribe(10)
+	logins := bus.Subscribe(10, LoginEvent)
+	bus.Publish(LoginEvent, nil)
+	bus.Publish(LogoutEvent, nil)
+	assert.Len(t, drain(all), 2)
+	evts := drain(logins)
+	assert.Len(t, evts, 1)
+	assert.Equal(t, LoginEvent, evts[0].Type)
+}
+
+func TestEventBus_Dropped(t *testing.T) {
+	bus := NewEventBus()
+	sub := bus.Subscribe(1)
+	bus.Publish(LoginEvent, nil)
+	bus.Publish(LogoutEvent, nil)
+	assert.Equal(t, int64(1), sub.Dropped())
+	sub.Unsubscribe()
+	sub.Unsubscribe()
+	bus.Publish(LoginEvent, nil)
+	evt, ok := <-sub.C()
+	assert.True(t, ok)
+	assert.Equal(t, LoginEvent, evt.Type)
+	_, ok = <-sub.C()
+	assert.False(t, ok)
+}
+
+func TestSubscribeFunc(t *testing.T) {
+	bus := NewEventBus()
+	ch := make(chan StateChangeEventData, 1)
+	unsubscribe := SubscribeFunc(bus, StateChangeEvent, 1, func(data StateChangeEventData) { ch <- data })
+	defer unsubscribe()
+	bus.Publish(StateChangeEvent, StateChangeEventData{Locked: true, Actor: "Test"})
+	select {
+	case data := <-ch:
+		assert.Equal(t, StateChangeEventData{Locked: true, Actor: "Test"}, data)
+	case <-time.After(time.Second):
+		t.Fatal("callback not called")
+	}
+}
+
+func TestOGame_TrackEvents(t *testing.T) {
+	bot, _ := NewNoLogin(&device.Device{}, "", "", "", "")
+	sub := bot.Subscribe(100)
+
+	bot.trackAttacks([]ogame.AttackEvent{{ID: 1}})
+	bot.trackAttacks([]ogame.AttackEvent{{ID: 1}, {ID: 2}})
+	evts := drain(sub)
+	assert.Len(t, evts, 2)
+	assert.Equal(t, int64(2), evts[1].Data.(ogame.AttackEvent).ID)
+
+	past, future := time.Now().Add(-time.Minute), time.Now().Add(time.Hour)
+	bot.trackFleets([]ogame.Fleet{
+		{ID: 1, ReturnFlight: true, BackTime: past},
+		{ID: 2, ReturnFlight: true, BackTime: future},
+		{ID: 3, Mission: ogame.Park, BackTime: past},
+		{ID: 4, Mission: ogame.Spy, BackTime: future},
+		{ID: 5},
+	})
+	bot.trackFleets([]ogame.Fleet{{ID: 4, Mission: ogame.Spy, BackTime: future}, {ID: 5}})
+	evts = drain(sub)
+	assert.Len(t, evts, 1) // The fleet returning later was destroyed, the deployed one reached its destination
+	assert.Equal(t, FleetReturnedEvent, evts[0].Type)
+	assert.Equal(t, ogame.FleetID(1), evts[0].Data.(ogame.Fleet).ID)
+
+	// Seen outbound once, and gone after its back time: the round trip happened between the two calls
+	fleet := bot.eventsTracker.fleets[4]
+	fleet.BackTime = past
+	bot.eventsTracker.fleets[4] = fleet
+	bot.trackFleets([]ogame.Fleet{{ID: 5}})
+	evts = drain(sub)
+	assert.Len(t, evts, 1)
+	assert.Equal(t, ogame.FleetID(4), evts[0].Data.(ogame.Fleet).ID)
+	assert.False(t, evts[0].Data.(ogame.Fleet).ReturnFlight)
+
+	var details ogame.ResourcesDetails
+	details.Metal.Available = 1000
+	details.Metal.CurrentProduction = 3600
+	bot.trackResources(1, details)
+	bot.trackResources(1, details)
+	bot.eventsTracker.resources[1] = trackedResources{details: details, fetchedAt: time.Now().Add(-time.Hour)}
+	details.Metal.Available = 4600
+	bot.trackResources(1, details) // Produced in an hour
+	assert.Len(t, drain(sub), 0)
+	prev := details.Available()
+	details.Metal.Available = 1600
+	bot.trackResources(1, details)
+	evts = drain(sub)
+	assert.Len(t, evts, 1)
+	assert.Equal(t, ResourcesChangedEventData{CelestialID: 1, Previous: prev, Current: ogame.Resources{Metal: 1600}}, evts[0].Data)
+
+	finished := ogame.Construction{ID: ogame.MetalMineID, Level: 5}
+	bot.trackConstructions(1, ogame.Constructions{Building: finished, Research: ogame.Construction{ID: ogame.EnergyTechnologyID, Countdown: time.Hour}})
+	bot.trackConstructions(1, ogame.Constructions{})
+	evts = drain(sub)
+	assert.Len(t, evts, 1) // The research left the queue before its countdown, it was cancelled
+	assert.Equal(t, ConstructionFinishedEventData{CelestialID: 1, Construction: finished}, evts[0].Data)
+}
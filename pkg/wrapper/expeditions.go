@@ -0,0 +1,69 @@
+package wrapper
+
+import (
+	"sync"
+	"time"
+
+	"github.com/alaingilbert/ogame/pkg/ogame"
+)
+
+// maxExpeditionFleets number of expedition fleets remembered to match the expedition messages
+const maxExpeditionFleets = 1000
+
+// expeditionFleets expedition fleets seen by the bot, used to know which ships were sent on an expedition
+type expeditionFleets struct {
+	sync.Mutex
+	fleets map[ogame.FleetID]ogame.Fleet
+	order  []ogame.FleetID
+}
+
+func newExpeditionFleets() *expeditionFleets {
+	return &expeditionFleets{fleets: make(map[ogame.FleetID]ogame.Fleet)}
+}
+
+// add remembers the expedition fleets. The ships of a fleet are the ones seen first,
+// a returning expedition also carries the ships it found.
+func (e *expeditionFleets) add(fleets ...ogame.Fleet) {
+	e.Lock()
+	defer e.Unlock()
+	for _, fleet := range fleets {
+		if fleet.Mission != ogame.Expedition {
+			continue
+		}
+		if _, ok := e.fleets[fleet.ID]; ok {
+			continue
+		}
+		e.fleets[fleet.ID] = fleet
+		e.order = append(e.order, fleet.ID)
+		if len(e.order) > maxExpeditionFleets {
+			delete(e.fleets, e.order[0])
+			e.order = e.order[1:]
+		}
+	}
+}
+
+// fleetOf finds the fleet of an expedition message, the message is received at the end of the holding time
+func (e *expeditionFleets) fleetOf(msg ogame.ExpeditionMessage) (ogame.ShipsInfos, bool) {
+	const margin = time.Minute
+	e.Lock()
+	defer e.Unlock()
+	for _, fleet := range e.fleets {
+		dest := fleet.Destination
+		if dest.Galaxy != msg.Coordinate.Galaxy || dest.System != msg.Coordinate.System || dest.Position != msg.Coordinate.Position {
+			continue
+		}
+		if msg.CreatedAt.Before(fleet.ArrivalTime.Add(-margin)) || msg.CreatedAt.After(fleet.BackTime.Add(margin)) {
+			continue
+		}
+		return fleet.Ships, true
+	}
+	return ogame.ShipsInfos{}, false
+}
+
+func (b *OGame) getExpeditionStats(maxPage int64) (ogame.ExpeditionStats, error) {
+	msgs, err := b.getExpeditionMessages(maxPage)
+	if err != nil {
+		return ogame.ExpeditionStats{}, err
+	}
+	return ogame.NewExpeditionStats(msgs, b.expeditionFleets.fleetOf), nil
+}
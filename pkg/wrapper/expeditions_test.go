@@ -0,0 +1,28 @@
+package wrapper
+
+import (
+	"testing"
+	"time"
+
+	"github.com/alaingilbert/ogame/pkg/ogame"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestExpeditionFleets_FleetOf(t *testing.T) {
+	at := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	e := newExpeditionFleets()
+	e.add(
+		ogame.Fleet{ID: 1, Mission: ogame.Transport, Destination: ogame.Coordinate{Galaxy: 1, System: 1, Position: 16}, ArrivalTime: at, BackTime: at.Add(2 * time.Hour)},
+		ogame.Fleet{ID: 2, Mission: ogame.Expedition, Destination: ogame.Coordinate{Galaxy: 1, System: 1, Position: 16}, ArrivalTime: at, BackTime: at.Add(2 * time.Hour), Ships: ogame.ShipsInfos{LargeCargo: 5}},
+	)
+	// Ships found by the expedition are not part of the composition that was sent
+	e.add(ogame.Fleet{ID: 2, Mission: ogame.Expedition, ReturnFlight: true, Ships: ogame.ShipsInfos{LargeCargo: 5, LightFighter: 10}})
+
+	ships, ok := e.fleetOf(ogame.ExpeditionMessage{Coordinate: ogame.Coordinate{Galaxy: 1, System: 1, Position: 16, Type: ogame.PlanetType}, CreatedAt: at.Add(time.Hour)})
+	assert.True(t, ok)
+	assert.Equal(t, ogame.ShipsInfos{LargeCargo: 5}, ships)
+	_, ok = e.fleetOf(ogame.ExpeditionMessage{Coordinate: ogame.Coordinate{Galaxy: 1, System: 1, Position: 16}, CreatedAt: at.Add(3 * time.Hour)})
+	assert.False(t, ok)
+	_, ok = e.fleetOf(ogame.ExpeditionMessage{Coordinate: ogame.Coordinate{Galaxy: 1, System: 2, Position: 16}, CreatedAt: at.Add(time.Hour)})
+	assert.False(t, ok)
+}
@@ -4,7 +4,6 @@ import (
 	"bytes"
 	"encoding/base64"
 	"errors"
-	"fmt"
 	"github.com/alaingilbert/ogame/pkg/gameforge"
 	"io"
 	"net/http"
@@ -19,10 +18,11 @@ import (
 
 // APIResp ...
 type APIResp struct {
-	Status  string
-	Code    int
-	Message string
-	Result  any
+	Status    string
+	Code      int
+	Message   string
+	ErrorCode string `json:",omitempty"` // Machine-readable error code, eg: not_enough_deuterium
+	Result    any
 }
 
 // SuccessResp ...
@@ -32,7 +32,25 @@ func SuccessResp(data any) APIResp {
 
 // ErrorResp ...
 func ErrorResp(code int, message string) APIResp {
-	return APIResp{Status: "error", Code: code, Message: message}
+	return ErrorCodeResp(code, StatusErrorCode(code), message)
+}
+
+// ErrorCodeResp error response with a specific error code
+func ErrorCodeResp(code int, errorCode, message string) APIResp {
+	return APIResp{Status: "error", Code: code, ErrorCode: errorCode, Message: message}
+}
+
+// InitiatorContextKey key of the echo context holding who made the request, eg: the name of an api key
+const InitiatorContextKey = "initiator"
+
+// initiated returns the bot of the request, its task is attributed to the initiator of the request in the task queue.
+// When there is an initiator, the task queue is held until a method of the returned Prioritizable is called.
+func initiated(c echo.Context) Prioritizable {
+	bot := c.Get("bot").(*OGame)
+	if initiator, _ := c.Get(InitiatorContextKey).(string); initiator != "" {
+		return bot.SetInitiator(initiator)
+	}
+	return bot
 }
 
 // HomeHandler ...
@@ -67,7 +85,7 @@ func GetServerDataHandler(c echo.Context) error {
 
 // SetUserAgentHandler deprecated
 func SetUserAgentHandler(c echo.Context) error {
-	return c.JSON(http.StatusOK, ErrorResp(http.StatusBadRequest, "deprecated"))
+	return c.JSON(http.StatusGone, ErrorResp(http.StatusGone, "deprecated"))
 }
 
 // ServerURLHandler ...
@@ -86,10 +104,20 @@ func GetLanguageHandler(c echo.Context) error {
 // curl 127.0.0.1:1234/bot/page-content -d 'page=overview&cp=123'
 func PageContentHandler(c echo.Context) error {
 	bot := c.Get("bot").(*OGame)
-	if err := c.Request().ParseForm(); err != nil {
-		return c.JSON(http.StatusBadRequest, ErrorResp(400, err.Error()))
+	var req PageContentRequest
+	if err := BindRequest(c, &req); err != nil {
+		return ErrorJSON(c, http.StatusBadRequest, err)
+	}
+	vals := req.Values()
+	for key := range c.QueryParams() {
+		if !vals.Has(key) {
+			vals.Set(key, c.QueryParam(key))
+		}
+	}
+	pageHTML, err := bot.GetPageContent(vals)
+	if err != nil {
+		return ErrorJSON(c, http.StatusInternalServerError, err)
 	}
-	pageHTML, _ := bot.GetPageContent(c.Request().Form)
 	return c.JSON(http.StatusOK, SuccessResp(pageHTML))
 }
 
@@ -97,10 +125,7 @@ func PageContentHandler(c echo.Context) error {
 func LoginHandler(c echo.Context) error {
 	bot := c.Get("bot").(*OGame)
 	if _, _, err := bot.LoginWithExistingCookies(); err != nil {
-		if err == gameforge.ErrBadCredentials {
-			return c.JSON(http.StatusBadRequest, ErrorResp(400, err.Error()))
-		}
-		return c.JSON(http.StatusInternalServerError, ErrorResp(500, err.Error()))
+		return ErrorJSON(c, http.StatusInternalServerError, err)
 	}
 	return c.JSON(http.StatusOK, SuccessResp(nil))
 }
@@ -145,7 +170,10 @@ func ServerVersionHandler(c echo.Context) error {
 // ServerTimeHandler ...
 func ServerTimeHandler(c echo.Context) error {
 	bot := c.Get("bot").(*OGame)
-	serverTime, _ := bot.ServerTime()
+	serverTime, err := bot.ServerTime()
+	if err != nil {
+		return ErrorJSON(c, http.StatusInternalServerError, err)
+	}
 	return c.JSON(http.StatusOK, SuccessResp(serverTime))
 }
 
@@ -154,7 +182,7 @@ func IsUnderAttackHandler(c echo.Context) error {
 	bot := c.Get("bot").(*OGame)
 	isUnderAttack, err := bot.IsUnderAttack()
 	if err != nil {
-		return c.JSON(http.StatusInternalServerError, ErrorResp(500, err.Error()))
+		return ErrorJSON(c, http.StatusInternalServerError, err)
 	}
 	return c.JSON(http.StatusOK, SuccessResp(isUnderAttack))
 }
@@ -167,7 +195,7 @@ func IsUnderAttackByIDHandler(c echo.Context) error {
 	}
 	isUnderAttack, err := bot.IsUnderAttack(ChangePlanet(ogame.CelestialID(planetID)))
 	if err != nil {
-		return c.JSON(http.StatusInternalServerError, ErrorResp(500, err.Error()))
+		return ErrorJSON(c, http.StatusInternalServerError, err)
 	}
 	return c.JSON(http.StatusOK, SuccessResp(isUnderAttack))
 }
@@ -182,7 +210,10 @@ func IsVacationModeHandler(c echo.Context) error {
 // GetUserInfosHandler ...
 func GetUserInfosHandler(c echo.Context) error {
 	bot := c.Get("bot").(*OGame)
-	userInfo, _ := bot.GetUserInfos()
+	userInfo, err := bot.GetUserInfos()
+	if err != nil {
+		return ErrorJSON(c, http.StatusInternalServerError, err)
+	}
 	return c.JSON(http.StatusOK, SuccessResp(userInfo))
 }
 
@@ -232,7 +263,7 @@ func GetEspionageReportMessagesHandler(c echo.Context) error {
 	bot := c.Get("bot").(*OGame)
 	report, err := bot.GetEspionageReportMessages(-1)
 	if err != nil {
-		return c.JSON(http.StatusInternalServerError, ErrorResp(500, err.Error()))
+		return ErrorJSON(c, http.StatusInternalServerError, err)
 	}
 	return c.JSON(http.StatusOK, SuccessResp(report))
 }
@@ -246,11 +277,25 @@ func GetEspionageReportHandler(c echo.Context) error {
 	}
 	espionageReport, err := bot.GetEspionageReport(msgID)
 	if err != nil {
-		return c.JSON(http.StatusInternalServerError, ErrorResp(500, err.Error()))
+		return ErrorJSON(c, http.StatusInternalServerError, err)
 	}
 	return c.JSON(http.StatusOK, SuccessResp(espionageReport))
 }
 
+// GetCombatReportHandler ...
+func GetCombatReportHandler(c echo.Context) error {
+	bot := c.Get("bot").(*OGame)
+	msgID, err := utils.ParseI64(c.Param("msgid"))
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, ErrorResp(400, "invalid msgid id"))
+	}
+	combatReport, err := bot.GetCombatReport(msgID)
+	if err != nil {
+		return ErrorJSON(c, http.StatusInternalServerError, err)
+	}
+	return c.JSON(http.StatusOK, SuccessResp(combatReport))
+}
+
 // GetEspionageReportForHandler ...
 func GetEspionageReportForHandler(c echo.Context) error {
 	bot := c.Get("bot").(*OGame)
@@ -268,7 +313,7 @@ func GetEspionageReportForHandler(c echo.Context) error {
 	}
 	planet, err := bot.GetEspionageReportFor(ogame.Coordinate{Type: ogame.PlanetType, Galaxy: galaxy, System: system, Position: position})
 	if err != nil {
-		return c.JSON(http.StatusInternalServerError, ErrorResp(500, err.Error()))
+		return ErrorJSON(c, http.StatusInternalServerError, err)
 	}
 	return c.JSON(http.StatusOK, SuccessResp(planet))
 }
@@ -276,17 +321,15 @@ func GetEspionageReportForHandler(c echo.Context) error {
 // SendMessageHandler ...
 // curl 127.0.0.1:1234/bot/send-message -d 'playerID=123&message="Sup boi!"'
 func SendMessageHandler(c echo.Context) error {
-	bot := c.Get("bot").(*OGame)
-	playerID, err := utils.ParseI64(c.Request().PostFormValue("playerID"))
-	if err != nil {
-		return c.JSON(http.StatusBadRequest, ErrorResp(400, err.Error()))
+	var req SendMessageRequest
+	if err := BindRequest(c, &req); err != nil {
+		return ErrorJSON(c, http.StatusBadRequest, err)
 	}
-	message := c.Request().PostFormValue("message")
-	if err := bot.SendMessage(playerID, message); err != nil {
+	if err := initiated(c).SendMessage(req.PlayerID, req.Message); err != nil {
 		if err.Error() == "invalid parameters" {
-			return c.JSON(http.StatusBadRequest, ErrorResp(400, err.Error()))
+			return ErrorJSON(c, http.StatusBadRequest, err)
 		}
-		return c.JSON(http.StatusInternalServerError, ErrorResp(500, err.Error()))
+		return ErrorJSON(c, http.StatusInternalServerError, err)
 	}
 	return c.JSON(http.StatusOK, SuccessResp(nil))
 }
@@ -294,25 +337,30 @@ func SendMessageHandler(c echo.Context) error {
 // GetFleetsHandler ...
 func GetFleetsHandler(c echo.Context) error {
 	bot := c.Get("bot").(*OGame)
-	fleets, _, _ := bot.GetFleets()
+	fleets, _, err := bot.GetFleets()
+	if err != nil {
+		return ErrorJSON(c, http.StatusInternalServerError, err)
+	}
 	return c.JSON(http.StatusOK, SuccessResp(fleets))
 }
 
 // GetSlotsHandler ...
 func GetSlotsHandler(c echo.Context) error {
 	bot := c.Get("bot").(*OGame)
-	slots, _ := bot.GetSlots()
+	slots, err := bot.GetSlots()
+	if err != nil {
+		return ErrorJSON(c, http.StatusInternalServerError, err)
+	}
 	return c.JSON(http.StatusOK, SuccessResp(slots))
 }
 
 // CancelFleetHandler ...
 func CancelFleetHandler(c echo.Context) error {
-	bot := c.Get("bot").(*OGame)
 	fleetID, err := utils.ParseI64(c.Param("fleetID"))
 	if err != nil {
-		return c.JSON(http.StatusBadRequest, ErrorResp(400, err.Error()))
+		return ErrorJSON(c, http.StatusBadRequest, err)
 	}
-	return c.JSON(http.StatusOK, SuccessResp(bot.CancelFleet(ogame.FleetID(fleetID))))
+	return c.JSON(http.StatusOK, SuccessResp(initiated(c).CancelFleet(ogame.FleetID(fleetID))))
 }
 
 // GetAttacksHandler ...
@@ -320,7 +368,7 @@ func GetAttacksHandler(c echo.Context) error {
 	bot := c.Get("bot").(*OGame)
 	attacks, err := bot.GetAttacks()
 	if err != nil {
-		return c.JSON(http.StatusInternalServerError, ErrorResp(500, err.Error()))
+		return ErrorJSON(c, http.StatusInternalServerError, err)
 	}
 	return c.JSON(http.StatusOK, SuccessResp(attacks))
 }
@@ -330,15 +378,15 @@ func GalaxyInfosHandler(c echo.Context) error {
 	bot := c.Get("bot").(*OGame)
 	galaxy, err := utils.ParseI64(c.Param("galaxy"))
 	if err != nil {
-		return c.JSON(http.StatusBadRequest, ErrorResp(400, err.Error()))
+		return ErrorJSON(c, http.StatusBadRequest, err)
 	}
 	system, err := utils.ParseI64(c.Param("system"))
 	if err != nil {
-		return c.JSON(http.StatusBadRequest, ErrorResp(400, err.Error()))
+		return ErrorJSON(c, http.StatusBadRequest, err)
 	}
 	res, err := bot.GalaxyInfos(galaxy, system)
 	if err != nil {
-		return c.JSON(http.StatusInternalServerError, ErrorResp(500, err.Error()))
+		return ErrorJSON(c, http.StatusInternalServerError, err)
 	}
 	return c.JSON(http.StatusOK, SuccessResp(res))
 }
@@ -346,15 +394,17 @@ func GalaxyInfosHandler(c echo.Context) error {
 // GetResearchHandler ...
 func GetResearchHandler(c echo.Context) error {
 	bot := c.Get("bot").(*OGame)
-	researches, _ := bot.GetResearch()
+	researches, err := bot.GetResearch()
+	if err != nil {
+		return ErrorJSON(c, http.StatusInternalServerError, err)
+	}
 	return c.JSON(http.StatusOK, SuccessResp(researches))
 }
 
 // BuyOfferOfTheDayHandler ...
 func BuyOfferOfTheDayHandler(c echo.Context) error {
-	bot := c.Get("bot").(*OGame)
-	if err := bot.BuyOfferOfTheDay(); err != nil {
-		return c.JSON(http.StatusBadRequest, ErrorResp(400, err.Error()))
+	if err := initiated(c).BuyOfferOfTheDay(); err != nil {
+		return ErrorJSON(c, http.StatusBadRequest, err)
 	}
 	return c.JSON(http.StatusOK, SuccessResp(nil))
 }
@@ -362,7 +412,10 @@ func BuyOfferOfTheDayHandler(c echo.Context) error {
 // GetMoonsHandler ...
 func GetMoonsHandler(c echo.Context) error {
 	bot := c.Get("bot").(*OGame)
-	moons, _ := bot.GetMoons()
+	moons, err := bot.GetMoons()
+	if err != nil {
+		return ErrorJSON(c, http.StatusInternalServerError, err)
+	}
 	return c.JSON(http.StatusOK, SuccessResp(moons))
 }
 
@@ -397,7 +450,7 @@ func GetMoonByCoordHandler(c echo.Context) error {
 	}
 	planet, err := bot.GetMoon(ogame.Coordinate{Type: ogame.MoonType, Galaxy: galaxy, System: system, Position: position})
 	if err != nil {
-		return c.JSON(http.StatusInternalServerError, ErrorResp(500, err.Error()))
+		return ErrorJSON(c, http.StatusInternalServerError, err)
 	}
 	return c.JSON(http.StatusOK, SuccessResp(planet))
 }
@@ -405,7 +458,10 @@ func GetMoonByCoordHandler(c echo.Context) error {
 // GetPlanetsHandler ...
 func GetPlanetsHandler(c echo.Context) error {
 	bot := c.Get("bot").(*OGame)
-	planets, _ := bot.GetPlanets()
+	planets, err := bot.GetPlanets()
+	if err != nil {
+		return ErrorJSON(c, http.StatusInternalServerError, err)
+	}
 	return c.JSON(http.StatusOK, SuccessResp(planets))
 }
 
@@ -416,16 +472,13 @@ func CelestialAbandonHandler(c echo.Context) error {
 	if err != nil {
 		return c.JSON(http.StatusBadRequest, ErrorResp(400, "invalid celestial id"))
 	}
-	err = bot.Abandon(celestialID)
+	err = initiated(c).Abandon(celestialID)
 	if err != nil {
-		return c.JSON(http.StatusBadRequest, ErrorResp(400, err.Error()))
+		return ErrorJSON(c, http.StatusBadRequest, err)
 	}
 	_, err = bot.GetCelestial(celestialID)
 	if err != nil {
-		return c.JSON(http.StatusOK, SuccessResp(struct {
-			CelestialID int64
-			Result      string
-		}{
+		return c.JSON(http.StatusOK, SuccessResp(AbandonResult{
 			CelestialID: celestialID,
 			Result:      "succeed",
 		}))
@@ -444,21 +497,20 @@ func GetCelestialItemsHandler(c echo.Context) error {
 	}
 	items, err := bot.GetItems(ogame.CelestialID(celestialID))
 	if err != nil {
-		return c.JSON(http.StatusBadRequest, ErrorResp(400, err.Error()))
+		return ErrorJSON(c, http.StatusBadRequest, err)
 	}
 	return c.JSON(http.StatusOK, SuccessResp(items))
 }
 
 // ActivateCelestialItemHandler ...
 func ActivateCelestialItemHandler(c echo.Context) error {
-	bot := c.Get("bot").(*OGame)
 	celestialID, err := utils.ParseI64(c.Param("celestialID"))
 	if err != nil {
 		return c.JSON(http.StatusBadRequest, ErrorResp(400, "invalid celestial id"))
 	}
 	ref := c.Param("itemRef")
-	if err := bot.ActivateItem(ref, ogame.CelestialID(celestialID)); err != nil {
-		return c.JSON(http.StatusBadRequest, ErrorResp(400, err.Error()))
+	if err := initiated(c).ActivateItem(ref, ogame.CelestialID(celestialID)); err != nil {
+		return ErrorJSON(c, http.StatusBadRequest, err)
 	}
 	return c.JSON(http.StatusOK, SuccessResp(nil))
 }
@@ -472,7 +524,7 @@ func GetPlanetHandler(c echo.Context) error {
 	}
 	planet, err := bot.GetPlanet(ogame.PlanetID(planetID))
 	if err != nil {
-		return c.JSON(http.StatusInternalServerError, ErrorResp(500, err.Error()))
+		return ErrorJSON(c, http.StatusInternalServerError, err)
 	}
 	return c.JSON(http.StatusOK, SuccessResp(planet))
 }
@@ -494,7 +546,7 @@ func GetPlanetByCoordHandler(c echo.Context) error {
 	}
 	planet, err := bot.GetPlanet(ogame.Coordinate{Type: ogame.PlanetType, Galaxy: galaxy, System: system, Position: position})
 	if err != nil {
-		return c.JSON(http.StatusInternalServerError, ErrorResp(500, err.Error()))
+		return ErrorJSON(c, http.StatusInternalServerError, err)
 	}
 	return c.JSON(http.StatusOK, SuccessResp(planet))
 }
@@ -508,7 +560,7 @@ func GetResourcesDetailsHandler(c echo.Context) error {
 	}
 	resources, err := bot.GetResourcesDetails(ogame.CelestialID(planetID))
 	if err != nil {
-		return c.JSON(http.StatusInternalServerError, ErrorResp(500, err.Error()))
+		return ErrorJSON(c, http.StatusInternalServerError, err)
 	}
 	return c.JSON(http.StatusOK, SuccessResp(resources))
 }
@@ -522,7 +574,7 @@ func GetResourceSettingsHandler(c echo.Context) error {
 	}
 	res, err := bot.GetResourceSettings(ogame.PlanetID(planetID))
 	if err != nil {
-		return c.JSON(http.StatusInternalServerError, ErrorResp(500, err.Error()))
+		return ErrorJSON(c, http.StatusInternalServerError, err)
 	}
 	return c.JSON(http.StatusOK, SuccessResp(res))
 }
@@ -530,53 +582,16 @@ func GetResourceSettingsHandler(c echo.Context) error {
 // SetResourceSettingsHandler ...
 // curl 127.0.0.1:1234/bot/planets/123/resource-settings -d 'metalMine=100&crystalMine=100&deuteriumSynthesizer=100&solarPlant=100&fusionReactor=100&solarSatellite=100'
 func SetResourceSettingsHandler(c echo.Context) error {
-	bot := c.Get("bot").(*OGame)
 	planetID, err := utils.ParseI64(c.Param("planetID"))
 	if err != nil {
 		return c.JSON(http.StatusBadRequest, ErrorResp(400, "invalid planet id"))
 	}
-	metalMine, err := utils.ParseI64(c.Request().PostFormValue("metalMine"))
-	if err != nil {
-		return c.JSON(http.StatusBadRequest, ErrorResp(400, "invalid metalMine"))
+	var req ResourceSettingsRequest
+	if err := BindRequest(c, &req); err != nil {
+		return ErrorJSON(c, http.StatusBadRequest, err)
 	}
-	crystalMine, err := utils.ParseI64(c.Request().PostFormValue("crystalMine"))
-	if err != nil {
-		return c.JSON(http.StatusBadRequest, ErrorResp(400, "invalid crystalMine"))
-	}
-	deuteriumSynthesizer, err := utils.ParseI64(c.Request().PostFormValue("deuteriumSynthesizer"))
-	if err != nil {
-		return c.JSON(http.StatusBadRequest, ErrorResp(400, "invalid deuteriumSynthesizer"))
-	}
-	solarPlant, err := utils.ParseI64(c.Request().PostFormValue("solarPlant"))
-	if err != nil {
-		return c.JSON(http.StatusBadRequest, ErrorResp(400, "invalid solarPlant"))
-	}
-	fusionReactor, err := utils.ParseI64(c.Request().PostFormValue("fusionReactor"))
-	if err != nil {
-		return c.JSON(http.StatusBadRequest, ErrorResp(400, "invalid fusionReactor"))
-	}
-	solarSatellite, err := utils.ParseI64(c.Request().PostFormValue("solarSatellite"))
-	if err != nil {
-		return c.JSON(http.StatusBadRequest, ErrorResp(400, "invalid solarSatellite"))
-	}
-	crawler, err := utils.ParseI64(c.Request().PostFormValue("crawler"))
-	if err != nil {
-		return c.JSON(http.StatusBadRequest, ErrorResp(400, "invalid crawler"))
-	}
-	settings := ogame.ResourceSettings{
-		MetalMine:            metalMine,
-		CrystalMine:          crystalMine,
-		DeuteriumSynthesizer: deuteriumSynthesizer,
-		SolarPlant:           solarPlant,
-		FusionReactor:        fusionReactor,
-		SolarSatellite:       solarSatellite,
-		Crawler:              crawler,
-	}
-	if err := bot.SetResourceSettings(ogame.PlanetID(planetID), settings); err != nil {
-		if err == ogame.ErrInvalidPlanetID {
-			return c.JSON(http.StatusBadRequest, ErrorResp(400, err.Error()))
-		}
-		return c.JSON(http.StatusInternalServerError, ErrorResp(500, err.Error()))
+	if err := initiated(c).SetResourceSettings(ogame.PlanetID(planetID), req.ResourceSettings); err != nil {
+		return ErrorJSON(c, http.StatusInternalServerError, err)
 	}
 	return c.JSON(http.StatusOK, SuccessResp(nil))
 }
@@ -590,7 +605,7 @@ func GetLfBuildingsHandler(c echo.Context) error {
 	}
 	res, err := bot.GetLfBuildings(ogame.CelestialID(planetID))
 	if err != nil {
-		return c.JSON(http.StatusInternalServerError, ErrorResp(500, err.Error()))
+		return ErrorJSON(c, http.StatusInternalServerError, err)
 	}
 	return c.JSON(http.StatusOK, SuccessResp(res))
 }
@@ -604,7 +619,7 @@ func GetLfResearchHandler(c echo.Context) error {
 	}
 	res, err := bot.GetLfResearch(ogame.CelestialID(planetID))
 	if err != nil {
-		return c.JSON(http.StatusInternalServerError, ErrorResp(500, err.Error()))
+		return ErrorJSON(c, http.StatusInternalServerError, err)
 	}
 	return c.JSON(http.StatusOK, SuccessResp(res))
 }
@@ -618,7 +633,7 @@ func GetResourcesBuildingsHandler(c echo.Context) error {
 	}
 	res, err := bot.GetResourcesBuildings(ogame.CelestialID(planetID))
 	if err != nil {
-		return c.JSON(http.StatusInternalServerError, ErrorResp(500, err.Error()))
+		return ErrorJSON(c, http.StatusInternalServerError, err)
 	}
 	return c.JSON(http.StatusOK, SuccessResp(res))
 }
@@ -632,7 +647,7 @@ func GetDefenseHandler(c echo.Context) error {
 	}
 	res, err := bot.GetDefense(ogame.CelestialID(planetID))
 	if err != nil {
-		return c.JSON(http.StatusInternalServerError, ErrorResp(500, err.Error()))
+		return ErrorJSON(c, http.StatusInternalServerError, err)
 	}
 	return c.JSON(http.StatusOK, SuccessResp(res))
 }
@@ -646,7 +661,7 @@ func GetShipsHandler(c echo.Context) error {
 	}
 	res, err := bot.GetShips(ogame.CelestialID(planetID))
 	if err != nil {
-		return c.JSON(http.StatusInternalServerError, ErrorResp(500, err.Error()))
+		return ErrorJSON(c, http.StatusInternalServerError, err)
 	}
 	return c.JSON(http.StatusOK, SuccessResp(res))
 }
@@ -660,14 +675,13 @@ func GetFacilitiesHandler(c echo.Context) error {
 	}
 	res, err := bot.GetFacilities(ogame.CelestialID(planetID))
 	if err != nil {
-		return c.JSON(http.StatusInternalServerError, ErrorResp(500, err.Error()))
+		return ErrorJSON(c, http.StatusInternalServerError, err)
 	}
 	return c.JSON(http.StatusOK, SuccessResp(res))
 }
 
 // BuildHandler ...
 func BuildHandler(c echo.Context) error {
-	bot := c.Get("bot").(*OGame)
 	planetID, err := utils.ParseI64(c.Param("planetID"))
 	if err != nil {
 		return c.JSON(http.StatusBadRequest, ErrorResp(400, "invalid planet id"))
@@ -680,15 +694,14 @@ func BuildHandler(c echo.Context) error {
 	if err != nil {
 		return c.JSON(http.StatusBadRequest, ErrorResp(400, "invalid nbr"))
 	}
-	if err := bot.Build(ogame.CelestialID(planetID), ogame.ID(ogameID), nbr); err != nil {
-		return c.JSON(http.StatusInternalServerError, ErrorResp(500, err.Error()))
+	if err := initiated(c).Build(ogame.CelestialID(planetID), ogame.ID(ogameID), nbr); err != nil {
+		return ErrorJSON(c, http.StatusInternalServerError, err)
 	}
 	return c.JSON(http.StatusOK, SuccessResp(nil))
 }
 
 // BuildCancelableHandler ...
 func BuildCancelableHandler(c echo.Context) error {
-	bot := c.Get("bot").(*OGame)
 	planetID, err := utils.ParseI64(c.Param("planetID"))
 	if err != nil {
 		return c.JSON(http.StatusBadRequest, ErrorResp(400, "invalid planet id"))
@@ -697,15 +710,14 @@ func BuildCancelableHandler(c echo.Context) error {
 	if err != nil {
 		return c.JSON(http.StatusBadRequest, ErrorResp(400, "invalid ogame id"))
 	}
-	if err := bot.BuildCancelable(ogame.CelestialID(planetID), ogame.ID(ogameID)); err != nil {
-		return c.JSON(http.StatusInternalServerError, ErrorResp(500, err.Error()))
+	if err := initiated(c).BuildCancelable(ogame.CelestialID(planetID), ogame.ID(ogameID)); err != nil {
+		return ErrorJSON(c, http.StatusInternalServerError, err)
 	}
 	return c.JSON(http.StatusOK, SuccessResp(nil))
 }
 
 // BuildProductionHandler ...
 func BuildProductionHandler(c echo.Context) error {
-	bot := c.Get("bot").(*OGame)
 	planetID, err := utils.ParseI64(c.Param("planetID"))
 	if err != nil {
 		return c.JSON(http.StatusBadRequest, ErrorResp(400, "invalid planet id"))
@@ -718,15 +730,14 @@ func BuildProductionHandler(c echo.Context) error {
 	if err != nil {
 		return c.JSON(http.StatusBadRequest, ErrorResp(400, "invalid nbr"))
 	}
-	if err := bot.BuildProduction(ogame.CelestialID(planetID), ogame.ID(ogameID), nbr); err != nil {
-		return c.JSON(http.StatusInternalServerError, ErrorResp(500, err.Error()))
+	if err := initiated(c).BuildProduction(ogame.CelestialID(planetID), ogame.ID(ogameID), nbr); err != nil {
+		return ErrorJSON(c, http.StatusInternalServerError, err)
 	}
 	return c.JSON(http.StatusOK, SuccessResp(nil))
 }
 
 // BuildBuildingHandler ...
 func BuildBuildingHandler(c echo.Context) error {
-	bot := c.Get("bot").(*OGame)
 	planetID, err := utils.ParseI64(c.Param("planetID"))
 	if err != nil {
 		return c.JSON(http.StatusBadRequest, ErrorResp(400, "invalid planet id"))
@@ -735,15 +746,14 @@ func BuildBuildingHandler(c echo.Context) error {
 	if err != nil {
 		return c.JSON(http.StatusBadRequest, ErrorResp(400, "invalid ogame id"))
 	}
-	if err := bot.BuildBuilding(ogame.CelestialID(planetID), ogame.ID(ogameID)); err != nil {
-		return c.JSON(http.StatusInternalServerError, ErrorResp(500, err.Error()))
+	if err := initiated(c).BuildBuilding(ogame.CelestialID(planetID), ogame.ID(ogameID)); err != nil {
+		return ErrorJSON(c, http.StatusInternalServerError, err)
 	}
 	return c.JSON(http.StatusOK, SuccessResp(nil))
 }
 
 // BuildTechnologyHandler ...
 func BuildTechnologyHandler(c echo.Context) error {
-	bot := c.Get("bot").(*OGame)
 	planetID, err := utils.ParseI64(c.Param("planetID"))
 	if err != nil {
 		return c.JSON(http.StatusBadRequest, ErrorResp(400, "invalid planet id"))
@@ -752,15 +762,14 @@ func BuildTechnologyHandler(c echo.Context) error {
 	if err != nil {
 		return c.JSON(http.StatusBadRequest, ErrorResp(400, "invalid ogame id"))
 	}
-	if err := bot.BuildTechnology(ogame.CelestialID(planetID), ogame.ID(ogameID)); err != nil {
-		return c.JSON(http.StatusInternalServerError, ErrorResp(500, err.Error()))
+	if err := initiated(c).BuildTechnology(ogame.CelestialID(planetID), ogame.ID(ogameID)); err != nil {
+		return ErrorJSON(c, http.StatusInternalServerError, err)
 	}
 	return c.JSON(http.StatusOK, SuccessResp(nil))
 }
 
 // BuildDefenseHandler ...
 func BuildDefenseHandler(c echo.Context) error {
-	bot := c.Get("bot").(*OGame)
 	planetID, err := utils.ParseI64(c.Param("planetID"))
 	if err != nil {
 		return c.JSON(http.StatusBadRequest, ErrorResp(400, "invalid planet id"))
@@ -773,15 +782,14 @@ func BuildDefenseHandler(c echo.Context) error {
 	if err != nil {
 		return c.JSON(http.StatusBadRequest, ErrorResp(400, "invalid nbr"))
 	}
-	if err := bot.BuildDefense(ogame.CelestialID(planetID), ogame.ID(ogameID), nbr); err != nil {
-		return c.JSON(http.StatusInternalServerError, ErrorResp(500, err.Error()))
+	if err := initiated(c).BuildDefense(ogame.CelestialID(planetID), ogame.ID(ogameID), nbr); err != nil {
+		return ErrorJSON(c, http.StatusInternalServerError, err)
 	}
 	return c.JSON(http.StatusOK, SuccessResp(nil))
 }
 
 // BuildShipsHandler ...
 func BuildShipsHandler(c echo.Context) error {
-	bot := c.Get("bot").(*OGame)
 	planetID, err := utils.ParseI64(c.Param("planetID"))
 	if err != nil {
 		return c.JSON(http.StatusBadRequest, ErrorResp(400, "invalid planet id"))
@@ -794,8 +802,8 @@ func BuildShipsHandler(c echo.Context) error {
 	if err != nil {
 		return c.JSON(http.StatusBadRequest, ErrorResp(400, "invalid nbr"))
 	}
-	if err := bot.BuildShips(ogame.CelestialID(planetID), ogame.ID(ogameID), nbr); err != nil {
-		return c.JSON(http.StatusInternalServerError, ErrorResp(500, err.Error()))
+	if err := initiated(c).BuildShips(ogame.CelestialID(planetID), ogame.ID(ogameID), nbr); err != nil {
+		return ErrorJSON(c, http.StatusInternalServerError, err)
 	}
 	return c.JSON(http.StatusOK, SuccessResp(nil))
 }
@@ -809,7 +817,7 @@ func GetProductionHandler(c echo.Context) error {
 	}
 	res, _, err := bot.GetProduction(ogame.CelestialID(planetID))
 	if err != nil {
-		return c.JSON(http.StatusInternalServerError, ErrorResp(500, err.Error()))
+		return ErrorJSON(c, http.StatusInternalServerError, err)
 	}
 	return c.JSON(http.StatusOK, SuccessResp(res))
 }
@@ -821,18 +829,12 @@ func ConstructionsBeingBuiltHandler(c echo.Context) error {
 	if err != nil {
 		return c.JSON(http.StatusBadRequest, ErrorResp(400, "invalid planet id"))
 	}
-	constructions, _ := bot.ConstructionsBeingBuilt(ogame.CelestialID(planetID))
+	constructions, err := bot.ConstructionsBeingBuilt(ogame.CelestialID(planetID))
+	if err != nil {
+		return ErrorJSON(c, http.StatusInternalServerError, err)
+	}
 	return c.JSON(http.StatusOK, SuccessResp(
-		struct {
-			BuildingID          int64
-			BuildingCountdown   int64
-			ResearchID          int64
-			ResearchCountdown   int64
-			LfBuildingID        int64
-			LfBuildingCountdown int64
-			LfResearchID        int64
-			LfResearchCountdown int64
-		}{
+		ConstructionsResult{
 			BuildingID:          int64(constructions.Building.ID),
 			BuildingCountdown:   int64(constructions.Building.Countdown.Seconds()),
 			ResearchID:          int64(constructions.Research.ID),
@@ -847,26 +849,24 @@ func ConstructionsBeingBuiltHandler(c echo.Context) error {
 
 // CancelBuildingHandler ...
 func CancelBuildingHandler(c echo.Context) error {
-	bot := c.Get("bot").(*OGame)
 	planetID, err := utils.ParseI64(c.Param("planetID"))
 	if err != nil {
 		return c.JSON(http.StatusBadRequest, ErrorResp(400, "invalid planet id"))
 	}
-	if err := bot.CancelBuilding(ogame.CelestialID(planetID)); err != nil {
-		return c.JSON(http.StatusInternalServerError, ErrorResp(500, err.Error()))
+	if err := initiated(c).CancelBuilding(ogame.CelestialID(planetID)); err != nil {
+		return ErrorJSON(c, http.StatusInternalServerError, err)
 	}
 	return c.JSON(http.StatusOK, SuccessResp(nil))
 }
 
 // CancelResearchHandler ...
 func CancelResearchHandler(c echo.Context) error {
-	bot := c.Get("bot").(*OGame)
 	planetID, err := utils.ParseI64(c.Param("planetID"))
 	if err != nil {
 		return c.JSON(http.StatusBadRequest, ErrorResp(400, "invalid planet id"))
 	}
-	if err := bot.CancelResearch(ogame.CelestialID(planetID)); err != nil {
-		return c.JSON(http.StatusInternalServerError, ErrorResp(500, err.Error()))
+	if err := initiated(c).CancelResearch(ogame.CelestialID(planetID)); err != nil {
+		return ErrorJSON(c, http.StatusInternalServerError, err)
 	}
 	return c.JSON(http.StatusOK, SuccessResp(nil))
 }
@@ -880,7 +880,7 @@ func GetResourcesHandler(c echo.Context) error {
 	}
 	res, err := bot.GetResources(ogame.CelestialID(planetID))
 	if err != nil {
-		return c.JSON(http.StatusInternalServerError, ErrorResp(500, err.Error()))
+		return ErrorJSON(c, http.StatusInternalServerError, err)
 	}
 	return c.JSON(http.StatusOK, SuccessResp(res))
 }
@@ -922,124 +922,29 @@ func GetPriceHandler(c echo.Context) error {
 // SendFleetHandler ...
 // curl 127.0.0.1:1234/bot/planets/123/send-fleet -d 'ships=203,1&ships=204,10&speed=10&galaxy=1&system=1&type=1&position=1&mission=3&metal=1&crystal=2&deuterium=3'
 func SendFleetHandler(c echo.Context) error {
-	bot := c.Get("bot").(*OGame)
 	planetID, err := utils.ParseI64(c.Param("planetID"))
 	if err != nil {
 		return c.JSON(http.StatusBadRequest, ErrorResp(400, "invalid planet id"))
 	}
-
-	if err := c.Request().ParseForm(); err != nil {
-		return c.JSON(http.StatusBadRequest, ErrorResp(400, "invalid form"))
-	}
-
-	var ships ogame.ShipsInfos
-	where := ogame.Coordinate{Type: ogame.PlanetType}
-	mission := ogame.Transport
-	var duration int64
-	var unionID int64
-	payload := ogame.Resources{}
-	speed := ogame.HundredPercent
-	for key, values := range c.Request().PostForm {
-		switch key {
-		case "ships":
-			for _, s := range values {
-				a := strings.Split(s, ",")
-				shipID, err := utils.ParseI64(a[0])
-				if err != nil || !ogame.ID(shipID).IsShip() {
-					return c.JSON(http.StatusBadRequest, ErrorResp(400, "invalid ship id "+a[0]))
-				}
-				nbr, err := utils.ParseI64(a[1])
-				if err != nil || nbr < 0 {
-					return c.JSON(http.StatusBadRequest, ErrorResp(400, "invalid nbr "+a[1]))
-				}
-				ships.Set(ogame.ID(shipID), nbr)
-			}
-		case "speed":
-			speedInt, err := utils.ParseI64(values[0])
-			if err != nil || speedInt < 0 || speedInt > 10 {
-				return c.JSON(http.StatusBadRequest, ErrorResp(400, "invalid speed"))
-			}
-			speed = ogame.Speed(speedInt)
-		case "galaxy":
-			galaxy, err := utils.ParseI64(values[0])
-			if err != nil {
-				return c.JSON(http.StatusBadRequest, ErrorResp(400, "invalid galaxy"))
-			}
-			where.Galaxy = galaxy
-		case "system":
-			system, err := utils.ParseI64(values[0])
-			if err != nil {
-				return c.JSON(http.StatusBadRequest, ErrorResp(400, "invalid system"))
-			}
-			where.System = system
-		case "position":
-			position, err := utils.ParseI64(values[0])
-			if err != nil {
-				return c.JSON(http.StatusBadRequest, ErrorResp(400, "invalid position"))
-			}
-			where.Position = position
-		case "type":
-			t, err := utils.ParseI64(values[0])
-			if err != nil {
-				return c.JSON(http.StatusBadRequest, ErrorResp(400, "invalid type"))
-			}
-			where.Type = ogame.CelestialType(t)
-		case "mission":
-			missionInt, err := utils.ParseI64(values[0])
-			if err != nil {
-				return c.JSON(http.StatusBadRequest, ErrorResp(400, "invalid mission"))
-			}
-			mission = ogame.MissionID(missionInt)
-		case "duration":
-			duration, err = utils.ParseI64(values[0])
-			if err != nil {
-				return c.JSON(http.StatusBadRequest, ErrorResp(400, "invalid duration"))
-			}
-		case "union":
-			unionID, err = utils.ParseI64(values[0])
-			if err != nil {
-				return c.JSON(http.StatusBadRequest, ErrorResp(400, "invalid union id"))
-			}
-		case "metal":
-			metal, err := utils.ParseI64(values[0])
-			if err != nil || metal < 0 {
-				return c.JSON(http.StatusBadRequest, ErrorResp(400, "invalid metal"))
-			}
-			payload.Metal = metal
-		case "crystal":
-			crystal, err := utils.ParseI64(values[0])
-			if err != nil || crystal < 0 {
-				return c.JSON(http.StatusBadRequest, ErrorResp(400, "invalid crystal"))
-			}
-			payload.Crystal = crystal
-		case "deuterium":
-			deuterium, err := utils.ParseI64(values[0])
-			if err != nil || deuterium < 0 {
-				return c.JSON(http.StatusBadRequest, ErrorResp(400, "invalid deuterium"))
-			}
-			payload.Deuterium = deuterium
-		}
+	var req SendFleetRequest
+	if err := BindRequest(c, &req); err != nil {
+		return ErrorJSON(c, http.StatusBadRequest, err)
 	}
-
-	fleet, err := bot.SendFleet(ogame.CelestialID(planetID), ships, speed, where, mission, payload, duration, unionID)
-	if err != nil &&
-		(err == ogame.ErrInvalidPlanetID ||
-			err == ogame.ErrNoShipSelected ||
-			err == ogame.ErrUninhabitedPlanet ||
-			err == ogame.ErrNoDebrisField ||
-			err == ogame.ErrPlayerInVacationMode ||
-			err == ogame.ErrAdminOrGM ||
-			err == ogame.ErrNoAstrophysics ||
-			err == ogame.ErrNoobProtection ||
-			err == ogame.ErrPlayerTooStrong ||
-			err == ogame.ErrNoMoonAvailable ||
-			err == ogame.ErrNoRecyclerAvailable ||
-			err == ogame.ErrNoEventsRunning ||
-			err == ogame.ErrPlanetAlreadyReservedForRelocation) {
-		return c.JSON(http.StatusBadRequest, ErrorResp(400, err.Error()))
+	if err := req.Validate(); err != nil {
+		return ErrorJSON(c, http.StatusBadRequest, err)
+	}
+	speed := req.Speed
+	if speed == 0 {
+		speed = ogame.HundredPercent
 	}
+	mission := req.Mission
+	if mission == 0 {
+		mission = ogame.Transport
+	}
+	payload := ogame.Resources{Metal: req.Metal, Crystal: req.Crystal, Deuterium: req.Deuterium}
+	fleet, err := initiated(c).SendFleet(ogame.CelestialID(planetID), req.Ships, speed, req.Coordinate(), mission, payload, req.Duration, req.Union)
 	if err != nil {
-		return c.JSON(http.StatusInternalServerError, ErrorResp(500, err.Error()))
+		return ErrorJSON(c, http.StatusInternalServerError, err)
 	}
 	return c.JSON(http.StatusOK, SuccessResp(fleet))
 }
@@ -1047,42 +952,16 @@ func SendFleetHandler(c echo.Context) error {
 // SendDiscoveryHandler ...
 // curl 127.0.0.1:1234/bot/planets/123/send-discovery -d 'galaxy=1&system=1&type=1&position=1'
 func SendDiscoveryHandler(c echo.Context) error {
-	bot := c.Get("bot").(*OGame)
 	planetID, err := utils.ParseI64(c.Param("planetID"))
 	if err != nil {
 		return c.JSON(http.StatusBadRequest, ErrorResp(400, "invalid planet id"))
 	}
-
-	if err := c.Request().ParseForm(); err != nil {
-		return c.JSON(http.StatusBadRequest, ErrorResp(400, "invalid form"))
+	var req CoordinateRequest
+	if err := BindRequest(c, &req); err != nil {
+		return ErrorJSON(c, http.StatusBadRequest, err)
 	}
-
-	where := ogame.Coordinate{Type: ogame.PlanetType}
-	for key, values := range c.Request().PostForm {
-		switch key {
-		case "galaxy":
-			galaxy, err := utils.ParseI64(values[0])
-			if err != nil {
-				return c.JSON(http.StatusBadRequest, ErrorResp(400, "invalid galaxy"))
-			}
-			where.Galaxy = galaxy
-		case "system":
-			system, err := utils.ParseI64(values[0])
-			if err != nil {
-				return c.JSON(http.StatusBadRequest, ErrorResp(400, "invalid system"))
-			}
-			where.System = system
-		case "position":
-			position, err := utils.ParseI64(values[0])
-			if err != nil {
-				return c.JSON(http.StatusBadRequest, ErrorResp(400, "invalid position"))
-			}
-			where.Position = position
-		}
-	}
-
-	if err := bot.SendDiscoveryFleet(ogame.CelestialID(planetID), where); err != nil {
-		return c.JSON(http.StatusBadRequest, ErrorResp(400, err.Error()))
+	if err := initiated(c).SendDiscoveryFleet(ogame.CelestialID(planetID), req.Coordinate()); err != nil {
+		return ErrorJSON(c, http.StatusBadRequest, err)
 	}
 	return c.JSON(http.StatusOK, SuccessResp(true))
 }
@@ -1117,17 +996,17 @@ func GetStaticHandler(c echo.Context) error {
 	newURL := bot.cache.serverURL + c.Request().URL.String()
 	req, err := http.NewRequest(http.MethodGet, newURL, nil)
 	if err != nil {
-		return c.JSON(http.StatusInternalServerError, ErrorResp(500, err.Error()))
+		return ErrorJSON(c, http.StatusInternalServerError, err)
 	}
 	req.Header.Add("Accept-Encoding", "gzip, deflate, br")
 	resp, err := bot.device.GetClient().Do(req)
 	if err != nil {
-		return c.JSON(http.StatusInternalServerError, ErrorResp(500, err.Error()))
+		return ErrorJSON(c, http.StatusInternalServerError, err)
 	}
 	defer resp.Body.Close()
 	body, err := io.ReadAll(resp.Body)
 	if err != nil {
-		return c.JSON(http.StatusInternalServerError, ErrorResp(500, err.Error()))
+		return ErrorJSON(c, http.StatusInternalServerError, err)
 	}
 
 	// Copy the original HTTP headers to our client
@@ -1193,7 +1072,7 @@ func GetStaticHEADHandler(c echo.Context) error {
 	}
 	headers, err := bot.HeadersForPage(newURL)
 	if err != nil {
-		return c.JSON(http.StatusBadRequest, ErrorResp(400, err.Error()))
+		return ErrorJSON(c, http.StatusBadRequest, err)
 	}
 	if len(headers) < 1 {
 		return c.NoContent(http.StatusFailedDependency)
@@ -1224,28 +1103,26 @@ func GetEmpireHandler(c echo.Context) error {
 	}
 	getEmpire, err := bot.GetEmpireJSON(celestialType)
 	if err != nil {
-		return c.JSON(http.StatusInternalServerError, ErrorResp(500, err.Error()))
+		return ErrorJSON(c, http.StatusInternalServerError, err)
 	}
 	return c.JSON(http.StatusOK, SuccessResp(getEmpire))
 }
 
 // DeleteMessageHandler ...
 func DeleteMessageHandler(c echo.Context) error {
-	bot := c.Get("bot").(*OGame)
 	messageID, err := utils.ParseI64(c.Param("messageID"))
 	if err != nil {
 		return c.JSON(http.StatusBadRequest, ErrorResp(400, "invalid message id"))
 	}
-	if err := bot.DeleteMessage(messageID); err != nil {
-		return c.JSON(http.StatusBadRequest, ErrorResp(400, err.Error()))
+	if err := initiated(c).DeleteMessage(messageID); err != nil {
+		return ErrorJSON(c, http.StatusBadRequest, err)
 	}
 	return c.JSON(http.StatusOK, SuccessResp(nil))
 }
 
 // DeleteEspionageMessagesHandler ...
 func DeleteEspionageMessagesHandler(c echo.Context) error {
-	bot := c.Get("bot").(*OGame)
-	if err := bot.DeleteAllMessagesFromTab(20); err != nil { // 20 = Espionage Reports
+	if err := initiated(c).DeleteAllMessagesFromTab(20); err != nil { // 20 = Espionage Reports
 		return c.JSON(http.StatusBadRequest, ErrorResp(400, "Unable to delete Espionage Reports"))
 	}
 	return c.JSON(http.StatusOK, SuccessResp(nil))
@@ -1253,7 +1130,6 @@ func DeleteEspionageMessagesHandler(c echo.Context) error {
 
 // DeleteMessagesFromTabHandler ...
 func DeleteMessagesFromTabHandler(c echo.Context) error {
-	bot := c.Get("bot").(*OGame)
 	tabIndex, err := utils.ParseI64(c.Param("tabIndex"))
 	if err != nil {
 		return c.JSON(http.StatusBadRequest, ErrorResp(400, "must provide tabIndex"))
@@ -1268,55 +1144,46 @@ func DeleteMessagesFromTabHandler(c echo.Context) error {
 		*/
 		return c.JSON(http.StatusBadRequest, ErrorResp(400, "invalid tabIndex provided"))
 	}
-	if err := bot.DeleteAllMessagesFromTab(ogame.MessagesTabID(tabIndex)); err != nil {
+	if err := initiated(c).DeleteAllMessagesFromTab(ogame.MessagesTabID(tabIndex)); err != nil {
 		return c.JSON(http.StatusBadRequest, ErrorResp(400, "Unable to delete message from tab "+utils.FI64(tabIndex)))
 	}
 	return c.JSON(http.StatusOK, SuccessResp(nil))
 }
 
 // SendIPMHandler ...
+// curl 127.0.0.1:1234/bot/planets/123/send-ipm -d 'ipmAmount=5&galaxy=1&system=2&position=3&type=1&priority=401'
 func SendIPMHandler(c echo.Context) error {
 	bot := c.Get("bot").(*OGame)
-	ipmAmount, err := utils.ParseI64(c.Param("ipmAmount"))
-	if err != nil || ipmAmount < 1 {
-		return c.JSON(http.StatusBadRequest, ErrorResp(400, "invalid ipmAmount"))
-	}
 	planetID, err := utils.ParseI64(c.Param("planetID"))
 	if err != nil || planetID < 1 {
 		return c.JSON(http.StatusBadRequest, ErrorResp(400, "invalid planet id"))
 	}
-	galaxy, err := utils.ParseI64(c.Request().PostFormValue("galaxy"))
-	if err != nil || galaxy < 1 || galaxy > bot.cache.serverData.Galaxies {
-		return c.JSON(http.StatusBadRequest, ErrorResp(400, "invalid galaxy"))
+	var req SendIPMRequest
+	if err := BindRequest(c, &req); err != nil {
+		return ErrorJSON(c, http.StatusBadRequest, err)
 	}
-	system, err := utils.ParseI64(c.Request().PostFormValue("system"))
-	if err != nil || system < 1 || system > bot.cache.serverData.Systems {
+	coord := req.Coordinate()
+	switch {
+	case req.IpmAmount < 1:
+		return c.JSON(http.StatusBadRequest, ErrorResp(400, "invalid ipmAmount"))
+	case coord.Galaxy < 1 || coord.Galaxy > bot.cache.serverData.Galaxies:
+		return c.JSON(http.StatusBadRequest, ErrorResp(400, "invalid galaxy"))
+	case coord.System < 1 || coord.System > bot.cache.serverData.Systems:
 		return c.JSON(http.StatusBadRequest, ErrorResp(400, "invalid system"))
-	}
-	position, err := utils.ParseI64(c.Request().PostFormValue("position"))
-	if err != nil || position < 1 || position > 15 {
+	case coord.Position < 1 || coord.Position > 15:
 		return c.JSON(http.StatusBadRequest, ErrorResp(400, "invalid position"))
-	}
-	planetTypeInt, err := utils.ParseI64(c.Param("type"))
-	if err != nil {
-		return c.JSON(http.StatusBadRequest, ErrorResp(400, err.Error()))
-	}
-	planetType := ogame.CelestialType(planetTypeInt)
-	if planetType != ogame.PlanetType && planetType != ogame.MoonType { // only accept planet/moon types
+	case coord.Type != ogame.PlanetType && coord.Type != ogame.MoonType: // only accept planet/moon types
 		return c.JSON(http.StatusBadRequest, ErrorResp(400, "invalid type"))
 	}
-	priority := utils.DoParseI64(c.Request().PostFormValue("priority"))
-	coord := ogame.Coordinate{Type: planetType, Galaxy: galaxy, System: system, Position: position}
-	duration, err := bot.SendIPM(ogame.PlanetID(planetID), coord, ipmAmount, ogame.ID(priority))
+	duration, err := initiated(c).SendIPM(ogame.PlanetID(planetID), coord, req.IpmAmount, req.Priority)
 	if err != nil {
-		return c.JSON(http.StatusBadRequest, ErrorResp(400, err.Error()))
+		return ErrorJSON(c, http.StatusBadRequest, err)
 	}
 	return c.JSON(http.StatusOK, SuccessResp(duration))
 }
 
 // TeardownHandler ...
 func TeardownHandler(c echo.Context) error {
-	bot := c.Get("bot").(*OGame)
 	planetID, err := utils.ParseI64(c.Param("planetID"))
 	if err != nil || planetID < 0 {
 		return c.JSON(http.StatusBadRequest, ErrorResp(400, "invalid planet id"))
@@ -1325,8 +1192,8 @@ func TeardownHandler(c echo.Context) error {
 	if err != nil || planetID < 0 {
 		return c.JSON(http.StatusBadRequest, ErrorResp(400, "invalid ogame id"))
 	}
-	if err = bot.TearDown(ogame.CelestialID(planetID), ogame.ID(ogameID)); err != nil {
-		return c.JSON(http.StatusBadRequest, ErrorResp(400, err.Error()))
+	if err = initiated(c).TearDown(ogame.CelestialID(planetID), ogame.ID(ogameID)); err != nil {
+		return ErrorJSON(c, http.StatusBadRequest, err)
 	}
 	return c.JSON(http.StatusOK, SuccessResp(nil))
 }
@@ -1343,33 +1210,18 @@ func GetAuctionHandler(c echo.Context) error {
 
 // DoAuctionHandler (`celestialID=metal:crystal:deuterium` eg: `123456=123:456:789`)
 func DoAuctionHandler(c echo.Context) error {
-	bot := c.Get("bot").(*OGame)
-	bid := make(map[ogame.CelestialID]ogame.Resources)
-	if err := c.Request().ParseForm(); err != nil { // Required for PostForm, not for PostFormValue
-		return c.JSON(http.StatusBadRequest, ErrorResp(400, "invalid form"))
-	}
-	for key, values := range c.Request().PostForm {
-		for _, s := range values {
-			var metal, crystal, deuterium int64
-			if n, err := fmt.Sscanf(s, "%d:%d:%d", &metal, &crystal, &deuterium); err != nil || n != 3 {
-				return c.JSON(http.StatusBadRequest, ErrorResp(400, "invalid bid format"))
-			}
-			celestialIDInt, err := utils.ParseI64(key)
-			if err != nil {
-				return c.JSON(http.StatusBadRequest, ErrorResp(400, "invalid celestial ID"))
-			}
-			bid[ogame.CelestialID(celestialIDInt)] = ogame.Resources{Metal: metal, Crystal: crystal, Deuterium: deuterium}
-		}
+	var bid AuctionBidRequest
+	if err := BindRequest(c, &bid); err != nil {
+		return ErrorJSON(c, http.StatusBadRequest, err)
 	}
-	if err := bot.DoAuction(bid); err != nil {
-		return c.JSON(http.StatusInternalServerError, ErrorResp(500, err.Error()))
+	if err := initiated(c).DoAuction(bid); err != nil {
+		return ErrorJSON(c, http.StatusInternalServerError, err)
 	}
 	return c.JSON(http.StatusOK, SuccessResp(nil))
 }
 
 // PhalanxHandler ...
 func PhalanxHandler(c echo.Context) error {
-	bot := c.Get("bot").(*OGame)
 	moonID, err := utils.ParseI64(c.Param("moonID"))
 	if err != nil {
 		return c.JSON(http.StatusBadRequest, ErrorResp(400, "invalid moon id"))
@@ -1387,52 +1239,33 @@ func PhalanxHandler(c echo.Context) error {
 		return c.JSON(http.StatusBadRequest, ErrorResp(400, "invalid position"))
 	}
 	coord := ogame.Coordinate{Type: ogame.PlanetType, Galaxy: galaxy, System: system, Position: position}
-	fleets, err := bot.Phalanx(ogame.MoonID(moonID), coord)
+	fleets, err := initiated(c).Phalanx(ogame.MoonID(moonID), coord)
 	if err != nil {
-		return c.JSON(http.StatusBadRequest, ErrorResp(400, err.Error()))
+		return ErrorJSON(c, http.StatusBadRequest, err)
 	}
 	return c.JSON(http.StatusOK, SuccessResp(fleets))
 }
 
 // JumpGateHandler ...
 func JumpGateHandler(c echo.Context) error {
-	bot := c.Get("bot").(*OGame)
-	if err := c.Request().ParseForm(); err != nil {
-		return c.JSON(http.StatusBadRequest, ErrorResp(400, "invalid form"))
-	}
 	moonOriginID, err := utils.ParseI64(c.Param("moonID"))
 	if err != nil {
 		return c.JSON(http.StatusBadRequest, ErrorResp(400, "invalid origin moon id"))
 	}
-	moonDestinationID, err := utils.ParseI64(c.Request().PostFormValue("moonDestination"))
-	if err != nil {
-		return c.JSON(http.StatusBadRequest, ErrorResp(400, "invalid destination moon id"))
-	}
-	var ships ogame.ShipsInfos
-	for key, values := range c.Request().PostForm {
-		switch key {
-		case "ships":
-			for _, s := range values {
-				a := strings.Split(s, ",")
-				shipID, err := utils.ParseI64(a[0])
-				if err != nil || !ogame.ID(shipID).IsShip() {
-					return c.JSON(http.StatusBadRequest, ErrorResp(400, "invalid ship id "+a[0]))
-				}
-				nbr, err := utils.ParseI64(a[1])
-				if err != nil || nbr < 0 {
-					return c.JSON(http.StatusBadRequest, ErrorResp(400, "invalid nbr "+a[1]))
-				}
-				ships.Set(ogame.ID(shipID), nbr)
-			}
-		}
+	var req JumpGateRequest
+	if err := BindRequest(c, &req); err != nil {
+		return ErrorJSON(c, http.StatusBadRequest, err)
+	}
+	if err := validateShips(req.Ships); err != nil {
+		return ErrorJSON(c, http.StatusBadRequest, err)
 	}
-	success, rechargeCountdown, err := bot.JumpGate(ogame.MoonID(moonOriginID), ogame.MoonID(moonDestinationID), ships)
+	success, rechargeCountdown, err := initiated(c).JumpGate(ogame.MoonID(moonOriginID), req.MoonDestination, req.Ships)
 	if err != nil {
-		return c.JSON(http.StatusBadRequest, ErrorResp(400, err.Error()))
+		return ErrorJSON(c, http.StatusBadRequest, err)
 	}
-	return c.JSON(http.StatusOK, SuccessResp(map[string]any{
-		"success":           success,
-		"rechargeCountdown": rechargeCountdown,
+	return c.JSON(http.StatusOK, SuccessResp(JumpGateResult{
+		Success:           success,
+		RechargeCountdown: rechargeCountdown,
 	}))
 }
 
@@ -1445,16 +1278,16 @@ func TechsHandler(c echo.Context) error {
 	}
 	techs, err := bot.GetTechs(ogame.CelestialID(celestialID))
 	if err != nil {
-		return c.JSON(http.StatusBadRequest, ErrorResp(400, err.Error()))
+		return ErrorJSON(c, http.StatusBadRequest, err)
 	}
-	return c.JSON(http.StatusOK, SuccessResp(map[string]any{
-		"supplies":     techs.ResourcesBuildings,
-		"facilities":   techs.Facilities,
-		"ships":        techs.ShipsInfos,
-		"defenses":     techs.DefensesInfos,
-		"researches":   techs.Researches,
-		"lfbuildings":  techs.LfBuildings,
-		"lfResearches": techs.LfResearches,
+	return c.JSON(http.StatusOK, SuccessResp(TechsResult{
+		Supplies:     techs.ResourcesBuildings,
+		Facilities:   techs.Facilities,
+		Ships:        techs.ShipsInfos,
+		Defenses:     techs.DefensesInfos,
+		Researches:   techs.Researches,
+		LfBuildings:  techs.LfBuildings,
+		LfResearches: techs.LfResearches,
 	}))
 }
 
@@ -1529,7 +1362,7 @@ func GetCaptchaChallengeHandler(c echo.Context) error {
 	if errors.As(err, &captchaErr) {
 		questionRaw, iconsRaw, err := gameforge.StartChallenge(bot.ctx, bot.GetClient(), captchaErr.ChallengeID)
 		if err != nil {
-			return c.JSON(http.StatusInternalServerError, ErrorResp(500, err.Error()))
+			return ErrorJSON(c, http.StatusInternalServerError, err)
 		}
 		questionB64 := base64.StdEncoding.EncodeToString(questionRaw)
 		iconsB64 := base64.StdEncoding.EncodeToString(iconsRaw)
@@ -1539,7 +1372,7 @@ func GetCaptchaChallengeHandler(c echo.Context) error {
 			Icons:    iconsB64,
 		}))
 	} else if err != nil {
-		return c.JSON(http.StatusInternalServerError, ErrorResp(500, err.Error()))
+		return ErrorJSON(c, http.StatusInternalServerError, err)
 	}
 	return c.JSON(http.StatusOK, SuccessResp(CaptchaChallenge{}))
 }
@@ -1549,7 +1382,7 @@ func GetPublicIPHandler(c echo.Context) error {
 	bot := c.Get("bot").(*OGame)
 	ip, err := bot.GetPublicIP()
 	if err != nil {
-		return c.JSON(http.StatusInternalServerError, ErrorResp(500, err.Error()))
+		return ErrorJSON(c, http.StatusInternalServerError, err)
 	}
 	return c.JSON(http.StatusOK, SuccessResp(ip))
 }
@@ -1,6 +1,7 @@
 package wrapper
 
 import (
+	"context"
 	"crypto/tls"
 	"github.com/alaingilbert/ogame/pkg/device"
 	"github.com/alaingilbert/ogame/pkg/gameforge"
@@ -76,6 +77,7 @@ type Prioritizable interface {
 	GetCachedResearch() ogame.Researches
 	GetCelestial(IntoCelestial) (Celestial, error)
 	GetCelestials() ([]Celestial, error)
+	GetCombatReport(msgID int64) (ogame.CombatReport, error)
 	GetCombatReportSummaryForFleet(ogame.FleetID) (ogame.CombatReportSummary, error)
 	GetCombatReportSummaryFor(ogame.Coordinate) (ogame.CombatReportSummary, error)
 	GetDMCosts(ogame.CelestialID) (ogame.DMCosts, error)
@@ -86,6 +88,7 @@ type Prioritizable interface {
 	GetEspionageReportMessages(maxPage int64) ([]ogame.EspionageReportSummary, error)
 	GetExpeditionMessageAt(time.Time) (ogame.ExpeditionMessage, error)
 	GetExpeditionMessages(maxPage int64) ([]ogame.ExpeditionMessage, error)
+	GetExpeditionStats(maxPage int64) (ogame.ExpeditionStats, error)
 	GetFleetDispatch(ogame.CelestialID, ...Option) (ogame.FleetDispatchInfos, error)
 	GetFleets(...Option) ([]ogame.Fleet, ogame.Slots, error)
 	GetFleetsFromEventList() ([]ogame.Fleet, error)
@@ -189,6 +192,7 @@ type Wrapper interface {
 	AddAccount(number int, lang string) (*gameforge.AddAccountResponse, error)
 	BytesDownloaded() int64
 	BytesUploaded() int64
+	CancelScheduledTask(id int64) bool
 	CharacterClass() ogame.CharacterClass
 	ConstructionTime(id ogame.ID, nbr int64, facilities ogame.Facilities) time.Duration
 	CountColonies() (int64, int64)
@@ -240,6 +244,10 @@ type Wrapper interface {
 	RegisterHTMLInterceptor(func(method, url string, params, payload url.Values, pageHTML []byte))
 	RegisterWSCallback(string, func([]byte))
 	RemoveWSCallback(string)
+	ScheduleAt(at time.Time, priority taskRunner.Priority, name string, clb func(Prioritizable) error) *taskRunner.TaskHandle
+	ScheduleCancelFleetAt(at time.Time, fleetID ogame.FleetID) (*taskRunner.TaskHandle, error)
+	ScheduleIn(d time.Duration, priority taskRunner.Priority, name string, clb func(Prioritizable) error) *taskRunner.TaskHandle
+	ScheduleSendFleetAt(at time.Time, celestialID ogame.CelestialID, ships ogame.ShipsInfos, speed ogame.Speed, where ogame.Coordinate, mission ogame.MissionID, resources ogame.Resources, holdingTime, unionID int64) (*taskRunner.TaskHandle, error)
 	ServerURL() string
 	ServerVersion() string
 	SetAllianceClass(ogame.AllianceClass)
@@ -249,8 +257,12 @@ type Wrapper interface {
 	SetOGameCredentials(username, password, otpSecret, bearerToken string)
 	SetProxy(proxyAddress, username, password, proxyType string, loginOnly bool, config *tls.Config) error
 	SetResearches(ogame.Researches)
+	SetTxHoldWarning(threshold time.Duration, clb func(taskRunner.TaskInfo))
 	SoftLogout()
+	Subscribe(bufferSize int, types ...EventType) *Subscription
 	SystemDistance(system1, system2 int64) int64
 	ValidateAccount(code string) error
+	WithContext(ctx context.Context) (Prioritizable, error)
 	WithPriority(priority taskRunner.Priority) Prioritizable
+	WithPriorityContext(ctx context.Context, priority taskRunner.Priority, initiator string) (Prioritizable, error)
 }
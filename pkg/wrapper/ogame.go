@@ -80,6 +80,9 @@ type OGame struct {
 	extractor            extractor.Extractor
 	apiNewHostname       string
 	captchaCallback      gameforge.CaptchaSolver
+	events               *EventBus
+	eventsTracker        *eventsTracker
+	expeditionFleets     *expeditionFleets
 	device               *device.Device
 	cache                struct {
 		serverData            ServerData
@@ -130,6 +133,14 @@ type Params struct {
 	CaptchaSolver  gameforge.CaptchaSolver
 	Logger         *log.Logger
 	Quiet          bool
+
+	// ScheduledTasksPath file where scheduled fleets (ScheduleSendFleetAt/ScheduleCancelFleetAt) are saved,
+	// so they survive a restart of the process. Not persisted if empty.
+	ScheduledTasksPath string
+
+	// TxHoldWarning logs a warning when a transaction holds the bot for longer than this duration.
+	// Default 5 minutes, negative to disable.
+	TxHoldWarning time.Duration
 }
 
 // New creates a new instance of OGame wrapper.
@@ -179,6 +190,9 @@ func newWithParams(params Params) (*OGame, error) {
 	b.enable()
 	b.quiet = params.Quiet
 	b.logger = params.Logger
+	b.events = NewEventBus()
+	b.eventsTracker = newEventsTracker()
+	b.expeditionFleets = newExpeditionFleets()
 
 	b.universe = params.Universe
 	b.setOGameCredentials(params.Username, params.Password, params.OTPSecret, params.BearerToken)
@@ -193,6 +207,18 @@ func newWithParams(params Params) (*OGame, error) {
 
 	factory := func() *Prioritize { return &Prioritize{bot: b} }
 	b.taskRunnerInst = taskRunner.NewTaskRunner(params.Ctx, factory)
+	b.registerScheduledTaskKinds()
+	if params.TxHoldWarning == 0 {
+		params.TxHoldWarning = 5 * time.Minute
+	}
+	if params.TxHoldWarning > 0 {
+		b.setTxHoldWarning(params.TxHoldWarning, nil)
+	}
+	if params.ScheduledTasksPath != "" {
+		if err := b.taskRunnerInst.SetPersistPath(params.ScheduledTasksPath); err != nil {
+			return nil, err
+		}
+	}
 
 	b.wsCallbacks.Store(make(map[string]func([]byte)))
 
@@ -305,6 +331,7 @@ func (b *OGame) execInterceptorCallbacks(method, url string, params, payload url
 	for _, fn := range b.interceptorCallbacks {
 		fn(method, url, params, payload, pageHTML)
 	}
+	b.publish(PageEvent, PageEventData{Method: method, URL: url, Params: params, Payload: payload, PageHTML: pageHTML})
 }
 
 // V11 IntroBypass
@@ -343,6 +370,10 @@ func postSessions(b *OGame) (bearerToken string, err error) {
 			Password:  b.password,
 			OtpSecret: b.otpSecret,
 		})
+		var captchaErr *gameforge.CaptchaRequiredError
+		if errors.As(err, &captchaErr) {
+			b.publish(CaptchaRequiredEvent, CaptchaRequiredEventData{ChallengeID: captchaErr.ChallengeID})
+		}
 		if err != nil {
 			return err
 		}
@@ -623,6 +654,7 @@ func (b *OGame) connectChatV8(chatRetry *exponentialBackoff.ExponentialBackoff,
 		b.wsCallbacks.Each(func(_ string, clb func(msg []byte)) {
 			go clb([]byte(buf))
 		})
+		b.publish(WSMessageEvent, []byte(buf))
 		if buf == "3probe" {
 			_ = websocket.Message.Send(ws, "5")
 			_ = websocket.Message.Send(ws, "40/chat,")
@@ -650,6 +682,7 @@ func (b *OGame) connectChatV8(chatRetry *exponentialBackoff.ExponentialBackoff,
 			for _, clb := range b.chatCallbacks {
 				clb(chatMsg)
 			}
+			b.publish(ChatMessageEvent, chatMsg)
 		} else if regexp.MustCompile(`^\d+/auctioneer`).MatchString(buf) {
 			pck, err := processAuctioneerMessage(buf)
 			if err != nil {
@@ -659,6 +692,7 @@ func (b *OGame) connectChatV8(chatRetry *exponentialBackoff.ExponentialBackoff,
 			for _, clb := range b.auctioneerCallbacks {
 				clb(pck)
 			}
+			b.publish(AuctioneerEvent, pck)
 		} else {
 			b.error("unknown message received:", buf)
 			select {
@@ -771,6 +805,7 @@ func (b *OGame) logout() error {
 func (b *OGame) softLogout() {
 	if b.isLoggedInAtom.CompareAndSwap(true, false) {
 		b.closeChatCancel()
+		b.publish(LogoutEvent, nil)
 	}
 }
 
@@ -1615,6 +1650,7 @@ func (b *OGame) getFleets(opts ...Option) ([]ogame.Fleet, ogame.Slots, error) {
 	if err != nil {
 		return []ogame.Fleet{}, ogame.Slots{}, err
 	}
+	b.trackFleets(fleets)
 	return fleets, slots, nil
 }
 
@@ -2574,6 +2610,7 @@ func (b *OGame) getAttacks(opts ...Option) (out []ogame.AttackEvent, err error)
 		return
 	}
 	fixAttackEvents(out, planets)
+	b.trackAttacks(out)
 	return
 }
 
@@ -3047,7 +3084,12 @@ func (b *OGame) constructionsBeingBuilt(celestialID ogame.CelestialID) (ogame.Co
 	if err != nil {
 		return ogame.Constructions{}, err
 	}
-	return page.ExtractConstructions()
+	constructions, err := page.ExtractConstructions()
+	if err != nil {
+		return constructions, err
+	}
+	b.trackConstructions(celestialID, constructions)
+	return constructions, nil
 }
 
 func (b *OGame) cancel(token string, techID, listID int64) error {
@@ -3099,7 +3141,7 @@ func (b *OGame) getResources(celestialID ogame.CelestialID) (ogame.Resources, er
 	if err != nil {
 		return ogame.Resources{}, err
 	}
-	return ogame.Resources{
+	resources := ogame.Resources{
 		Metal:      res.Metal.Available,
 		Crystal:    res.Crystal.Available,
 		Deuterium:  res.Deuterium.Available,
@@ -3107,7 +3149,9 @@ func (b *OGame) getResources(celestialID ogame.CelestialID) (ogame.Resources, er
 		Darkmatter: res.Darkmatter.Available,
 		Population: res.Population.Available,
 		Food:       res.Food.Available,
-	}, nil
+	}
+	b.trackResources(celestialID, res)
+	return resources, nil
 }
 
 func (b *OGame) getResourcesDetails(celestialID ogame.CelestialID) (ogame.ResourcesDetails, error) {
@@ -3507,7 +3551,9 @@ func (b *OGame) sendFleet(celestialID ogame.CelestialID, ships ogame.ShipsInfos,
 	if err != nil {
 		return zeroFleet, err
 	}
+	b.trackFleets(fleets)
 	if maxV, err := getLastFleetFor(fleets, originCoords, where, mission); err == nil && maxV.ID > maxInitialFleetID {
+		b.publish(FleetSentEvent, maxV)
 		return maxV, nil
 	}
 
@@ -3788,6 +3834,14 @@ func (b *OGame) getCombatReportFor(coord ogame.Coordinate) (ogame.CombatReportSu
 	return ogame.CombatReportSummary{}, errors.New("combat report not found for " + coord.String())
 }
 
+func (b *OGame) getCombatReport(msgID int64) (ogame.CombatReport, error) {
+	pageHTML, err := b.getPageContent(url.Values{"page": {"componentOnly"}, "component": {"messagedetails"}, "messageId": {utils.FI64(msgID)}})
+	if err != nil {
+		return ogame.CombatReport{}, err
+	}
+	return b.extractor.ExtractCombatReport(pageHTML)
+}
+
 func (b *OGame) getEspionageReport(msgID int64) (ogame.EspionageReport, error) {
 	pageHTML, err := b.getPageContent(url.Values{"page": {"componentOnly"}, "component": {"messagedetails"}, "messageId": {utils.FI64(msgID)}})
 	if err != nil {
@@ -3926,60 +3980,21 @@ func (b *OGame) deleteAllMessagesFromTab(tabID ogame.MessagesTabID) error {
 	return err
 }
 
-func energyProduced(temp ogame.Temperature, resourcesBuildings ogame.ResourcesBuildings, resSettings ogame.ResourceSettings, energyTechnology int64) int64 {
-	energyProduced := int64(float64(ogame.SolarPlant.Production(resourcesBuildings.SolarPlant)) * (float64(resSettings.SolarPlant) / 100))
-	energyProduced += int64(float64(ogame.FusionReactor.Production(energyTechnology, resourcesBuildings.FusionReactor)) * (float64(resSettings.FusionReactor) / 100))
-	energyProduced += int64(float64(ogame.SolarSatellite.Production(temp, resourcesBuildings.SolarSatellite, false)) * (float64(resSettings.SolarSatellite) / 100))
-	return energyProduced
-}
-
-func energyNeeded(resourcesBuildings ogame.ResourcesBuildings, resSettings ogame.ResourceSettings) int64 {
-	energyNeeded := int64(float64(ogame.MetalMine.EnergyConsumption(resourcesBuildings.MetalMine)) * (float64(resSettings.MetalMine) / 100))
-	energyNeeded += int64(float64(ogame.CrystalMine.EnergyConsumption(resourcesBuildings.CrystalMine)) * (float64(resSettings.CrystalMine) / 100))
-	energyNeeded += int64(float64(ogame.DeuteriumSynthesizer.EnergyConsumption(resourcesBuildings.DeuteriumSynthesizer)) * (float64(resSettings.DeuteriumSynthesizer) / 100))
-	return energyNeeded
-}
-
-func productionRatio(temp ogame.Temperature, resourcesBuildings ogame.ResourcesBuildings, resSettings ogame.ResourceSettings, energyTechnology int64) float64 {
-	energyProduced := energyProduced(temp, resourcesBuildings, resSettings, energyTechnology)
-	energyNeeded := energyNeeded(resourcesBuildings, resSettings)
-	ratio := 1.0
-	if energyNeeded > energyProduced {
-		ratio = float64(energyProduced) / float64(energyNeeded)
-	}
-	return ratio
-}
-
-func getProductions(resBuildings ogame.ResourcesBuildings, resSettings ogame.ResourceSettings, researches ogame.Researches, universeSpeed int64,
-	temp ogame.Temperature, globalRatio float64) ogame.Resources {
-	energyProduced := energyProduced(temp, resBuildings, resSettings, researches.EnergyTechnology)
-	energyNeeded := energyNeeded(resBuildings, resSettings)
-	metalSetting := float64(resSettings.MetalMine) / 100
-	crystalSetting := float64(resSettings.CrystalMine) / 100
-	deutSetting := float64(resSettings.DeuteriumSynthesizer) / 100
-	return ogame.Resources{
-		Metal:     ogame.MetalMine.Production(universeSpeed, metalSetting, globalRatio, researches.PlasmaTechnology, resBuildings.MetalMine),
-		Crystal:   ogame.CrystalMine.Production(universeSpeed, crystalSetting, globalRatio, researches.PlasmaTechnology, resBuildings.CrystalMine),
-		Deuterium: ogame.DeuteriumSynthesizer.Production(universeSpeed, temp.Mean(), deutSetting, globalRatio, researches.PlasmaTechnology, resBuildings.DeuteriumSynthesizer) - ogame.FusionReactor.GetFuelConsumption(universeSpeed, float64(resSettings.FusionReactor)/100, resBuildings.FusionReactor),
-		Energy:    energyProduced - energyNeeded,
-	}
-}
-
 func (b *OGame) getResourcesProductions(planetID ogame.PlanetID) (ogame.Resources, error) {
 	planet, _ := b.getPlanet(planetID)
 	resBuildings, _ := b.getResourcesBuildings(planetID.Celestial())
 	researches, _ := b.getResearch()
 	universeSpeed := b.cache.serverData.Speed
 	resSettings, _ := b.getResourceSettings(planetID)
-	ratio := productionRatio(planet.Temperature, resBuildings, resSettings, researches.EnergyTechnology)
-	productions := getProductions(resBuildings, resSettings, researches, universeSpeed, planet.Temperature, ratio)
+	ratio := ogame.ProductionRatio(planet.Temperature, resBuildings, resSettings, researches.EnergyTechnology, false)
+	productions := ogame.Productions(resBuildings, resSettings, researches, universeSpeed, planet.Temperature, ratio, false)
 	return productions, nil
 }
 
 func getResourcesProductionsLight(resBuildings ogame.ResourcesBuildings, researches ogame.Researches,
 	resSettings ogame.ResourceSettings, temp ogame.Temperature, universeSpeed int64) ogame.Resources {
-	ratio := productionRatio(temp, resBuildings, resSettings, researches.EnergyTechnology)
-	productions := getProductions(resBuildings, resSettings, researches, universeSpeed, temp, ratio)
+	ratio := ogame.ProductionRatio(temp, resBuildings, resSettings, researches.EnergyTechnology, false)
+	productions := ogame.Productions(resBuildings, resSettings, researches, universeSpeed, temp, ratio, false)
 	return productions
 }
 
@@ -4011,6 +4026,7 @@ func (b *OGame) stateChanged(locked bool, actor string) {
 	for _, clb := range b.stateChangeCallbacks {
 		clb(locked, actor)
 	}
+	b.publish(StateChangeEvent, StateChangeEventData{Locked: locked, Actor: actor})
 }
 
 func (b *OGame) botLock(lockedBy string) {
@@ -4493,6 +4509,25 @@ func (b *OGame) withPriority(priority taskRunner.Priority) *Prioritize {
 	return b.taskRunnerInst.WithPriority(priority)
 }
 
+func (b *OGame) withPriorityContext(ctx context.Context, priority taskRunner.Priority, initiator string) (*Prioritize, error) {
+	p, err := b.taskRunnerInst.WithContext(ctx, priority, initiator)
+	if err != nil {
+		return nil, err
+	}
+	p.initiator = initiator
+	return p, nil
+}
+
+func (b *OGame) setTxHoldWarning(threshold time.Duration, clb func(taskRunner.TaskInfo)) {
+	b.taskRunnerInst.SetHoldThreshold(threshold, func(info taskRunner.TaskInfo) {
+		_, state := b.GetState()
+		b.warn(fmt.Sprintf("transaction held for %s by %s (initiator: %q)", info.Age.Round(time.Second), state, info.Initiator))
+		if clb != nil {
+			clb(info)
+		}
+	})
+}
+
 func (b *OGame) getDevice() *device.Device {
 	return b.device
 }
@@ -4633,8 +4668,9 @@ func (b *OGame) registerHTMLInterceptor(fn func(method, url string, params, payl
 	b.interceptorCallbacks = append(b.interceptorCallbacks, fn)
 }
 
-func (b *OGame) setInitiator(_ string) Prioritizable {
-	return nil
+func (b *OGame) setInitiator(initiator string) Prioritizable {
+	p, _ := b.withPriorityContext(context.Background(), taskRunner.Normal, initiator)
+	return p
 }
 
 func (b *OGame) done() {}
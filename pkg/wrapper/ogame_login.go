@@ -189,6 +189,7 @@ func (b *OGame) loginPart1(token string) (server gameforge.Server, userAccount g
 func (b *OGame) loginPart2(server gameforge.Server) (err error) {
 	b.isLoggedInAtom.Store(true) // At this point, we are logged in
 	b.isConnectedAtom.Store(true)
+	b.publish(LoginEvent, nil)
 	// Get server data
 	start := time.Now()
 	b.server = server
@@ -1,6 +1,7 @@
 package wrapper
 
 import (
+	"context"
 	"crypto/tls"
 	"github.com/alaingilbert/ogame/pkg/device"
 	"github.com/alaingilbert/ogame/pkg/extractor"
@@ -95,6 +96,28 @@ func (b *OGame) WithPriority(priority taskRunner.Priority) Prioritizable {
 	return b.withPriority(priority)
 }
 
+// WithContext same as WithPriority with Normal priority, but the call returns taskRunner.ErrTaskCancelled
+// if ctx expires before the task could be processed.
+func (b *OGame) WithContext(ctx context.Context) (Prioritizable, error) {
+	return b.WithPriorityContext(ctx, taskRunner.Normal, "")
+}
+
+// WithPriorityContext same as WithPriority, but the call returns taskRunner.ErrTaskCancelled
+// if ctx expires before the task could be processed. The initiator is shown in the tasks overview while waiting.
+func (b *OGame) WithPriorityContext(ctx context.Context, priority taskRunner.Priority, initiator string) (Prioritizable, error) {
+	p, err := b.withPriorityContext(ctx, priority, initiator)
+	if err != nil {
+		return nil, err
+	}
+	return p, nil
+}
+
+// SetTxHoldWarning calls clb (and logs a warning) every time a transaction holds the bot for longer than threshold.
+// A threshold of 0 disables the detection.
+func (b *OGame) SetTxHoldWarning(threshold time.Duration, clb func(taskRunner.TaskInfo)) {
+	b.setTxHoldWarning(threshold, clb)
+}
+
 // Begin start a transaction. Once this function is called, "Done" must be called to release the lock.
 func (b *OGame) Begin() Prioritizable {
 	return b.WithPriority(taskRunner.Normal).Begin()
@@ -105,7 +128,7 @@ func (b *OGame) BeginNamed(name string) Prioritizable {
 	return b.WithPriority(taskRunner.Normal).BeginNamed(name)
 }
 
-// SetInitiator ...
+// SetInitiator queues a task with Normal priority, attributed to initiator in the tasks overview and the bot state
 func (b *OGame) SetInitiator(initiator string) Prioritizable {
 	return b.setInitiator(initiator)
 }
@@ -593,6 +616,11 @@ func (b *OGame) SendIPM(planetID ogame.PlanetID, coord ogame.Coordinate, nbr int
 	return b.WithPriority(taskRunner.Normal).SendIPM(planetID, coord, nbr, priority)
 }
 
+// GetCombatReport gets a detailed combat report
+func (b *OGame) GetCombatReport(msgID int64) (ogame.CombatReport, error) {
+	return b.WithPriority(taskRunner.Normal).GetCombatReport(msgID)
+}
+
 // GetCombatReportSummaryForFleet gets the latest combat report for a given FleetID
 func (b *OGame) GetCombatReportSummaryForFleet(fleetID ogame.FleetID) (ogame.CombatReportSummary, error) {
 	return b.WithPriority(taskRunner.Normal).GetCombatReportSummaryForFleet(fleetID)
@@ -613,6 +641,12 @@ func (b *OGame) GetExpeditionMessages(maxPage int64) ([]ogame.ExpeditionMessage,
 	return b.WithPriority(taskRunner.Normal).GetExpeditionMessages(maxPage)
 }
 
+// GetExpeditionStats aggregates the outcomes of the expedition messages by position and by fleet composition.
+// Fleet compositions are only known for the expeditions the bot saw in movement.
+func (b *OGame) GetExpeditionStats(maxPage int64) (ogame.ExpeditionStats, error) {
+	return b.WithPriority(taskRunner.Normal).GetExpeditionStats(maxPage)
+}
+
 // GetExpeditionMessageAt gets the expedition message for time t
 func (b *OGame) GetExpeditionMessageAt(t time.Time) (ogame.ExpeditionMessage, error) {
 	return b.WithPriority(taskRunner.Normal).GetExpeditionMessageAt(t)
@@ -733,6 +767,12 @@ func (b *OGame) RegisterHTMLInterceptor(fn func(method, url string, params, payl
 	b.registerHTMLInterceptor(fn)
 }
 
+// Subscribe to the events of the given types (all events if none given).
+// Events are delivered on a channel of size bufferSize, and dropped when it is full.
+func (b *OGame) Subscribe(bufferSize int, types ...EventType) *Subscription {
+	return b.events.Subscribe(bufferSize, types...)
+}
+
 // Phalanx scan a coordinate from a moon to get fleets information
 // IMPORTANT: My account was instantly banned when I scanned an invalid coordinate.
 // IMPORTANT: This function DOES validate that the coordinate is a valid planet in range of phalanx
@@ -822,11 +862,38 @@ func (b *OGame) GetAllResources() (map[ogame.CelestialID]ogame.Resources, error)
 	return b.WithPriority(taskRunner.Normal).GetAllResources()
 }
 
-// GetTasks return how many tasks are queued in the heap.
+// GetTasks return how many tasks are queued in the heap, and the scheduled tasks.
 func (b *OGame) GetTasks() taskRunner.TasksOverview {
 	return b.getTasks()
 }
 
+// ScheduleAt executes clb in a transaction with the given priority at the given time.
+// Tasks scheduled with a callback are not persisted.
+func (b *OGame) ScheduleAt(at time.Time, priority taskRunner.Priority, name string, clb func(Prioritizable) error) *taskRunner.TaskHandle {
+	return b.scheduleAt(at, priority, name, clb)
+}
+
+// ScheduleIn executes clb in a transaction with the given priority once the duration has elapsed
+func (b *OGame) ScheduleIn(d time.Duration, priority taskRunner.Priority, name string, clb func(Prioritizable) error) *taskRunner.TaskHandle {
+	return b.scheduleIn(d, priority, name, clb)
+}
+
+// ScheduleSendFleetAt sends a fleet at the given time. The task is persisted if Params.ScheduledTasksPath is set.
+func (b *OGame) ScheduleSendFleetAt(at time.Time, celestialID ogame.CelestialID, ships ogame.ShipsInfos, speed ogame.Speed,
+	where ogame.Coordinate, mission ogame.MissionID, resources ogame.Resources, holdingTime, unionID int64) (*taskRunner.TaskHandle, error) {
+	return b.scheduleSendFleetAt(at, celestialID, ships, speed, where, mission, resources, holdingTime, unionID)
+}
+
+// ScheduleCancelFleetAt recalls a fleet at the given time. The task is persisted if Params.ScheduledTasksPath is set.
+func (b *OGame) ScheduleCancelFleetAt(at time.Time, fleetID ogame.FleetID) (*taskRunner.TaskHandle, error) {
+	return b.scheduleCancelFleetAt(at, fleetID)
+}
+
+// CancelScheduledTask cancels a scheduled task, returns false if the task already started or does not exist
+func (b *OGame) CancelScheduledTask(id int64) bool {
+	return b.taskRunnerInst.CancelScheduled(id)
+}
+
 // GetDMCosts returns fast build with DM information
 func (b *OGame) GetDMCosts(celestialID ogame.CelestialID) (ogame.DMCosts, error) {
 	return b.WithPriority(taskRunner.Normal).GetDMCosts(celestialID)
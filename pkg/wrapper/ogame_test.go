@@ -8,6 +8,7 @@ import (
 	"github.com/alaingilbert/ogame/pkg/utils"
 	"github.com/hashicorp/go-version"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 	"os"
 	"regexp"
 	"testing"
@@ -71,34 +72,6 @@ func TestWrapper(t *testing.T) {
 //	assert.Equal(t, Resources{Metal: 109444, Crystal: 41697, Deuterium: 16347, Energy: -5169}, prod)
 //}
 
-func TestProductionRatio(t *testing.T) {
-	ratio := productionRatio(
-		ogame.Temperature{Min: -23, Max: 17},
-		ogame.ResourcesBuildings{MetalMine: 29, CrystalMine: 16, DeuteriumSynthesizer: 26, SolarPlant: 29, FusionReactor: 13, SolarSatellite: 51},
-		ogame.ResourceSettings{MetalMine: 100, CrystalMine: 100, DeuteriumSynthesizer: 100, SolarPlant: 100, FusionReactor: 100, SolarSatellite: 100},
-		12,
-	)
-	assert.Equal(t, 1.0, ratio)
-}
-
-func TestEnergyNeeded(t *testing.T) {
-	needed := energyNeeded(
-		ogame.ResourcesBuildings{MetalMine: 29, CrystalMine: 16, DeuteriumSynthesizer: 26},
-		ogame.ResourceSettings{MetalMine: 100, CrystalMine: 100, DeuteriumSynthesizer: 100},
-	)
-	assert.Equal(t, int64(4601+736+6198), needed)
-}
-
-func TestEnergyProduced(t *testing.T) {
-	produced := energyProduced(
-		ogame.Temperature{Min: -23, Max: 17},
-		ogame.ResourcesBuildings{SolarPlant: 29, FusionReactor: 13, SolarSatellite: 51},
-		ogame.ResourceSettings{SolarPlant: 100, FusionReactor: 100, SolarSatellite: 100},
-		12,
-	)
-	assert.Equal(t, int64(9200+3002+1326), produced)
-}
-
 func TestExtractCargoCapacity(t *testing.T) {
 	pageHTMLBytes, _ := os.ReadFile("../../samples/unversioned/sendfleet3.htm")
 	fleet3Doc := utils.First(goquery.NewDocumentFromReader(bytes.NewReader(pageHTMLBytes)))
@@ -348,3 +321,14 @@ func TestSanitizeServerVersion(t *testing.T) {
 	assert.Equal(t, "8.7.4", sanitizeServerVersion("8.7.4-pl3"))
 	assert.Equal(t, "8.7.5", sanitizeServerVersion("8.7.5"))
 }
+
+func TestOGame_SetInitiator(t *testing.T) {
+	bot, _ := NewNoLogin(&device.Device{}, "", "", "", "")
+	tx := bot.SetInitiator("fleet-saver").Begin()
+	running := bot.GetTasks().Running
+	require.NotNil(t, running)
+	assert.Equal(t, "fleet-saver", running.Initiator)
+	_, state := bot.GetState()
+	assert.Equal(t, "fleet-saver:Tx", state)
+	tx.Done()
+}
@@ -556,6 +556,13 @@ func (b *Prioritize) SendIPM(planetID ogame.PlanetID, coord ogame.Coordinate, nb
 	return b.bot.sendIPM(planetID, coord, nbr, priority)
 }
 
+// GetCombatReport gets a detailed combat report
+func (b *Prioritize) GetCombatReport(msgID int64) (ogame.CombatReport, error) {
+	b.begin("GetCombatReport")
+	defer b.done()
+	return b.bot.getCombatReport(msgID)
+}
+
 // GetCombatReportSummaryForFleet gets the latest combat report for a given FleetID
 func (b *Prioritize) GetCombatReportSummaryForFleet(fleetID ogame.FleetID) (ogame.CombatReportSummary, error) {
 	b.begin("GetCombatReportSummaryForFleet")
@@ -606,6 +613,14 @@ func (b *Prioritize) GetExpeditionMessages(maxPage int64) ([]ogame.ExpeditionMes
 	return b.bot.getExpeditionMessages(maxPage)
 }
 
+// GetExpeditionStats aggregates the outcomes of the expedition messages by position and by fleet composition.
+// Fleet compositions are only known for the expeditions the bot saw in movement.
+func (b *Prioritize) GetExpeditionStats(maxPage int64) (ogame.ExpeditionStats, error) {
+	b.begin("GetExpeditionStats")
+	defer b.done()
+	return b.bot.getExpeditionStats(maxPage)
+}
+
 // GetExpeditionMessageAt gets the expedition message for time t
 func (b *Prioritize) GetExpeditionMessageAt(t time.Time) (ogame.ExpeditionMessage, error) {
 	b.begin("GetExpeditionMessageAt")
@@ -0,0 +1,281 @@
+package wrapper
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/url"
+	"strings"
+
+	"github.com/alaingilbert/ogame/pkg/ogame"
+	"github.com/alaingilbert/ogame/pkg/utils"
+	echo "github.com/labstack/echo/v4"
+)
+
+// FormRequest body of a route, json encoded or form encoded for backward compatibility
+type FormRequest interface {
+	ParseForm(form url.Values) error
+}
+
+// BindRequest decodes the body of the request into req, json if the content type is application/json, form otherwise
+func BindRequest(c echo.Context, req FormRequest) error {
+	if strings.HasPrefix(c.Request().Header.Get(echo.HeaderContentType), echo.MIMEApplicationJSON) {
+		if err := json.NewDecoder(c.Request().Body).Decode(req); err != nil {
+			return fmt.Errorf("invalid json body: %w", err)
+		}
+		return nil
+	}
+	if err := c.Request().ParseForm(); err != nil {
+		return errors.New("invalid form")
+	}
+	return req.ParseForm(c.Request().PostForm)
+}
+
+// formInt parses the form value key into dst, dst is untouched if the key is missing
+func formInt[T ~int | ~int64 | ~float64](form url.Values, key string, dst *T) error {
+	values, ok := form[key]
+	if !ok || len(values) == 0 {
+		return nil
+	}
+	v, err := utils.ParseI64(values[0])
+	if err != nil {
+		return errors.New("invalid " + key)
+	}
+	*dst = T(v)
+	return nil
+}
+
+// formInts parses the form values of keys, stops at the first error
+func formInts(form url.Values, fields map[string]*int64) error {
+	for key, dst := range fields {
+		if err := formInt(form, key, dst); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// parseShipsForm parses the "ships" form values, eg: ships=204,10&ships=205,3
+func parseShipsForm(values []string) (ships ogame.ShipsInfos, err error) {
+	for _, s := range values {
+		a := strings.Split(s, ",")
+		shipID, err := utils.ParseI64(a[0])
+		if err != nil || !ogame.ID(shipID).IsShip() {
+			return ships, errors.New("invalid ship id " + a[0])
+		}
+		if len(a) < 2 {
+			return ships, errors.New("invalid nbr")
+		}
+		nbr, err := utils.ParseI64(a[1])
+		if err != nil || nbr < 0 {
+			return ships, errors.New("invalid nbr " + a[1])
+		}
+		ships.Set(ogame.ID(shipID), nbr)
+	}
+	return ships, nil
+}
+
+// validateShips ensures no ship has a negative amount
+func validateShips(ships ogame.ShipsInfos) (err error) {
+	ships.Each(func(shipID ogame.ID, nb int64) {
+		if nb < 0 && err == nil {
+			err = errors.New("invalid nbr " + utils.FI64(nb))
+		}
+	})
+	return err
+}
+
+// CoordinateRequest target of a route
+type CoordinateRequest struct {
+	Galaxy   int64
+	System   int64
+	Position int64
+	Type     ogame.CelestialType // Planet if zero
+}
+
+// ParseForm implements FormRequest
+func (r *CoordinateRequest) ParseForm(form url.Values) error {
+	if err := formInts(form, map[string]*int64{"galaxy": &r.Galaxy, "system": &r.System, "position": &r.Position}); err != nil {
+		return err
+	}
+	return formInt(form, "type", &r.Type)
+}
+
+// Coordinate returns the target coordinate
+func (r CoordinateRequest) Coordinate() ogame.Coordinate {
+	where := ogame.Coordinate{Galaxy: r.Galaxy, System: r.System, Position: r.Position, Type: r.Type}
+	if where.Type == 0 {
+		where.Type = ogame.PlanetType
+	}
+	return where
+}
+
+// SendFleetRequest body of the send-fleet route
+type SendFleetRequest struct {
+	CoordinateRequest
+	Ships     ogame.ShipsInfos
+	Speed     ogame.Speed     // 1 to 10 (10%..100%), 100% if zero
+	Mission   ogame.MissionID // Transport if zero
+	Duration  int64           // Holding time in hours
+	Union     int64
+	Metal     int64
+	Crystal   int64
+	Deuterium int64
+}
+
+// ParseForm implements FormRequest
+func (r *SendFleetRequest) ParseForm(form url.Values) (err error) {
+	if r.Ships, err = parseShipsForm(form["ships"]); err != nil {
+		return err
+	}
+	if err := r.CoordinateRequest.ParseForm(form); err != nil {
+		return err
+	}
+	if err := formInt(form, "speed", &r.Speed); err != nil {
+		return err
+	}
+	if err := formInt(form, "mission", &r.Mission); err != nil {
+		return err
+	}
+	if err := formInt(form, "union", &r.Union); err != nil {
+		return errors.New("invalid union id")
+	}
+	return formInts(form, map[string]*int64{"duration": &r.Duration, "metal": &r.Metal, "crystal": &r.Crystal, "deuterium": &r.Deuterium})
+}
+
+// Validate checks the values of the request
+func (r SendFleetRequest) Validate() error {
+	switch {
+	case r.Speed < 0 || r.Speed > 10:
+		return errors.New("invalid speed")
+	case r.Metal < 0:
+		return errors.New("invalid metal")
+	case r.Crystal < 0:
+		return errors.New("invalid crystal")
+	case r.Deuterium < 0:
+		return errors.New("invalid deuterium")
+	}
+	return validateShips(r.Ships)
+}
+
+// SendIPMRequest body of the send-ipm route
+type SendIPMRequest struct {
+	CoordinateRequest
+	IpmAmount int64
+	Priority  ogame.ID // Defense to target first
+}
+
+// ParseForm implements FormRequest
+func (r *SendIPMRequest) ParseForm(form url.Values) error {
+	if err := r.CoordinateRequest.ParseForm(form); err != nil {
+		return err
+	}
+	if err := formInt(form, "ipmAmount", &r.IpmAmount); err != nil {
+		return err
+	}
+	_ = formInt(form, "priority", &r.Priority) // Invalid priority means no priority
+	return nil
+}
+
+// JumpGateRequest body of the jump-gate route
+type JumpGateRequest struct {
+	MoonDestination ogame.MoonID
+	Ships           ogame.ShipsInfos
+}
+
+// ParseForm implements FormRequest
+func (r *JumpGateRequest) ParseForm(form url.Values) (err error) {
+	if err := formInt(form, "moonDestination", &r.MoonDestination); err != nil {
+		return errors.New("invalid destination moon id")
+	}
+	r.Ships, err = parseShipsForm(form["ships"])
+	return err
+}
+
+// SendMessageRequest body of the send-message route
+type SendMessageRequest struct {
+	PlayerID int64
+	Message  string
+}
+
+// ParseForm implements FormRequest
+func (r *SendMessageRequest) ParseForm(form url.Values) error {
+	if err := formInt(form, "playerID", &r.PlayerID); err != nil {
+		return err
+	}
+	r.Message = form.Get("message")
+	return nil
+}
+
+// ResourceSettingsRequest body of the resource-settings route, json keys are the ones of ogame.ResourceSettings
+type ResourceSettingsRequest struct {
+	ogame.ResourceSettings
+}
+
+// ParseForm implements FormRequest, every setting is required
+func (r *ResourceSettingsRequest) ParseForm(form url.Values) error {
+	s := &r.ResourceSettings
+	for _, f := range []struct {
+		key string
+		dst *int64
+	}{
+		{"metalMine", &s.MetalMine},
+		{"crystalMine", &s.CrystalMine},
+		{"deuteriumSynthesizer", &s.DeuteriumSynthesizer},
+		{"solarPlant", &s.SolarPlant},
+		{"fusionReactor", &s.FusionReactor},
+		{"solarSatellite", &s.SolarSatellite},
+		{"crawler", &s.Crawler},
+	} {
+		v, err := utils.ParseI64(form.Get(f.key))
+		if err != nil {
+			return errors.New("invalid " + f.key)
+		}
+		*f.dst = v
+	}
+	return nil
+}
+
+// AuctionBidRequest body of the do-auction route, the resources taken from each celestial
+type AuctionBidRequest map[ogame.CelestialID]ogame.Resources
+
+// ParseForm implements FormRequest, eg: 123456=1000:2000:3000
+func (r *AuctionBidRequest) ParseForm(form url.Values) error {
+	bid := make(AuctionBidRequest)
+	for key, values := range form {
+		for _, s := range values {
+			var metal, crystal, deuterium int64
+			if n, err := fmt.Sscanf(s, "%d:%d:%d", &metal, &crystal, &deuterium); err != nil || n != 3 {
+				return errors.New("invalid bid format")
+			}
+			celestialIDInt, err := utils.ParseI64(key)
+			if err != nil {
+				return errors.New("invalid celestial ID")
+			}
+			bid[ogame.CelestialID(celestialIDInt)] = ogame.Resources{Metal: metal, Crystal: crystal, Deuterium: deuterium}
+		}
+	}
+	*r = bid
+	return nil
+}
+
+// PageContentRequest body of the page-content route, the query of the page
+type PageContentRequest map[string]string
+
+// ParseForm implements FormRequest
+func (r *PageContentRequest) ParseForm(form url.Values) error {
+	*r = make(PageContentRequest)
+	for key := range form {
+		(*r)[key] = form.Get(key)
+	}
+	return nil
+}
+
+// Values returns the query of the page
+func (r PageContentRequest) Values() url.Values {
+	vals := url.Values{}
+	for k, v := range r {
+		vals.Set(k, v)
+	}
+	return vals
+}
@@ -0,0 +1,69 @@
+package wrapper
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/alaingilbert/ogame/pkg/ogame"
+	echo "github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+)
+
+func newRequestContext(contentType, body string) echo.Context {
+	req := httptest.NewRequest(http.MethodPost, "/", strings.NewReader(body))
+	req.Header.Set(echo.HeaderContentType, contentType)
+	return echo.New().NewContext(req, httptest.NewRecorder())
+}
+
+func TestBindRequest(t *testing.T) {
+	expected := SendFleetRequest{
+		CoordinateRequest: CoordinateRequest{Galaxy: 1, System: 2, Position: 3, Type: ogame.MoonType},
+		Ships:             ogame.ShipsInfos{SmallCargo: 10, LargeCargo: 1},
+		Speed:             5,
+		Mission:           ogame.Park,
+		Metal:             1,
+		Deuterium:         3,
+	}
+	var req SendFleetRequest
+	c := newRequestContext(echo.MIMEApplicationForm, "ships=202,10&ships=203,1&speed=5&galaxy=1&system=2&position=3&type=3&mission=4&metal=1&deuterium=3")
+	assert.NoError(t, BindRequest(c, &req))
+	assert.Equal(t, expected, req)
+
+	req = SendFleetRequest{}
+	c = newRequestContext(echo.MIMEApplicationJSON, `{"Ships": {"SmallCargo": 10, "LargeCargo": 1}, "speed": 5, "galaxy": 1, "system": 2, "position": 3, "type": 3, "mission": 4, "metal": 1, "deuterium": 3}`)
+	assert.NoError(t, BindRequest(c, &req))
+	assert.Equal(t, expected, req)
+
+	c = newRequestContext(echo.MIMEApplicationForm, "ships=1,10")
+	assert.EqualError(t, BindRequest(c, &req), "invalid ship id 1")
+	c = newRequestContext(echo.MIMEApplicationJSON, `{"galaxy": "x"}`)
+	assert.Error(t, BindRequest(c, &req))
+
+	var bid AuctionBidRequest
+	c = newRequestContext(echo.MIMEApplicationForm, "123=1:2:3")
+	assert.NoError(t, BindRequest(c, &bid))
+	assert.Equal(t, AuctionBidRequest{123: {Metal: 1, Crystal: 2, Deuterium: 3}}, bid)
+	c = newRequestContext(echo.MIMEApplicationJSON, `{"123": {"Metal": 1, "Crystal": 2, "Deuterium": 3}}`)
+	assert.NoError(t, BindRequest(c, &bid))
+	assert.Equal(t, AuctionBidRequest{123: {Metal: 1, Crystal: 2, Deuterium: 3}}, bid)
+}
+
+func TestAPIErrorFor(t *testing.T) {
+	status, code := APIErrorFor(fmt.Errorf("send fleet: %w", ogame.ErrNotEnoughDeuterium), http.StatusInternalServerError)
+	assert.Equal(t, http.StatusUnprocessableEntity, status)
+	assert.Equal(t, "not_enough_deuterium", code)
+	status, code = APIErrorFor(ogame.ErrAllSlotsInUse, http.StatusInternalServerError)
+	assert.Equal(t, http.StatusConflict, status)
+	assert.Equal(t, "all_slots_in_use", code)
+	status, code = APIErrorFor(ogame.NewAttackBlockActivatedErr(time.Now()), http.StatusInternalServerError)
+	assert.Equal(t, http.StatusForbidden, status)
+	assert.Equal(t, "attack_block_activated", code)
+	status, code = APIErrorFor(errors.New("unknown"), http.StatusBadRequest)
+	assert.Equal(t, http.StatusBadRequest, status)
+	assert.Equal(t, ErrCodeBadRequest, code)
+}
@@ -0,0 +1,241 @@
+package wrapper
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/alaingilbert/ogame/pkg/gameforge"
+	"github.com/alaingilbert/ogame/pkg/ogame"
+	"github.com/alaingilbert/ogame/pkg/openapi"
+	"github.com/alaingilbert/ogame/pkg/taskRunner"
+)
+
+// Scopes of the ogamed api keys, required by the routes.
+// Every scope allows the read routes, admin allows every route.
+const (
+	ScopeRead        = "read"
+	ScopeFleet       = "fleet"
+	ScopeBuild       = "build"
+	ScopeMarketplace = "marketplace"
+	ScopeAdmin       = "admin"
+)
+
+// coordParams path params of a coordinate
+var coordParams = []openapi.Param{
+	{Name: "galaxy", In: openapi.InPath},
+	{Name: "system", In: openapi.InPath},
+	{Name: "position", In: openapi.InPath},
+}
+
+// coordForm form params of a coordinate
+var coordForm = []openapi.Param{
+	{Name: "galaxy", In: openapi.InForm, Required: true},
+	{Name: "system", In: openapi.InForm, Required: true},
+	{Name: "position", In: openapi.InForm, Required: true},
+	{Name: "type", In: openapi.InForm, Description: "1: planet (default), 2: debris, 3: moon"},
+}
+
+// shipsForm form param of a list of ships
+var shipsForm = openapi.Param{Name: "ships", In: openapi.InForm, Type: "", Repeated: true, Required: true,
+	Description: "shipID,nbr eg: 204,10"}
+
+// eventTypesParam query param filtering the streamed events
+var eventTypesParam = openapi.Param{Name: "types", In: openapi.InQuery, Type: "", Repeated: true,
+	Description: "Event types to stream, comma separated, all if empty: stateChange, chatMessage, auctioneer, login, logout, " +
+		"attackDetected, fleetSent, fleetReturned, constructionFinished, resourcesChanged, captchaRequired"}
+
+// SystemInfosResult json encoding of ogame.SystemInfos
+type SystemInfosResult struct {
+	Galaxy           int64
+	System           int64
+	Planets          [15]*ogame.PlanetInfos
+	ExpeditionDebris struct {
+		Metal             int64
+		Crystal           int64
+		Deuterium         int64
+		PathfindersNeeded int64
+	}
+}
+
+// ConstructionsResult result of the constructions route, countdowns are in seconds
+type ConstructionsResult struct {
+	BuildingID          int64
+	BuildingCountdown   int64
+	ResearchID          int64
+	ResearchCountdown   int64
+	LfBuildingID        int64
+	LfBuildingCountdown int64
+	LfResearchID        int64
+	LfResearchCountdown int64
+}
+
+// TechsResult result of the techs route
+type TechsResult struct {
+	Supplies     ogame.ResourcesBuildings `json:"supplies"`
+	Facilities   ogame.Facilities         `json:"facilities"`
+	Ships        ogame.ShipsInfos         `json:"ships"`
+	Defenses     ogame.DefensesInfos      `json:"defenses"`
+	Researches   ogame.Researches         `json:"researches"`
+	LfBuildings  ogame.LfBuildings        `json:"lfbuildings"`
+	LfResearches ogame.LfResearches       `json:"lfResearches"`
+}
+
+// JumpGateResult result of the jump gate route
+type JumpGateResult struct {
+	Success           bool  `json:"success"`
+	RechargeCountdown int64 `json:"rechargeCountdown"`
+}
+
+// AbandonResult result of the abandon route
+type AbandonResult struct {
+	CelestialID int64
+	Result      string
+}
+
+// Routes routes of the bot API served by ogamed, with their documentation
+var Routes = []openapi.Route{
+	{Method: http.MethodGet, Path: "/tasks", Handler: TasksHandler, Tag: "bot", Summary: "Tasks queued in the bot", Result: taskRunner.TasksOverview{}},
+
+	{Method: http.MethodGet, Path: "/bot/events", Handler: EventsHandler, Tag: "events", Summary: "Server-sent events stream of the bot events, data is a json Event",
+		Params: []openapi.Param{eventTypesParam}, ContentType: "text/event-stream"},
+	{Method: http.MethodGet, Path: "/bot/events/ws", Handler: EventsWSHandler, Tag: "events", Summary: "WebSocket stream of the bot events, one json Event per message",
+		Params: []openapi.Param{eventTypesParam}, ContentType: "application/json"},
+
+	{Method: http.MethodGet, Path: "/bot/captcha", Handler: GetCaptchaHandler, Scope: ScopeAdmin, Tag: "captcha", Summary: "Html page to solve the login captcha", ContentType: "text/html"},
+	{Method: http.MethodPost, Path: "/bot/captcha/solve", Handler: GetCaptchaSolverHandler, Tag: "captcha", Summary: "Solve the login captcha and login",
+		Params: []openapi.Param{{Name: "challenge_id", In: openapi.InForm, Type: ""}, {Name: "answer", In: openapi.InForm, Description: "0, 1, 2 or 3"}}, ContentType: "text/html"},
+	{Method: http.MethodGet, Path: "/bot/captcha/challenge", Handler: GetCaptchaChallengeHandler, Scope: ScopeAdmin, Tag: "captcha", Summary: "Login captcha challenge, images are base64 encoded", Result: CaptchaChallenge{}},
+
+	{Method: http.MethodGet, Path: "/bot/ip", Handler: GetPublicIPHandler, Tag: "bot", Summary: "Public ip of the bot", Result: ""},
+	{Method: http.MethodGet, Path: "/bot/server", Handler: GetServerHandler, Tag: "server", Summary: "Gameforge server of the account", Result: gameforge.Server{}},
+	{Method: http.MethodGet, Path: "/bot/server-data", Handler: GetServerDataHandler, Tag: "server", Summary: "Universe settings", Result: ServerData{}},
+	{Method: http.MethodPost, Path: "/bot/set-user-agent", Handler: SetUserAgentHandler, Tag: "bot", Summary: "Deprecated"},
+	{Method: http.MethodGet, Path: "/bot/server-url", Handler: ServerURLHandler, Tag: "server", Summary: "Url of the universe", Result: ""},
+	{Method: http.MethodGet, Path: "/bot/language", Handler: GetLanguageHandler, Tag: "server", Summary: "Language of the universe", Result: ""},
+	{Method: http.MethodGet, Path: "/bot/empire/type/:typeID", Handler: GetEmpireHandler, Tag: "celestials", Summary: "Empire page",
+		Params: []openapi.Param{{Name: "typeID", In: openapi.InPath, Description: "0: planets, 1: moons"}}, Result: []any{}},
+	{Method: http.MethodPost, Path: "/bot/page-content", Handler: PageContentHandler, Tag: "bot", Summary: "Html of a game page, the form values are the query of the page",
+		Params: []openapi.Param{{Name: "page", In: openapi.InForm, Type: ""}, {Name: "cp", In: openapi.InForm}}, Result: []byte{}, Body: PageContentRequest{}},
+	{Method: http.MethodGet, Path: "/bot/login", Handler: LoginHandler, Scope: ScopeAdmin, Tag: "bot", Summary: "Login, using the existing cookies if possible"},
+	{Method: http.MethodGet, Path: "/bot/logout", Handler: LogoutHandler, Scope: ScopeAdmin, Tag: "bot", Summary: "Logout"},
+	{Method: http.MethodGet, Path: "/bot/username", Handler: GetUsernameHandler, Tag: "account", Summary: "Username of the account", Result: ""},
+	{Method: http.MethodGet, Path: "/bot/universe-name", Handler: GetUniverseNameHandler, Tag: "server", Summary: "Name of the universe", Result: ""},
+	{Method: http.MethodGet, Path: "/bot/server/speed", Handler: GetUniverseSpeedHandler, Tag: "server", Summary: "Economy speed", Result: int64(0)},
+	{Method: http.MethodGet, Path: "/bot/server/speed-fleet", Handler: GetUniverseSpeedFleetHandler, Tag: "server", Summary: "Fleet speed", Result: int64(0)},
+	{Method: http.MethodGet, Path: "/bot/server/version", Handler: ServerVersionHandler, Tag: "server", Summary: "Version of the game", Result: ""},
+	{Method: http.MethodGet, Path: "/bot/server/time", Handler: ServerTimeHandler, Tag: "server", Summary: "Time of the server", Result: time.Time{}},
+	{Method: http.MethodGet, Path: "/bot/is-under-attack", Handler: IsUnderAttackHandler, Tag: "fleets", Summary: "Hostile fleets are coming", Result: false},
+	{Method: http.MethodGet, Path: "/bot/is-vacation-mode", Handler: IsVacationModeHandler, Tag: "account", Summary: "Account is in vacation mode", Result: false},
+	{Method: http.MethodGet, Path: "/bot/user-infos", Handler: GetUserInfosHandler, Tag: "account", Summary: "Player name, points and rank", Result: ogame.UserInfos{}},
+	{Method: http.MethodGet, Path: "/bot/character-class", Handler: GetCharacterClassHandler, Tag: "account", Summary: "Class of the player", Result: ogame.CharacterClass(0)},
+	{Method: http.MethodGet, Path: "/bot/has-commander", Handler: HasCommanderHandler, Tag: "account", Summary: "Commander officer is active", Result: false},
+	{Method: http.MethodGet, Path: "/bot/has-admiral", Handler: HasAdmiralHandler, Tag: "account", Summary: "Admiral officer is active", Result: false},
+	{Method: http.MethodGet, Path: "/bot/has-engineer", Handler: HasEngineerHandler, Tag: "account", Summary: "Engineer officer is active", Result: false},
+	{Method: http.MethodGet, Path: "/bot/has-geologist", Handler: HasGeologistHandler, Tag: "account", Summary: "Geologist officer is active", Result: false},
+	{Method: http.MethodGet, Path: "/bot/has-technocrat", Handler: HasTechnocratHandler, Tag: "account", Summary: "Technocrat officer is active", Result: false},
+	{Method: http.MethodPost, Path: "/bot/send-message", Handler: SendMessageHandler, Tag: "messages", Summary: "Send a message to a player",
+		Params: []openapi.Param{{Name: "playerID", In: openapi.InForm, Required: true}, {Name: "message", In: openapi.InForm, Type: "", Required: true}}, Body: SendMessageRequest{}},
+	{Method: http.MethodGet, Path: "/bot/fleets", Handler: GetFleetsHandler, Tag: "fleets", Summary: "Own fleets in flight", Result: []ogame.Fleet{}},
+	{Method: http.MethodGet, Path: "/bot/fleets/slots", Handler: GetSlotsHandler, Tag: "fleets", Summary: "Fleet and expedition slots", Result: ogame.Slots{}},
+	{Method: http.MethodPost, Path: "/bot/fleets/:fleetID/cancel", Handler: CancelFleetHandler, Scope: ScopeFleet, Tag: "fleets", Summary: "Recall a fleet"},
+	{Method: http.MethodGet, Path: "/bot/espionage-report/:msgid", Handler: GetEspionageReportHandler, Tag: "messages", Summary: "Espionage report", Result: ogame.EspionageReport{}},
+	{Method: http.MethodGet, Path: "/bot/espionage-report/:galaxy/:system/:position", Handler: GetEspionageReportForHandler, Tag: "messages", Summary: "Latest espionage report of a planet",
+		Params: coordParams, Result: ogame.EspionageReport{}},
+	{Method: http.MethodGet, Path: "/bot/espionage-report", Handler: GetEspionageReportMessagesHandler, Tag: "messages", Summary: "Summaries of all the espionage reports", Result: []ogame.EspionageReportSummary{}},
+	{Method: http.MethodGet, Path: "/bot/combat-report/:msgid", Handler: GetCombatReportHandler, Tag: "messages", Summary: "Combat report", Result: ogame.CombatReport{}},
+	{Method: http.MethodPost, Path: "/bot/delete-report/:messageID", Handler: DeleteMessageHandler, Tag: "messages", Summary: "Delete a message"},
+	{Method: http.MethodPost, Path: "/bot/delete-all-espionage-reports", Handler: DeleteEspionageMessagesHandler, Tag: "messages", Summary: "Delete all the espionage reports"},
+	{Method: http.MethodPost, Path: "/bot/delete-all-reports/:tabIndex", Handler: DeleteMessagesFromTabHandler, Tag: "messages", Summary: "Delete all the messages of a tab",
+		Params: []openapi.Param{{Name: "tabIndex", In: openapi.InPath, Description: "20: espionage, 21: combat reports, 22: expeditions, 23: unions/transport, 24: other"}}},
+	{Method: http.MethodGet, Path: "/bot/attacks", Handler: GetAttacksHandler, Tag: "fleets", Summary: "Hostile fleets coming", Result: []ogame.AttackEvent{}},
+	{Method: http.MethodGet, Path: "/bot/get-auction", Handler: GetAuctionHandler, Tag: "auction", Summary: "Current auction", Result: ogame.Auction{}},
+	{Method: http.MethodPost, Path: "/bot/do-auction", Handler: DoAuctionHandler, Scope: ScopeMarketplace, Tag: "auction", Summary: "Bid on the current auction",
+		Params: []openapi.Param{{Name: "{celestialID}", In: openapi.InForm, Type: "", Description: "metal:crystal:deuterium taken from the celestial, eg: 123456=123:456:789"}}, Body: AuctionBidRequest{}},
+	{Method: http.MethodGet, Path: "/bot/galaxy-infos/:galaxy/:system", Handler: GalaxyInfosHandler, Tag: "galaxy", Summary: "Galaxy page of a system", Result: SystemInfosResult{}},
+	{Method: http.MethodGet, Path: "/bot/get-research", Handler: GetResearchHandler, Tag: "account", Summary: "Research levels", Result: ogame.Researches{}},
+	{Method: http.MethodGet, Path: "/bot/buy-offer-of-the-day", Handler: BuyOfferOfTheDayHandler, Scope: ScopeBuild, Tag: "account", Summary: "Buy the offer of the day of the trader"},
+	{Method: http.MethodGet, Path: "/bot/price/:ogameID/:nbr", Handler: GetPriceHandler, Tag: "objects", Summary: "Price of nbr units or of the level nbr", Result: ogame.Resources{}},
+	{Method: http.MethodGet, Path: "/bot/requirements/:ogameID", Handler: GetRequirementsHandler, Tag: "objects", Summary: "Requirements of an object, level by id", Result: map[ogame.ID]int64{}},
+	{Method: http.MethodGet, Path: "/bot/moons", Handler: GetMoonsHandler, Tag: "moons", Summary: "Moons of the player", Result: []ogame.Moon{}},
+	{Method: http.MethodGet, Path: "/bot/moons/:moonID", Handler: GetMoonHandler, Tag: "moons", Summary: "Moon of the player", Result: ogame.Moon{}},
+	{Method: http.MethodGet, Path: "/bot/moons/:galaxy/:system/:position", Handler: GetMoonByCoordHandler, Tag: "moons", Summary: "Moon of the player at a coordinate",
+		Params: coordParams, Result: ogame.Moon{}},
+	{Method: http.MethodGet, Path: "/bot/celestials/:celestialID/items", Handler: GetCelestialItemsHandler, Tag: "celestials", Summary: "Items available on a celestial", Result: []ogame.Item{}},
+	{Method: http.MethodGet, Path: "/bot/celestials/:celestialID/items/:itemRef/activate", Handler: ActivateCelestialItemHandler, Scope: ScopeBuild, Tag: "celestials", Summary: "Activate an item",
+		Params: []openapi.Param{{Name: "itemRef", In: openapi.InPath, Type: ""}}},
+	{Method: http.MethodGet, Path: "/bot/celestials/:celestialID/techs", Handler: TechsHandler, Tag: "celestials", Summary: "All the levels of a celestial", Result: TechsResult{}},
+	{Method: http.MethodGet, Path: "/bot/celestials/:celestialID/abandon", Handler: CelestialAbandonHandler, Scope: ScopeAdmin, Tag: "celestials", Summary: "Abandon a celestial", Result: AbandonResult{}},
+	{Method: http.MethodGet, Path: "/bot/planets", Handler: GetPlanetsHandler, Tag: "planets", Summary: "Planets of the player", Result: []ogame.Planet{}},
+	{Method: http.MethodGet, Path: "/bot/planets/:planetID", Handler: GetPlanetHandler, Tag: "planets", Summary: "Planet of the player", Result: ogame.Planet{}},
+	{Method: http.MethodGet, Path: "/bot/planets/:planetID/is-under-attack", Handler: IsUnderAttackByIDHandler, Tag: "planets", Summary: "Hostile fleets are coming, seen from a planet", Result: false},
+	{Method: http.MethodGet, Path: "/bot/planets/:galaxy/:system/:position", Handler: GetPlanetByCoordHandler, Tag: "planets", Summary: "Planet of the player at a coordinate",
+		Params: coordParams, Result: ogame.Planet{}},
+	{Method: http.MethodGet, Path: "/bot/planets/:planetID/resources-details", Handler: GetResourcesDetailsHandler, Tag: "planets", Summary: "Resources, storage and production", Result: ogame.ResourcesDetails{}},
+	{Method: http.MethodGet, Path: "/bot/planets/:planetID/resource-settings", Handler: GetResourceSettingsHandler, Tag: "planets", Summary: "Production percentages", Result: ogame.ResourceSettings{}},
+	{Method: http.MethodPost, Path: "/bot/planets/:planetID/resource-settings", Handler: SetResourceSettingsHandler, Scope: ScopeBuild, Tag: "planets", Summary: "Set the production percentages",
+		Params: []openapi.Param{
+			{Name: "metalMine", In: openapi.InForm, Required: true},
+			{Name: "crystalMine", In: openapi.InForm, Required: true},
+			{Name: "deuteriumSynthesizer", In: openapi.InForm, Required: true},
+			{Name: "solarPlant", In: openapi.InForm, Required: true},
+			{Name: "fusionReactor", In: openapi.InForm, Required: true},
+			{Name: "solarSatellite", In: openapi.InForm, Required: true},
+			{Name: "crawler", In: openapi.InForm, Required: true},
+		}, Body: ResourceSettingsRequest{}},
+	{Method: http.MethodGet, Path: "/bot/planets/:planetID/resources-buildings", Handler: GetResourcesBuildingsHandler, Tag: "planets", Summary: "Resources buildings levels", Result: ogame.ResourcesBuildings{}},
+	{Method: http.MethodGet, Path: "/bot/planets/:planetID/lifeform-buildings", Handler: GetLfBuildingsHandler, Tag: "planets", Summary: "Lifeform buildings levels", Result: ogame.LfBuildings{}},
+	{Method: http.MethodGet, Path: "/bot/planets/:planetID/lifeform-techs", Handler: GetLfResearchHandler, Tag: "planets", Summary: "Lifeform researches levels", Result: ogame.LfResearches{}},
+	{Method: http.MethodGet, Path: "/bot/planets/:planetID/defence", Handler: GetDefenseHandler, Tag: "planets", Summary: "Defenses", Result: ogame.DefensesInfos{}},
+	{Method: http.MethodGet, Path: "/bot/planets/:planetID/ships", Handler: GetShipsHandler, Tag: "planets", Summary: "Ships", Result: ogame.ShipsInfos{}},
+	{Method: http.MethodGet, Path: "/bot/planets/:planetID/facilities", Handler: GetFacilitiesHandler, Tag: "planets", Summary: "Facilities levels", Result: ogame.Facilities{}},
+	{Method: http.MethodPost, Path: "/bot/planets/:planetID/build/:ogameID/:nbr", Handler: BuildHandler, Scope: ScopeBuild, Tag: "planets", Summary: "Build any object, nbr is ignored for buildings and researches"},
+	{Method: http.MethodPost, Path: "/bot/planets/:planetID/build/cancelable/:ogameID", Handler: BuildCancelableHandler, Scope: ScopeBuild, Tag: "planets", Summary: "Build a building or research"},
+	{Method: http.MethodPost, Path: "/bot/planets/:planetID/build/production/:ogameID/:nbr", Handler: BuildProductionHandler, Scope: ScopeBuild, Tag: "planets", Summary: "Build ships or defenses"},
+	{Method: http.MethodPost, Path: "/bot/planets/:planetID/build/building/:ogameID", Handler: BuildBuildingHandler, Scope: ScopeBuild, Tag: "planets", Summary: "Build a building"},
+	{Method: http.MethodPost, Path: "/bot/planets/:planetID/build/technology/:ogameID", Handler: BuildTechnologyHandler, Scope: ScopeBuild, Tag: "planets", Summary: "Start a research"},
+	{Method: http.MethodPost, Path: "/bot/planets/:planetID/build/defence/:ogameID/:nbr", Handler: BuildDefenseHandler, Scope: ScopeBuild, Tag: "planets", Summary: "Build defenses"},
+	{Method: http.MethodPost, Path: "/bot/planets/:planetID/build/ships/:ogameID/:nbr", Handler: BuildShipsHandler, Scope: ScopeBuild, Tag: "planets", Summary: "Build ships"},
+	{Method: http.MethodPost, Path: "/bot/planets/:planetID/teardown/:ogameID", Handler: TeardownHandler, Scope: ScopeBuild, Tag: "planets", Summary: "Tear down a level of a building"},
+	{Method: http.MethodGet, Path: "/bot/planets/:planetID/production", Handler: GetProductionHandler, Tag: "planets", Summary: "Ships and defenses being built", Result: []ogame.Quantifiable{}},
+	{Method: http.MethodGet, Path: "/bot/planets/:planetID/constructions", Handler: ConstructionsBeingBuiltHandler, Tag: "planets", Summary: "Building and researches being built", Result: ConstructionsResult{}},
+	{Method: http.MethodPost, Path: "/bot/planets/:planetID/cancel-building", Handler: CancelBuildingHandler, Scope: ScopeBuild, Tag: "planets", Summary: "Cancel the building being built"},
+	{Method: http.MethodPost, Path: "/bot/planets/:planetID/cancel-research", Handler: CancelResearchHandler, Scope: ScopeBuild, Tag: "planets", Summary: "Cancel the research in progress"},
+	{Method: http.MethodGet, Path: "/bot/planets/:planetID/resources", Handler: GetResourcesHandler, Tag: "planets", Summary: "Resources", Result: ogame.Resources{}},
+	{Method: http.MethodPost, Path: "/bot/planets/:planetID/send-fleet", Handler: SendFleetHandler, Scope: ScopeFleet, Tag: "fleets", Summary: "Send a fleet",
+		Params: append([]openapi.Param{
+			shipsForm,
+			{Name: "speed", In: openapi.InForm, Description: "1 to 10 (10%..100%), default 10"},
+			{Name: "mission", In: openapi.InForm, Description: "Mission id, default 3 (transport)"},
+			{Name: "duration", In: openapi.InForm, Description: "Holding time in hours (expedition, park in that ally)"},
+			{Name: "union", In: openapi.InForm, Description: "Id of the ACS union"},
+			{Name: "metal", In: openapi.InForm},
+			{Name: "crystal", In: openapi.InForm},
+			{Name: "deuterium", In: openapi.InForm},
+		}, coordForm...), Result: ogame.Fleet{}, Body: SendFleetRequest{}},
+	{Method: http.MethodPost, Path: "/bot/planets/:planetID/send-discovery", Handler: SendDiscoveryHandler, Scope: ScopeFleet, Tag: "fleets", Summary: "Send a discovery fleet",
+		Params: coordForm[:3], Result: false, Body: CoordinateRequest{}},
+	{Method: http.MethodPost, Path: "/bot/planets/:planetID/send-ipm", Handler: SendIPMHandler, Scope: ScopeFleet, Tag: "fleets", Summary: "Send interplanetary missiles, returns the flight duration in seconds",
+		Params: append([]openapi.Param{
+			{Name: "ipmAmount", In: openapi.InForm, Required: true},
+			{Name: "priority", In: openapi.InForm, Description: "Id of the defense to target first"},
+		}, coordForm...), Result: int64(0), Body: SendIPMRequest{}},
+	{Method: http.MethodGet, Path: "/bot/moons/:moonID/phalanx/:galaxy/:system/:position", Handler: PhalanxHandler, Scope: ScopeFleet, Tag: "moons", Summary: "Fleets seen by the phalanx of a moon",
+		Params: coordParams, Result: []ogame.PhalanxFleet{}},
+	{Method: http.MethodPost, Path: "/bot/moons/:moonID/jump-gate", Handler: JumpGateHandler, Scope: ScopeFleet, Tag: "moons", Summary: "Jump ships to another moon",
+		Params: []openapi.Param{{Name: "moonDestination", In: openapi.InForm, Required: true}, shipsForm}, Result: JumpGateResult{}, Body: JumpGateRequest{}},
+}
+
+// GameRoutes routes proxying the game pages and static files, used by browser plugins like AntiGame
+var GameRoutes = []openapi.Route{
+	{Method: http.MethodGet, Path: "/game/allianceInfo.php", Handler: GetAlliancePageContentHandler, Scope: ScopeAdmin, Tag: "game", Summary: "Alliance page",
+		Params: []openapi.Param{{Name: "allianceId", In: openapi.InQuery}}, ContentType: "text/html", Root: true},
+	{Method: http.MethodGet, Path: "/game/index.php", Handler: GetFromGameHandler, Scope: ScopeAdmin, Tag: "game", Summary: "Game page, the query is the query of the page", ContentType: "text/html", Root: true},
+	{Method: http.MethodPost, Path: "/game/index.php", Handler: PostToGameHandler, Scope: ScopeAdmin, Tag: "game", Summary: "Post to a game page, the query is the query of the page", ContentType: "text/html", Root: true},
+	{Method: http.MethodGet, Path: "/cdn/*", Handler: GetStaticHandler, Scope: ScopeAdmin, Tag: "game", Summary: "Static file of the game", ContentType: "application/octet-stream", Root: true},
+	{Method: http.MethodGet, Path: "/assets/css/*", Handler: GetStaticHandler, Scope: ScopeAdmin, Tag: "game", Summary: "Static file of the game", ContentType: "text/css", Root: true},
+	{Method: http.MethodGet, Path: "/headerCache/*", Handler: GetStaticHandler, Scope: ScopeAdmin, Tag: "game", Summary: "Static file of the game", ContentType: "application/octet-stream", Root: true},
+	{Method: http.MethodGet, Path: "/favicon.ico", Handler: GetStaticHandler, Scope: ScopeAdmin, Tag: "game", Summary: "Favicon of the game", ContentType: "image/x-icon", Root: true},
+	{Method: http.MethodGet, Path: "/game/sw.js", Handler: GetStaticHandler, Scope: ScopeAdmin, Tag: "game", Summary: "Service worker of the game", ContentType: "application/javascript", Root: true},
+	{Method: http.MethodGet, Path: "/api/*", Handler: GetStaticHandler, Scope: ScopeAdmin, Tag: "game", Summary: "Universe xml api, eg: /api/serverData.xml", ContentType: "application/xml", Root: true},
+	{Method: http.MethodHead, Path: "/api/*", Handler: GetStaticHEADHandler, Scope: ScopeAdmin, Tag: "game", Summary: "Headers of the universe xml api, used to check if the cached files need to be refreshed", ContentType: "application/xml", Root: true},
+}
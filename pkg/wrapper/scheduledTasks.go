@@ -0,0 +1,90 @@
+package wrapper
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/alaingilbert/ogame/pkg/ogame"
+	"github.com/alaingilbert/ogame/pkg/taskRunner"
+)
+
+// Kinds of the scheduled tasks that can be persisted
+const (
+	SendFleetTaskKind   = "sendFleet"
+	CancelFleetTaskKind = "cancelFleet"
+)
+
+// SendFleetTaskPayload parameters of a scheduled SendFleet
+type SendFleetTaskPayload struct {
+	CelestialID ogame.CelestialID
+	Ships       ogame.ShipsInfos
+	Speed       ogame.Speed
+	Where       ogame.Coordinate
+	Mission     ogame.MissionID
+	Resources   ogame.Resources
+	HoldingTime int64
+	UnionID     int64
+}
+
+// CancelFleetTaskPayload parameters of a scheduled CancelFleet
+type CancelFleetTaskPayload struct {
+	FleetID ogame.FleetID
+}
+
+func (b *OGame) registerScheduledTaskKinds() {
+	b.taskRunnerInst.SetPersistErrorHandler(func(err error) {
+		b.error("failed to save the scheduled tasks: " + err.Error())
+	})
+	b.taskRunnerInst.RegisterKind(SendFleetTaskKind, func(p *Prioritize, payload json.RawMessage) {
+		var params SendFleetTaskPayload
+		if err := json.Unmarshal(payload, &params); err != nil {
+			p.begin("SendFleet").done()
+			b.error("invalid scheduled send fleet payload: " + err.Error())
+			return
+		}
+		if _, err := p.SendFleet(params.CelestialID, params.Ships, params.Speed, params.Where, params.Mission,
+			params.Resources, params.HoldingTime, params.UnionID); err != nil {
+			b.error("scheduled send fleet failed: " + err.Error())
+		}
+	})
+	b.taskRunnerInst.RegisterKind(CancelFleetTaskKind, func(p *Prioritize, payload json.RawMessage) {
+		var params CancelFleetTaskPayload
+		if err := json.Unmarshal(payload, &params); err != nil {
+			p.begin("CancelFleet").done()
+			b.error("invalid scheduled cancel fleet payload: " + err.Error())
+			return
+		}
+		if err := p.CancelFleet(params.FleetID); err != nil {
+			b.error("scheduled cancel fleet failed: " + err.Error())
+		}
+	})
+}
+
+func (b *OGame) scheduleAt(at time.Time, priority taskRunner.Priority, name string, clb func(Prioritizable) error) *taskRunner.TaskHandle {
+	return b.taskRunnerInst.ScheduleAt(at, priority, name, b.scheduledTx(name, clb))
+}
+
+func (b *OGame) scheduleIn(d time.Duration, priority taskRunner.Priority, name string, clb func(Prioritizable) error) *taskRunner.TaskHandle {
+	return b.taskRunnerInst.ScheduleIn(d, priority, name, b.scheduledTx(name, clb))
+}
+
+// scheduledTx executes clb in a transaction, the error is logged since nobody waits for the scheduled task
+func (b *OGame) scheduledTx(name string, clb func(Prioritizable) error) func(*Prioritize) {
+	return func(p *Prioritize) {
+		if err := p.TxNamed(name, clb); err != nil {
+			b.error("scheduled task " + name + " failed: " + err.Error())
+		}
+	}
+}
+
+func (b *OGame) scheduleSendFleetAt(at time.Time, celestialID ogame.CelestialID, ships ogame.ShipsInfos, speed ogame.Speed,
+	where ogame.Coordinate, mission ogame.MissionID, resources ogame.Resources, holdingTime, unionID int64) (*taskRunner.TaskHandle, error) {
+	payload := SendFleetTaskPayload{CelestialID: celestialID, Ships: ships, Speed: speed, Where: where, Mission: mission,
+		Resources: resources, HoldingTime: holdingTime, UnionID: unionID}
+	return b.taskRunnerInst.ScheduleKindAt(at, taskRunner.Important, "SendFleet", SendFleetTaskKind, payload)
+}
+
+func (b *OGame) scheduleCancelFleetAt(at time.Time, fleetID ogame.FleetID) (*taskRunner.TaskHandle, error) {
+	payload := CancelFleetTaskPayload{FleetID: fleetID}
+	return b.taskRunnerInst.ScheduleKindAt(at, taskRunner.Important, "CancelFleet", CancelFleetTaskKind, payload)
+}